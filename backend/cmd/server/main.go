@@ -1,16 +1,21 @@
 package main
 
 import (
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"character-management-app/internal/config"
 	"character-management-app/internal/handlers"
-	"character-management-app/internal/middleware"
+	"character-management-app/internal/linkpreview"
 	"character-management-app/internal/repositories"
+	"character-management-app/internal/router"
 	"character-management-app/internal/services"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
@@ -27,104 +32,145 @@ func main() {
 	}
 
 	// リポジトリの初期化
+	workspaceRepo := repositories.NewWorkspaceRepository(db)
 	groupRepo := repositories.NewGroupRepository(db)
 	characterRepo := repositories.NewCharacterRepository(db)
 	labelRepo := repositories.NewLabelRepository(db)
+	labelCategoryRepo := repositories.NewLabelCategoryRepository(db)
 	relationshipRepo := repositories.NewRelationshipRepository(db)
+	savedViewRepo := repositories.NewSavedViewRepository(db)
 
-	// サービスの初期化
-	groupService := services.NewGroupService(groupRepo)
-	characterService := services.NewCharacterService(characterRepo, groupRepo, labelRepo)
-	labelService := services.NewLabelService(labelRepo)
-	relationshipService := services.NewRelationshipService(relationshipRepo, characterRepo)
-	
-	// アップロードディレクトリの設定
-	uploadDir := os.Getenv("UPLOAD_DIR")
-	if uploadDir == "" {
-		uploadDir = "./uploads"
-	}
-	imageService := services.NewImageService(uploadDir)
-	log.Printf("Image service initialized with upload directory: %s", uploadDir)
-
-	// ハンドラーの初期化
-	groupHandler := handlers.NewGroupHandler(groupService)
-	characterHandler := handlers.NewCharacterHandler(characterService, imageService)
-	labelHandler := handlers.NewLabelHandler(labelService)
-	relationshipHandler := handlers.NewRelationshipHandler(relationshipService)
-
-	// Ginルーターの設定
-	r := gin.Default()
-
-	// ミドルウェアの設定
-	r.Use(middleware.CORS())
-	r.Use(middleware.ErrorHandler())
+	// 人物1人あたりのラベルの上限（グループの labelLimit が未設定の場合に使う）
+	labelLimit, err := int64Env("LABEL_LIMIT", services.DefaultLabelLimit)
+	if err != nil {
+		log.Fatal("Invalid LABEL_LIMIT:", err)
+	}
+	if labelLimit < 1 {
+		log.Fatal("LABEL_LIMIT must be at least 1")
+	}
 
-	// 404と405のハンドラー設定
-	r.NoRoute(middleware.NotFoundHandler())
-	r.NoMethod(middleware.MethodNotAllowedHandler())
+	// 関連リンクのタイトルとファビコンの取得（外部のサイトに接続するため既定では無効）
+	var linkFetcher linkpreview.Fetcher
+	if os.Getenv("LINK_PREVIEW") == "true" {
+		linkFetcher = linkpreview.NewHTTPFetcher()
+	}
 
-	// ヘルスチェックエンドポイント
-	r.GET("/health", func(c *gin.Context) {
-		if err := config.HealthCheck(db); err != nil {
-			c.JSON(500, gin.H{"status": "error", "message": err.Error()})
-			return
-		}
-		c.JSON(200, gin.H{"status": "ok", "message": "Database connection is healthy"})
+	// サービスの初期化
+	workspaceService := services.NewWorkspaceService(workspaceRepo)
+	groupService := services.NewGroupService(groupRepo)
+	characterService := services.NewCharacterService(characterRepo, groupRepo, labelRepo, savedViewRepo, services.CharacterOptions{
+		LabelLimit:  int(labelLimit),
+		LinkFetcher: linkFetcher,
 	})
+	labelService := services.NewLabelService(labelRepo, groupRepo, labelCategoryRepo)
+	labelCategoryService := services.NewLabelCategoryService(labelCategoryRepo)
+	relationshipService := services.NewRelationshipService(relationshipRepo, characterRepo, savedViewRepo)
+	savedViewService := services.NewSavedViewService(savedViewRepo, characterRepo)
+
+	// URL の文字列の配列として保存した関連リンクを種類付きのリンクに移行（失敗した人物は次回の起動時に再試行）
+	if migrated, err := characterService.MigrateRelatedLinks(); err != nil {
+		log.Println("Failed to migrate some related links:", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated related links of %d characters", migrated)
+	}
 
-	// ルートの設定
-	api := r.Group("/api/v1")
-	{
-		// グループ関連のルート
-		groups := api.Group("/groups")
-		{
-			groups.GET("", groupHandler.GetGroups)
-			groups.POST("", groupHandler.CreateGroup)
-			groups.GET("/:id", groupHandler.GetGroup)
-			groups.PUT("/:id", groupHandler.UpdateGroup)
-			groups.DELETE("/:id", groupHandler.DeleteGroup)
-		}
-
-		// 人物関連のルート
-		characters := api.Group("/characters")
-		{
-			characters.GET("", characterHandler.GetCharacters)
-			characters.POST("", characterHandler.CreateCharacter)
-			characters.GET("/:id", characterHandler.GetCharacter)
-			characters.PUT("/:id", characterHandler.UpdateCharacter)
-			characters.DELETE("/:id", characterHandler.DeleteCharacter)
-			characters.POST("/:id/labels/:labelId", characterHandler.AddLabelToCharacter)
-			characters.DELETE("/:id/labels/:labelId", characterHandler.RemoveLabelFromCharacter)
-		}
+	// 画像ストレージの設定
+	imageStorage, err := config.InitStorage()
+	if err != nil {
+		log.Fatal("Failed to configure image storage:", err)
+	}
+	maxPixels, err := int64Env("IMAGE_MAX_PIXELS", services.DefaultMaxImagePixels)
+	if err != nil {
+		log.Fatal("Invalid IMAGE_MAX_PIXELS:", err)
+	}
+	workspaceQuota, err := int64Env("WORKSPACE_STORAGE_QUOTA", 0)
+	if err != nil {
+		log.Fatal("Invalid WORKSPACE_STORAGE_QUOTA:", err)
+	}
+	// 画像の配信用URLの署名（UPLOAD_PUBLIC=true の場合のみ署名せず、URL を知っていれば誰でも画像を取得できる）
+	urlSecret, err := uploadURLSecret()
+	if err != nil {
+		log.Fatal(err)
+	}
+	urlTTL, err := durationEnv("UPLOAD_URL_TTL")
+	if err != nil {
+		log.Fatal("Invalid UPLOAD_URL_TTL:", err)
+	}
+	// 保存して間もない画像を孤立とみなさない猶予（画像の削除と孤立したファイルの削除で共通）
+	gracePeriod, err := durationEnv("UPLOAD_GC_GRACE_PERIOD")
+	if err != nil {
+		log.Fatal("Invalid UPLOAD_GC_GRACE_PERIOD:", err)
+	}
+	imageService := services.NewImageService(imageStorage, characterRepo, services.ImageOptions{
+		OutputFormat:     services.ImageFormat(os.Getenv("IMAGE_OUTPUT_FORMAT")),
+		KeepColorProfile: os.Getenv("IMAGE_KEEP_COLOR_PROFILE") == "true",
+		MaxPixels:        maxPixels,
+		WorkspaceQuota:   workspaceQuota,
+		URLSecret:        urlSecret,
+		URLTTL:           urlTTL,
+		GracePeriod:      gracePeriod,
+	})
 
-		// ラベル関連のルート
-		labels := api.Group("/labels")
-		{
-			labels.GET("", labelHandler.GetLabels)
-			labels.POST("", labelHandler.CreateLabel)
-			labels.GET("/:id", labelHandler.GetLabel)
-			labels.PUT("/:id", labelHandler.UpdateLabel)
-			labels.DELETE("/:id", labelHandler.DeleteLabel)
-		}
+	// 保存済みの画像を内容のハッシュで管理するキーに移行（失敗した画像は次回の起動時に再試行）
+	if migrated, err := imageService.MigrateContentKeys(); err != nil {
+		log.Println("Failed to migrate some images:", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated %d images to content-addressed storage", migrated)
+	}
+	// 保存容量の計算に使う写真の大きさを記録（失敗した画像は次回の起動時に再試行）
+	if recorded, err := imageService.RecordPhotoSizes(); err != nil {
+		log.Println("Failed to record some photo sizes:", err)
+	} else if recorded > 0 {
+		log.Printf("Recorded sizes of %d photos", recorded)
+	}
 
-		// 関係関連のルート
-		relationships := api.Group("/relationships")
-		{
-			relationships.GET("", relationshipHandler.GetRelationships)
-			relationships.POST("", relationshipHandler.CreateRelationship)
-			relationships.GET("/:id", relationshipHandler.GetRelationship)
-			relationships.PUT("/:id", relationshipHandler.UpdateRelationship)
-			relationships.DELETE("/:id", relationshipHandler.DeleteRelationship)
-		}
+	// 孤立したアップロード画像の確認・削除
+	uploadMaintenanceService := services.NewUploadMaintenanceService(imageStorage, characterRepo, gracePeriod)
+	gcInterval, err := durationEnv("UPLOAD_GC_INTERVAL")
+	if err != nil {
+		log.Fatal("Invalid UPLOAD_GC_INTERVAL:", err)
+	}
+	if gcInterval > 0 {
+		go collectUploadsPeriodically(uploadMaintenanceService, gcInterval)
 	}
 
-	// 静的ファイルの配信（画像用）
-	staticUploadDir := os.Getenv("UPLOAD_DIR")
-	if staticUploadDir == "" {
-		staticUploadDir = "./uploads"
+	// ハンドラーの初期化
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	groupHandler := handlers.NewGroupHandler(groupService, imageService)
+	characterHandler := handlers.NewCharacterHandler(characterService, imageService)
+	labelHandler := handlers.NewLabelHandler(labelService)
+	labelCategoryHandler := handlers.NewLabelCategoryHandler(labelCategoryService)
+	relationshipHandler := handlers.NewRelationshipHandler(relationshipService, imageService)
+	savedViewHandler := handlers.NewSavedViewHandler(savedViewService, imageService)
+	uploadHandler := handlers.NewUploadHandler(imageService)
+	adminHandler := handlers.NewAdminHandler(uploadMaintenanceService)
+
+	// リクエストボディの上限（アップロードする画像を含む）
+	maxBodySize, err := int64Env("MAX_FILE_SIZE", defaultMaxBodySize)
+	if err != nil {
+		log.Fatal("Invalid MAX_FILE_SIZE:", err)
 	}
-	r.Static("/uploads", staticUploadDir)
-	log.Printf("Static file serving configured for: %s", staticUploadDir)
+
+	// ルーターの設定
+	r := router.New(router.Config{
+		WorkspaceHandler:     workspaceHandler,
+		GroupHandler:         groupHandler,
+		CharacterHandler:     characterHandler,
+		LabelHandler:         labelHandler,
+		LabelCategoryHandler: labelCategoryHandler,
+		RelationshipHandler:  relationshipHandler,
+		SavedViewHandler:     savedViewHandler,
+		UploadHandler:        uploadHandler,
+		AdminHandler:         adminHandler,
+		ResolveWorkspace:     workspaceService.ResolveWorkspaceID,
+		WorkspaceBaseDomain:  os.Getenv("WORKSPACE_BASE_DOMAIN"),
+		RequireIfMatch:       os.Getenv("REQUIRE_IF_MATCH") == "true",
+		HealthCheck: func() error {
+			return config.HealthCheck(db)
+		},
+		AdminToken:  os.Getenv("ADMIN_TOKEN"),
+		MaxBodySize: maxBodySize,
+	})
 
 	// サーバー起動
 	port := os.Getenv("PORT")
@@ -136,4 +182,72 @@ func main() {
 	if err := r.Run(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
\ No newline at end of file
+}
+
+// defaultMaxBodySize MAX_FILE_SIZE が未設定の場合のリクエストボディの上限（10MB）
+const defaultMaxBodySize = 10 << 20
+
+// uploadURLSecret 画像の配信用URLの署名に使う秘密鍵を決める
+// UPLOAD_URL_SECRET が未設定の場合はプロセスごとの乱数を使い、UPLOAD_PUBLIC=true の場合のみ署名しない
+func uploadURLSecret() ([]byte, error) {
+	secret := os.Getenv("UPLOAD_URL_SECRET")
+	public := os.Getenv("UPLOAD_PUBLIC") == "true"
+	switch {
+	case secret != "" && public:
+		return nil, errors.New("UPLOAD_URL_SECRET cannot be used with UPLOAD_PUBLIC=true")
+	case secret != "" && len(secret) < services.MinURLSecretLength:
+		return nil, fmt.Errorf("UPLOAD_URL_SECRET must be at least %d characters", services.MinURLSecretLength)
+	case secret != "" && os.Getenv("S3_PUBLIC_URL") != "":
+		return nil, errors.New("UPLOAD_URL_SECRET cannot be used with S3_PUBLIC_URL; signed URLs are verified by the backend")
+	case secret != "":
+		return []byte(secret), nil
+	case public:
+		log.Println("UPLOAD_PUBLIC=true: uploaded images are served without signed URLs")
+		return nil, nil
+	case os.Getenv("S3_PUBLIC_URL") != "":
+		return nil, errors.New("S3_PUBLIC_URL serves images without signed URLs; set UPLOAD_PUBLIC=true to allow it")
+	}
+	random := make([]byte, services.MinURLSecretLength)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate upload URL secret: %w", err)
+	}
+	log.Println("UPLOAD_URL_SECRET is not set; using a random secret (signed URLs are invalidated on restart and are not shared between instances)")
+	return random, nil
+}
+
+// int64Env 環境変数を整数として読み込む（未設定の場合は defaultValue）
+func int64Env(name string, defaultValue int64) (int64, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// durationEnv 環境変数を期間（例: 24h）として読み込む（未設定の場合は 0）
+func durationEnv(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// collectUploadsPeriodically 一定間隔で孤立したアップロード画像を削除し、欠けているファイルを記録する
+func collectUploadsPeriodically(service services.UploadMaintenanceService, interval time.Duration) {
+	for range time.Tick(interval) {
+		report, err := service.CheckUploads(false)
+		if err != nil {
+			log.Println("Upload garbage collection failed:", err)
+		}
+		if report == nil {
+			continue
+		}
+		if report.DeletedFiles > 0 {
+			log.Printf("Deleted %d orphaned uploads (%d bytes)", report.DeletedFiles, report.ReclaimedBytes)
+		}
+		for _, missing := range report.MissingFiles {
+			log.Printf("Character %s references missing uploads: %v", missing.CharacterID, missing.MissingKeys)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"character-management-app/internal/models"
 
+	"github.com/google/uuid"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -69,19 +70,97 @@ func runMigrations(db *gorm.DB) error {
 
 	// モデルの自動マイグレーション
 	err := db.AutoMigrate(
+		&models.Workspace{},
 		&models.Group{},
+		&models.LabelCategory{},
 		&models.Label{},
 		&models.Character{},
+		&models.CharacterImage{},
+		&models.CharacterAlias{},
 		&models.Relationship{},
+		&models.SavedView{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to auto migrate: %w", err)
 	}
 
+	if err := migrateWorkspaces(db); err != nil {
+		return fmt.Errorf("failed to migrate workspaces: %w", err)
+	}
+
+	if err := migrateCharacterImages(db); err != nil {
+		return fmt.Errorf("failed to migrate character images: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
 
+// migrateWorkspaces 既存データをデフォルトワークスペースへ移行
+func migrateWorkspaces(db *gorm.DB) error {
+	// デフォルトワークスペースを用意
+	var workspace models.Workspace
+	err := db.Where("slug = ?", models.DefaultWorkspaceSlug).
+		Attrs(models.Workspace{ID: uuid.New().String(), Name: "Default"}).
+		FirstOrCreate(&workspace).Error
+	if err != nil {
+		return fmt.Errorf("failed to ensure default workspace: %w", err)
+	}
+
+	// ワークスペース未設定のグループとラベルを割り当て
+	if err := db.Model(&models.Group{}).Where("workspace_id = ''").
+		Update("workspace_id", workspace.ID).Error; err != nil {
+		return fmt.Errorf("failed to assign groups to default workspace: %w", err)
+	}
+	if err := db.Model(&models.Label{}).Where("workspace_id = ''").
+		Update("workspace_id", workspace.ID).Error; err != nil {
+		return fmt.Errorf("failed to assign labels to default workspace: %w", err)
+	}
+
+	// ラベル名のグローバルな一意制約を削除（ワークスペース単位の制約に置き換え済み）
+	if db.Migrator().HasIndex(&models.Label{}, "idx_labels_name") {
+		if err := db.Migrator().DropIndex(&models.Label{}, "idx_labels_name"); err != nil {
+			return fmt.Errorf("failed to drop global label name index: %w", err)
+		}
+	}
+
+	// ワークスペース単位のラベル名の一意制約を削除（グループ専用のラベルを含む範囲ごとの制約に置き換え済み）
+	if db.Migrator().HasIndex(&models.Label{}, "idx_labels_workspace_name") {
+		if err := db.Migrator().DropIndex(&models.Label{}, "idx_labels_workspace_name"); err != nil {
+			return fmt.Errorf("failed to drop workspace label name index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateCharacterImages ギャラリー導入前の人物の photo を代表画像としてギャラリーに追加
+func migrateCharacterImages(db *gorm.DB) error {
+	var characters []models.Character
+	err := db.Select("id", "photo", "photo_crop", "photo_size").
+		Where("photo IS NOT NULL AND photo <> ''").
+		Where("id NOT IN (?)", db.Model(&models.CharacterImage{}).Select("character_id").Where("is_primary = ?", true)).
+		Find(&characters).Error
+	if err != nil {
+		return fmt.Errorf("failed to find characters without primary image: %w", err)
+	}
+
+	for _, character := range characters {
+		image := models.CharacterImage{
+			ID:          uuid.New().String(),
+			CharacterID: character.ID,
+			Key:         *character.Photo,
+			Crop:        character.PhotoCrop,
+			Size:        character.PhotoSize,
+			IsPrimary:   true,
+		}
+		if err := db.Create(&image).Error; err != nil {
+			return fmt.Errorf("failed to create primary image for character %s: %w", character.ID, err)
+		}
+	}
+	return nil
+}
+
 // 環境変数を取得するヘルパー関数
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"character-management-app/internal/storage"
+)
+
+// UploadURLPrefix バックエンドがアップロード画像を配信するパス
+const UploadURLPrefix = "/uploads"
+
+// 画像ストレージの初期化
+// STORAGE_DRIVER が s3 の場合は S3互換ストレージ、それ以外は UPLOAD_DIR のローカルディスクを使う
+func InitStorage() (storage.Storage, error) {
+	driver := getEnv("STORAGE_DRIVER", "local")
+
+	switch driver {
+	case "local":
+		uploadDir := getEnv("UPLOAD_DIR", "./uploads")
+		log.Printf("Image storage: local directory %s", uploadDir)
+		return storage.NewLocal(uploadDir, UploadURLPrefix), nil
+	case "s3":
+		store, err := storage.NewS3(storage.S3Config{
+			Endpoint:        getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:          getEnv("S3_REGION", "us-east-1"),
+			Bucket:          getEnv("S3_BUCKET", ""),
+			AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+			PathStyle:       getEnv("S3_FORCE_PATH_STYLE", "false") == "true",
+			PublicURL:       getEnv("S3_PUBLIC_URL", ""),
+		}, UploadURLPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure s3 storage: %w", err)
+		}
+		log.Printf("Image storage: s3 bucket %s", getEnv("S3_BUCKET", ""))
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER: %q", driver)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理用ハンドラー
+type AdminHandler struct {
+	uploadMaintenanceService services.UploadMaintenanceService
+}
+
+// NewAdminHandler 管理用ハンドラーのコンストラクタ
+func NewAdminHandler(uploadMaintenanceService services.UploadMaintenanceService) *AdminHandler {
+	return &AdminHandler{
+		uploadMaintenanceService: uploadMaintenanceService,
+	}
+}
+
+// CollectUploads アップロード画像の整合性を確認し、参照されていないファイルを削除する
+// dryRun を省略した場合は報告のみ（削除するには dryRun=false を指定する）
+// @Summary アップロード画像の整合性確認と不要ファイルの削除
+// @Tags admin
+// @Produce json
+// @Param dryRun query bool false "報告のみ（既定: true）"
+// @Success 200 {object} services.UploadReport
+// @Failure 401 {object} middleware.Problem
+// @Router /api/v1/admin/uploads/gc [post]
+func (h *AdminHandler) CollectUploads(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "true"))
+	if err != nil {
+		c.Error(middleware.NewAppError("INVALID_QUERY", "dryRun must be true or false", err.Error()))
+		return
+	}
+
+	report, err := h.uploadMaintenanceService.CheckUploads(dryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"character-management-app/internal/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUploadMaintenanceService モックサービス
+type MockUploadMaintenanceService struct {
+	mock.Mock
+}
+
+func (m *MockUploadMaintenanceService) CheckUploads(dryRun bool) (*services.UploadReport, error) {
+	args := m.Called(dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UploadReport), args.Error(1)
+}
+
+func TestAdminHandler_CollectUploads(t *testing.T) {
+	setup := func() (*MockUploadMaintenanceService, http.Handler) {
+		mockService := new(MockUploadMaintenanceService)
+		handler := NewAdminHandler(mockService)
+		router := setupTestRouter()
+		router.POST("/api/v1/admin/uploads/gc", handler.CollectUploads)
+		return mockService, router
+	}
+
+	t.Run("既定では報告のみ", func(t *testing.T) {
+		mockService, router := setup()
+		mockService.On("CheckUploads", true).Return(&services.UploadReport{
+			DryRun:        true,
+			CheckedFiles:  2,
+			OrphanedFiles: []services.OrphanedFile{{Key: "orphan/full.jpg", Size: 10}},
+			MissingFiles:  []services.MissingPhoto{},
+		}, nil)
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/uploads/gc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var report services.UploadReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.Len(t, report.OrphanedFiles, 1)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("dryRun=false で削除", func(t *testing.T) {
+		mockService, router := setup()
+		mockService.On("CheckUploads", false).Return(&services.UploadReport{DeletedFiles: 1, ReclaimedBytes: 10}, nil)
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/uploads/gc?dryRun=false", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("dryRun が不正", func(t *testing.T) {
+		mockService, router := setup()
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/uploads/gc?dryRun=maybe", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "CheckUploads", mock.Anything)
+	})
+}
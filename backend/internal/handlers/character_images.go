@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/models"
+	"character-management-app/internal/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// characterImageDocument ギャラリーの画像で編集できる項目（追加時のフォーム・PATCH 適用後に検証する）
+type characterImageDocument struct {
+	Caption   string `json:"caption"`
+	Credit    string `json:"credit" validate:"max=255"`
+	SourceURL string `json:"sourceUrl" validate:"omitempty,url,max=2048"`
+	License   string `json:"license" validate:"max=100"`
+	IsPrimary bool   `json:"isPrimary"`
+}
+
+// ReorderCharacterImagesRequest ギャラリーの並べ替えリクエスト
+type ReorderCharacterImagesRequest struct {
+	ImageIDs []string `json:"imageIds" validate:"required"`
+}
+
+// GetCharacterImages 人物のギャラリーの画像を表示順に取得
+func (h *CharacterHandler) GetCharacterImages(c *gin.Context) {
+	images, err := h.service(c).GetCharacterImages(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, imagesWithURLs(h.imageService, images))
+}
+
+// GetCharacterImage 人物のギャラリーの画像を取得
+func (h *CharacterHandler) GetCharacterImage(c *gin.Context) {
+	image, err := h.service(c).GetCharacterImage(c.Param("id"), c.Param("imageId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, imageWithURLs(h.imageService, *image))
+}
+
+// AddCharacterImage 人物のギャラリーに画像を追加（マルチパートの image フィールドと説明などの項目）
+// 人物に写真がない場合と isPrimary を指定した場合は追加した画像が代表画像（photo）になる
+func (h *CharacterHandler) AddCharacterImage(c *gin.Context) {
+	characterID := c.Param("id")
+
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+
+	// フォームを解析（ボディが上限を超えた場合は413）
+	if _, err := c.MultipartForm(); err != nil {
+		c.Error(invalidRequest(err))
+		return
+	}
+
+	doc := characterImageDocument{
+		Caption:   c.PostForm("caption"),
+		Credit:    c.PostForm("credit"),
+		SourceURL: c.PostForm("sourceUrl"),
+		License:   c.PostForm("license"),
+	}
+	if value := c.PostForm("isPrimary"); value != "" {
+		isPrimary, err := strconv.ParseBool(value)
+		if err != nil {
+			c.Error(middleware.NewAppError("INVALID_REQUEST", "isPrimary must be true or false", err.Error()))
+			return
+		}
+		doc.IsPrimary = isPrimary
+	}
+	if !validateRequest(c, &doc) {
+		return
+	}
+
+	crop, err := parseCrop(c, "crop")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		c.Error(middleware.NewAppError("INVALID_REQUEST", "image file is required", err.Error()))
+		return
+	}
+	defer file.Close()
+
+	// 画像サービスを使用してファイルを保存（サイズ別のバリアントを生成）
+	key, err := h.imageService.SaveImage(file, crop)
+	if err != nil {
+		// 対応していない形式・壊れた画像はサービスのエラーとして返す
+		var serviceError *services.Error
+		if errors.As(err, &serviceError) {
+			c.Error(err)
+			return
+		}
+		fmt.Printf("ERROR: Failed to save image file %s: %v\n", header.Filename, err)
+		c.Error(middleware.NewAppError("IMAGE_UPLOAD_FAILED", "failed to save uploaded file", err.Error()))
+		return
+	}
+
+	// ギャラリーの画像は置き換えではなく追加のため、全ての画像を使用量に含める
+	size, err := h.checkPhotoQuota(c, "", key)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	image, err := h.service(c).AddCharacterImage(characterID, &models.CharacterImage{
+		Key:       key,
+		Crop:      crop,
+		Size:      size,
+		IsPrimary: doc.IsPrimary,
+		Caption:   doc.Caption,
+		Credit:    doc.Credit,
+		SourceURL: doc.SourceURL,
+		License:   doc.License,
+	})
+	if err != nil {
+		h.discardUploadedPhoto(&key)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, imageWithURLs(h.imageService, *image))
+}
+
+// UpdateCharacterImage ギャラリーの画像の説明・クレジット・出典・ライセンスを部分更新し、代表画像を切り替える
+// 画像ファイルは変更できない（新しい画像を追加して古い画像を削除する）
+func (h *CharacterHandler) UpdateCharacterImage(c *gin.Context) {
+	characterID := c.Param("id")
+	imageID := c.Param("imageId")
+
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+
+	existing, err := h.service(c).GetCharacterImage(characterID, imageID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	current := characterImageDocument{
+		Caption:   existing.Caption,
+		Credit:    existing.Credit,
+		SourceURL: existing.SourceURL,
+		License:   existing.License,
+		IsPrimary: existing.IsPrimary,
+	}
+	var patched characterImageDocument
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
+		return
+	}
+
+	image, err := h.service(c).UpdateCharacterImage(characterID, &models.CharacterImage{
+		ID:        imageID,
+		IsPrimary: patched.IsPrimary,
+		Caption:   patched.Caption,
+		Credit:    patched.Credit,
+		SourceURL: patched.SourceURL,
+		License:   patched.License,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, imageWithURLs(h.imageService, *image))
+}
+
+// ReorderCharacterImages ギャラリーの画像を指定した順に並べ替える（全ての画像の ID を指定する）
+func (h *CharacterHandler) ReorderCharacterImages(c *gin.Context) {
+	characterID := c.Param("id")
+
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+
+	var req ReorderCharacterImagesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	images, err := h.service(c).ReorderCharacterImages(characterID, req.ImageIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, imagesWithURLs(h.imageService, images))
+}
+
+// DeleteCharacterImage ギャラリーから画像を削除し、どこからも参照されなくなったファイルを削除する
+// 代表画像を削除した場合は表示順で最初の画像が代表画像になる
+func (h *CharacterHandler) DeleteCharacterImage(c *gin.Context) {
+	characterID := c.Param("id")
+
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+
+	image, err := h.service(c).DeleteCharacterImage(characterID, c.Param("imageId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.deletePhoto(image.Key)
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"character-management-app/internal/models"
+	"character-management-app/internal/services"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharacterHandler_CharacterImages(t *testing.T) {
+	newRouter := func(mockService *MockCharacterService, mockImageService *MockImageService) http.Handler {
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters/:id/images", handler.GetCharacterImages)
+		router.POST("/characters/:id/images", handler.AddCharacterImage)
+		router.PUT("/characters/:id/images/order", handler.ReorderCharacterImages)
+		router.PATCH("/characters/:id/images/:imageId", handler.UpdateCharacterImage)
+		router.DELETE("/characters/:id/images/:imageId", handler.DeleteCharacterImage)
+		return router
+	}
+	multipartBody := func(fields map[string]string, withImage bool) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		if withImage {
+			part, _ := writer.CreateFormFile("image", "costume.png")
+			part.Write([]byte("fake image data"))
+		}
+		writer.Close()
+		return &buf, writer.FormDataContentType()
+	}
+
+	t.Run("画像の一覧を取得", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService, new(MockImageService))
+
+		mockService.On("GetCharacterImages", "char-1").Return([]models.CharacterImage{
+			{ID: "image-1", CharacterID: "char-1", Key: "aaaa/full.jpg", IsPrimary: true},
+			{ID: "image-2", CharacterID: "char-1", Key: "bbbb/full.png", Position: 1, Credit: "作者"},
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/characters/char-1/images", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response, 2)
+		assert.Equal(t, "aaaa/full.jpg", response[0]["url"])
+		assert.Equal(t, true, response[0]["isPrimary"])
+		assert.Equal(t, "作者", response[1]["credit"])
+	})
+
+	t.Run("画像を追加", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+
+		key := "bbbb/full.png"
+		mockImageService.On("SaveImage", mock.Anything, (*models.PhotoCrop)(nil)).Return(key, nil)
+		mockImageService.On("CheckQuota", "", "", key).Return(int64(2048), nil)
+		mockService.On("AddCharacterImage", "char-1", mock.MatchedBy(func(image *models.CharacterImage) bool {
+			return image.Key == key && image.Size == 2048 && image.Caption == "衣装違い" &&
+				image.SourceURL == "https://example.com/costume" && image.IsPrimary
+		})).Return(&models.CharacterImage{ID: "image-2", CharacterID: "char-1", Key: key, IsPrimary: true}, nil)
+
+		body, contentType := multipartBody(map[string]string{
+			"caption":   "衣装違い",
+			"sourceUrl": "https://example.com/costume",
+			"isPrimary": "true",
+		}, true)
+		req, _ := http.NewRequest("POST", "/characters/char-1/images", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+		mockImageService.AssertExpectations(t)
+	})
+
+	t.Run("画像なしの追加は400", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+
+		body, contentType := multipartBody(map[string]string{"caption": "衣装違い"}, false)
+		req, _ := http.NewRequest("POST", "/characters/char-1/images", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockImageService.AssertNotCalled(t, "SaveImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("不正な出典URLは画像を保存せず422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+
+		body, contentType := multipartBody(map[string]string{"sourceUrl": "not a url"}, true)
+		req, _ := http.NewRequest("POST", "/characters/char-1/images", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockImageService.AssertNotCalled(t, "SaveImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("上限を超えた場合は保存した画像を破棄", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+
+		key := "bbbb/full.png"
+		mockImageService.On("SaveImage", mock.Anything, (*models.PhotoCrop)(nil)).Return(key, nil)
+		mockImageService.On("CheckQuota", "", "", key).Return(int64(2048), nil)
+		mockService.On("AddCharacterImage", "char-1", mock.Anything).
+			Return(nil, services.NewLimitExceededError("IMAGE_LIMIT_EXCEEDED", "character cannot have more than 20 images", nil))
+		mockImageService.On("DeleteImage", key).Return(nil)
+
+		body, contentType := multipartBody(nil, true)
+		req, _ := http.NewRequest("POST", "/characters/char-1/images", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockImageService.AssertExpectations(t)
+	})
+
+	t.Run("説明を部分更新", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService, new(MockImageService))
+
+		existing := &models.CharacterImage{ID: "image-2", CharacterID: "char-1", Caption: "旧", Credit: "作者", License: "CC BY 4.0"}
+		mockService.On("GetCharacterImage", "char-1", "image-2").Return(existing, nil)
+		mockService.On("UpdateCharacterImage", "char-1", mock.MatchedBy(func(image *models.CharacterImage) bool {
+			return image.ID == "image-2" && image.Caption == "新" && image.Credit == "作者" && image.License == "CC BY 4.0" && !image.IsPrimary
+		})).Return(&models.CharacterImage{ID: "image-2", CharacterID: "char-1", Caption: "新", Credit: "作者"}, nil)
+
+		req, _ := http.NewRequest("PATCH", "/characters/char-1/images/image-2", bytes.NewBufferString(`{"caption":"新"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("画像ファイルはパッチで変更できない", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService, new(MockImageService))
+
+		mockService.On("GetCharacterImage", "char-1", "image-2").Return(&models.CharacterImage{ID: "image-2", CharacterID: "char-1"}, nil)
+
+		req, _ := http.NewRequest("PATCH", "/characters/char-1/images/image-2", bytes.NewBufferString(`{"url":"other/full.png"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "UpdateCharacterImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("並べ替え", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService, new(MockImageService))
+
+		mockService.On("ReorderCharacterImages", "char-1", []string{"image-2", "image-1"}).Return([]models.CharacterImage{
+			{ID: "image-2", CharacterID: "char-1"},
+			{ID: "image-1", CharacterID: "char-1", Position: 1, IsPrimary: true},
+		}, nil)
+
+		req, _ := http.NewRequest("PUT", "/characters/char-1/images/order", bytes.NewBufferString(`{"imageIds":["image-2","image-1"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("削除した画像のファイルを削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+
+		mockService.On("DeleteCharacterImage", "char-1", "image-2").
+			Return(&models.CharacterImage{ID: "image-2", CharacterID: "char-1", Key: "bbbb/full.png"}, nil)
+		mockImageService.On("DeleteImage", "bbbb/full.png").Return(nil)
+
+		req, _ := http.NewRequest("DELETE", "/characters/char-1/images/image-2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockService.AssertExpectations(t)
+		mockImageService.AssertExpectations(t)
+	})
+
+	t.Run("存在しない画像の削除は404", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+
+		mockService.On("DeleteCharacterImage", "char-1", "missing").
+			Return(nil, services.NewNotFoundError("CHARACTER_IMAGE_NOT_FOUND", "character image not found"))
+
+		req, _ := http.NewRequest("DELETE", "/characters/char-1/images/missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockImageService.AssertNotCalled(t, "DeleteImage", mock.Anything)
+	})
+}
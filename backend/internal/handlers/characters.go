@@ -1,15 +1,17 @@
 package handlers
 
 import (
+	"character-management-app/internal/middleware"
 	"character-management-app/internal/models"
 	"character-management-app/internal/services"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/datatypes"
 )
 
 // CharacterHandler 人物ハンドラー
@@ -26,44 +28,95 @@ func NewCharacterHandler(characterService services.CharacterService, imageServic
 	}
 }
 
+// service リクエストのワークスペースに限定したサービスを返す
+func (h *CharacterHandler) service(c *gin.Context) services.CharacterService {
+	return h.characterService.WithWorkspace(middleware.WorkspaceID(c))
+}
+
 // CreateCharacterRequest 人物作成リクエスト
 type CreateCharacterRequest struct {
-	GroupID      string   `json:"groupId" validate:"required"`
-	Name         string   `json:"name" validate:"required,max=255"`
-	Information  string   `json:"information"`
-	RelatedLinks []string `json:"relatedLinks"`
+	GroupID      string               `json:"groupId" validate:"required"`
+	Name         string               `json:"name" validate:"required,max=255"`
+	Information  string               `json:"information"`
+	RelatedLinks []models.RelatedLink `json:"relatedLinks"`
+	// Aliases 別名・称号・読み・ローマ字表記（マルチパートでは aliases フィールドの JSON）
+	Aliases []CharacterAliasRequest `json:"aliases" validate:"omitempty,max=50,dive"`
+	// PhotoCrop マルチパートで写真と一緒に送る切り抜き範囲と注目点（photoCrop フィールドの JSON）
+	PhotoCrop *models.PhotoCrop `json:"-"`
+	// PhotoSize アップロードした写真の保存後の大きさ（ワークスペースの保存容量の確認時に求める）
+	PhotoSize int64 `json:"-"`
 }
 
 // UpdateCharacterRequest 人物更新リクエスト
 type UpdateCharacterRequest struct {
-	GroupID      string   `json:"groupId" validate:"required"`
-	Name         string   `json:"name" validate:"required,max=255"`
-	Information  string   `json:"information"`
-	RelatedLinks []string `json:"relatedLinks"`
+	GroupID      string               `json:"groupId" validate:"required"`
+	Name         string               `json:"name" validate:"required,max=255"`
+	Information  string               `json:"information"`
+	RelatedLinks []models.RelatedLink `json:"relatedLinks"`
+	// Aliases 別名・称号・読み・ローマ字表記（省略した場合は変更しない。マルチパートでは aliases フィールドの JSON）
+	Aliases []CharacterAliasRequest `json:"aliases" validate:"omitempty,max=50,dive"`
+	// PhotoCrop マルチパートで写真と一緒に送る切り抜き範囲と注目点（photoCrop フィールドの JSON）
+	PhotoCrop *models.PhotoCrop `json:"-"`
+	// PhotoSize アップロードした写真の保存後の大きさ（ワークスペースの保存容量の確認時に求める）
+	PhotoSize int64 `json:"-"`
+}
+
+// CharacterAliasRequest 人物の別名（type は alias・title・reading・romanization のいずれか）
+type CharacterAliasRequest struct {
+	Type string `json:"type" validate:"required,oneof=alias title reading romanization"`
+	Name string `json:"name" validate:"required,max=255"`
 }
 
 // GetCharacters 人物一覧を取得
+// labelId を指定した場合はラベルで絞り込み、includeDescendants=true では子孫のラベルが付いた人物も含める
+// q を指定した場合は条件式（例: (武将 OR 公家) AND NOT 故人）で絞り込む
 func (h *CharacterHandler) GetCharacters(c *gin.Context) {
 	// クエリパラメータからgroupIdを取得
 	groupID := c.Query("groupId")
+	labelID := c.Query("labelId")
+	q := c.Query("q")
 	
 	var characters []models.Character
 	var err error
 	
-	if groupID != "" {
+	if labelID != "" || q != "" {
+		// ラベル（とその子孫）や条件式で人物を検索（groupId との組み合わせも可）
+		includeDescendants, parseErr := strconv.ParseBool(c.DefaultQuery("includeDescendants", "false"))
+		if parseErr != nil {
+			c.Error(middleware.NewAppError("INVALID_QUERY", "includeDescendants must be true or false", parseErr.Error()))
+			return
+		}
+		characters, err = h.service(c).SearchCharacters(services.CharacterQuery{
+			GroupID:            groupID,
+			LabelID:            labelID,
+			IncludeDescendants: includeDescendants,
+			Q:                  q,
+		})
+	} else if groupID != "" {
 		// グループIDが指定されている場合、そのグループの人物を取得
-		characters, err = h.characterService.GetCharactersByGroupID(groupID)
+		characters, err = h.service(c).GetCharactersByGroupID(groupID)
 	} else {
 		// グループIDが指定されていない場合、全ての人物を取得
-		characters, err = h.characterService.GetAllCharacters()
+		characters, err = h.service(c).GetAllCharacters()
 	}
 	
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	
-	c.JSON(http.StatusOK, characters)
+	c.JSON(http.StatusOK, charactersWithURLs(h.imageService, characters))
+}
+
+// FindDuplicateCharacters 名前・別名が一致する同じグループの人物をまとめて取得（groupId で絞り込み可）
+func (h *CharacterHandler) FindDuplicateCharacters(c *gin.Context) {
+	duplicates, err := h.service(c).FindDuplicates(c.Query("groupId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, duplicatesWithURLs(h.imageService, duplicates))
 }
 
 // CreateCharacter 人物を作成
@@ -77,21 +130,19 @@ func (h *CharacterHandler) CreateCharacter(c *gin.Context) {
 	if strings.Contains(contentType, "multipart/form-data") {
 		// マルチパートフォームデータの場合
 		if err := h.handleMultipartCreate(c, &req, &photoPath); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 	} else {
 		// JSONの場合
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(invalidRequest(err))
 			return
 		}
 	}
 	
-	// RelatedLinksをJSONに変換
-	relatedLinksJSON, err := json.Marshal(req.RelatedLinks)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal related links"})
+	if !validateRequest(c, &req) {
+		h.discardUploadedPhoto(photoPath)
 		return
 	}
 	
@@ -101,34 +152,44 @@ func (h *CharacterHandler) CreateCharacter(c *gin.Context) {
 		Name:         req.Name,
 		Photo:        photoPath,
 		Information:  req.Information,
-		RelatedLinks: datatypes.JSON(relatedLinksJSON),
+		RelatedLinks: req.RelatedLinks,
+		Aliases:      characterAliases(req.Aliases),
+	}
+	if photoPath != nil {
+		character.PhotoCrop = req.PhotoCrop
+		character.PhotoSize = req.PhotoSize
 	}
 	
 	// 人物を作成
-	createdCharacter, err := h.characterService.CreateCharacter(character)
+	createdCharacter, err := h.service(c).CreateCharacter(character)
 	if err != nil {
 		// 画像ファイルが保存されている場合は削除
-		if photoPath != nil {
-			h.imageService.DeleteImage(*photoPath)
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.discardUploadedPhoto(photoPath)
+		c.Error(err)
 		return
 	}
 	
-	c.JSON(http.StatusCreated, createdCharacter)
+	setVariantETag(c, createdCharacter.Version, photoETagVariant(h.imageService, *createdCharacter))
+	c.JSON(http.StatusCreated, characterWithURLs(h.imageService, *createdCharacter))
 }
 
 // GetCharacter 人物を取得
 func (h *CharacterHandler) GetCharacter(c *gin.Context) {
 	id := c.Param("id")
 	
-	character, err := h.characterService.GetCharacterByID(id)
+	character, err := h.service(c).GetCharacterByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Character not found"})
+		c.Error(err)
+		return
+	}
+	
+	variant := photoETagVariant(h.imageService, *character)
+	if notModified(c, character.Version, variant) {
 		return
 	}
 	
-	c.JSON(http.StatusOK, character)
+	setVariantETag(c, character.Version, variant)
+	c.JSON(http.StatusOK, characterWithURLs(h.imageService, *character))
 }
 
 // UpdateCharacter 人物を更新
@@ -138,9 +199,14 @@ func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 	var photoPath *string
 	
 	// 既存の人物を取得
-	existingCharacter, err := h.characterService.GetCharacterByID(id)
+	existingCharacter, err := h.service(c).GetCharacterByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Character not found"})
+		c.Error(err)
+		return
+	}
+	
+	// If-Match で指定されたバージョンを確認（以降は取得時のバージョンに対して更新）
+	if !checkIfMatch(c, existingCharacter.Version) {
 		return
 	}
 	
@@ -150,21 +216,19 @@ func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 	if strings.Contains(contentType, "multipart/form-data") {
 		// マルチパートフォームデータの場合
 		if err := h.handleMultipartUpdate(c, &req, &photoPath); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 	} else {
 		// JSONの場合
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(invalidRequest(err))
 			return
 		}
 	}
 	
-	// RelatedLinksをJSONに変換
-	relatedLinksJSON, err := json.Marshal(req.RelatedLinks)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal related links"})
+	if !validateRequest(c, &req) {
+		h.discardUploadedPhoto(photoPath)
 		return
 	}
 	
@@ -173,34 +237,195 @@ func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 		GroupID:      req.GroupID,
 		Name:         req.Name,
 		Information:  req.Information,
-		RelatedLinks: datatypes.JSON(relatedLinksJSON),
+		RelatedLinks: req.RelatedLinks,
+		Aliases:      characterAliases(req.Aliases),
+		Version:      existingCharacter.Version,
 	}
 	
 	// 画像が新しくアップロードされた場合
 	if photoPath != nil {
 		character.Photo = photoPath
+		character.PhotoCrop = req.PhotoCrop
+		character.PhotoSize = req.PhotoSize
 	} else {
-		// 画像が変更されない場合は既存の画像パスを保持
+		// 画像が変更されない場合は既存の画像パスと切り抜き範囲を保持
 		character.Photo = existingCharacter.Photo
+		character.PhotoCrop = existingCharacter.PhotoCrop
+		character.PhotoSize = existingCharacter.PhotoSize
 	}
 	
 	// 人物を更新
-	updatedCharacter, err := h.characterService.UpdateCharacter(id, character)
+	updatedCharacter, err := h.service(c).UpdateCharacter(id, character)
 	if err != nil {
 		// 新しい画像ファイルが保存されている場合は削除
-		if photoPath != nil {
-			h.imageService.DeleteImage(*photoPath)
+		h.discardUploadedPhoto(photoPath)
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondCharacterConflict(c, id, existingCharacter.Version)
+			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	
 	// 古い画像ファイルを削除（新しい画像がアップロードされた場合）
 	if photoPath != nil && existingCharacter.Photo != nil {
-		h.imageService.DeleteImage(*existingCharacter.Photo)
+		h.deletePhoto(*existingCharacter.Photo)
+	}
+	
+	setVariantETag(c, updatedCharacter.Version, photoETagVariant(h.imageService, *updatedCharacter))
+	c.JSON(http.StatusOK, characterWithURLs(h.imageService, *updatedCharacter))
+}
+
+// characterPatchDocument PATCH で編集できる人物の項目（パッチ適用後に検証する）
+type characterPatchDocument struct {
+	GroupID      string                  `json:"groupId" validate:"required"`
+	Name         string                  `json:"name" validate:"required,max=255"`
+	Information  string                  `json:"information"`
+	RelatedLinks []models.RelatedLink    `json:"relatedLinks"`
+	Aliases      []CharacterAliasRequest `json:"aliases" validate:"max=50,dive"`
+	Photo        *string                 `json:"photo"`
+}
+
+// PatchCharacter 人物を部分更新（JSON Merge Patch / JSON Patch）
+// 写真はパッチで削除（null）のみ可能で、差し替えはマルチパートの PUT で行う
+func (h *CharacterHandler) PatchCharacter(c *gin.Context) {
+	id := c.Param("id")
+	
+	existingCharacter, err := h.service(c).GetCharacterByID(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	
+	if !checkIfMatch(c, existingCharacter.Version) {
+		return
+	}
+	
+	// 現在の値をパッチ適用前のドキュメントにする
+	current := characterPatchDocument{
+		GroupID:      existingCharacter.GroupID,
+		Name:         existingCharacter.Name,
+		Information:  existingCharacter.Information,
+		RelatedLinks: existingCharacter.RelatedLinks,
+		Aliases:      aliasRequests(existingCharacter.Aliases),
+		Photo:        existingCharacter.Photo,
+	}
+	
+	var patched characterPatchDocument
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
+		return
+	}
+	
+	if patched.Photo != nil && (existingCharacter.Photo == nil || *patched.Photo != *existingCharacter.Photo) {
+		c.Error(middleware.NewAppError("PHOTO_NOT_PATCHABLE", "photo can only be removed with PATCH; upload a new photo with PUT", nil).
+			WithStatus(http.StatusUnprocessableEntity))
+		return
+	}
+	
+	// aliases を null にした場合は全ての別名を削除する
+	if patched.Aliases == nil {
+		patched.Aliases = []CharacterAliasRequest{}
+	}
+	
+	// パッチ適用元のバージョンに対して更新する
+	character := &models.Character{
+		GroupID:      patched.GroupID,
+		Name:         patched.Name,
+		Photo:        patched.Photo,
+		Information:  patched.Information,
+		RelatedLinks: patched.RelatedLinks,
+		Aliases:      characterAliases(patched.Aliases),
+		Version:      existingCharacter.Version,
+	}
+	if patched.Photo != nil {
+		character.PhotoCrop = existingCharacter.PhotoCrop
+		character.PhotoSize = existingCharacter.PhotoSize
+	}
+	
+	updatedCharacter, err := h.service(c).UpdateCharacter(id, character)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondCharacterConflict(c, id, existingCharacter.Version)
+			return
+		}
+		c.Error(err)
+		return
+	}
+	
+	// 写真が削除された場合はファイルも削除
+	if patched.Photo == nil && existingCharacter.Photo != nil {
+		h.deletePhoto(*existingCharacter.Photo)
+	}
+	
+	setVariantETag(c, updatedCharacter.Version, photoETagVariant(h.imageService, *updatedCharacter))
+	c.JSON(http.StatusOK, characterWithURLs(h.imageService, *updatedCharacter))
+}
+
+// CropCharacterPhoto 登録済みの写真を切り抜き直す（画像の再アップロードは不要）
+// 新しいキーにバリアントを作り直し、更新に成功した後で元の画像を削除する
+func (h *CharacterHandler) CropCharacterPhoto(c *gin.Context) {
+	id := c.Param("id")
+	
+	existingCharacter, err := h.service(c).GetCharacterByID(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	
+	if !checkIfMatch(c, existingCharacter.Version) {
+		return
+	}
+	
+	if existingCharacter.Photo == nil {
+		c.Error(middleware.NewAppError("PHOTO_REQUIRED", "character has no photo to crop", nil).
+			WithStatus(http.StatusUnprocessableEntity))
+		return
+	}
+	
+	var crop models.PhotoCrop
+	if !bindJSON(c, &crop) {
+		return
 	}
 	
-	c.JSON(http.StatusOK, updatedCharacter)
+	photoPath, err := h.imageService.CropImage(*existingCharacter.Photo, &crop)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	photoSize, err := h.checkPhotoQuota(c, id, photoPath)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	
+	// 取得時のバージョンに対して写真と切り抜き範囲だけを差し替える
+	character := &models.Character{
+		GroupID:      existingCharacter.GroupID,
+		Name:         existingCharacter.Name,
+		Photo:        &photoPath,
+		PhotoCrop:    &crop,
+		PhotoSize:    photoSize,
+		Information:  existingCharacter.Information,
+		RelatedLinks: existingCharacter.RelatedLinks,
+		Version:      existingCharacter.Version,
+	}
+	
+	updatedCharacter, err := h.service(c).UpdateCharacter(id, character)
+	if err != nil {
+		h.discardUploadedPhoto(&photoPath)
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondCharacterConflict(c, id, existingCharacter.Version)
+			return
+		}
+		c.Error(err)
+		return
+	}
+	
+	h.deletePhoto(*existingCharacter.Photo)
+	
+	setVariantETag(c, updatedCharacter.Version, photoETagVariant(h.imageService, *updatedCharacter))
+	c.JSON(http.StatusOK, characterWithURLs(h.imageService, *updatedCharacter))
 }
 
 // DeleteCharacter 人物を削除
@@ -208,21 +433,33 @@ func (h *CharacterHandler) DeleteCharacter(c *gin.Context) {
 	id := c.Param("id")
 	
 	// 削除前に人物を取得（画像ファイル削除のため）
-	character, err := h.characterService.GetCharacterByID(id)
+	character, err := h.service(c).GetCharacterByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Character not found"})
+		c.Error(err)
+		return
+	}
+	
+	// If-Match で指定されたバージョンを確認
+	if !checkIfMatch(c, character.Version) {
 		return
 	}
 	
 	// 人物を削除
-	if err := h.characterService.DeleteCharacter(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service(c).DeleteCharacter(id); err != nil {
+		c.Error(err)
 		return
 	}
 	
-	// 画像ファイルを削除
+	// 画像ファイルを削除（ギャラリーの画像を含む。代表画像は photo と同じキー）
+	keys := make(map[string]bool)
 	if character.Photo != nil {
-		h.imageService.DeleteImage(*character.Photo)
+		keys[*character.Photo] = true
+	}
+	for _, image := range character.Images {
+		keys[image.Key] = true
+	}
+	for key := range keys {
+		h.deletePhoto(key)
 	}
 	
 	c.JSON(http.StatusNoContent, nil)
@@ -233,20 +470,12 @@ func (h *CharacterHandler) AddLabelToCharacter(c *gin.Context) {
 	characterID := c.Param("id")
 	labelID := c.Param("labelId")
 	
-	if err := h.characterService.AddLabelToCharacter(characterID, labelID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "more than 5 labels") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "already has this label") {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+	
+	if err := h.service(c).AddLabelToCharacter(characterID, labelID); err != nil {
+		c.Error(err)
 		return
 	}
 	
@@ -258,45 +487,192 @@ func (h *CharacterHandler) RemoveLabelFromCharacter(c *gin.Context) {
 	characterID := c.Param("id")
 	labelID := c.Param("labelId")
 	
-	if err := h.characterService.RemoveLabelFromCharacter(characterID, labelID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+	
+	if err := h.service(c).RemoveLabelFromCharacter(characterID, labelID); err != nil {
+		c.Error(err)
 		return
 	}
 	
 	c.JSON(http.StatusOK, gin.H{"message": "Label removed successfully"})
 }
 
+// ReplaceCharacterLabelsRequest 人物のラベルの置き換えリクエスト
+type ReplaceCharacterLabelsRequest struct {
+	LabelIDs []string `json:"labelIds" validate:"required,max=100"`
+}
+
+// ReplaceCharacterLabels 人物のラベルを指定したラベルに置き換える（空の配列で全て外す）
+func (h *CharacterHandler) ReplaceCharacterLabels(c *gin.Context) {
+	characterID := c.Param("id")
+
+	if !h.checkCharacterPrecondition(c, characterID) {
+		return
+	}
+
+	var req ReplaceCharacterLabelsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	character, err := h.service(c).ReplaceCharacterLabels(characterID, req.LabelIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setVariantETag(c, character.Version, photoETagVariant(h.imageService, *character))
+	c.JSON(http.StatusOK, characterWithURLs(h.imageService, *character))
+}
+
+// AssignLabelRequest ラベルの一括付け外しリクエスト
+type AssignLabelRequest struct {
+	Add    []string `json:"add" validate:"max=1000"`
+	Remove []string `json:"remove" validate:"max=1000"`
+}
+
+// AssignLabel ラベルを複数の人物に一括で付け外しし、人物ごとの結果を返す
+func (h *CharacterHandler) AssignLabel(c *gin.Context) {
+	var req AssignLabelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	results, err := h.service(c).AssignLabelToCharacters(c.Param("id"), req.Add, req.Remove)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// MergeLabel ラベルを別のラベルに統合する（ラベルが付いた人物を付け替えてから統合元のラベルを削除する）
+func (h *CharacterHandler) MergeLabel(c *gin.Context) {
+	result, err := h.service(c).MergeLabel(c.Param("id"), c.Param("targetId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if result.Target != nil {
+		target := labelWithTextColor(*result.Target)
+		result.Target = &target
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// checkCharacterPrecondition If-Match が指定されている場合に人物のバージョンを確認
+// 条件を満たさない場合はレスポンスを書き込んで false を返す
+func (h *CharacterHandler) checkCharacterPrecondition(c *gin.Context, id string) bool {
+	if c.GetHeader("If-Match") == "" {
+		return true
+	}
+
+	character, err := h.service(c).GetCharacterByID(id)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+
+	return checkIfMatch(c, character.Version)
+}
+
+// respondCharacterConflict 更新競合時に最新のバージョンを付けて412を返す
+func (h *CharacterHandler) respondCharacterConflict(c *gin.Context, id string, fallbackVersion uint) {
+	if current, err := h.service(c).GetCharacterByID(id); err == nil {
+		fallbackVersion = current.Version
+	}
+	respondPreconditionFailed(c, fallbackVersion)
+}
+
+// discardUploadedPhoto 保存済みのアップロード画像を破棄する（後続の処理が失敗した場合）
+func (h *CharacterHandler) discardUploadedPhoto(photoPath *string) {
+	if photoPath != nil {
+		h.deletePhoto(*photoPath)
+	}
+}
+
+// deletePhoto 不要になった画像を削除する
+// 失敗してもレスポンスには影響させず記録のみ行う（残ったファイルは管理用の GC で削除する）
+func (h *CharacterHandler) deletePhoto(key string) {
+	if err := h.imageService.DeleteImage(key); err != nil {
+		fmt.Printf("ERROR: Failed to delete image %s: %v\n", key, err)
+	}
+}
+
+// checkPhotoQuota 保存した写真がワークスペースの保存容量に収まるか確認し、写真の大きさを返す
+// characterID の人物の現在の写真は置き換えるため使用量に含めない（作成時は空）。超えた場合は保存した写真を破棄する
+func (h *CharacterHandler) checkPhotoQuota(c *gin.Context, characterID, key string) (int64, error) {
+	size, err := h.imageService.CheckQuota(middleware.WorkspaceID(c), characterID, key)
+	if err != nil {
+		h.deletePhoto(key)
+		return 0, err
+	}
+	return size, nil
+}
+
 // handleMultipartCreate マルチパートフォームデータでの作成処理
 func (h *CharacterHandler) handleMultipartCreate(c *gin.Context, req *CreateCharacterRequest, photoPath **string) error {
+	// フォームを解析（ボディが上限を超えた場合は413）
+	if _, err := c.MultipartForm(); err != nil {
+		return invalidRequest(err)
+	}
+	
 	// フォームデータを取得
 	req.GroupID = c.PostForm("groupId")
 	req.Name = c.PostForm("name")
 	req.Information = c.PostForm("information")
 	
-	// RelatedLinksを解析
+	// RelatedLinksを解析（URL の文字列の配列も受け付ける）
 	relatedLinksStr := c.PostForm("relatedLinks")
 	if relatedLinksStr != "" {
 		if err := json.Unmarshal([]byte(relatedLinksStr), &req.RelatedLinks); err != nil {
-			return fmt.Errorf("invalid relatedLinks format: %w", err)
+			return middleware.NewAppError("INVALID_REQUEST", "invalid relatedLinks format", err.Error())
 		}
 	}
 	
+	// 別名を解析
+	if aliasesStr := c.PostForm("aliases"); aliasesStr != "" {
+		if err := json.Unmarshal([]byte(aliasesStr), &req.Aliases); err != nil {
+			return middleware.NewAppError("INVALID_REQUEST", "invalid aliases format", err.Error())
+		}
+	}
+	
+	// 切り抜き範囲と注目点を解析
+	photoCrop, err := parseCrop(c, "photoCrop")
+	if err != nil {
+		return err
+	}
+	req.PhotoCrop = photoCrop
+	
 	// 画像ファイルを処理
 	file, header, err := c.Request.FormFile("photo")
 	if err == nil {
 		defer file.Close()
 		
-		// 画像サービスを使用してファイルを保存（800x600にリサイズ）
-		path, err := h.imageService.SaveImage(file, header.Filename, 800, 600)
+		// 画像サービスを使用してファイルを保存（サイズ別のバリアントを生成）
+		path, err := h.imageService.SaveImage(file, photoCrop)
 		if err != nil {
+			// 対応していない形式・壊れた画像はサービスのエラーとして返す
+			var serviceError *services.Error
+			if errors.As(err, &serviceError) {
+				return err
+			}
 			fmt.Printf("ERROR: Failed to save image file %s: %v\n", header.Filename, err)
-			return fmt.Errorf("failed to save uploaded file: %w", err)
+			return middleware.NewAppError("IMAGE_UPLOAD_FAILED", "failed to save uploaded file", err.Error())
+		}
+		
+		// ワークスペースの保存容量を確認
+		req.PhotoSize, err = h.checkPhotoQuota(c, "", path)
+		if err != nil {
+			return err
 		}
 		*photoPath = &path
+	} else if photoCrop != nil {
+		return middleware.NewAppError("INVALID_REQUEST", "photoCrop requires photo; use PUT /characters/{id}/photo/crop to re-crop the current photo", nil)
 	}
 	
 	return nil
@@ -304,33 +680,98 @@ func (h *CharacterHandler) handleMultipartCreate(c *gin.Context, req *CreateChar
 
 // handleMultipartUpdate マルチパートフォームデータでの更新処理
 func (h *CharacterHandler) handleMultipartUpdate(c *gin.Context, req *UpdateCharacterRequest, photoPath **string) error {
+	// フォームを解析（ボディが上限を超えた場合は413）
+	if _, err := c.MultipartForm(); err != nil {
+		return invalidRequest(err)
+	}
+	
 	// フォームデータを取得
 	req.GroupID = c.PostForm("groupId")
 	req.Name = c.PostForm("name")
 	req.Information = c.PostForm("information")
 	
-	// RelatedLinksを解析
+	// RelatedLinksを解析（URL の文字列の配列も受け付ける）
 	relatedLinksStr := c.PostForm("relatedLinks")
 	if relatedLinksStr != "" {
 		if err := json.Unmarshal([]byte(relatedLinksStr), &req.RelatedLinks); err != nil {
-			return fmt.Errorf("invalid relatedLinks format: %w", err)
+			return middleware.NewAppError("INVALID_REQUEST", "invalid relatedLinks format", err.Error())
+		}
+	}
+	
+	// 別名を解析
+	if aliasesStr := c.PostForm("aliases"); aliasesStr != "" {
+		if err := json.Unmarshal([]byte(aliasesStr), &req.Aliases); err != nil {
+			return middleware.NewAppError("INVALID_REQUEST", "invalid aliases format", err.Error())
 		}
 	}
 	
+	// 切り抜き範囲と注目点を解析
+	photoCrop, err := parseCrop(c, "photoCrop")
+	if err != nil {
+		return err
+	}
+	req.PhotoCrop = photoCrop
+	
 	// 画像ファイルを処理
 	file, header, err := c.Request.FormFile("photo")
 	if err == nil {
 		defer file.Close()
 		
-		// 画像サービスを使用してファイルを保存（800x600にリサイズ）
-		path, err := h.imageService.SaveImage(file, header.Filename, 800, 600)
+		// 画像サービスを使用してファイルを保存（サイズ別のバリアントを生成）
+		path, err := h.imageService.SaveImage(file, photoCrop)
 		if err != nil {
+			// 対応していない形式・壊れた画像はサービスのエラーとして返す
+			var serviceError *services.Error
+			if errors.As(err, &serviceError) {
+				return err
+			}
 			fmt.Printf("ERROR: Failed to save image file %s: %v\n", header.Filename, err)
-			return fmt.Errorf("failed to save uploaded file: %w", err)
+			return middleware.NewAppError("IMAGE_UPLOAD_FAILED", "failed to save uploaded file", err.Error())
+		}
+		
+		// ワークスペースの保存容量を確認
+		req.PhotoSize, err = h.checkPhotoQuota(c, c.Param("id"), path)
+		if err != nil {
+			return err
 		}
 		*photoPath = &path
+	} else if photoCrop != nil {
+		return middleware.NewAppError("INVALID_REQUEST", "photoCrop requires photo; use PUT /characters/{id}/photo/crop to re-crop the current photo", nil)
 	}
 	
 	return nil
 }
 
+// characterAliases 別名のリクエストを人物の別名にする（未指定の場合は nil で、更新時は既存の別名を保持する）
+func characterAliases(reqs []CharacterAliasRequest) []models.CharacterAlias {
+	if reqs == nil {
+		return nil
+	}
+	aliases := make([]models.CharacterAlias, len(reqs))
+	for i, req := range reqs {
+		aliases[i] = models.CharacterAlias{Type: req.Type, Name: req.Name}
+	}
+	return aliases
+}
+
+// aliasRequests 人物の別名をリクエストの形にする（PATCH の適用前のドキュメント用）
+func aliasRequests(aliases []models.CharacterAlias) []CharacterAliasRequest {
+	reqs := make([]CharacterAliasRequest, len(aliases))
+	for i, alias := range aliases {
+		reqs[i] = CharacterAliasRequest{Type: alias.Type, Name: alias.Name}
+	}
+	return reqs
+}
+
+// parseCrop マルチパートの切り抜き範囲のフィールド（JSON）を解析（未指定の場合は nil）
+func parseCrop(c *gin.Context, field string) (*models.PhotoCrop, error) {
+	value := c.PostForm(field)
+	if value == "" {
+		return nil, nil
+	}
+	var crop models.PhotoCrop
+	if err := json.Unmarshal([]byte(value), &crop); err != nil {
+		return nil, middleware.NewAppError("INVALID_REQUEST", fmt.Sprintf("invalid %s format", field), err.Error())
+	}
+	return &crop, nil
+}
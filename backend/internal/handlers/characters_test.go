@@ -2,18 +2,25 @@ package handlers
 
 import (
 	"bytes"
+	"character-management-app/internal/middleware"
 	"character-management-app/internal/models"
+	"character-management-app/internal/services"
+	"character-management-app/internal/storage"
 	"encoding/json"
 	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"gorm.io/datatypes"
+	"github.com/stretchr/testify/require"
 )
 
 // MockCharacterService キャラクターサービスのモック
@@ -36,6 +43,21 @@ func (m *MockCharacterService) GetCharactersByGroupID(groupID string) ([]models.
 	return args.Get(0).([]models.Character), args.Error(1)
 }
 
+func (m *MockCharacterService) SearchCharacters(query services.CharacterQuery) ([]models.Character, error) {
+	args := m.Called(query)
+	return args.Get(0).([]models.Character), args.Error(1)
+}
+
+func (m *MockCharacterService) FindDuplicates(groupID string) ([]services.CharacterDuplicate, error) {
+	args := m.Called(groupID)
+	return args.Get(0).([]services.CharacterDuplicate), args.Error(1)
+}
+
+func (m *MockCharacterService) MigrateRelatedLinks() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockCharacterService) GetAllCharacters() ([]models.Character, error) {
 	args := m.Called()
 	return args.Get(0).([]models.Character), args.Error(1)
@@ -61,40 +83,147 @@ func (m *MockCharacterService) RemoveLabelFromCharacter(characterID, labelID str
 	return args.Error(0)
 }
 
+func (m *MockCharacterService) ReplaceCharacterLabels(characterID string, labelIDs []string) (*models.Character, error) {
+	args := m.Called(characterID, labelIDs)
+	return args.Get(0).(*models.Character), args.Error(1)
+}
+
+func (m *MockCharacterService) AssignLabelToCharacters(labelID string, addIDs, removeIDs []string) ([]services.LabelAssignmentResult, error) {
+	args := m.Called(labelID, addIDs, removeIDs)
+	return args.Get(0).([]services.LabelAssignmentResult), args.Error(1)
+}
+
+func (m *MockCharacterService) MergeLabel(sourceID, targetID string) (*services.LabelMergeResult, error) {
+	args := m.Called(sourceID, targetID)
+	return args.Get(0).(*services.LabelMergeResult), args.Error(1)
+}
+
+func (m *MockCharacterService) GetCharacterImages(characterID string) ([]models.CharacterImage, error) {
+	args := m.Called(characterID)
+	return args.Get(0).([]models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterService) GetCharacterImage(characterID, imageID string) (*models.CharacterImage, error) {
+	args := m.Called(characterID, imageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterService) AddCharacterImage(characterID string, image *models.CharacterImage) (*models.CharacterImage, error) {
+	args := m.Called(characterID, image)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterService) UpdateCharacterImage(characterID string, image *models.CharacterImage) (*models.CharacterImage, error) {
+	args := m.Called(characterID, image)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterService) ReorderCharacterImages(characterID string, imageIDs []string) ([]models.CharacterImage, error) {
+	args := m.Called(characterID, imageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterService) DeleteCharacterImage(characterID, imageID string) (*models.CharacterImage, error) {
+	args := m.Called(characterID, imageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterService) WithWorkspace(workspaceID string) services.CharacterService {
+	return m
+}
+
 // MockImageService 画像サービスのモック
 type MockImageService struct {
 	mock.Mock
 }
 
-func (m *MockImageService) SaveImage(file io.Reader, filename string, maxWidth, maxHeight uint) (string, error) {
-	args := m.Called(file, filename, maxWidth, maxHeight)
+func (m *MockImageService) SaveImage(file io.Reader, crop *models.PhotoCrop) (string, error) {
+	args := m.Called(file, crop)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockImageService) DeleteImage(filePath string) error {
-	args := m.Called(filePath)
-	return args.Error(0)
+func (m *MockImageService) CropImage(key string, crop *models.PhotoCrop) (string, error) {
+	args := m.Called(key, crop)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockImageService) MigrateContentKeys() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockImageService) RecordPhotoSizes() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
 }
 
-func (m *MockImageService) ValidateImageFile(filename string) error {
-	args := m.Called(filename)
+func (m *MockImageService) CheckQuota(workspaceID, characterID, key string) (int64, error) {
+	args := m.Called(workspaceID, characterID, key)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockImageService) VerifyURL(key, expires, signature string) (time.Time, error) {
+	args := m.Called(key, expires, signature)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockImageService) DeleteImage(key string) error {
+	args := m.Called(key)
 	return args.Error(0)
 }
 
+func (m *MockImageService) OpenImage(key string) (*storage.Object, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.Object), args.Error(1)
+}
+
+// URL レスポンスの作成のたびに呼ばれるため、期待値を設定せずにキーをそのまま返す
+func (m *MockImageService) URL(key string) string {
+	return key
+}
+
+// VariantURLs URL と同じく期待値を設定せずにバリアントのURLなし（nil）を返す
+func (m *MockImageService) VariantURLs(key string) map[string]string {
+	return nil
+}
+
+// URLExpiry URL と同じく期待値を設定せずに署名なし（ゼロ値）を返す
+func (m *MockImageService) URLExpiry() time.Time {
+	return time.Time{}
+}
+
+
 
 
 func TestCharacterHandler_GetCharacters(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
-	
-	router := setupTestRouter()
-	router.GET("/characters", handler.GetCharacters)
-	
 	t.Run("全キャラクター取得", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
 		characters := []models.Character{
-			{ID: "char-1", Name: "Character 1"},
-			{ID: "char-2", Name: "Character 2"},
+			{ID: "char-1", Name: "Character 1", RelatedLinks: []models.RelatedLink{}},
+			{ID: "char-2", Name: "Character 2", RelatedLinks: []models.RelatedLink{}},
 		}
 		
 		mockService.On("GetAllCharacters").Return(characters, nil)
@@ -114,8 +243,14 @@ func TestCharacterHandler_GetCharacters(t *testing.T) {
 	})
 	
 	t.Run("グループ別キャラクター取得", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
 		characters := []models.Character{
-			{ID: "char-1", GroupID: "group-1", Name: "Character 1"},
+			{ID: "char-1", GroupID: "group-1", Name: "Character 1", RelatedLinks: []models.RelatedLink{}},
 		}
 		
 		mockService.On("GetCharactersByGroupID", "group-1").Return(characters, nil)
@@ -135,6 +270,12 @@ func TestCharacterHandler_GetCharacters(t *testing.T) {
 	})
 	
 	t.Run("全キャラクター取得でエラー", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
 		mockService.On("GetAllCharacters").Return([]models.Character{}, errors.New("database error"))
 		
 		req, _ := http.NewRequest("GET", "/characters", nil)
@@ -147,6 +288,12 @@ func TestCharacterHandler_GetCharacters(t *testing.T) {
 	})
 	
 	t.Run("グループ別キャラクター取得でエラー", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
 		mockService.On("GetCharactersByGroupID", "group-1").Return([]models.Character{}, errors.New("group not found"))
 		
 		req, _ := http.NewRequest("GET", "/characters?groupId=group-1", nil)
@@ -157,30 +304,107 @@ func TestCharacterHandler_GetCharacters(t *testing.T) {
 		
 		mockService.AssertExpectations(t)
 	})
+	
+	t.Run("ラベル別キャラクター取得（子孫を含む）", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
+		characters := []models.Character{
+			{ID: "char-1", GroupID: "group-1", Name: "Character 1", RelatedLinks: []models.RelatedLink{}},
+		}
+		
+		mockService.On("SearchCharacters", services.CharacterQuery{
+			GroupID:            "group-1",
+			LabelID:            "label-1",
+			IncludeDescendants: true,
+		}).Return(characters, nil)
+		
+		req, _ := http.NewRequest("GET", "/characters?groupId=group-1&labelId=label-1&includeDescendants=true", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("includeDescendants が不正な場合は400", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
+		req, _ := http.NewRequest("GET", "/characters?labelId=label-1&includeDescendants=maybe", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "SearchCharacters", mock.Anything)
+	})
+	
+	t.Run("条件式で検索", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
+		mockService.On("SearchCharacters", services.CharacterQuery{Q: "(武将 OR 公家) AND NOT 故人"}).Return([]models.Character{}, nil)
+		
+		req, _ := http.NewRequest("GET", "/characters?q="+url.QueryEscape("(武将 OR 公家) AND NOT 故人"), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("条件式の構文エラーは422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters", handler.GetCharacters)
+		
+		mockService.On("SearchCharacters", services.CharacterQuery{Q: "武将 OR"}).Return([]models.Character(nil),
+			services.NewValidationError("INVALID_QUERY_SYNTAX", "syntax error at position 5: unexpected end of query", map[string]interface{}{"position": 5}))
+		
+		req, _ := http.NewRequest("GET", "/characters?q="+url.QueryEscape("武将 OR"), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		
+		var problem middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "INVALID_QUERY_SYNTAX", problem.Code)
+		assert.Equal(t, float64(5), problem.Details.(map[string]interface{})["position"])
+	})
 }
 
 func TestCharacterHandler_CreateCharacter(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
-	
-	router := setupTestRouter()
-	router.POST("/characters", handler.CreateCharacter)
-	
 	t.Run("JSON形式でキャラクター作成", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
 		reqData := CreateCharacterRequest{
 			GroupID:      "group-1",
 			Name:         "Test Character",
 			Information:  "Test Info",
-			RelatedLinks: []string{"http://example.com"},
+			RelatedLinks: []models.RelatedLink{{URL: "http://example.com"}},
 		}
 		
-		relatedLinksJSON, _ := json.Marshal(reqData.RelatedLinks)
 		expectedCharacter := &models.Character{
 			GroupID:      "group-1",
 			Name:         "Test Character",
 			Information:  "Test Info",
-			RelatedLinks: datatypes.JSON(relatedLinksJSON),
+			RelatedLinks: reqData.RelatedLinks,
 		}
 		
 		createdCharacter := &models.Character{
@@ -188,7 +412,7 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 			GroupID:      "group-1",
 			Name:         "Test Character",
 			Information:  "Test Info",
-			RelatedLinks: datatypes.JSON(relatedLinksJSON),
+			RelatedLinks: reqData.RelatedLinks,
 		}
 		
 		mockService.On("CreateCharacter", mock.MatchedBy(func(char *models.Character) bool {
@@ -215,6 +439,12 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 	})
 	
 	t.Run("マルチパート形式でキャラクター作成（画像付き）", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
 		// マルチパートフォームデータを作成
 		var buf bytes.Buffer
 		writer := multipart.NewWriter(&buf)
@@ -230,9 +460,9 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 		writer.Close()
 		
 		// モックの設定
-		mockImageService.On("SaveImage", mock.Anything, "test.jpg", uint(800), uint(600)).Return("uploads/characters/test.jpg", nil)
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return("uploads/characters/test.jpg", nil)
+		mockImageService.On("CheckQuota", "", "", "uploads/characters/test.jpg").Return(int64(1024), nil)
 		
-		relatedLinksJSON, _ := json.Marshal([]string{"http://example.com"})
 		photoPath := "uploads/characters/test.jpg"
 		createdCharacter := &models.Character{
 			ID:           "char-1",
@@ -240,7 +470,7 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 			Name:         "Test Character",
 			Information:  "Test Info",
 			Photo:        &photoPath,
-			RelatedLinks: datatypes.JSON(relatedLinksJSON),
+			RelatedLinks: []models.RelatedLink{{URL: "http://example.com"}},
 		}
 		
 		mockService.On("CreateCharacter", mock.MatchedBy(func(char *models.Character) bool {
@@ -268,7 +498,48 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 		mockImageService.AssertExpectations(t)
 	})
 	
+	t.Run("対応していない形式の画像", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		writer.WriteField("groupId", "group-1")
+		writer.WriteField("name", "Test Character")
+		part, _ := writer.CreateFormFile("photo", "photo.jpg")
+		part.Write([]byte("BM not really an image"))
+		writer.Close()
+
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).
+			Return("", services.NewValidationError("UNSUPPORTED_IMAGE_TYPE", "unsupported image type: image/bmp", nil))
+
+		req, _ := http.NewRequest("POST", "/characters", &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response middleware.Problem
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "UNSUPPORTED_IMAGE_TYPE", response.Code)
+
+		mockService.AssertNotCalled(t, "CreateCharacter", mock.Anything)
+		mockImageService.AssertExpectations(t)
+	})
+
 	t.Run("画像保存エラー時のキャラクター作成", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
 		// マルチパートフォームデータを作成
 		var buf bytes.Buffer
 		writer := multipart.NewWriter(&buf)
@@ -282,7 +553,7 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 		writer.Close()
 		
 		// モックの設定（画像保存でエラー）
-		mockImageService.On("SaveImage", mock.Anything, "test.jpg", uint(800), uint(600)).Return("", errors.New("failed to save image"))
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return("", errors.New("failed to save image"))
 		
 		req, _ := http.NewRequest("POST", "/characters", &buf)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -296,6 +567,12 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 	})
 	
 	t.Run("サービスエラー時の画像削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
 		// マルチパートフォームデータを作成
 		var buf bytes.Buffer
 		writer := multipart.NewWriter(&buf)
@@ -309,7 +586,8 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 		writer.Close()
 		
 		// モックの設定
-		mockImageService.On("SaveImage", mock.Anything, "test.jpg", uint(800), uint(600)).Return("uploads/characters/test.jpg", nil)
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return("uploads/characters/test.jpg", nil)
+		mockImageService.On("CheckQuota", "", "", "uploads/characters/test.jpg").Return(int64(1024), nil)
 		mockService.On("CreateCharacter", mock.Anything).Return((*models.Character)(nil), errors.New("service error"))
 		mockImageService.On("DeleteImage", "uploads/characters/test.jpg").Return(nil)
 		
@@ -326,6 +604,12 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 	})
 	
 	t.Run("不正なJSONでキャラクター作成", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
 		req, _ := http.NewRequest("POST", "/characters", bytes.NewBuffer([]byte("invalid json")))
 		req.Header.Set("Content-Type", "application/json")
 		
@@ -336,6 +620,12 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 	})
 	
 	t.Run("不正なRelatedLinksでキャラクター作成", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
 		// マルチパートフォームデータを作成
 		var buf bytes.Buffer
 		writer := multipart.NewWriter(&buf)
@@ -353,17 +643,57 @@ func TestCharacterHandler_CreateCharacter(t *testing.T) {
 		
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+	
+	t.Run("別名付きでキャラクター作成", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
+		mockService.On("CreateCharacter", mock.MatchedBy(func(char *models.Character) bool {
+			return assert.ObjectsAreEqual([]models.CharacterAlias{
+				{Type: models.AliasTypeTitle, Name: "右大臣"},
+				{Type: models.AliasTypeReading, Name: "おだ のぶなが"},
+			}, char.Aliases)
+		})).Return(&models.Character{ID: "char-1"}, nil)
+		
+		body := `{"groupId":"group-1","name":"織田信長","aliases":[{"type":"title","name":"右大臣"},{"type":"reading","name":"おだ のぶなが"}]}`
+		req, _ := http.NewRequest("POST", "/characters", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("不明な種類の別名は422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
+		body := `{"groupId":"group-1","name":"織田信長","aliases":[{"type":"nickname","name":"うつけ"}]}`
+		req, _ := http.NewRequest("POST", "/characters", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "CreateCharacter", mock.Anything)
+	})
 }
 
 func TestCharacterHandler_GetCharacter(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
-	
-	router := setupTestRouter()
-	router.GET("/characters/:id", handler.GetCharacter)
-	
 	t.Run("正常なキャラクター取得", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters/:id", handler.GetCharacter)
+		
 		character := &models.Character{
 			ID:      "char-1",
 			GroupID: "group-1",
@@ -387,7 +717,13 @@ func TestCharacterHandler_GetCharacter(t *testing.T) {
 	})
 	
 	t.Run("存在しないキャラクター取得", func(t *testing.T) {
-		mockService.On("GetCharacterByID", "nonexistent").Return((*models.Character)(nil), errors.New("character not found"))
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.GET("/characters/:id", handler.GetCharacter)
+		
+		mockService.On("GetCharacterByID", "nonexistent").Return((*models.Character)(nil), services.NewNotFoundError("CHARACTER_NOT_FOUND", "character not found"))
 		
 		req, _ := http.NewRequest("GET", "/characters/nonexistent", nil)
 		w := httptest.NewRecorder()
@@ -399,15 +735,361 @@ func TestCharacterHandler_GetCharacter(t *testing.T) {
 	})
 }
 
-func TestCharacterHandler_UpdateCharacter(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
+func TestCharacterHandler_GetCharacter_ETag(t *testing.T) {
+	t.Run("ETagヘッダーを返す", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.GET("/characters/:id", handler.GetCharacter)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 4}, nil)
+		
+		req, _ := http.NewRequest("GET", "/characters/char-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `W/"4"`, w.Header().Get("ETag"))
+	})
+	
+	t.Run("If-None-Matchが一致すれば304", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.GET("/characters/:id", handler.GetCharacter)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 4}, nil)
+		
+		req, _ := http.NewRequest("GET", "/characters/char-1", nil)
+		req.Header.Set("If-None-Match", `W/"3", W/"4"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+	
+	t.Run("署名付きURLの有効期限が変わったら304を返さない", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)
+		imageService := services.NewImageService(storage.NewLocal(t.TempDir(), "/uploads"), nil, services.ImageOptions{
+			URLSecret: []byte(strings.Repeat("s", services.MinURLSecretLength)),
+			Clock:     func() time.Time { return now },
+		})
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, imageService)
+		router := setupTestRouter()
+		router.GET("/characters/:id", handler.GetCharacter)
+		
+		photoPath := "aaaa/full.jpg"
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Photo: &photoPath, Version: 4}, nil)
+		
+		// get If-None-Match を付けて人物を取得
+		get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", "/characters/char-1", nil)
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+		
+		expires := strconv.FormatInt(time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC).Unix(), 10)
+		w := get("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		assert.Equal(t, `W/"4-`+expires+`"`, etag)
+		
+		// 同じ有効期限の間は304
+		w = get(etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		
+		// 有効期限を含まないETagでは304を返さない
+		assert.Equal(t, http.StatusOK, get(`W/"4"`).Code)
+		
+		// 署名の区切りを過ぎたら古いETagでは新しいURLを返す
+		now = now.Add(time.Hour)
+		w = get(etag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		renewed := strconv.FormatInt(time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC).Unix(), 10)
+		assert.Equal(t, `W/"4-`+renewed+`"`, w.Header().Get("ETag"))
+		var response models.Character
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Contains(t, *response.Photo, "expires="+renewed)
+	})
+}
+
+func TestCharacterHandler_UpdateCharacter_Precondition(t *testing.T) {
+	reqData := UpdateCharacterRequest{GroupID: "group-1", Name: "New Name"}
+	jsonData, _ := json.Marshal(reqData)
 	
-	router := setupTestRouter()
-	router.PUT("/characters/:id", handler.UpdateCharacter)
+	t.Run("古いIf-Matchは412", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 5}, nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"4"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `"currentVersion":5`)
+		mockService.AssertNotCalled(t, "UpdateCharacter", mock.Anything, mock.Anything)
+	})
 	
+	t.Run("If-Matchのバージョンをサービスに渡す", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 5}, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Version == 5
+		})).Return(&models.Character{ID: "char-1", Name: "New Name", Version: 6}, nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"5"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `W/"6"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("有効期限付きのETagのIf-Matchはバージョンで比較", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 5}, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Version == 5
+		})).Return(&models.Character{ID: "char-1", Name: "New Name", Version: 6}, nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"5-1767276000"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCharacterHandler_PatchCharacter(t *testing.T) {
+	existing := &models.Character{ID: "char-1", GroupID: "group-1", Name: "Old Name", Version: 3}
+	
+	newRouter := func(mockService *MockCharacterService) http.Handler {
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.PATCH("/characters/:id", handler.PatchCharacter)
+		return router
+	}
+	
+	t.Run("JSON Merge Patchで名前のみ更新", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Name == "New Name" && char.GroupID == "group-1" && char.Version == 3
+		})).Return(&models.Character{ID: "char-1", GroupID: "group-1", Name: "New Name", Version: 4}, nil)
+		
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`{"name":"New Name"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `W/"4"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("写真を削除するとギャラリーの次の画像が代表画像になる", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PATCH("/characters/:id", handler.PatchCharacter)
+
+		photo := "aaaa/full.jpg"
+		promoted := "cccc/full.jpg"
+		withGallery := &models.Character{ID: "char-1", GroupID: "group-1", Name: "徳川家康", Photo: &photo, Version: 3, Images: []models.CharacterImage{
+			{ID: "image-1", Key: photo, IsPrimary: true, Position: 0},
+			{ID: "image-3", Key: promoted, Position: 1},
+			{ID: "image-2", Key: "bbbb/full.jpg", Position: 2},
+		}}
+		mockService.On("GetCharacterByID", "char-1").Return(withGallery, nil)
+		// リポジトリが代表画像を削除し、表示順で先頭の画像を代表画像にした結果
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Photo == nil && char.Version == 3
+		})).Return(&models.Character{ID: "char-1", GroupID: "group-1", Name: "徳川家康", Photo: &promoted, Version: 4, Images: []models.CharacterImage{
+			{ID: "image-3", Key: promoted, IsPrimary: true, Position: 1},
+			{ID: "image-2", Key: "bbbb/full.jpg", Position: 2},
+		}}, nil)
+		mockImageService.On("DeleteImage", photo).Return(nil)
+
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`{"photo":null}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.Character
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Photo)
+		assert.Equal(t, promoted, *response.Photo)
+		require.Len(t, response.Images, 2)
+		assert.True(t, response.Images[0].IsPrimary)
+		// 削除するのは元の代表画像のファイルだけ
+		mockImageService.AssertExpectations(t)
+		mockImageService.AssertNotCalled(t, "DeleteImage", promoted)
+	})
+	
+	t.Run("JSON Patchで情報を追加", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Information == "info" && char.Name == "Old Name"
+		})).Return(&models.Character{ID: "char-1", Version: 4}, nil)
+		
+		body := `[{"op":"add","path":"/information","value":"info"}]`
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("不明なフィールドは400", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`{"id":"other"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "UpdateCharacter", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("必須項目をnullにすると422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`{"name":null}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "UpdateCharacter", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("未対応のContent-Typeは415", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`name=x`))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+	
+	t.Run("更新競合は412", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil).Once()
+		mockService.On("UpdateCharacter", "char-1", mock.Anything).Return((*models.Character)(nil), services.ErrVersionConflict)
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 4}, nil)
+		
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`{"name":"New Name"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), `"currentVersion":4`)
+	})
+	
+	t.Run("JSON Patchで別名を追加すると既存の別名も保持", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		withAlias := &models.Character{ID: "char-1", GroupID: "group-1", Name: "織田信長", Version: 3, Aliases: []models.CharacterAlias{
+			{Type: models.AliasTypeAlias, Name: "吉法師"},
+		}}
+		mockService.On("GetCharacterByID", "char-1").Return(withAlias, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return assert.ObjectsAreEqual([]models.CharacterAlias{
+				{Type: models.AliasTypeAlias, Name: "吉法師"},
+				{Type: models.AliasTypeReading, Name: "おだ のぶなが"},
+			}, char.Aliases)
+		})).Return(&models.Character{ID: "char-1", Version: 4}, nil)
+		
+		body := `[{"op":"add","path":"/aliases/-","value":{"type":"reading","name":"おだ のぶなが"}}]`
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+	
+	t.Run("aliasesをnullにすると全て削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Aliases != nil && len(char.Aliases) == 0
+		})).Return(&models.Character{ID: "char-1", Version: 4}, nil)
+		
+		req, _ := http.NewRequest("PATCH", "/characters/char-1", bytes.NewBufferString(`{"aliases":null}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 	t.Run("JSON形式でキャラクター更新", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
 		existingCharacter := &models.Character{
 			ID:      "char-1",
 			GroupID: "group-1",
@@ -450,6 +1132,12 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 	})
 	
 	t.Run("マルチパート形式でキャラクター更新（画像付き）", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
 		oldPhotoPath := "uploads/characters/old.jpg"
 		existingCharacter := &models.Character{
 			ID:      "char-1",
@@ -482,9 +1170,10 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 		
 		// モックの設定
 		mockService.On("GetCharacterByID", "char-1").Return(existingCharacter, nil)
-		mockImageService.On("SaveImage", mock.Anything, "new.jpg", uint(800), uint(600)).Return(newPhotoPath, nil)
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return(newPhotoPath, nil)
+		mockImageService.On("CheckQuota", "", "char-1", newPhotoPath).Return(int64(1024), nil)
 		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
-			return char.Name == "Updated Name" && char.Photo != nil && *char.Photo == newPhotoPath
+			return char.Name == "Updated Name" && char.Photo != nil && *char.Photo == newPhotoPath && char.PhotoSize == 1024
 		})).Return(updatedCharacter, nil)
 		mockImageService.On("DeleteImage", oldPhotoPath).Return(nil)
 		
@@ -507,12 +1196,18 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 	})
 	
 	t.Run("存在しないキャラクター更新", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
 		reqData := UpdateCharacterRequest{
 			GroupID: "group-1",
 			Name:    "New Name",
 		}
 		
-		mockService.On("GetCharacterByID", "nonexistent").Return((*models.Character)(nil), errors.New("character not found"))
+		mockService.On("GetCharacterByID", "nonexistent").Return((*models.Character)(nil), services.NewNotFoundError("CHARACTER_NOT_FOUND", "character not found"))
 		
 		jsonData, _ := json.Marshal(reqData)
 		req, _ := http.NewRequest("PUT", "/characters/nonexistent", bytes.NewBuffer(jsonData))
@@ -527,6 +1222,12 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 	})
 	
 	t.Run("画像保存エラー時のキャラクター更新", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
 		existingCharacter := &models.Character{
 			ID:      "char-1",
 			GroupID: "group-1",
@@ -547,7 +1248,7 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 		
 		// モックの設定（画像保存でエラー）
 		mockService.On("GetCharacterByID", "char-1").Return(existingCharacter, nil)
-		mockImageService.On("SaveImage", mock.Anything, "test.jpg", uint(800), uint(600)).Return("", errors.New("failed to save image"))
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return("", errors.New("failed to save image"))
 		
 		req, _ := http.NewRequest("PUT", "/characters/char-1", &buf)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -562,6 +1263,12 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 	})
 	
 	t.Run("サービスエラー時の新画像削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
 		existingCharacter := &models.Character{
 			ID:      "char-1",
 			GroupID: "group-1",
@@ -582,7 +1289,8 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 		
 		// モックの設定
 		mockService.On("GetCharacterByID", "char-1").Return(existingCharacter, nil)
-		mockImageService.On("SaveImage", mock.Anything, "test.jpg", uint(800), uint(600)).Return("uploads/characters/test.jpg", nil)
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return("uploads/characters/test.jpg", nil)
+		mockImageService.On("CheckQuota", "", "char-1", "uploads/characters/test.jpg").Return(int64(1024), nil)
 		mockService.On("UpdateCharacter", "char-1", mock.Anything).Return((*models.Character)(nil), errors.New("service error"))
 		mockImageService.On("DeleteImage", "uploads/characters/test.jpg").Return(nil)
 		
@@ -599,15 +1307,271 @@ func TestCharacterHandler_UpdateCharacter(t *testing.T) {
 	})
 }
 
-func TestCharacterHandler_DeleteCharacter(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
+func TestCharacterHandler_CropCharacterPhoto(t *testing.T) {
+	oldPhotoPath := "1700000000_old/full.jpg"
+	existing := &models.Character{ID: "char-1", GroupID: "group-1", Name: "Name", Photo: &oldPhotoPath, Version: 2}
+	
+	newRouter := func(mockService *MockCharacterService, mockImageService *MockImageService) http.Handler {
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.PUT("/characters/:id/photo/crop", handler.CropCharacterPhoto)
+		return router
+	}
+	
+	t.Run("切り抜き直して古い画像を削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+		
+		newPhotoPath := "1700000001_new/full.jpg"
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		mockImageService.On("CropImage", oldPhotoPath, mock.MatchedBy(func(crop *models.PhotoCrop) bool {
+			return crop.Focus != nil && crop.Focus.X == 0.3 && crop.Rect == nil
+		})).Return(newPhotoPath, nil)
+		mockImageService.On("CheckQuota", "", "char-1", newPhotoPath).Return(int64(1024), nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Name == "Name" && *char.Photo == newPhotoPath && char.PhotoCrop.Focus.Y == 0.2 && char.Version == 2
+		})).Return(&models.Character{ID: "char-1", GroupID: "group-1", Name: "Name", Photo: &newPhotoPath, Version: 3}, nil)
+		mockImageService.On("DeleteImage", oldPhotoPath).Return(nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1/photo/crop", bytes.NewBufferString(`{"focus":{"x":0.3,"y":0.2}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `W/"3"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+		mockImageService.AssertExpectations(t)
+	})
+	
+	t.Run("写真がない人物は422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService, new(MockImageService))
+		
+		mockService.On("GetCharacterByID", "char-2").Return(&models.Character{ID: "char-2", GroupID: "group-1", Name: "Name"}, nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-2/photo/crop", bytes.NewBufferString(`{"focus":{"x":0.3,"y":0.2}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "PHOTO_REQUIRED", response.Code)
+	})
+	
+	t.Run("範囲外の指定は422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+		
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		mockImageService.On("CropImage", oldPhotoPath, mock.Anything).
+			Return("", services.NewValidationError("INVALID_PHOTO_CROP", "invalid photo crop", nil))
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1/photo/crop", bytes.NewBufferString(`{"rect":{"x":0.5,"y":0,"width":0.8,"height":1}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "UpdateCharacter", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("更新競合時は新しい画像を削除して412", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		router := newRouter(mockService, mockImageService)
+		
+		newPhotoPath := "1700000001_new/full.jpg"
+		mockService.On("GetCharacterByID", "char-1").Return(existing, nil)
+		mockImageService.On("CropImage", oldPhotoPath, mock.Anything).Return(newPhotoPath, nil)
+		mockImageService.On("CheckQuota", "", "char-1", newPhotoPath).Return(int64(1024), nil)
+		mockService.On("UpdateCharacter", "char-1", mock.Anything).Return((*models.Character)(nil), services.ErrVersionConflict)
+		mockImageService.On("DeleteImage", newPhotoPath).Return(nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1/photo/crop", bytes.NewBufferString(`{"focus":{"x":0.3,"y":0.2}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockImageService.AssertExpectations(t)
+		mockImageService.AssertNotCalled(t, "DeleteImage", oldPhotoPath)
+	})
+}
+
+func TestCharacterHandler_PhotoCropOnUpload(t *testing.T) {
+	multipartBody := func(withPhoto bool, photoCrop string) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		writer.WriteField("groupId", "group-1")
+		writer.WriteField("name", "Name")
+		writer.WriteField("photoCrop", photoCrop)
+		if withPhoto {
+			part, _ := writer.CreateFormFile("photo", "photo.jpg")
+			part.Write([]byte("fake image data"))
+		}
+		writer.Close()
+		return &buf, writer.FormDataContentType()
+	}
+	
+	t.Run("画像と一緒に切り抜き範囲を保存", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
+		photoPath := "1700000000_abc/full.jpg"
+		mockImageService.On("SaveImage", mock.Anything, mock.MatchedBy(func(crop *models.PhotoCrop) bool {
+			return crop != nil && crop.Rect.Width == 0.5 && crop.Focus.X == 0.25
+		})).Return(photoPath, nil)
+		mockImageService.On("CheckQuota", "", "", photoPath).Return(int64(1024), nil)
+		mockService.On("CreateCharacter", mock.MatchedBy(func(char *models.Character) bool {
+			return *char.Photo == photoPath && char.PhotoCrop != nil && char.PhotoCrop.Rect.Height == 0.5
+		})).Return(&models.Character{ID: "char-1", GroupID: "group-1", Name: "Name", Photo: &photoPath, Version: 1}, nil)
+		
+		body, contentType := multipartBody(true, `{"rect":{"x":0,"y":0,"width":0.5,"height":0.5},"focus":{"x":0.25,"y":0.25}}`)
+		req, _ := http.NewRequest("POST", "/characters", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+		mockImageService.AssertExpectations(t)
+	})
+	
+	t.Run("画像なしの切り抜き範囲は400", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
+		body, contentType := multipartBody(false, `{"focus":{"x":0.5,"y":0.5}}`)
+		req, _ := http.NewRequest("POST", "/characters", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "CreateCharacter", mock.Anything)
+	})
+	
+	t.Run("写真を変更しない更新では切り抜き範囲を保持", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.PUT("/characters/:id", handler.UpdateCharacter)
+		
+		photoPath := "1700000000_abc/full.jpg"
+		crop := &models.PhotoCrop{Focus: &models.FocalPoint{X: 0.25, Y: 0.25}}
+		mockService.On("GetCharacterByID", "char-1").
+			Return(&models.Character{ID: "char-1", GroupID: "group-1", Name: "Name", Photo: &photoPath, PhotoCrop: crop, Version: 1}, nil)
+		mockService.On("UpdateCharacter", "char-1", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Name == "New Name" && *char.Photo == photoPath && char.PhotoCrop == crop
+		})).Return(&models.Character{ID: "char-1", GroupID: "group-1", Name: "New Name", Version: 2}, nil)
+		
+		req, _ := http.NewRequest("PUT", "/characters/char-1", bytes.NewBufferString(`{"groupId":"group-1","name":"New Name"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCharacterHandler_UploadLimits(t *testing.T) {
+	multipartBody := func(photo []byte) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		writer.WriteField("groupId", "group-1")
+		writer.WriteField("name", "Name")
+		part, _ := writer.CreateFormFile("photo", "photo.jpg")
+		part.Write(photo)
+		writer.Close()
+		return &buf, writer.FormDataContentType()
+	}
+	
+	t.Run("ボディが上限を超える場合は413", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.Use(middleware.BodyLimit(1024))
+		router.POST("/characters", handler.CreateCharacter)
+		
+		body, contentType := multipartBody(bytes.Repeat([]byte("x"), 2048))
+		req, _ := http.NewRequest("POST", "/characters", body)
+		req.Header.Set("Content-Type", contentType)
+		// Content-Length を指定しない（読み込み中に上限を超える）場合
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+		var problem middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "PAYLOAD_TOO_LARGE", problem.Code)
+		mockImageService.AssertNotCalled(t, "SaveImage", mock.Anything, mock.Anything)
+		mockService.AssertNotCalled(t, "CreateCharacter", mock.Anything)
+	})
 	
-	router := setupTestRouter()
-	router.DELETE("/characters/:id", handler.DeleteCharacter)
+	t.Run("Content-Length が上限を超える場合は読み込まずに413", func(t *testing.T) {
+		handler := NewCharacterHandler(new(MockCharacterService), new(MockImageService))
+		router := setupTestRouter()
+		router.Use(middleware.BodyLimit(1024))
+		router.POST("/characters", handler.CreateCharacter)
+		
+		req, _ := http.NewRequest("POST", "/characters", bytes.NewBufferString(`{"groupId":"group-1","name":"`+strings.Repeat("x", 2048)+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
 	
+	t.Run("保存容量を超える場合は413で保存した写真を破棄", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters", handler.CreateCharacter)
+		
+		photoPath := "abc/full.jpg"
+		mockImageService.On("SaveImage", mock.Anything, mock.Anything).Return(photoPath, nil)
+		mockImageService.On("CheckQuota", "", "", photoPath).
+			Return(int64(0), services.NewTooLargeError("STORAGE_QUOTA_EXCEEDED", "workspace storage quota exceeded", nil))
+		mockImageService.On("DeleteImage", photoPath).Return(nil)
+		
+		body, contentType := multipartBody([]byte("fake image data"))
+		req, _ := http.NewRequest("POST", "/characters", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		var problem middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "STORAGE_QUOTA_EXCEEDED", problem.Code)
+		mockImageService.AssertExpectations(t)
+		mockService.AssertNotCalled(t, "CreateCharacter", mock.Anything)
+	})
+}
+
+func TestCharacterHandler_DeleteCharacter(t *testing.T) {
 	t.Run("正常なキャラクター削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.DELETE("/characters/:id", handler.DeleteCharacter)
+		
 		photoPath := "uploads/characters/test.jpg"
 		character := &models.Character{
 			ID:    "char-1",
@@ -629,8 +1593,47 @@ func TestCharacterHandler_DeleteCharacter(t *testing.T) {
 		mockImageService.AssertExpectations(t)
 	})
 	
+	t.Run("ギャラリーの画像ファイルも削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.DELETE("/characters/:id", handler.DeleteCharacter)
+		
+		photoPath := "aaaa/full.jpg"
+		character := &models.Character{
+			ID:    "char-1",
+			Name:  "Test Character",
+			Photo: &photoPath,
+			Images: []models.CharacterImage{
+				{ID: "image-1", CharacterID: "char-1", Key: photoPath, IsPrimary: true},
+				{ID: "image-2", CharacterID: "char-1", Key: "bbbb/full.png", Position: 1},
+			},
+		}
+		
+		mockService.On("GetCharacterByID", "char-1").Return(character, nil)
+		mockService.On("DeleteCharacter", "char-1").Return(nil)
+		// 代表画像は photo と同じキーのため1回だけ削除する
+		mockImageService.On("DeleteImage", photoPath).Return(nil).Once()
+		mockImageService.On("DeleteImage", "bbbb/full.png").Return(nil).Once()
+		
+		req, _ := http.NewRequest("DELETE", "/characters/char-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockImageService.AssertExpectations(t)
+		mockImageService.AssertNumberOfCalls(t, "DeleteImage", 2)
+	})
+	
 	t.Run("存在しないキャラクター削除", func(t *testing.T) {
-		mockService.On("GetCharacterByID", "nonexistent").Return((*models.Character)(nil), errors.New("character not found"))
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.DELETE("/characters/:id", handler.DeleteCharacter)
+		
+		mockService.On("GetCharacterByID", "nonexistent").Return((*models.Character)(nil), services.NewNotFoundError("CHARACTER_NOT_FOUND", "character not found"))
 		
 		req, _ := http.NewRequest("DELETE", "/characters/nonexistent", nil)
 		w := httptest.NewRecorder()
@@ -643,14 +1646,13 @@ func TestCharacterHandler_DeleteCharacter(t *testing.T) {
 }
 
 func TestCharacterHandler_AddLabelToCharacter(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
-	
-	router := setupTestRouter()
-	router.POST("/characters/:id/labels/:labelId", handler.AddLabelToCharacter)
-	
 	t.Run("正常なラベル追加", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters/:id/labels/:labelId", handler.AddLabelToCharacter)
+		
 		mockService.On("AddLabelToCharacter", "char-1", "label-1").Return(nil)
 		
 		req, _ := http.NewRequest("POST", "/characters/char-1/labels/label-1", nil)
@@ -668,27 +1670,39 @@ func TestCharacterHandler_AddLabelToCharacter(t *testing.T) {
 	})
 	
 	t.Run("ラベル数制限エラー", func(t *testing.T) {
-		mockService.On("AddLabelToCharacter", "char-1", "label-1").Return(errors.New("character cannot have more than 5 labels"))
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.POST("/characters/:id/labels/:labelId", handler.AddLabelToCharacter)
+		
+		mockService.On("AddLabelToCharacter", "char-1", "label-1").Return(
+			services.NewLimitExceededError("LABEL_LIMIT_EXCEEDED", "character cannot have more than 5 labels", nil))
 		
 		req, _ := http.NewRequest("POST", "/characters/char-1/labels/label-1", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 		
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+		
+		var problem middleware.Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "LABEL_LIMIT_EXCEEDED", problem.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
 		
 		mockService.AssertExpectations(t)
 	})
 }
 
 func TestCharacterHandler_RemoveLabelFromCharacter(t *testing.T) {
-	mockService := new(MockCharacterService)
-	mockImageService := new(MockImageService)
-	handler := NewCharacterHandler(mockService, mockImageService)
-	
-	router := setupTestRouter()
-	router.DELETE("/characters/:id/labels/:labelId", handler.RemoveLabelFromCharacter)
-	
 	t.Run("正常なラベル削除", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		mockImageService := new(MockImageService)
+		handler := NewCharacterHandler(mockService, mockImageService)
+		router := setupTestRouter()
+		router.DELETE("/characters/:id/labels/:labelId", handler.RemoveLabelFromCharacter)
+		
 		mockService.On("RemoveLabelFromCharacter", "char-1", "label-1").Return(nil)
 		
 		req, _ := http.NewRequest("DELETE", "/characters/char-1/labels/label-1", nil)
@@ -704,4 +1718,140 @@ func TestCharacterHandler_RemoveLabelFromCharacter(t *testing.T) {
 		
 		mockService.AssertExpectations(t)
 	})
+}
+
+func TestCharacterHandler_ReplaceCharacterLabels(t *testing.T) {
+	newRouter := func(mockService *MockCharacterService) http.Handler {
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.PUT("/characters/:id/labels", handler.ReplaceCharacterLabels)
+		return router
+	}
+
+	t.Run("置き換え後の人物とETagを返す", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+
+		mockService.On("ReplaceCharacterLabels", "char-1", []string{"label-1", "label-2"}).
+			Return(&models.Character{ID: "char-1", Version: 4, Labels: []models.Label{{ID: "label-1"}, {ID: "label-2"}}}, nil)
+
+		req, _ := http.NewRequest("PUT", "/characters/char-1/labels", bytes.NewBufferString(`{"labelIds":["label-1","label-2"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `W/"4"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("labelIds がない場合は422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+
+		req, _ := http.NewRequest("PUT", "/characters/char-1/labels", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "ReplaceCharacterLabels", mock.Anything, mock.Anything)
+	})
+
+	t.Run("If-Match が一致しない場合は412", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+
+		mockService.On("GetCharacterByID", "char-1").Return(&models.Character{ID: "char-1", Version: 3}, nil)
+
+		req, _ := http.NewRequest("PUT", "/characters/char-1/labels", bytes.NewBufferString(`{"labelIds":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"2"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockService.AssertNotCalled(t, "ReplaceCharacterLabels", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCharacterHandler_AssignLabel(t *testing.T) {
+	t.Run("人物ごとの結果を返す", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.POST("/labels/:id/assign", handler.AssignLabel)
+
+		mockService.On("AssignLabelToCharacters", "label-1", []string{"char-1", "char-2"}, []string{"char-3"}).Return([]services.LabelAssignmentResult{
+			{CharacterID: "char-1", Action: services.LabelActionAdd, Status: services.LabelStatusAdded},
+			{CharacterID: "char-2", Action: services.LabelActionAdd, Status: services.LabelStatusFailed, Code: "LABEL_LIMIT_EXCEEDED", Message: "character cannot have more than 5 labels"},
+			{CharacterID: "char-3", Action: services.LabelActionRemove, Status: services.LabelStatusRemoved},
+		}, nil)
+
+		req, _ := http.NewRequest("POST", "/labels/label-1/assign", bytes.NewBufferString(`{"add":["char-1","char-2"],"remove":["char-3"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Results []map[string]interface{} `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 3)
+		assert.Equal(t, "added", response.Results[0]["status"])
+		assert.NotContains(t, response.Results[0], "code")
+		assert.Equal(t, "LABEL_LIMIT_EXCEEDED", response.Results[1]["code"])
+		assert.Equal(t, "remove", response.Results[2]["action"])
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCharacterHandler_MergeLabel(t *testing.T) {
+	newRouter := func(mockService *MockCharacterService) http.Handler {
+		handler := NewCharacterHandler(mockService, new(MockImageService))
+		router := setupTestRouter()
+		router.POST("/labels/:id/merge-into/:targetId", handler.MergeLabel)
+		return router
+	}
+
+	t.Run("統合の結果を返す", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+
+		mockService.On("MergeLabel", "label-daimio", "label-daimyo").
+			Return(&services.LabelMergeResult{Target: &models.Label{ID: "label-daimyo", Name: "大名"}, Moved: 2, Deduplicated: 1}, nil)
+
+		req, _ := http.NewRequest("POST", "/labels/label-daimio/merge-into/label-daimyo", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(2), response["moved"])
+		assert.Equal(t, float64(1), response["deduplicated"])
+		assert.Equal(t, "label-daimyo", response["target"].(map[string]interface{})["id"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("上限を超える人物がいる場合は422", func(t *testing.T) {
+		mockService := new(MockCharacterService)
+		router := newRouter(mockService)
+
+		mockService.On("MergeLabel", "label-1", "label-2").Return((*services.LabelMergeResult)(nil),
+			services.NewLimitExceededError("LABEL_CATEGORY_LIMIT_EXCEEDED", "some characters would have more than 1 labels in category \"陣営\"", nil))
+
+		req, _ := http.NewRequest("POST", "/labels/label-1/merge-into/label-2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var problem middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "LABEL_CATEGORY_LIMIT_EXCEEDED", problem.Code)
+	})
 }
\ No newline at end of file
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// requestValidator リクエストやパッチ適用後のドキュメントを validate タグで検証するバリデーター
+var requestValidator = validator.New()
+
+// bindJSON JSONボディを読み込んで検証する
+// 失敗した場合はエラーを登録して false を返す（形式の誤りは400、検証エラーは422）
+func bindJSON(c *gin.Context, out interface{}) bool {
+	if err := c.ShouldBindJSON(out); err != nil {
+		c.Error(invalidRequest(err))
+		return false
+	}
+	return validateRequest(c, out)
+}
+
+// validateRequest リクエストを validate タグで検証する
+func validateRequest(c *gin.Context, req interface{}) bool {
+	if err := requestValidator.Struct(req); err != nil {
+		c.Error(err)
+		return false
+	}
+	return true
+}
+
+// invalidRequest 解釈できないリクエストのエラー（ボディが上限を超えた場合は413）
+func invalidRequest(err error) *middleware.AppError {
+	if tooLarge := payloadTooLarge(err); tooLarge != nil {
+		return tooLarge
+	}
+	return middleware.NewAppError("INVALID_REQUEST", "Invalid request body", err.Error())
+}
+
+// payloadTooLarge ボディの読み込みが BodyLimit の上限を超えて失敗した場合は413のエラーを返す（それ以外は nil）
+func payloadTooLarge(err error) *middleware.AppError {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return middleware.PayloadTooLargeError(maxBytesError.Limit)
+	}
+	return nil
+}
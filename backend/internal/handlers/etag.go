@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/models"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidETag If-Match に解釈できないETagが指定された場合のエラー
+var errInvalidETag = errors.New("invalid entity tag")
+
+// formatETag バージョンからETagを生成
+// variant はバージョンが同じでも表現が変わる場合の値（署名付きURLの有効期限など）で、空でなければ W/"<version>-<variant>" にする
+func formatETag(version uint, variant string) string {
+	if variant == "" {
+		return fmt.Sprintf(`W/"%d"`, version)
+	}
+	return fmt.Sprintf(`W/"%d-%s"`, version, variant)
+}
+
+// parseETag ETagからバージョンを取り出す（強い・弱いETagの両方を受け付け、variant は無視する）
+func parseETag(tag string) (uint, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, errInvalidETag
+	}
+
+	value, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	version, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || version == 0 {
+		return 0, errInvalidETag
+	}
+	return uint(version), nil
+}
+
+// setETag レスポンスにETagを設定
+func setETag(c *gin.Context, version uint) {
+	setVariantETag(c, version, "")
+}
+
+// setVariantETag バージョン以外で表現が変わる値（variant）を含むETagを設定
+func setVariantETag(c *gin.Context, version uint, variant string) {
+	c.Header("ETag", formatETag(version, variant))
+}
+
+// charactersETagVariant レスポンスに含める人物のIDとバージョンから variant を作る
+// 含めた人物だけが変わった場合にもETagを変えるため、グループ・関係のETagに使う（人物がない場合は空文字）
+func charactersETagVariant(characters ...models.Character) string {
+	if len(characters) == 0 {
+		return ""
+	}
+	entries := make([]string, len(characters))
+	for i, character := range characters {
+		entries[i] = fmt.Sprintf("%s:%d", character.ID, character.Version)
+	}
+	sort.Strings(entries)
+
+	hash := fnv.New64a()
+	hash.Write([]byte(strings.Join(entries, "\n")))
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
+// joinETagVariants 空でない variant を "-" でつなぐ
+func joinETagVariants(variants ...string) string {
+	parts := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		if variant != "" {
+			parts = append(parts, variant)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// ifMatchVersions If-Match ヘッダー（カンマ区切りのETagのリスト）から期待するバージョンを取得
+// ヘッダーがない場合と "*" の場合は nil（バージョンを問わない）を返す
+func ifMatchVersions(c *gin.Context) ([]uint, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var versions []uint
+	for _, tag := range strings.Split(header, ",") {
+		version, err := parseETag(tag)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// ifMatchVersion If-Match ヘッダーから期待するバージョンを取得
+// ヘッダーがない場合と "*" の場合は 0（バージョンを問わない）を返す
+// 異なるバージョンのETagが複数指定された場合は current で現在のバージョンを取得し、いずれかと一致すればそのバージョンを返す
+func ifMatchVersion(c *gin.Context, current func() (uint, error)) (uint, error) {
+	versions, err := ifMatchVersions(c)
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	if !containsOnly(versions, versions[0]) {
+		currentVersion, err := current()
+		if err != nil {
+			return 0, err
+		}
+		if !containsVersion(versions, currentVersion) {
+			return 0, errInvalidETag
+		}
+		return currentVersion, nil
+	}
+	return versions[0], nil
+}
+
+// checkIfMatch If-Match のいずれかのETagが現在のバージョンと一致するか確認する
+// 一致しない場合は412を書き込んで false を返す
+func checkIfMatch(c *gin.Context, currentVersion uint) bool {
+	versions, err := ifMatchVersions(c)
+	if err != nil || (versions != nil && !containsVersion(versions, currentVersion)) {
+		respondPreconditionFailed(c, currentVersion)
+		return false
+	}
+	return true
+}
+
+// containsVersion versions に version が含まれるか
+func containsVersion(versions []uint, version uint) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// containsOnly versions がすべて version か
+func containsOnly(versions []uint, version uint) bool {
+	for _, v := range versions {
+		if v != version {
+			return false
+		}
+	}
+	return true
+}
+
+// notModified If-None-Match が現在のETag（バージョンと variant）と一致する場合に304を返す
+// 比較は弱い比較で、W/ の有無は区別しない
+func notModified(c *gin.Context, version uint, variant string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+
+	current := strings.TrimPrefix(formatETag(version, variant), "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			return respondNotModified(c, version, variant)
+		}
+	}
+	return false
+}
+
+// respondNotModified 304レスポンスを返す
+func respondNotModified(c *gin.Context, version uint, variant string) bool {
+	setVariantETag(c, version, variant)
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// respondPreconditionFailed 412レスポンスを現在のバージョンとともに返す
+// ETag はリソースによって variant が異なり GET のETagと一致しないため付けない（最新のETagは GET で取得する）
+func respondPreconditionFailed(c *gin.Context, currentVersion uint) {
+	middleware.WriteProblem(c, http.StatusPreconditionFailed, middleware.NewAppError(
+		"PRECONDITION_FAILED",
+		"Resource has been modified by another request",
+		gin.H{"currentVersion": currentVersion},
+	))
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"character-management-app/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharactersETagVariant(t *testing.T) {
+	char1 := models.Character{ID: "char-1", Version: 1}
+	char2 := models.Character{ID: "char-2", Version: 3}
+
+	assert.Empty(t, charactersETagVariant())
+	// 人物の順序には依存しない
+	variant := charactersETagVariant(char1, char2)
+	assert.Equal(t, variant, charactersETagVariant(char2, char1))
+
+	char1.Version = 2
+	assert.NotEqual(t, variant, charactersETagVariant(char1, char2))
+	assert.NotEqual(t, variant, charactersETagVariant(char2))
+}
+
+func TestRelationshipETagVariant(t *testing.T) {
+	photo := "aaaa/full.jpg"
+	relationship := models.Relationship{
+		ID:         "rel-1",
+		Version:    1,
+		Character1: models.Character{ID: "char-1", Version: 1, Photo: &photo},
+		Character2: models.Character{ID: "char-2", Version: 1},
+	}
+	signed := expiringPhotoURLs{expiry: time.Unix(1700003600, 0)}
+
+	variant := relationshipETagVariant(nil, relationship)
+	assert.Equal(t, charactersETagVariant(relationship.Character1, relationship.Character2), variant)
+	assert.Equal(t, variant+"-1700003600", relationshipETagVariant(signed, relationship))
+	assert.Equal(t, `W/"1-`+variant+`"`, formatETag(relationship.Version, variant))
+
+	// 人物だけが変わった場合も variant が変わる
+	relationship.Character2.Version = 2
+	assert.NotEqual(t, variant, relationshipETagVariant(nil, relationship))
+}
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected uint
+	}{
+		{`W/"4"`, 4},
+		{`"4"`, 4},
+		{`W/"4-1700003600"`, 4},
+		{`W/"4-3k9x1-1700003600"`, 4},
+	}
+	for _, tt := range tests {
+		version, err := parseETag(tt.tag)
+		assert.NoError(t, err, tt.tag)
+		assert.Equal(t, tt.expected, version, tt.tag)
+	}
+
+	for _, tag := range []string{`4`, `W/""`, `W/"0"`, `W/"-4"`, `W/"abc-1"`} {
+		_, err := parseETag(tag)
+		assert.ErrorIs(t, err, errInvalidETag, tag)
+	}
+}
+
+// ifMatchContext If-Match ヘッダーを付けたリクエストのコンテキストを作る
+func ifMatchContext(header string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+	if header != "" {
+		c.Request.Header.Set("If-Match", header)
+	}
+	return c, w
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		header  string
+		matches bool
+	}{
+		{"", true},
+		{"*", true},
+		{`W/"4-a"`, true},
+		{`W/"3-a", W/"4-b"`, true},
+		{`W/"2", "3"`, false},
+		{`W/"4", invalid`, false},
+	}
+	for _, tt := range tests {
+		c, w := ifMatchContext(tt.header)
+
+		assert.Equal(t, tt.matches, checkIfMatch(c, 4), tt.header)
+		if !tt.matches {
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code, tt.header)
+			// variant を含む GET のETagと食い違わないよう、412にはETagを付けない
+			assert.Empty(t, w.Header().Get("ETag"), tt.header)
+		}
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	current := func() (uint, error) { return 4, nil }
+	unused := func() (uint, error) {
+		t.Fatal("current version should not be needed")
+		return 0, nil
+	}
+
+	t.Run("同じバージョンだけなら現在のバージョンを取得しない", func(t *testing.T) {
+		c, _ := ifMatchContext(`W/"3-a", W/"3-b"`)
+
+		version, err := ifMatchVersion(c, unused)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), version)
+	})
+
+	t.Run("異なるバージョンのうち現在のバージョンと一致するものを返す", func(t *testing.T) {
+		c, _ := ifMatchContext(`W/"3-a", W/"4-b"`)
+
+		version, err := ifMatchVersion(c, current)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), version)
+	})
+
+	t.Run("どれも一致しない場合はエラー", func(t *testing.T) {
+		c, _ := ifMatchContext(`W/"2", W/"3"`)
+
+		_, err := ifMatchVersion(c, current)
+
+		assert.ErrorIs(t, err, errInvalidETag)
+	})
+
+	t.Run("ヘッダーがない場合は0", func(t *testing.T) {
+		c, _ := ifMatchContext("")
+
+		version, err := ifMatchVersion(c, unused)
+
+		assert.NoError(t, err)
+		assert.Zero(t, version)
+	})
+}
@@ -2,25 +2,34 @@ package handlers
 
 import (
 	"character-management-app/internal/middleware"
+	"character-management-app/internal/models"
 	"character-management-app/internal/services"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // GroupHandler グループハンドラー
 type GroupHandler struct {
 	groupService services.GroupService
+	photoURLs    PhotoURLs
 }
 
 // NewGroupHandler グループハンドラーのコンストラクタ
-func NewGroupHandler(groupService services.GroupService) *GroupHandler {
+// photoURLs は所属する人物の写真の配信用URLの生成に使う（nil の場合はキーのまま返す）
+func NewGroupHandler(groupService services.GroupService, photoURLs PhotoURLs) *GroupHandler {
 	return &GroupHandler{
 		groupService: groupService,
+		photoURLs:    photoURLs,
 	}
 }
 
+// service リクエストのワークスペースに限定したサービスを返す
+func (h *GroupHandler) service(c *gin.Context) services.GroupService {
+	return h.groupService.WithWorkspace(middleware.WorkspaceID(c))
+}
+
 // GetGroups グループ一覧を取得
 // @Summary グループ一覧取得
 // @Description 全てのグループを取得します
@@ -28,17 +37,17 @@ func NewGroupHandler(groupService services.GroupService) *GroupHandler {
 // @Accept json
 // @Produce json
 // @Success 200 {array} models.Group
-// @Failure 500 {object} middleware.AppError
+// @Failure 500 {object} middleware.Problem
 // @Router /api/v1/groups [get]
 func (h *GroupHandler) GetGroups(c *gin.Context) {
-	groups, err := h.groupService.GetAllGroups()
+	groups, err := h.service(c).GetAllGroups()
 	if err != nil {
-		c.Error(middleware.NewAppError("GET_GROUPS_FAILED", "Failed to get groups", err.Error()))
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":    groups,
+		"data":    groupsWithURLs(h.photoURLs, groups),
 		"message": "Groups retrieved successfully",
 	})
 }
@@ -51,24 +60,25 @@ func (h *GroupHandler) GetGroups(c *gin.Context) {
 // @Produce json
 // @Param group body services.CreateGroupRequest true "グループ作成リクエスト"
 // @Success 201 {object} models.Group
-// @Failure 400 {object} middleware.AppError
-// @Failure 500 {object} middleware.AppError
+// @Failure 400 {object} middleware.Problem
+// @Failure 500 {object} middleware.Problem
 // @Router /api/v1/groups [post]
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	var req services.CreateGroupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(middleware.NewAppError("INVALID_REQUEST", "Invalid request body", err.Error()))
+		c.Error(invalidRequest(err))
 		return
 	}
 
-	group, err := h.groupService.CreateGroup(&req)
+	group, err := h.service(c).CreateGroup(&req)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	setVariantETag(c, group.Version, groupETagVariant(h.photoURLs, *group))
 	c.JSON(http.StatusCreated, gin.H{
-		"data":    group,
+		"data":    groupWithURLs(h.photoURLs, *group),
 		"message": "Group created successfully",
 	})
 }
@@ -80,10 +90,12 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "グループID"
+// @Param If-None-Match header string false "前回取得時のETag"
 // @Success 200 {object} models.Group
-// @Failure 400 {object} middleware.AppError
-// @Failure 404 {object} middleware.AppError
-// @Failure 500 {object} middleware.AppError
+// @Success 304
+// @Failure 400 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 500 {object} middleware.Problem
 // @Router /api/v1/groups/{id} [get]
 func (h *GroupHandler) GetGroup(c *gin.Context) {
 	id := c.Param("id")
@@ -92,18 +104,19 @@ func (h *GroupHandler) GetGroup(c *gin.Context) {
 		return
 	}
 
-	group, err := h.groupService.GetGroup(id)
+	group, err := h.service(c).GetGroup(id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.Error(middleware.NewAppError("GROUP_NOT_FOUND", "Group not found", nil))
-			return
-		}
-		c.Error(middleware.NewAppError("GET_GROUP_FAILED", "Failed to get group", err.Error()))
+		c.Error(err)
+		return
+	}
+
+	if notModified(c, group.Version, groupETagVariant(h.photoURLs, *group)) {
 		return
 	}
 
+	setVariantETag(c, group.Version, groupETagVariant(h.photoURLs, *group))
 	c.JSON(http.StatusOK, gin.H{
-		"data":    group,
+		"data":    groupWithURLs(h.photoURLs, *group),
 		"message": "Group retrieved successfully",
 	})
 }
@@ -116,10 +129,12 @@ func (h *GroupHandler) GetGroup(c *gin.Context) {
 // @Produce json
 // @Param id path string true "グループID"
 // @Param group body services.UpdateGroupRequest true "グループ更新リクエスト"
+// @Param If-Match header string false "更新対象のETag"
 // @Success 200 {object} models.Group
-// @Failure 400 {object} middleware.AppError
-// @Failure 404 {object} middleware.AppError
-// @Failure 500 {object} middleware.AppError
+// @Failure 400 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 412 {object} middleware.Problem
+// @Failure 500 {object} middleware.Problem
 // @Router /api/v1/groups/{id} [put]
 func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 	id := c.Param("id")
@@ -130,22 +145,113 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 
 	var req services.UpdateGroupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(middleware.NewAppError("INVALID_REQUEST", "Invalid request body", err.Error()))
+		c.Error(invalidRequest(err))
+		return
+	}
+
+	// If-Match はボディの version より優先する
+	expectedVersion, err := ifMatchVersion(c, func() (uint, error) {
+		group, err := h.service(c).GetGroup(id)
+		if err != nil {
+			return 0, err
+		}
+		return group.Version, nil
+	})
+	if err != nil {
+		h.respondGroupConflict(c, id)
+		return
+	}
+	if expectedVersion != 0 {
+		req.Version = expectedVersion
+	}
+
+	group, err := h.service(c).UpdateGroup(id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondGroupConflict(c, id)
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	setVariantETag(c, group.Version, groupETagVariant(h.photoURLs, *group))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    groupWithURLs(h.photoURLs, *group),
+		"message": "Group updated successfully",
+	})
+}
+
+// groupPatchDocument PATCH で編集できるグループの項目（パッチ適用後に検証する）
+type groupPatchDocument struct {
+	Name        string  `json:"name" validate:"required,max=255"`
+	Description *string `json:"description"`
+	LabelLimit  *int    `json:"labelLimit" validate:"omitempty,min=1,max=100"`
+}
+
+// PatchGroup グループを部分更新
+// @Summary グループ部分更新
+// @Description JSON Merge Patch (RFC 7396) または JSON Patch (RFC 6902) でグループを部分更新します
+// @Tags groups
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "グループID"
+// @Param patch body object true "パッチ"
+// @Param If-Match header string false "更新対象のETag"
+// @Success 200 {object} models.Group
+// @Failure 400 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 412 {object} middleware.Problem
+// @Failure 415 {object} middleware.Problem
+// @Router /api/v1/groups/{id} [patch]
+func (h *GroupHandler) PatchGroup(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := h.service(c).GetGroup(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !checkIfMatch(c, existing.Version) {
+		return
+	}
+
+	current := groupPatchDocument{
+		Name:        existing.Name,
+		Description: existing.Description,
+		LabelLimit:  existing.LabelLimit,
+	}
+
+	var patched groupPatchDocument
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
 		return
 	}
 
-	group, err := h.groupService.UpdateGroup(id, &req)
+	// 説明・ラベルの上限が null になった場合は削除し、パッチ適用元のバージョンに対して更新する
+	req := services.UpdateGroupRequest{
+		Name:             &patched.Name,
+		Description:      patched.Description,
+		LabelLimit:       patched.LabelLimit,
+		Version:          existing.Version,
+		ClearDescription: patched.Description == nil,
+		ClearLabelLimit:  patched.LabelLimit == nil,
+	}
+
+	group, err := h.service(c).UpdateGroup(id, &req)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.Error(middleware.NewAppError("GROUP_NOT_FOUND", "Group not found", nil))
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondGroupConflict(c, id)
 			return
 		}
 		c.Error(err)
 		return
 	}
 
+	setVariantETag(c, group.Version, groupETagVariant(h.photoURLs, *group))
 	c.JSON(http.StatusOK, gin.H{
-		"data":    group,
+		"data":    groupWithURLs(h.photoURLs, *group),
 		"message": "Group updated successfully",
 	})
 }
@@ -157,10 +263,12 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "グループID"
+// @Param If-Match header string false "削除対象のETag"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} middleware.AppError
-// @Failure 404 {object} middleware.AppError
-// @Failure 500 {object} middleware.AppError
+// @Failure 400 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 412 {object} middleware.Problem
+// @Failure 500 {object} middleware.Problem
 // @Router /api/v1/groups/{id} [delete]
 func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 	id := c.Param("id")
@@ -169,17 +277,40 @@ func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 		return
 	}
 
-	err := h.groupService.DeleteGroup(id)
-	if err != nil {
-		if err.Error() == "group not found" {
-			c.Error(middleware.NewAppError("GROUP_NOT_FOUND", "Group not found", nil))
+	// If-Match が指定されている場合は現在のバージョンを確認
+	if c.GetHeader("If-Match") != "" {
+		group, err := h.service(c).GetGroup(id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !checkIfMatch(c, group.Version) {
 			return
 		}
-		c.Error(middleware.NewAppError("DELETE_GROUP_FAILED", "Failed to delete group", err.Error()))
+	}
+
+	if err := h.service(c).DeleteGroup(id); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Group deleted successfully",
 	})
-}
\ No newline at end of file
+}
+
+// respondGroupConflict 最新のバージョンを付けて412を返す
+func (h *GroupHandler) respondGroupConflict(c *gin.Context, id string) {
+	group, err := h.service(c).GetGroup(id)
+	if err != nil {
+		middleware.WriteError(c, err)
+		return
+	}
+	respondPreconditionFailed(c, group.Version)
+}
+
+// groupETagVariant グループのETagの variant（所属する人物のバージョンと署名付きURLの有効期限）
+// 人物の変更ではグループのバージョンが進まないため、所属する人物の変更でもETagを変える
+func groupETagVariant(urls PhotoURLs, group models.Group) string {
+	return joinETagVariants(charactersETagVariant(group.Characters...), photoETagVariant(urls, group.Characters...))
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"character-management-app/internal/middleware"
 	"character-management-app/internal/models"
+	"character-management-app/internal/repositories"
 	"character-management-app/internal/services"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,6 +17,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -57,15 +60,19 @@ func (m *MockGroupService) DeleteGroup(id string) error {
 	return args.Error(0)
 }
 
+func (m *MockGroupService) WithWorkspace(workspaceID string) services.GroupService {
+	return m
+}
 
 
-func TestGroupHandler_GetGroups(t *testing.T) {
-	mockService := new(MockGroupService)
-	handler := NewGroupHandler(mockService)
-	router := setupTestRouter()
-	router.GET("/groups", handler.GetGroups)
 
+func TestGroupHandler_GetGroups(t *testing.T) {
 	t.Run("正常なグループ一覧取得", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.GET("/groups", handler.GetGroups)
+		
 		// テストデータ
 		expectedGroups := []models.Group{
 			{ID: "1", Name: "Group 1", CreatedAt: time.Now(), UpdatedAt: time.Now()},
@@ -99,6 +106,11 @@ func TestGroupHandler_GetGroups(t *testing.T) {
 	})
 
 	t.Run("サービスエラー", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.GET("/groups", handler.GetGroups)
+		
 		// モックの設定
 		mockService.On("GetAllGroups").Return([]models.Group{}, errors.New("service error"))
 
@@ -110,26 +122,26 @@ func TestGroupHandler_GetGroups(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
-		assert.Equal(t, "GET_GROUPS_FAILED", response.Code)
-		assert.Equal(t, "Failed to get groups", response.Message)
+		assert.Equal(t, "INTERNAL_ERROR", response.Code)
+		assert.Equal(t, "Internal server error", response.Detail)
 		
 		mockService.AssertExpectations(t)
 	})
 }
 
 func TestGroupHandler_CreateGroup(t *testing.T) {
-	mockService := new(MockGroupService)
-	handler := NewGroupHandler(mockService)
-	router := setupTestRouter()
-	router.POST("/groups", handler.CreateGroup)
-
 	t.Run("正常なグループ作成", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.POST("/groups", handler.CreateGroup)
+		
 		// テストデータ
 		description := "Test Description"
 		requestBody := services.CreateGroupRequest{
@@ -174,6 +186,11 @@ func TestGroupHandler_CreateGroup(t *testing.T) {
 	})
 
 	t.Run("不正なJSONリクエスト", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.POST("/groups", handler.CreateGroup)
+		
 		// リクエスト作成
 		req, _ := http.NewRequest("POST", "/groups", bytes.NewBuffer([]byte("invalid json")))
 		req.Header.Set("Content-Type", "application/json")
@@ -185,15 +202,20 @@ func TestGroupHandler_CreateGroup(t *testing.T) {
 		// 検証
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
 		assert.Equal(t, "INVALID_REQUEST", response.Code)
-		assert.Equal(t, "Invalid request body", response.Message)
+		assert.Equal(t, "Invalid request body", response.Detail)
 	})
 
 	t.Run("バリデーションエラー", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.POST("/groups", handler.CreateGroup)
+		
 		// テストデータ
 		requestBody := services.CreateGroupRequest{
 			Name: "", // 空の名前
@@ -213,12 +235,17 @@ func TestGroupHandler_CreateGroup(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 		
 		mockService.AssertExpectations(t)
 	})
 
 	t.Run("サービスエラー", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.POST("/groups", handler.CreateGroup)
+		
 		// テストデータ
 		requestBody := services.CreateGroupRequest{
 			Name: "Test Group",
@@ -244,12 +271,12 @@ func TestGroupHandler_CreateGroup(t *testing.T) {
 }
 
 func TestGroupHandler_GetGroup(t *testing.T) {
-	mockService := new(MockGroupService)
-	handler := NewGroupHandler(mockService)
-	router := setupTestRouter()
-	router.GET("/groups/:id", handler.GetGroup)
-
 	t.Run("正常なグループ取得", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.GET("/groups/:id", handler.GetGroup)
+		
 		// テストデータ
 		expectedGroup := &models.Group{
 			ID:        "test-id",
@@ -286,8 +313,13 @@ func TestGroupHandler_GetGroup(t *testing.T) {
 	})
 
 	t.Run("グループが見つからない場合", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.GET("/groups/:id", handler.GetGroup)
+		
 		// モックの設定
-		mockService.On("GetGroup", "nonexistent-id").Return(nil, gorm.ErrRecordNotFound)
+		mockService.On("GetGroup", "nonexistent-id").Return(nil, services.NewNotFoundError("GROUP_NOT_FOUND", "group not found"))
 
 		// リクエスト作成
 		req, _ := http.NewRequest("GET", "/groups/nonexistent-id", nil)
@@ -297,19 +329,24 @@ func TestGroupHandler_GetGroup(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
 		assert.Equal(t, "GROUP_NOT_FOUND", response.Code)
-		assert.Equal(t, "Group not found", response.Message)
+		assert.Equal(t, "group not found", response.Detail)
 		
 		mockService.AssertExpectations(t)
 	})
 
 	t.Run("サービスエラー", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.GET("/groups/:id", handler.GetGroup)
+		
 		// モックの設定
 		mockService.On("GetGroup", "test-id").Return(nil, errors.New("service error"))
 
@@ -321,26 +358,64 @@ func TestGroupHandler_GetGroup(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
-		assert.Equal(t, "GET_GROUP_FAILED", response.Code)
-		assert.Equal(t, "Failed to get group", response.Message)
+		assert.Equal(t, "INTERNAL_ERROR", response.Code)
+		assert.Equal(t, "Internal server error", response.Detail)
 		
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("所属する人物が変わったら304を返さない", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.GET("/groups/:id", handler.GetGroup)
+
+		// 人物の変更ではグループのバージョンは進まない
+		mockService.On("GetGroup", "test-id").Return(&models.Group{
+			ID: "test-id", Version: 2, Characters: []models.Character{{ID: "char-1", Version: 1}},
+		}, nil).Twice()
+		mockService.On("GetGroup", "test-id").Return(&models.Group{
+			ID: "test-id", Version: 2, Characters: []models.Character{{ID: "char-1", Version: 2}},
+		}, nil).Once()
+
+		// get If-None-Match を付けてグループを取得
+		get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", "/groups/test-id", nil)
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := get("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		assert.Regexp(t, `^W/"2-[0-9a-z]+"$`, etag)
+
+		assert.Equal(t, http.StatusNotModified, get(etag).Code)
+
+		w = get(etag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestGroupHandler_UpdateGroup(t *testing.T) {
-	mockService := new(MockGroupService)
-	handler := NewGroupHandler(mockService)
-	router := setupTestRouter()
-	router.PUT("/groups/:id", handler.UpdateGroup)
-
 	t.Run("正常なグループ更新", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.PUT("/groups/:id", handler.UpdateGroup)
+		
 		// テストデータ
 		newName := "Updated Group"
 		requestBody := services.UpdateGroupRequest{
@@ -383,6 +458,11 @@ func TestGroupHandler_UpdateGroup(t *testing.T) {
 	})
 
 	t.Run("不正なJSONリクエスト", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.PUT("/groups/:id", handler.UpdateGroup)
+		
 		// リクエスト作成
 		req, _ := http.NewRequest("PUT", "/groups/test-id", bytes.NewBuffer([]byte("invalid json")))
 		req.Header.Set("Content-Type", "application/json")
@@ -394,15 +474,20 @@ func TestGroupHandler_UpdateGroup(t *testing.T) {
 		// 検証
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
 		assert.Equal(t, "INVALID_REQUEST", response.Code)
-		assert.Equal(t, "Invalid request body", response.Message)
+		assert.Equal(t, "Invalid request body", response.Detail)
 	})
 
 	t.Run("グループが見つからない場合", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.PUT("/groups/:id", handler.UpdateGroup)
+		
 		// テストデータ
 		newName := "Updated Group"
 		requestBody := services.UpdateGroupRequest{
@@ -410,7 +495,7 @@ func TestGroupHandler_UpdateGroup(t *testing.T) {
 		}
 
 		// モックの設定
-		mockService.On("UpdateGroup", "nonexistent-id", &requestBody).Return(nil, gorm.ErrRecordNotFound)
+		mockService.On("UpdateGroup", "nonexistent-id", &requestBody).Return(nil, services.NewNotFoundError("GROUP_NOT_FOUND", "group not found"))
 
 		// リクエスト作成
 		jsonBody, _ := json.Marshal(requestBody)
@@ -422,19 +507,24 @@ func TestGroupHandler_UpdateGroup(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
 		assert.Equal(t, "GROUP_NOT_FOUND", response.Code)
-		assert.Equal(t, "Group not found", response.Message)
+		assert.Equal(t, "group not found", response.Detail)
 		
 		mockService.AssertExpectations(t)
 	})
 
 	t.Run("サービスエラー", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.PUT("/groups/:id", handler.UpdateGroup)
+		
 		// テストデータ
 		newName := "Updated Group"
 		requestBody := services.UpdateGroupRequest{
@@ -460,13 +550,71 @@ func TestGroupHandler_UpdateGroup(t *testing.T) {
 	})
 }
 
-func TestGroupHandler_DeleteGroup(t *testing.T) {
-	mockService := new(MockGroupService)
-	handler := NewGroupHandler(mockService)
-	router := setupTestRouter()
-	router.DELETE("/groups/:id", handler.DeleteGroup)
+func TestGroupHandler_PatchGroup(t *testing.T) {
+	description := "説明"
+	limit := 3
+	existing := &models.Group{ID: "test-id", Name: "Name", Description: &description, LabelLimit: &limit, Version: 2}
+
+	// patch JSON Merge Patch でグループを部分更新
+	patch := func(mockService *MockGroupService, body string) *httptest.ResponseRecorder {
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.PATCH("/groups/:id", handler.PatchGroup)
+
+		req, _ := http.NewRequest("PATCH", "/groups/test-id", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("nullで説明を削除", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		mockService.On("GetGroup", "test-id").Return(existing, nil)
+		mockService.On("UpdateGroup", "test-id", mock.MatchedBy(func(req *services.UpdateGroupRequest) bool {
+			return req.ClearDescription && !req.ClearLabelLimit && *req.LabelLimit == 3 && req.Version == 2
+		})).Return(&models.Group{ID: "test-id", Name: "Name", LabelLimit: &limit, Version: 3}, nil)
+
+		w := patch(mockService, `{"description":null}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("空文字の説明は削除しない", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		mockService.On("GetGroup", "test-id").Return(existing, nil)
+		mockService.On("UpdateGroup", "test-id", mock.MatchedBy(func(req *services.UpdateGroupRequest) bool {
+			return !req.ClearDescription && *req.Description == ""
+		})).Return(&models.Group{ID: "test-id", Name: "Name", Version: 3}, nil)
+
+		w := patch(mockService, `{"description":""}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("nullでラベルの上限を全体の既定値に戻す", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		mockService.On("GetGroup", "test-id").Return(existing, nil)
+		mockService.On("UpdateGroup", "test-id", mock.MatchedBy(func(req *services.UpdateGroupRequest) bool {
+			return req.ClearLabelLimit && !req.ClearDescription && *req.Description == "説明"
+		})).Return(&models.Group{ID: "test-id", Name: "Name", Description: &description, Version: 3}, nil)
+
+		w := patch(mockService, `{"labelLimit":null}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
 
+func TestGroupHandler_DeleteGroup(t *testing.T) {
 	t.Run("正常なグループ削除", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.DELETE("/groups/:id", handler.DeleteGroup)
+		
 		// モックの設定
 		mockService.On("DeleteGroup", "test-id").Return(nil)
 
@@ -490,8 +638,13 @@ func TestGroupHandler_DeleteGroup(t *testing.T) {
 	})
 
 	t.Run("グループが見つからない場合", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.DELETE("/groups/:id", handler.DeleteGroup)
+		
 		// モックの設定
-		mockService.On("DeleteGroup", "nonexistent-id").Return(errors.New("group not found"))
+		mockService.On("DeleteGroup", "nonexistent-id").Return(services.NewNotFoundError("GROUP_NOT_FOUND", "group not found"))
 
 		// リクエスト作成
 		req, _ := http.NewRequest("DELETE", "/groups/nonexistent-id", nil)
@@ -501,19 +654,24 @@ func TestGroupHandler_DeleteGroup(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
 		assert.Equal(t, "GROUP_NOT_FOUND", response.Code)
-		assert.Equal(t, "Group not found", response.Message)
+		assert.Equal(t, "group not found", response.Detail)
 		
 		mockService.AssertExpectations(t)
 	})
 
 	t.Run("サービスエラー", func(t *testing.T) {
+		mockService := new(MockGroupService)
+		handler := NewGroupHandler(mockService, nil)
+		router := setupTestRouter()
+		router.DELETE("/groups/:id", handler.DeleteGroup)
+		
 		// モックの設定
 		mockService.On("DeleteGroup", "test-id").Return(errors.New("service error"))
 
@@ -525,15 +683,169 @@ func TestGroupHandler_DeleteGroup(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// 検証
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		
-		var response middleware.AppError
+		var response middleware.Problem
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		
-		assert.Equal(t, "DELETE_GROUP_FAILED", response.Code)
-		assert.Equal(t, "Failed to delete group", response.Message)
+		assert.Equal(t, "INTERNAL_ERROR", response.Code)
+		assert.Equal(t, "Internal server error", response.Detail)
 		
 		mockService.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+
+// memoryGroupRepository ワークスペースで絞り込むメモリ上のグループリポジトリ（テナント分離の確認用）
+type memoryGroupRepository struct {
+	groups      map[string]*models.Group
+	workspaceID string
+}
+
+func newMemoryGroupRepository() *memoryGroupRepository {
+	return &memoryGroupRepository{groups: make(map[string]*models.Group)}
+}
+
+func (r *memoryGroupRepository) WithWorkspace(workspaceID string) repositories.GroupRepository {
+	return &memoryGroupRepository{groups: r.groups, workspaceID: workspaceID}
+}
+
+func (r *memoryGroupRepository) visible(group *models.Group) bool {
+	return r.workspaceID == "" || group.WorkspaceID == r.workspaceID
+}
+
+func (r *memoryGroupRepository) Create(group *models.Group) error {
+	group.ID = fmt.Sprintf("group-%d", len(r.groups)+1)
+	group.WorkspaceID = r.workspaceID
+	group.Version = 1
+	stored := *group
+	r.groups[group.ID] = &stored
+	return nil
+}
+
+func (r *memoryGroupRepository) GetByID(id string) (*models.Group, error) {
+	group, ok := r.groups[id]
+	if !ok || !r.visible(group) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *group
+	return &found, nil
+}
+
+func (r *memoryGroupRepository) GetAll() ([]models.Group, error) {
+	groups := []models.Group{}
+	for _, group := range r.groups {
+		if r.visible(group) {
+			groups = append(groups, *group)
+		}
+	}
+	return groups, nil
+}
+
+func (r *memoryGroupRepository) Update(group *models.Group) error {
+	stored, ok := r.groups[group.ID]
+	if !ok || !r.visible(stored) {
+		return gorm.ErrRecordNotFound
+	}
+	if stored.Version != group.Version {
+		return repositories.ErrVersionConflict
+	}
+	group.Version++
+	updated := *group
+	r.groups[group.ID] = &updated
+	return nil
+}
+
+func (r *memoryGroupRepository) Delete(id string) error {
+	if group, ok := r.groups[id]; ok && r.visible(group) {
+		delete(r.groups, id)
+	}
+	return nil
+}
+
+func (r *memoryGroupRepository) ExistsByID(id string) (bool, error) {
+	group, ok := r.groups[id]
+	return ok && r.visible(group), nil
+}
+
+func TestGroupHandler_WorkspaceIsolation(t *testing.T) {
+	workspaces := map[string]string{"": "ws-default", "acme": "ws-acme", "beta": "ws-beta"}
+	resolve := func(slug string) (string, error) {
+		if id, ok := workspaces[slug]; ok {
+			return id, nil
+		}
+		return "", services.NewNotFoundError("WORKSPACE_NOT_FOUND", "workspace not found")
+	}
+
+	repo := newMemoryGroupRepository()
+	handler := NewGroupHandler(services.NewGroupService(repo), nil)
+	router := setupTestRouter()
+	router.Use(middleware.Workspace(resolve, "example.com"))
+	router.POST("/groups", handler.CreateGroup)
+	router.GET("/groups", handler.GetGroups)
+	router.GET("/groups/:id", handler.GetGroup)
+	router.PUT("/groups/:id", handler.UpdateGroup)
+	router.DELETE("/groups/:id", handler.DeleteGroup)
+
+	send := func(method, path, workspace string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.WorkspaceHeader, workspace)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// ワークスペース acme にグループを作成
+	w := send("POST", "/groups", "acme", `{"name":"Acme のグループ"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data models.Group `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	groupPath := "/groups/" + created.Data.ID
+	assert.Equal(t, "ws-acme", created.Data.WorkspaceID)
+
+	t.Run("作成したワークスペースからは取得できる", func(t *testing.T) {
+		w := send("GET", groupPath, "acme", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("別のワークスペースからは見つからない", func(t *testing.T) {
+		for _, req := range []struct{ method, body string }{
+			{"GET", ""},
+			{"PUT", `{"name":"乗っ取り"}`},
+			{"DELETE", ""},
+		} {
+			w := send(req.method, groupPath, "beta", req.body)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, req.method)
+			var problem middleware.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "GROUP_NOT_FOUND", problem.Code, req.method)
+		}
+	})
+
+	t.Run("別のワークスペースの一覧には含まれない", func(t *testing.T) {
+		w := send("GET", "/groups", "beta", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []models.Group `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response.Data)
+	})
+
+	t.Run("別のワークスペースからの変更の影響を受けない", func(t *testing.T) {
+		w := send("GET", groupPath, "acme", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data models.Group `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Acme のグループ", response.Data.Name)
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/models"
+	"character-management-app/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LabelCategoryHandler ラベルの分類ハンドラー
+type LabelCategoryHandler struct {
+	categoryService services.LabelCategoryService
+}
+
+// NewLabelCategoryHandler ラベルの分類ハンドラーのコンストラクタ
+func NewLabelCategoryHandler(categoryService services.LabelCategoryService) *LabelCategoryHandler {
+	return &LabelCategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+// service リクエストのワークスペースに限定したサービスを返す
+func (h *LabelCategoryHandler) service(c *gin.Context) services.LabelCategoryService {
+	return h.categoryService.WithWorkspace(middleware.WorkspaceID(c))
+}
+
+// LabelCategoryRequest 分類の作成・更新リクエスト
+type LabelCategoryRequest struct {
+	Name            string `json:"name" validate:"required,max=100"`
+	MaxPerCharacter int    `json:"maxPerCharacter" validate:"required,min=1,max=100"`
+}
+
+// GetLabelCategories 分類の一覧を名前順に取得
+func (h *LabelCategoryHandler) GetLabelCategories(c *gin.Context) {
+	categories, err := h.service(c).GetAllCategories()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateLabelCategory 分類を作成
+func (h *LabelCategoryHandler) CreateLabelCategory(c *gin.Context) {
+	var req LabelCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	category, err := h.service(c).CreateCategory(&models.LabelCategory{
+		Name:            req.Name,
+		MaxPerCharacter: req.MaxPerCharacter,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusCreated, category)
+}
+
+// GetLabelCategory 分類を取得
+func (h *LabelCategoryHandler) GetLabelCategory(c *gin.Context) {
+	category, err := h.service(c).GetCategoryByID(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if notModified(c, category.Version, "") {
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, category)
+}
+
+// UpdateLabelCategory 分類を更新
+func (h *LabelCategoryHandler) UpdateLabelCategory(c *gin.Context) {
+	id := c.Param("id")
+	var req LabelCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// If-Match で指定されたバージョン
+	expectedVersion, err := ifMatchVersion(c, func() (uint, error) {
+		category, err := h.service(c).GetCategoryByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return category.Version, nil
+	})
+	if err != nil {
+		h.respondLabelCategoryConflict(c, id)
+		return
+	}
+
+	h.updateLabelCategory(c, id, &models.LabelCategory{
+		Name:            req.Name,
+		MaxPerCharacter: req.MaxPerCharacter,
+		Version:         expectedVersion,
+	})
+}
+
+// PatchLabelCategory 分類を部分更新（JSON Merge Patch / JSON Patch）
+func (h *LabelCategoryHandler) PatchLabelCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := h.service(c).GetCategoryByID(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !checkIfMatch(c, existing.Version) {
+		return
+	}
+
+	current := LabelCategoryRequest{
+		Name:            existing.Name,
+		MaxPerCharacter: existing.MaxPerCharacter,
+	}
+	var patched LabelCategoryRequest
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// パッチ適用元のバージョンに対して更新する
+	h.updateLabelCategory(c, id, &models.LabelCategory{
+		Name:            patched.Name,
+		MaxPerCharacter: patched.MaxPerCharacter,
+		Version:         existing.Version,
+	})
+}
+
+// updateLabelCategory 分類を更新して結果を返す（PUT・PATCH 共通）
+func (h *LabelCategoryHandler) updateLabelCategory(c *gin.Context, id string, category *models.LabelCategory) {
+	updated, err := h.service(c).UpdateCategory(id, category)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondLabelCategoryConflict(c, id)
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteLabelCategory 分類を削除（分類のラベルは分類なしになる）
+func (h *LabelCategoryHandler) DeleteLabelCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	// If-Match が指定されている場合は現在のバージョンを確認
+	if c.GetHeader("If-Match") != "" {
+		category, err := h.service(c).GetCategoryByID(id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !checkIfMatch(c, category.Version) {
+			return
+		}
+	}
+
+	if err := h.service(c).DeleteCategory(id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondLabelCategoryConflict 最新のバージョンを付けて412を返す
+func (h *LabelCategoryHandler) respondLabelCategoryConflict(c *gin.Context, id string) {
+	category, err := h.service(c).GetCategoryByID(id)
+	if err != nil {
+		middleware.WriteError(c, err)
+		return
+	}
+	respondPreconditionFailed(c, category.Version)
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"character-management-app/internal/middleware"
 	"character-management-app/internal/models"
 	"character-management-app/internal/services"
+	"errors"
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,54 +23,110 @@ func NewLabelHandler(labelService services.LabelService) *LabelHandler {
 	}
 }
 
-// CreateLabelRequest ラベル作成リクエスト
+// service リクエストのワークスペースに限定したサービスを返す
+func (h *LabelHandler) service(c *gin.Context) services.LabelService {
+	return h.labelService.WithWorkspace(middleware.WorkspaceID(c))
+}
+
+// CreateLabelRequest ラベル作成リクエスト（groupId を指定するとそのグループ専用のラベルになる）
 type CreateLabelRequest struct {
-	Name  string `json:"name" validate:"required,max=100"`
-	Color string `json:"color" validate:"required,hexcolor"`
+	Name       string  `json:"name" validate:"required,max=100"`
+	Color      string  `json:"color" validate:"required,hexcolor"`
+	GroupID    *string `json:"groupId"`
+	ParentID   *string `json:"parentId"`
+	CategoryID *string `json:"categoryId"`
 }
 
-// UpdateLabelRequest ラベル更新リクエスト
+// UpdateLabelRequest ラベル更新リクエスト（parentId・categoryId を省略・null にすると最上位・分類なしになる）
 type UpdateLabelRequest struct {
-	Name  string `json:"name" validate:"required,max=100"`
-	Color string `json:"color" validate:"required,hexcolor"`
+	Name       string  `json:"name" validate:"required,max=100"`
+	Color      string  `json:"color" validate:"required,hexcolor"`
+	ParentID   *string `json:"parentId"`
+	CategoryID *string `json:"categoryId"`
 }
 
 // GetLabels ラベル一覧を取得
+// groupId を指定した場合はそのグループの人物に付けられるラベル（全体のラベルとグループ専用のラベル）のみ
+// withUsage=true を指定した場合はラベルごとの人物とグループの数を付ける
 func (h *LabelHandler) GetLabels(c *gin.Context) {
-	labels, err := h.labelService.GetAllLabels()
+	withUsage, parseErr := strconv.ParseBool(c.DefaultQuery("withUsage", "false"))
+	if parseErr != nil {
+		c.Error(middleware.NewAppError("INVALID_QUERY", "withUsage must be true or false", parseErr.Error()))
+		return
+	}
+
+	var labels []models.Label
+	var err error
+	if groupID := c.Query("groupId"); groupID != "" {
+		labels, err = h.service(c).GetLabelsForGroup(groupID)
+	} else {
+		labels, err = h.service(c).GetAllLabels()
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	if withUsage {
+		withCounts, err := h.service(c).AttachLabelUsage(labels)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, withCounts)
 		return
 	}
 
 	c.JSON(http.StatusOK, labels)
 }
 
+// GetLabelTree ラベルの階層を木として取得
+func (h *LabelHandler) GetLabelTree(c *gin.Context) {
+	tree, err := h.service(c).GetLabelTree()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// SuggestLabelColor 既存のラベルと見分けやすい色を提案
+// groupId を指定した場合はそのグループの人物に付けられるラベルの色と比べる
+func (h *LabelHandler) SuggestLabelColor(c *gin.Context) {
+	suggestion, err := h.service(c).SuggestColor(c.Query("groupId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
 // CreateLabel ラベルを作成
 func (h *LabelHandler) CreateLabel(c *gin.Context) {
 	var req CreateLabelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	// ラベルモデルを作成
 	label := &models.Label{
-		Name:  req.Name,
-		Color: req.Color,
+		Name:       req.Name,
+		Color:      req.Color,
+		GroupID:    req.GroupID,
+		ParentID:   req.ParentID,
+		CategoryID: req.CategoryID,
 	}
 
 	// ラベルを作成
-	createdLabel, err := h.labelService.CreateLabel(label)
+	createdLabel, err := h.service(c).CreateLabel(label)
 	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setETag(c, createdLabel.Version)
 	c.JSON(http.StatusCreated, createdLabel)
 }
 
@@ -76,12 +134,17 @@ func (h *LabelHandler) CreateLabel(c *gin.Context) {
 func (h *LabelHandler) GetLabel(c *gin.Context) {
 	id := c.Param("id")
 
-	label, err := h.labelService.GetLabelByID(id)
+	label, err := h.service(c).GetLabelByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+		c.Error(err)
 		return
 	}
 
+	if notModified(c, label.Version, "") {
+		return
+	}
+
+	setETag(c, label.Version)
 	c.JSON(http.StatusOK, label)
 }
 
@@ -89,32 +152,102 @@ func (h *LabelHandler) GetLabel(c *gin.Context) {
 func (h *LabelHandler) UpdateLabel(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateLabelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// If-Match で指定されたバージョン
+	expectedVersion, err := ifMatchVersion(c, func() (uint, error) {
+		label, err := h.service(c).GetLabelByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return label.Version, nil
+	})
+	if err != nil {
+		h.respondLabelConflict(c, id)
 		return
 	}
 
 	// ラベルモデルを作成
 	label := &models.Label{
-		Name:  req.Name,
-		Color: req.Color,
+		Name:       req.Name,
+		Color:      req.Color,
+		ParentID:   req.ParentID,
+		CategoryID: req.CategoryID,
+		Version:    expectedVersion,
 	}
 
 	// ラベルを更新
-	updatedLabel, err := h.labelService.UpdateLabel(id, label)
+	updatedLabel, err := h.service(c).UpdateLabel(id, label)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondLabelConflict(c, id)
 			return
 		}
-		if strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	setETag(c, updatedLabel.Version)
+	c.JSON(http.StatusOK, updatedLabel)
+}
+
+// labelPatchDocument PATCH で編集できるラベルの項目（パッチ適用後に検証する）
+type labelPatchDocument struct {
+	Name       string  `json:"name" validate:"required,max=100"`
+	Color      string  `json:"color" validate:"required,hexcolor"`
+	ParentID   *string `json:"parentId"`
+	CategoryID *string `json:"categoryId"`
+}
+
+// PatchLabel ラベルを部分更新（JSON Merge Patch / JSON Patch）
+func (h *LabelHandler) PatchLabel(c *gin.Context) {
+	id := c.Param("id")
+
+	existingLabel, err := h.service(c).GetLabelByID(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !checkIfMatch(c, existingLabel.Version) {
+		return
+	}
+
+	current := labelPatchDocument{
+		Name:       existingLabel.Name,
+		Color:      existingLabel.Color,
+		ParentID:   existingLabel.ParentID,
+		CategoryID: existingLabel.CategoryID,
+	}
+
+	var patched labelPatchDocument
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// パッチ適用元のバージョンに対して更新する
+	label := &models.Label{
+		Name:       patched.Name,
+		Color:      patched.Color,
+		ParentID:   patched.ParentID,
+		CategoryID: patched.CategoryID,
+		Version:    existingLabel.Version,
+	}
+
+	updatedLabel, err := h.service(c).UpdateLabel(id, label)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondLabelConflict(c, id)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setETag(c, updatedLabel.Version)
 	c.JSON(http.StatusOK, updatedLabel)
 }
 
@@ -122,14 +255,32 @@ func (h *LabelHandler) UpdateLabel(c *gin.Context) {
 func (h *LabelHandler) DeleteLabel(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.labelService.DeleteLabel(id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	// If-Match が指定されている場合は現在のバージョンを確認
+	if c.GetHeader("If-Match") != "" {
+		label, err := h.service(c).GetLabelByID(id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !checkIfMatch(c, label.Version) {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	if err := h.service(c).DeleteLabel(id); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
-}
\ No newline at end of file
+}
+
+// respondLabelConflict 最新のバージョンを付けて412を返す
+func (h *LabelHandler) respondLabelConflict(c *gin.Context, id string) {
+	label, err := h.service(c).GetLabelByID(id)
+	if err != nil {
+		middleware.WriteError(c, err)
+		return
+	}
+	respondPreconditionFailed(c, label.Version)
+}
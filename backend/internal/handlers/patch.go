@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/patch"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// パッチ形式のメディアタイプ
+const (
+	mediaTypeMergePatch = "application/merge-patch+json"
+	mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// bindPatch 現在の値 current にリクエストボディのパッチを適用し、結果を検証して out に格納する
+// Content-Type が application/json-patch+json の場合は JSON Patch、
+// application/merge-patch+json または application/json の場合は JSON Merge Patch として扱う
+// 返すエラーは 415（未対応の形式）、400（不正なパッチ）、422（検証エラー）のいずれか
+func bindPatch(c *gin.Context, current interface{}, out interface{}) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("failed to encode current resource: %w", err)
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if tooLarge := payloadTooLarge(err); tooLarge != nil {
+			return tooLarge
+		}
+		return invalidPatch(fmt.Errorf("failed to read request body: %w", err))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	var patched []byte
+	switch mediaType {
+	case mediaTypeJSONPatch:
+		patched, err = patch.ApplyJSONPatch(doc, body)
+	case mediaTypeMergePatch, "application/json", "":
+		patched, err = patch.ApplyMergePatch(doc, body)
+	default:
+		return middleware.NewAppError("UNSUPPORTED_MEDIA_TYPE", "Unsupported patch media type", gin.H{
+			"supported": []string{mediaTypeMergePatch, mediaTypeJSONPatch},
+		}).WithStatus(http.StatusUnsupportedMediaType)
+	}
+	if err != nil {
+		if errors.Is(err, patch.ErrTestFailed) {
+			return middleware.NewAppError("PATCH_TEST_FAILED", "Patch test operation failed", err.Error()).WithStatus(http.StatusConflict)
+		}
+		return invalidPatch(err)
+	}
+
+	// 編集できない項目がパッチで追加された場合はエラーにする
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return invalidPatch(fmt.Errorf("patched document is invalid: %w", err))
+	}
+
+	return requestValidator.Struct(out)
+}
+
+// invalidPatch 適用できないパッチのエラー
+func invalidPatch(err error) *middleware.AppError {
+	return middleware.NewAppError("INVALID_PATCH", "Invalid patch", err.Error())
+}
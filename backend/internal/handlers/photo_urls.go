@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/palette"
+	"character-management-app/internal/services"
+	"strconv"
+	"time"
+)
+
+// PhotoURLs 画像のキーから配信用URLを生成する（services.ImageService が実装する）
+type PhotoURLs interface {
+	URL(key string) string
+	VariantURLs(key string) map[string]string
+	// URLExpiry 現在生成する署名付きURLの有効期限（署名しない場合はゼロ値）
+	URLExpiry() time.Time
+}
+
+// photoETagVariant 写真を含むレスポンスのETagに含める署名付きURLの有効期限
+// 署名付きURLは期限切れになるため、有効期限が変わったら古いETagで304を返さないようにする
+// 写真・ギャラリーの画像がない場合や署名しない場合は空文字（バージョンだけのETag）
+func photoETagVariant(urls PhotoURLs, characters ...models.Character) string {
+	if urls == nil || !hasPhotos(characters) {
+		return ""
+	}
+	expiry := urls.URLExpiry()
+	if expiry.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(expiry.Unix(), 10)
+}
+
+// hasPhotos 写真かギャラリーの画像のある人物が含まれるか
+func hasPhotos(characters []models.Character) bool {
+	for _, character := range characters {
+		if character.Photo != nil || len(character.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// characterWithURLs 写真・ギャラリーの画像のキーを配信用URLに置き換え、ラベルの文字色を設定した人物を返す
+// 引数の人物は変更しない（urls が nil の場合はキーのまま返す）
+func characterWithURLs(urls PhotoURLs, character models.Character) models.Character {
+	character.Labels = labelsWithTextColor(character.Labels)
+	if urls == nil {
+		return character
+	}
+	if character.Photo != nil {
+		url := urls.URL(*character.Photo)
+		character.PhotoVariants = urls.VariantURLs(*character.Photo)
+		character.Photo = &url
+	}
+	character.Images = imagesWithURLs(urls, character.Images)
+	return character
+}
+
+// labelWithTextColor 背景色に対する文字色を設定したラベルを返す
+func labelWithTextColor(label models.Label) models.Label {
+	label.TextColor = palette.TextColor(label.Color)
+	return label
+}
+
+// labelsWithTextColor ラベルごとに文字色を設定する（引数のラベルは変更しない）
+func labelsWithTextColor(labels []models.Label) []models.Label {
+	if labels == nil {
+		return nil
+	}
+	resolved := make([]models.Label, len(labels))
+	for i, label := range labels {
+		resolved[i] = labelWithTextColor(label)
+	}
+	return resolved
+}
+
+// charactersWithURLs 人物ごとに写真のキーを配信用URLに置き換える
+func charactersWithURLs(urls PhotoURLs, characters []models.Character) []models.Character {
+	if characters == nil {
+		return characters
+	}
+	resolved := make([]models.Character, len(characters))
+	for i, character := range characters {
+		resolved[i] = characterWithURLs(urls, character)
+	}
+	return resolved
+}
+
+// imageWithURLs ギャラリーの画像のキーを配信用URLに置き換える
+func imageWithURLs(urls PhotoURLs, image models.CharacterImage) models.CharacterImage {
+	if urls == nil {
+		return image
+	}
+	image.Variants = urls.VariantURLs(image.Key)
+	image.Key = urls.URL(image.Key)
+	return image
+}
+
+// imagesWithURLs ギャラリーの画像ごとにキーを配信用URLに置き換える
+func imagesWithURLs(urls PhotoURLs, images []models.CharacterImage) []models.CharacterImage {
+	if urls == nil || images == nil {
+		return images
+	}
+	resolved := make([]models.CharacterImage, len(images))
+	for i, image := range images {
+		resolved[i] = imageWithURLs(urls, image)
+	}
+	return resolved
+}
+
+// groupWithURLs グループに所属する人物の写真のキーを配信用URLに置き換える
+func groupWithURLs(urls PhotoURLs, group models.Group) models.Group {
+	group.Characters = charactersWithURLs(urls, group.Characters)
+	return group
+}
+
+// groupsWithURLs グループごとに所属する人物の写真のキーを配信用URLに置き換える
+func groupsWithURLs(urls PhotoURLs, groups []models.Group) []models.Group {
+	if groups == nil {
+		return groups
+	}
+	resolved := make([]models.Group, len(groups))
+	for i, group := range groups {
+		resolved[i] = groupWithURLs(urls, group)
+	}
+	return resolved
+}
+
+// relationshipWithURLs 関係の両方の人物の写真のキーを配信用URLに置き換える
+func relationshipWithURLs(urls PhotoURLs, relationship models.Relationship) models.Relationship {
+	relationship.Character1 = characterWithURLs(urls, relationship.Character1)
+	relationship.Character2 = characterWithURLs(urls, relationship.Character2)
+	return relationship
+}
+
+// relationshipsWithURLs 関係ごとに人物の写真のキーを配信用URLに置き換える
+func relationshipsWithURLs(urls PhotoURLs, relationships []models.Relationship) []models.Relationship {
+	if relationships == nil {
+		return relationships
+	}
+	resolved := make([]models.Relationship, len(relationships))
+	for i, relationship := range relationships {
+		resolved[i] = relationshipWithURLs(urls, relationship)
+	}
+	return resolved
+}
+
+// duplicatesWithURLs 重複候補の人物の写真のキーを配信用URLに置き換える
+func duplicatesWithURLs(urls PhotoURLs, duplicates []services.CharacterDuplicate) []services.CharacterDuplicate {
+	if duplicates == nil {
+		return duplicates
+	}
+	resolved := make([]services.CharacterDuplicate, len(duplicates))
+	for i, duplicate := range duplicates {
+		duplicate.Characters = charactersWithURLs(urls, duplicate.Characters)
+		resolved[i] = duplicate
+	}
+	return resolved
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/palette"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prefixPhotoURLs キーの前に /uploads/ を付ける PhotoURLs
+type prefixPhotoURLs struct{}
+
+func (prefixPhotoURLs) URL(key string) string {
+	return "/uploads/" + key
+}
+
+func (prefixPhotoURLs) VariantURLs(key string) map[string]string {
+	return map[string]string{"avatar": "/uploads/avatar/" + key}
+}
+
+func (prefixPhotoURLs) URLExpiry() time.Time {
+	return time.Time{}
+}
+
+// expiringPhotoURLs 署名付きURLの有効期限を返す PhotoURLs
+type expiringPhotoURLs struct {
+	prefixPhotoURLs
+	expiry time.Time
+}
+
+func (u expiringPhotoURLs) URLExpiry() time.Time {
+	return u.expiry
+}
+
+func TestPhotoETagVariant(t *testing.T) {
+	photo := "aaaa/full.jpg"
+	withPhoto := models.Character{ID: "char-1", Photo: &photo}
+	withImage := models.Character{ID: "char-2", Images: []models.CharacterImage{{Key: "bbbb/full.png"}}}
+	withoutPhoto := models.Character{ID: "char-3"}
+	signed := expiringPhotoURLs{expiry: time.Unix(1700003600, 0)}
+
+	assert.Equal(t, "1700003600", photoETagVariant(signed, withPhoto))
+	assert.Equal(t, "1700003600", photoETagVariant(signed, withoutPhoto, withImage))
+	assert.Empty(t, photoETagVariant(signed, withoutPhoto))
+	assert.Empty(t, photoETagVariant(signed))
+	assert.Empty(t, photoETagVariant(prefixPhotoURLs{}, withPhoto))
+	assert.Empty(t, photoETagVariant(nil, withPhoto))
+}
+
+func TestCharacterWithURLs(t *testing.T) {
+	photo := "aaaa/full.jpg"
+	character := models.Character{
+		ID:     "char-1",
+		Photo:  &photo,
+		Images: []models.CharacterImage{{ID: "image-1", Key: "bbbb/full.png"}},
+	}
+
+	t.Run("写真とギャラリーの画像を配信用URLに置き換える", func(t *testing.T) {
+		resolved := characterWithURLs(prefixPhotoURLs{}, character)
+
+		assert.Equal(t, "/uploads/aaaa/full.jpg", *resolved.Photo)
+		assert.Equal(t, map[string]string{"avatar": "/uploads/avatar/aaaa/full.jpg"}, resolved.PhotoVariants)
+		assert.Equal(t, "/uploads/bbbb/full.png", resolved.Images[0].Key)
+		assert.Equal(t, map[string]string{"avatar": "/uploads/avatar/bbbb/full.png"}, resolved.Images[0].Variants)
+	})
+
+	t.Run("元の人物のキーは変更しない", func(t *testing.T) {
+		characterWithURLs(prefixPhotoURLs{}, character)
+
+		assert.Equal(t, "aaaa/full.jpg", *character.Photo)
+		assert.Nil(t, character.PhotoVariants)
+		assert.Equal(t, "bbbb/full.png", character.Images[0].Key)
+	})
+
+	t.Run("写真のない人物", func(t *testing.T) {
+		resolved := characterWithURLs(prefixPhotoURLs{}, models.Character{ID: "char-2"})
+
+		assert.Nil(t, resolved.Photo)
+		assert.Nil(t, resolved.PhotoVariants)
+		assert.Nil(t, resolved.Images)
+	})
+
+	t.Run("PhotoURLs が未設定の場合はキーのまま", func(t *testing.T) {
+		resolved := characterWithURLs(nil, character)
+
+		assert.Equal(t, "aaaa/full.jpg", *resolved.Photo)
+		assert.Nil(t, resolved.PhotoVariants)
+	})
+
+	t.Run("ラベルの文字色を設定する", func(t *testing.T) {
+		labeled := models.Character{ID: "char-4", Labels: []models.Label{{ID: "label-1", Color: "#111827"}, {ID: "label-2", Color: "#FDE68A"}}}
+
+		resolved := characterWithURLs(nil, labeled)
+
+		assert.Equal(t, palette.White, resolved.Labels[0].TextColor)
+		assert.Equal(t, palette.Black, resolved.Labels[1].TextColor)
+		assert.Empty(t, labeled.Labels[0].TextColor)
+	})
+}
+
+func TestRelationshipWithURLs(t *testing.T) {
+	photo := "aaaa/full.jpg"
+	relationship := models.Relationship{
+		ID:         "rel-1",
+		Character1: models.Character{ID: "char-1", Photo: &photo},
+		Character2: models.Character{ID: "char-2"},
+	}
+
+	resolved := relationshipWithURLs(prefixPhotoURLs{}, relationship)
+
+	assert.Equal(t, "/uploads/aaaa/full.jpg", *resolved.Character1.Photo)
+	assert.Nil(t, resolved.Character2.Photo)
+	assert.Equal(t, "aaaa/full.jpg", *relationship.Character1.Photo)
+}
+
+func TestGroupsWithURLs(t *testing.T) {
+	photo := "aaaa/full.jpg"
+	groups := []models.Group{
+		{ID: "group-1", Characters: []models.Character{{ID: "char-1", Photo: &photo}}},
+		{ID: "group-2"},
+	}
+
+	resolved := groupsWithURLs(prefixPhotoURLs{}, groups)
+
+	assert.Equal(t, "/uploads/aaaa/full.jpg", *resolved[0].Characters[0].Photo)
+	assert.Nil(t, resolved[1].Characters)
+	assert.Equal(t, "aaaa/full.jpg", *groups[0].Characters[0].Photo)
+}
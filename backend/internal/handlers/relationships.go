@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"character-management-app/internal/middleware"
 	"character-management-app/internal/models"
 	"character-management-app/internal/services"
+	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,15 +13,23 @@ import (
 // RelationshipHandler 関係ハンドラー
 type RelationshipHandler struct {
 	relationshipService services.RelationshipService
+	photoURLs           PhotoURLs
 }
 
 // NewRelationshipHandler 関係ハンドラーのコンストラクタ
-func NewRelationshipHandler(relationshipService services.RelationshipService) *RelationshipHandler {
+// photoURLs は関係の人物の写真の配信用URLの生成に使う（nil の場合はキーのまま返す）
+func NewRelationshipHandler(relationshipService services.RelationshipService, photoURLs PhotoURLs) *RelationshipHandler {
 	return &RelationshipHandler{
 		relationshipService: relationshipService,
+		photoURLs:           photoURLs,
 	}
 }
 
+// service リクエストのワークスペースに限定したサービスを返す
+func (h *RelationshipHandler) service(c *gin.Context) services.RelationshipService {
+	return h.relationshipService.WithWorkspace(middleware.WorkspaceID(c))
+}
+
 // CreateRelationshipRequest 関係作成リクエスト
 type CreateRelationshipRequest struct {
 	Character1ID     string  `json:"character1Id" validate:"required"`
@@ -48,32 +57,27 @@ func (h *RelationshipHandler) GetRelationships(c *gin.Context) {
 
 	if characterID != "" {
 		// 人物IDが指定されている場合、その人物の関係を取得
-		relationships, err = h.relationshipService.GetRelationshipsByCharacterID(characterID)
+		relationships, err = h.service(c).GetRelationshipsByCharacterID(characterID)
 	} else if groupID != "" {
 		// グループIDが指定されている場合、そのグループの関係を取得
-		relationships, err = h.relationshipService.GetRelationshipsByGroupID(groupID)
+		relationships, err = h.service(c).GetRelationshipsByGroupID(groupID)
 	} else {
 		// 何も指定されていない場合、全ての関係を取得
-		relationships, err = h.relationshipService.GetAllRelationships()
+		relationships, err = h.service(c).GetAllRelationships()
 	}
 
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, relationships)
+	c.JSON(http.StatusOK, relationshipsWithURLs(h.photoURLs, relationships))
 }
 
 // CreateRelationship 関係を作成
 func (h *RelationshipHandler) CreateRelationship(c *gin.Context) {
 	var req CreateRelationshipRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -86,44 +90,52 @@ func (h *RelationshipHandler) CreateRelationship(c *gin.Context) {
 	}
 
 	// 関係を作成
-	createdRelationship, err := h.relationshipService.CreateRelationship(relationship)
+	createdRelationship, err := h.service(c).CreateRelationship(relationship)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "same character") ||
-			strings.Contains(err.Error(), "same group") ||
-			strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdRelationship)
+	setVariantETag(c, createdRelationship.Version, relationshipETagVariant(h.photoURLs, *createdRelationship))
+	c.JSON(http.StatusCreated, relationshipWithURLs(h.photoURLs, *createdRelationship))
 }
 
 // GetRelationship 関係を取得
 func (h *RelationshipHandler) GetRelationship(c *gin.Context) {
 	id := c.Param("id")
 
-	relationship, err := h.relationshipService.GetRelationshipByID(id)
+	relationship, err := h.service(c).GetRelationshipByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Relationship not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, relationship)
+	if notModified(c, relationship.Version, relationshipETagVariant(h.photoURLs, *relationship)) {
+		return
+	}
+
+	setVariantETag(c, relationship.Version, relationshipETagVariant(h.photoURLs, *relationship))
+	c.JSON(http.StatusOK, relationshipWithURLs(h.photoURLs, *relationship))
 }
 
 // UpdateRelationship 関係を更新
 func (h *RelationshipHandler) UpdateRelationship(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateRelationshipRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// If-Match で指定されたバージョン
+	expectedVersion, err := ifMatchVersion(c, func() (uint, error) {
+		relationship, err := h.service(c).GetRelationshipByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return relationship.Version, nil
+	})
+	if err != nil {
+		h.respondRelationshipConflict(c, id)
 		return
 	}
 
@@ -133,40 +145,119 @@ func (h *RelationshipHandler) UpdateRelationship(c *gin.Context) {
 		Character2ID:     req.Character2ID,
 		RelationshipType: req.RelationshipType,
 		Description:      req.Description,
+		Version:          expectedVersion,
 	}
 
 	// 関係を更新
-	updatedRelationship, err := h.relationshipService.UpdateRelationship(id, relationship)
+	updatedRelationship, err := h.service(c).UpdateRelationship(id, relationship)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondRelationshipConflict(c, id)
 			return
 		}
-		if strings.Contains(err.Error(), "same character") ||
-			strings.Contains(err.Error(), "same group") ||
-			strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	setVariantETag(c, updatedRelationship.Version, relationshipETagVariant(h.photoURLs, *updatedRelationship))
+	c.JSON(http.StatusOK, relationshipWithURLs(h.photoURLs, *updatedRelationship))
+}
+
+// relationshipPatchDocument PATCH で編集できる関係の項目（パッチ適用後に検証する）
+type relationshipPatchDocument struct {
+	Character1ID     string  `json:"character1Id" validate:"required"`
+	Character2ID     string  `json:"character2Id" validate:"required"`
+	RelationshipType string  `json:"relationshipType" validate:"required,max=100"`
+	Description      *string `json:"description"`
+}
+
+// PatchRelationship 関係を部分更新（JSON Merge Patch / JSON Patch）
+func (h *RelationshipHandler) PatchRelationship(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := h.service(c).GetRelationshipByID(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !checkIfMatch(c, existing.Version) {
+		return
+	}
+
+	current := relationshipPatchDocument{
+		Character1ID:     existing.Character1ID,
+		Character2ID:     existing.Character2ID,
+		RelationshipType: existing.RelationshipType,
+		Description:      existing.Description,
+	}
+
+	var patched relationshipPatchDocument
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// パッチ適用元のバージョンに対して更新する
+	relationship := &models.Relationship{
+		Character1ID:     patched.Character1ID,
+		Character2ID:     patched.Character2ID,
+		RelationshipType: patched.RelationshipType,
+		Description:      patched.Description,
+		Version:          existing.Version,
+	}
+
+	updatedRelationship, err := h.service(c).UpdateRelationship(id, relationship)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondRelationshipConflict(c, id)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedRelationship)
+	setVariantETag(c, updatedRelationship.Version, relationshipETagVariant(h.photoURLs, *updatedRelationship))
+	c.JSON(http.StatusOK, relationshipWithURLs(h.photoURLs, *updatedRelationship))
 }
 
 // DeleteRelationship 関係を削除
 func (h *RelationshipHandler) DeleteRelationship(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.relationshipService.DeleteRelationship(id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	// If-Match が指定されている場合は現在のバージョンを確認
+	if c.GetHeader("If-Match") != "" {
+		relationship, err := h.service(c).GetRelationshipByID(id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !checkIfMatch(c, relationship.Version) {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	if err := h.service(c).DeleteRelationship(id); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
-}
\ No newline at end of file
+}
+
+// respondRelationshipConflict 最新のバージョンを付けて412を返す
+func (h *RelationshipHandler) respondRelationshipConflict(c *gin.Context, id string) {
+	relationship, err := h.service(c).GetRelationshipByID(id)
+	if err != nil {
+		middleware.WriteError(c, err)
+		return
+	}
+	respondPreconditionFailed(c, relationship.Version)
+}
+
+// relationshipETagVariant 関係のETagの variant（両方の人物のバージョンと署名付きURLの有効期限）
+// 人物の変更では関係のバージョンが進まないため、人物の変更でもETagを変える
+func relationshipETagVariant(urls PhotoURLs, relationship models.Relationship) string {
+	characters := []models.Character{relationship.Character1, relationship.Character2}
+	return joinETagVariants(charactersETagVariant(characters...), photoETagVariant(urls, characters...))
+}
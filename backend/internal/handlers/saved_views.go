@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/models"
+	"character-management-app/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SavedViewHandler 保存したビューハンドラー
+type SavedViewHandler struct {
+	viewService services.SavedViewService
+	photoURLs   PhotoURLs
+}
+
+// NewSavedViewHandler 保存したビューハンドラーのコンストラクタ
+// photoURLs はビューの人物の写真の配信用URLの生成に使う（nil の場合はキーのまま返す）
+func NewSavedViewHandler(viewService services.SavedViewService, photoURLs PhotoURLs) *SavedViewHandler {
+	return &SavedViewHandler{
+		viewService: viewService,
+		photoURLs:   photoURLs,
+	}
+}
+
+// service リクエストのワークスペースに限定したサービスを返す
+func (h *SavedViewHandler) service(c *gin.Context) services.SavedViewService {
+	return h.viewService.WithWorkspace(middleware.WorkspaceID(c))
+}
+
+// SavedViewRequest ビューの作成・更新リクエスト
+type SavedViewRequest struct {
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description"`
+	Query       string  `json:"query" validate:"required,max=1000"`
+}
+
+// GetSavedViews ビューの一覧を名前順に取得
+func (h *SavedViewHandler) GetSavedViews(c *gin.Context) {
+	views, err := h.service(c).GetAllViews()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// CreateSavedView ビューを作成
+func (h *SavedViewHandler) CreateSavedView(c *gin.Context) {
+	var req SavedViewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	view, err := h.service(c).CreateView(&models.SavedView{
+		Name:        req.Name,
+		Description: req.Description,
+		Query:       req.Query,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, view.Version)
+	c.JSON(http.StatusCreated, view)
+}
+
+// GetSavedView ビューを取得
+func (h *SavedViewHandler) GetSavedView(c *gin.Context) {
+	view, err := h.service(c).GetViewByID(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if notModified(c, view.Version, "") {
+		return
+	}
+
+	setETag(c, view.Version)
+	c.JSON(http.StatusOK, view)
+}
+
+// GetSavedViewCharacters ビューの条件式に一致する人物を取得
+func (h *SavedViewHandler) GetSavedViewCharacters(c *gin.Context) {
+	characters, err := h.service(c).GetViewCharacters(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, charactersWithURLs(h.photoURLs, characters))
+}
+
+// UpdateSavedView ビューを更新
+func (h *SavedViewHandler) UpdateSavedView(c *gin.Context) {
+	id := c.Param("id")
+	var req SavedViewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// If-Match で指定されたバージョン
+	expectedVersion, err := ifMatchVersion(c, func() (uint, error) {
+		view, err := h.service(c).GetViewByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return view.Version, nil
+	})
+	if err != nil {
+		h.respondSavedViewConflict(c, id)
+		return
+	}
+
+	h.updateSavedView(c, id, &models.SavedView{
+		Name:        req.Name,
+		Description: req.Description,
+		Query:       req.Query,
+		Version:     expectedVersion,
+	})
+}
+
+// PatchSavedView ビューを部分更新（JSON Merge Patch / JSON Patch）
+func (h *SavedViewHandler) PatchSavedView(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := h.service(c).GetViewByID(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !checkIfMatch(c, existing.Version) {
+		return
+	}
+
+	current := SavedViewRequest{
+		Name:        existing.Name,
+		Description: existing.Description,
+		Query:       existing.Query,
+	}
+	var patched SavedViewRequest
+	if err := bindPatch(c, current, &patched); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// パッチ適用元のバージョンに対して更新する
+	h.updateSavedView(c, id, &models.SavedView{
+		Name:        patched.Name,
+		Description: patched.Description,
+		Query:       patched.Query,
+		Version:     existing.Version,
+	})
+}
+
+// updateSavedView ビューを更新して結果を返す（PUT・PATCH 共通）
+func (h *SavedViewHandler) updateSavedView(c *gin.Context, id string, view *models.SavedView) {
+	updated, err := h.service(c).UpdateView(id, view)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.respondSavedViewConflict(c, id)
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteSavedView ビューを削除
+func (h *SavedViewHandler) DeleteSavedView(c *gin.Context) {
+	id := c.Param("id")
+
+	// If-Match が指定されている場合は現在のバージョンを確認
+	if c.GetHeader("If-Match") != "" {
+		view, err := h.service(c).GetViewByID(id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !checkIfMatch(c, view.Version) {
+			return
+		}
+	}
+
+	if err := h.service(c).DeleteView(id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondSavedViewConflict 最新のバージョンを付けて412を返す
+func (h *SavedViewHandler) respondSavedViewConflict(c *gin.Context, id string) {
+	view, err := h.service(c).GetViewByID(id)
+	if err != nil {
+		middleware.WriteError(c, err)
+		return
+	}
+	respondPreconditionFailed(c, view.Version)
+}
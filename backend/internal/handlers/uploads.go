@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"character-management-app/internal/services"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadHandler アップロード画像の配信ハンドラー
+type UploadHandler struct {
+	imageService services.ImageService
+}
+
+// NewUploadHandler アップロード画像ハンドラーのコンストラクタ
+func NewUploadHandler(imageService services.ImageService) *UploadHandler {
+	return &UploadHandler{
+		imageService: imageService,
+	}
+}
+
+// GetUpload アップロード画像を返す
+// 署名付きURLを使う設定の場合は expires・signature を確認し、誤り・期限切れは403を返す
+// Range・If-Modified-Since・If-None-Match に対応する（ストレージがシークできない場合はメモリに読み込む）
+// @Summary アップロード画像の取得
+// @Tags uploads
+// @Produce image/jpeg,image/png
+// @Param filepath path string true "画像のキー"
+// @Param expires query int false "署名付きURLの有効期限（Unix 秒）"
+// @Param signature query string false "署名付きURLの署名"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /uploads/{filepath} [get]
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+
+	expiresAt, err := h.imageService.VerifyURL(key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	object, err := h.imageService.OpenImage(key)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer object.Body.Close()
+
+	if object.ContentType != "" {
+		c.Header("Content-Type", object.ContentType)
+	}
+	c.Header("Cache-Control", services.ImageCacheControl(key, expiresAt, time.Now()))
+	// 署名付きURLはクエリが変わっても同じ画像のため、キーから ETag を作って再検証できるようにする
+	c.Header("ETag", `"`+strings.ReplaceAll(key, `"`, "")+`"`)
+
+	content, ok := object.Body.(io.ReadSeeker)
+	if !ok {
+		// S3 等のリモートのオブジェクトは画像の大きさに上限があるため、読み込んでから範囲指定に応じる
+		data, err := io.ReadAll(object.Body)
+		if err != nil {
+			c.Error(fmt.Errorf("failed to read image: %w", err))
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	http.ServeContent(c.Writer, c.Request, key, object.ModTime, content)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/services"
+	"character-management-app/internal/storage"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadHandler_GetUpload(t *testing.T) {
+	setup := func() (*MockImageService, http.Handler) {
+		mockImageService := new(MockImageService)
+		handler := NewUploadHandler(mockImageService)
+		router := setupTestRouter()
+		router.GET("/uploads/*filepath", handler.GetUpload)
+		router.HEAD("/uploads/*filepath", handler.GetUpload)
+		return mockImageService, router
+	}
+	contentKey := strings.Repeat("ab", 32) + "/full.jpg"
+
+	t.Run("ローカルファイルを範囲指定で取得", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		path := filepath.Join(t.TempDir(), "photo.jpg")
+		require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0644))
+		file, err := os.Open(path)
+		require.NoError(t, err)
+
+		mockImageService.On("VerifyURL", "photo.jpg", "", "").Return(time.Time{}, nil)
+		mockImageService.On("OpenImage", "photo.jpg").Return(&storage.Object{
+			Body: file, ContentType: "image/jpeg", Size: 10, ModTime: time.Now(),
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/uploads/photo.jpg", nil)
+		req.Header.Set("Range", "bytes=2-4")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+		assert.Equal(t, "234", w.Body.String())
+		mockImageService.AssertExpectations(t)
+	})
+
+	t.Run("リモートのオブジェクトを中継", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		mockImageService.On("VerifyURL", "characters/photo.png", "", "").Return(time.Time{}, nil)
+		mockImageService.On("OpenImage", "characters/photo.png").Return(&storage.Object{
+			Body: io.NopCloser(strings.NewReader("image")), ContentType: "image/png", Size: 5,
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/uploads/characters/photo.png", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, "5", w.Header().Get("Content-Length"))
+		assert.Equal(t, "image", w.Body.String())
+	})
+
+	t.Run("リモートのオブジェクトも範囲指定で取得", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		mockImageService.On("VerifyURL", contentKey, "", "").Return(time.Time{}, nil)
+		mockImageService.On("OpenImage", contentKey).Return(&storage.Object{
+			Body: io.NopCloser(strings.NewReader("0123456789")), ContentType: "image/jpeg", Size: 10,
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/uploads/"+contentKey, nil)
+		req.Header.Set("Range", "bytes=5-")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "bytes 5-9/10", w.Header().Get("Content-Range"))
+		assert.Equal(t, "56789", w.Body.String())
+		// 内容のハッシュに保存した画像は長期間キャッシュさせる
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("ETag が一致する場合は304", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		mockImageService.On("VerifyURL", contentKey, "", "").Return(time.Time{}, nil)
+		mockImageService.On("OpenImage", contentKey).Return(&storage.Object{
+			Body: io.NopCloser(strings.NewReader("image")), ContentType: "image/jpeg", Size: 5,
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/uploads/"+contentKey, nil)
+		req.Header.Set("If-None-Match", `"`+contentKey+`"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("署名付きURLは有効期限まで本人のみキャッシュ", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		expiresAt := time.Now().Add(90 * time.Minute)
+		mockImageService.On("VerifyURL", contentKey, "1700000000", "sig").Return(expiresAt, nil)
+		mockImageService.On("OpenImage", contentKey).Return(&storage.Object{
+			Body: io.NopCloser(strings.NewReader("image")), ContentType: "image/jpeg", Size: 5,
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/uploads/"+contentKey+"?expires=1700000000&signature=sig", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Regexp(t, `^private, max-age=(5399|5400)$`, w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("署名の誤り・期限切れは403", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		mockImageService.On("VerifyURL", contentKey, "1700000000", "bad").
+			Return(time.Time{}, services.NewForbiddenError("INVALID_SIGNATURE", "image URL signature is invalid"))
+
+		req, _ := http.NewRequest("GET", "/uploads/"+contentKey+"?expires=1700000000&signature=bad", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var response middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "INVALID_SIGNATURE", response.Code)
+		mockImageService.AssertNotCalled(t, "OpenImage", contentKey)
+	})
+
+	t.Run("存在しない画像", func(t *testing.T) {
+		mockImageService, router := setup()
+
+		mockImageService.On("VerifyURL", "missing.jpg", "", "").Return(time.Time{}, nil)
+		mockImageService.On("OpenImage", "missing.jpg").Return(nil, services.NewNotFoundError("IMAGE_NOT_FOUND", "image not found"))
+
+		req, _ := http.NewRequest("GET", "/uploads/missing.jpg", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var response middleware.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "IMAGE_NOT_FOUND", response.Code)
+	})
+}
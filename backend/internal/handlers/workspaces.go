@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"character-management-app/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHandler ワークスペースハンドラー
+type WorkspaceHandler struct {
+	workspaceService services.WorkspaceService
+}
+
+// NewWorkspaceHandler ワークスペースハンドラーのコンストラクタ
+func NewWorkspaceHandler(workspaceService services.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+	}
+}
+
+// GetWorkspaces ワークスペース一覧を取得
+// @Summary ワークスペース一覧取得
+// @Description 全てのワークスペースを取得します
+// @Tags workspaces
+// @Produce json
+// @Success 200 {array} models.Workspace
+// @Failure 500 {object} middleware.Problem
+// @Router /api/v1/workspaces [get]
+func (h *WorkspaceHandler) GetWorkspaces(c *gin.Context) {
+	workspaces, err := h.workspaceService.GetAllWorkspaces()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    workspaces,
+		"message": "Workspaces retrieved successfully",
+	})
+}
+
+// CreateWorkspace ワークスペースを作成
+// @Summary ワークスペース作成
+// @Description 新しいワークスペースを作成します
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param workspace body services.CreateWorkspaceRequest true "ワークスペース作成リクエスト"
+// @Success 201 {object} models.Workspace
+// @Failure 400 {object} middleware.Problem
+// @Failure 409 {object} middleware.Problem
+// @Router /api/v1/workspaces [post]
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	var req services.CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidRequest(err))
+		return
+	}
+
+	workspace, err := h.workspaceService.CreateWorkspace(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    workspace,
+		"message": "Workspace created successfully",
+	})
+}
+
+// GetWorkspace ワークスペースを取得
+// @Summary ワークスペース詳細取得
+// @Description 指定されたスラッグのワークスペースを取得します
+// @Tags workspaces
+// @Produce json
+// @Param slug path string true "ワークスペースのスラッグ"
+// @Success 200 {object} models.Workspace
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/workspaces/{slug} [get]
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	workspace, err := h.workspaceService.GetWorkspaceBySlug(c.Param("slug"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    workspace,
+		"message": "Workspace retrieved successfully",
+	})
+}
@@ -0,0 +1,231 @@
+// Package linkpreview は関連リンクのページのタイトルとファビコンを取得する
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrBlockedAddress 接続先がループバック・プライベートアドレスなどサーバーの内部のネットワーク
+var ErrBlockedAddress = errors.New("address is not allowed")
+
+const (
+	// maxBodySize 読み込むページの上限（<head> の解析に十分な大きさ）
+	maxBodySize = 1 << 20
+	// maxRedirects リダイレクトをたどる回数の上限
+	maxRedirects = 5
+	// maxTitleLength タイトルの最大文字数（関連リンクのタイトルと同じ）
+	maxTitleLength = 255
+	// requestTimeout 1ページの取得にかける時間の上限
+	requestTimeout = 10 * time.Second
+	userAgent      = "character-management-app-linkpreview/1.0"
+)
+
+// Metadata リンク先のページの情報（取得できなかった項目は空）
+type Metadata struct {
+	Title      string
+	FaviconURL string
+}
+
+// Fetcher リンク先のページの情報を取得する（テストではスタブに差し替える）
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*Metadata, error)
+}
+
+// HTTPFetcher HTTP でページを取得して <title> と <link rel="icon"> を読む Fetcher
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher HTTP でページを取得する Fetcher を作成
+// サーバーの内部のネットワークに接続させないため、グローバルでないアドレスへの接続（リダイレクト先を含む）は拒否する
+func NewHTTPFetcher() *HTTPFetcher {
+	return newHTTPFetcher(publicOnly)
+}
+
+// newHTTPFetcher 接続先のアドレスを control で確認する Fetcher を作成（nil の場合は確認しない）
+func newHTTPFetcher(control func(network, address string, conn syscall.RawConn) error) *HTTPFetcher {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	transport := &http.Transport{
+		// 環境変数のプロキシを経由すると接続先の確認が効かないため使わない
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &HTTPFetcher{client: &http.Client{
+		Transport: transport,
+		Timeout:   requestTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}}
+}
+
+// Fetch ページを取得してタイトルとファビコンのURLを返す
+// ファビコンの指定がないページはサイトの /favicon.ico を返す（存在は確認しない）
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	// Content-Type と <meta charset> の文字コード（Shift_JIS など）を UTF-8 に変換して読む
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxBodySize), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect charset: %w", err)
+	}
+	return parse(body, resp.Request.URL)
+}
+
+// parse ページの <head> からタイトル（なければ og:title）とファビコンを読む
+func parse(r io.Reader, base *url.URL) (*Metadata, error) {
+	z := html.NewTokenizer(r)
+	var title strings.Builder
+	var ogTitle, icon, touchIcon string
+	inTitle := false
+
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				break loop
+			}
+			return nil, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = true
+			case atom.Meta:
+				attrs := tagAttrs(z, hasAttr)
+				if attrs["property"] == "og:title" && ogTitle == "" {
+					ogTitle = attrs["content"]
+				}
+			case atom.Link:
+				attrs := tagAttrs(z, hasAttr)
+				for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+					switch {
+					case rel == "icon" && icon == "":
+						icon = attrs["href"]
+					case rel == "apple-touch-icon" && touchIcon == "":
+						touchIcon = attrs["href"]
+					}
+				}
+			case atom.Body:
+				// <head> 以降は読まない
+				break loop
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Title {
+				inTitle = false
+			}
+		}
+	}
+
+	meta := &Metadata{Title: cleanTitle(title.String())}
+	if meta.Title == "" {
+		meta.Title = cleanTitle(ogTitle)
+	}
+	for _, href := range []string{icon, touchIcon, "/favicon.ico"} {
+		if resolved := resolve(base, href); resolved != "" {
+			meta.FaviconURL = resolved
+			break
+		}
+	}
+	return meta, nil
+}
+
+// tagAttrs タグの属性を名前（小文字）ごとに返す
+func tagAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, value []byte
+		key, value, hasAttr = z.TagAttr()
+		attrs[string(key)] = string(value)
+	}
+	return attrs
+}
+
+// cleanTitle 連続する空白をまとめ、最大文字数に切り詰める
+func cleanTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = string(runes[:maxTitleLength])
+	}
+	return title
+}
+
+// resolve ページのURLを基準に href を絶対URLにする（http・https 以外は空）
+func resolve(base *url.URL, href string) string {
+	if strings.TrimSpace(href) == "" {
+		return ""
+	}
+	resolved, err := base.Parse(strings.TrimSpace(href))
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	return resolved.String()
+}
+
+// publicOnly 接続先がグローバルなアドレスでなければ拒否する（名前解決後のアドレスで確認する）
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// isPublic グローバルなアドレスか（ループバック・プライベート・リンクローカル・マルチキャストなどでない）
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
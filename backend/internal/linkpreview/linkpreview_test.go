@@ -0,0 +1,121 @@
+package linkpreview
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!DOCTYPE html><html><head>
+<meta property="og:title" content="OGのタイトル">
+<title>
+  織田信長 -
+  Wikipedia
+</title>
+<link rel="apple-touch-icon" href="/touch.png">
+<link rel="shortcut icon" href="static/icon.png">
+</head><body><title>本文</title></body></html>`))
+	})
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><meta property="og:title" content="OGのタイトル"></head></html>`))
+	})
+	mux.HandleFunc("/sjis", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := japanese.ShiftJIS.NewEncoder().String(`<html><head><meta charset="Shift_JIS"><title>豊臣秀吉</title></head></html>`)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// テストのサーバーはループバックアドレスのため接続先を確認しない
+	fetcher := newHTTPFetcher(nil)
+
+	t.Run("タイトルとファビコンを読む", func(t *testing.T) {
+		meta, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+
+		require.NoError(t, err)
+		assert.Equal(t, "織田信長 - Wikipedia", meta.Title)
+		assert.Equal(t, server.URL+"/static/icon.png", meta.FaviconURL)
+	})
+
+	t.Run("タイトルがなければ og:title とサイトの favicon.ico", func(t *testing.T) {
+		meta, err := fetcher.Fetch(context.Background(), server.URL+"/og")
+
+		require.NoError(t, err)
+		assert.Equal(t, "OGのタイトル", meta.Title)
+		assert.Equal(t, server.URL+"/favicon.ico", meta.FaviconURL)
+	})
+
+	t.Run("meta charset の文字コードを変換", func(t *testing.T) {
+		meta, err := fetcher.Fetch(context.Background(), server.URL+"/sjis")
+
+		require.NoError(t, err)
+		assert.Equal(t, "豊臣秀吉", meta.Title)
+	})
+
+	t.Run("リダイレクト先を基準にする", func(t *testing.T) {
+		meta, err := fetcher.Fetch(context.Background(), server.URL+"/redirect")
+
+		require.NoError(t, err)
+		assert.Equal(t, "織田信長 - Wikipedia", meta.Title)
+	})
+
+	t.Run("HTML でないページ", func(t *testing.T) {
+		_, err := fetcher.Fetch(context.Background(), server.URL+"/image.png")
+
+		assert.ErrorContains(t, err, "unsupported content type")
+	})
+
+	t.Run("見つからないページ", func(t *testing.T) {
+		_, err := fetcher.Fetch(context.Background(), server.URL+"/missing")
+
+		assert.ErrorContains(t, err, "unexpected status 404")
+	})
+
+	t.Run("内部のネットワークへの接続は拒否する", func(t *testing.T) {
+		_, err := NewHTTPFetcher().Fetch(context.Background(), server.URL+"/page")
+
+		assert.ErrorIs(t, err, ErrBlockedAddress)
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.0.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isPublic(net.ParseIP(tt.ip)))
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminToken 管理用エンドポイントに Authorization: Bearer <token> を必須にするミドルウェア
+// token が空の場合は管理用エンドポイントを無効にする（全て403）
+func AdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			WriteProblem(c, http.StatusForbidden, NewAppError("ADMIN_DISABLED", "admin API is disabled; set ADMIN_TOKEN to enable it", nil))
+			return
+		}
+
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			WriteProblem(c, http.StatusUnauthorized, NewAppError("UNAUTHORIZED", "valid admin token is required", nil))
+			return
+		}
+		c.Next()
+	}
+}
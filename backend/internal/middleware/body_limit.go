@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit リクエストボディの大きさを limit バイトまでに制限するミドルウェア（0 以下の場合は制限しない）
+// Content-Length が上限を超える場合は読み込まずに 413 を返し、
+// 超えたことが読み込み中に分かった場合は *http.MaxBytesError を ErrorHandler が 413 に変換する
+func BodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			WriteProblem(c, http.StatusRequestEntityTooLarge, PayloadTooLargeError(limit))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// PayloadTooLargeError リクエストボディが上限を超えたエラー
+func PayloadTooLargeError(limit int64) *AppError {
+	return NewAppError("PAYLOAD_TOO_LARGE", "request body is too large", map[string]int64{"limit": limit}).
+		WithStatus(http.StatusRequestEntityTooLarge)
+}
@@ -9,9 +9,9 @@ func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// CORS ヘッダーを設定
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Workspace, If-Match, If-None-Match")
+		c.Header("Access-Control-Expose-Headers", "Content-Length, ETag")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		// プリフライトリクエストの処理
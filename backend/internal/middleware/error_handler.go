@@ -1,17 +1,25 @@
 package middleware
 
 import (
+	"character-management-app/internal/services"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// ProblemContentType RFC 7807 のエラーレスポンスのメディアタイプ
+const ProblemContentType = "application/problem+json"
+
 // AppError カスタムエラー型
+// Status を指定しない場合は 400 として扱う
 type AppError struct {
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
 	Details interface{} `json:"details,omitempty"`
+	Status  int         `json:"-"`
 }
 
 func (e *AppError) Error() string {
@@ -27,39 +35,118 @@ func NewAppError(code, message string, details interface{}) *AppError {
 	}
 }
 
+// WithStatus HTTPステータスを指定したエラーを返す
+func (e *AppError) WithStatus(status int) *AppError {
+	e.Status = status
+	return e
+}
+
+// Problem RFC 7807 (application/problem+json) 形式のエラーレスポンス
+// code と details はアプリケーション固有の拡張メンバー
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
 // ErrorHandler エラーハンドリングミドルウェア
+// ハンドラーが c.Error で登録した最後のエラーを problem+json で返す
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		// エラーが発生した場合の処理
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-			
-			switch e := err.Err.(type) {
-			case *AppError:
-				// カスタムエラーの場合
-				c.JSON(http.StatusBadRequest, e)
-			case validator.ValidationErrors:
-				// バリデーションエラーの場合
-				validationError := formatValidationError(e)
-				c.JSON(http.StatusBadRequest, validationError)
-			default:
-				// その他のエラーの場合
-				appError := &AppError{
-					Code:    "INTERNAL_ERROR",
-					Message: "Internal server error",
-				}
-				c.JSON(http.StatusInternalServerError, appError)
-			}
+		// エラーが発生した場合の処理（既にレスポンスを書き込んでいる場合は何もしない）
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			WriteError(c, c.Errors.Last().Err)
 		}
 	}
 }
 
+// WriteError エラーを分類して problem+json で返す
+func WriteError(c *gin.Context, err error) {
+	status, appError := classifyError(err)
+	if status >= http.StatusInternalServerError {
+		log.Printf("ERROR: %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+	WriteProblem(c, status, appError)
+}
+
+// WriteProblem 指定したステータスで problem+json を返してリクエストを中断する
+func WriteProblem(c *gin.Context, status int, appError *AppError) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   appError.Message,
+		Instance: c.Request.URL.Path,
+		Code:     appError.Code,
+		Details:  appError.Details,
+	})
+}
+
+// classifyError エラーからHTTPステータスとレスポンス内容を決める
+func classifyError(err error) (int, *AppError) {
+	var appError *AppError
+	if errors.As(err, &appError) {
+		if appError.Status == 0 {
+			return http.StatusBadRequest, appError
+		}
+		return appError.Status, appError
+	}
+
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return http.StatusRequestEntityTooLarge, PayloadTooLargeError(maxBytesError.Limit)
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return http.StatusUnprocessableEntity, formatValidationError(validationErrors)
+	}
+
+	if errors.Is(err, services.ErrVersionConflict) {
+		return http.StatusPreconditionFailed, NewAppError("PRECONDITION_FAILED", "Resource has been modified by another request", nil)
+	}
+
+	var serviceError *services.Error
+	if errors.As(err, &serviceError) {
+		return statusOf(serviceError), &AppError{
+			Code:    serviceError.Code,
+			Message: serviceError.Message,
+			Details: serviceError.Details,
+		}
+	}
+
+	return http.StatusInternalServerError, NewAppError("INTERNAL_ERROR", "Internal server error", nil)
+}
+
+// statusOf サービスのエラー分類をHTTPステータスに対応付ける
+func statusOf(err *services.Error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrValidation), errors.Is(err, services.ErrLimitExceeded):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // formatValidationError バリデーションエラーをフォーマット
 func formatValidationError(errs validator.ValidationErrors) *AppError {
 	details := make(map[string]string)
-	
+
 	for _, err := range errs {
 		field := err.Field()
 		switch err.Tag() {
@@ -88,19 +175,13 @@ func formatValidationError(errs validator.ValidationErrors) *AppError {
 // NotFoundHandler 404エラーハンドラー
 func NotFoundHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, &AppError{
-			Code:    "NOT_FOUND",
-			Message: "Resource not found",
-		})
+		WriteProblem(c, http.StatusNotFound, NewAppError("NOT_FOUND", "Resource not found", nil))
 	}
 }
 
 // MethodNotAllowedHandler 405エラーハンドラー
 func MethodNotAllowedHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.JSON(http.StatusMethodNotAllowed, &AppError{
-			Code:    "METHOD_NOT_ALLOWED",
-			Message: "Method not allowed",
-		})
+		WriteProblem(c, http.StatusMethodNotAllowed, NewAppError("METHOD_NOT_ALLOWED", "Method not allowed", nil))
 	}
-}
\ No newline at end of file
+}
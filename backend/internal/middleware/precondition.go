@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch 更新・削除リクエストに If-Match ヘッダーを必須にするミドルウェア
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if c.GetHeader("If-Match") == "" {
+				WriteProblem(c, http.StatusPreconditionRequired, NewAppError("PRECONDITION_REQUIRED", "If-Match header is required", nil))
+				return
+			}
+		}
+		c.Next()
+	}
+}
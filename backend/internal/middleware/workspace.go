@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHeader テナントを指定するリクエストヘッダー
+const WorkspaceHeader = "X-Workspace"
+
+// workspaceIDKey コンテキストに保存するワークスペースIDのキー
+const workspaceIDKey = "workspaceID"
+
+// WorkspaceResolver スラッグをワークスペースIDに解決する関数
+type WorkspaceResolver func(slug string) (string, error)
+
+// Workspace リクエストのテナントを解決するミドルウェア
+// ヘッダーを優先し、なければ baseDomain のサブドメインから判定する
+func Workspace(resolve WorkspaceResolver, baseDomain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := strings.TrimSpace(c.GetHeader(WorkspaceHeader))
+		if slug == "" {
+			slug = subdomain(c.Request.Host, baseDomain)
+		}
+
+		workspaceID, err := resolve(strings.ToLower(slug))
+		if err != nil {
+			WriteError(c, err)
+			return
+		}
+
+		c.Set(workspaceIDKey, workspaceID)
+		c.Next()
+	}
+}
+
+// WorkspaceID 解決済みのワークスペースIDを取得
+func WorkspaceID(c *gin.Context) string {
+	return c.GetString(workspaceIDKey)
+}
+
+// subdomain ホスト名から baseDomain 直下のサブドメインを取り出す
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	suffix := "." + strings.ToLower(baseDomain)
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+
+	sub := strings.TrimSuffix(host, suffix)
+	if strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"character-management-app/internal/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWorkspaces テスト用のスラッグとワークスペースID（空のスラッグは既定のワークスペース）
+var testWorkspaces = map[string]string{
+	"":     "ws-default",
+	"acme": "ws-acme",
+	"beta": "ws-beta",
+}
+
+func resolveTestWorkspace(slug string) (string, error) {
+	if id, ok := testWorkspaces[slug]; ok {
+		return id, nil
+	}
+	return "", services.NewNotFoundError("WORKSPACE_NOT_FOUND", "workspace not found")
+}
+
+// setupWorkspaceRouter 解決したワークスペースIDを返すルーターを作成
+func setupWorkspaceRouter(baseDomain string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Workspace(resolveTestWorkspace, baseDomain))
+	router.GET("/workspace", func(c *gin.Context) {
+		c.String(http.StatusOK, WorkspaceID(c))
+	})
+	return router
+}
+
+func TestWorkspace(t *testing.T) {
+	tests := []struct {
+		name       string
+		baseDomain string
+		host       string
+		header     string
+		expected   string
+	}{
+		{"ヘッダーで指定", "example.com", "localhost", "acme", "ws-acme"},
+		{"ヘッダーの前後の空白と大文字", "example.com", "localhost", " ACME ", "ws-acme"},
+		{"サブドメインで指定", "example.com", "acme.example.com", "", "ws-acme"},
+		{"ヘッダーがサブドメインより優先", "example.com", "acme.example.com", "beta", "ws-beta"},
+		{"ポート付きのホスト", "example.com", "acme.example.com:8080", "", "ws-acme"},
+		{"ホストの大文字", "Example.com", "ACME.EXAMPLE.COM", "", "ws-acme"},
+		{"ネストしたサブドメインは使わない", "example.com", "www.acme.example.com", "", "ws-default"},
+		{"ベースドメインそのもの", "example.com", "example.com", "", "ws-default"},
+		{"別のベースドメイン", "example.com", "acme.example.org", "", "ws-default"},
+		{"ベースドメインで終わる別のドメイン", "example.com", "acme.badexample.com", "", "ws-default"},
+		{"ベースドメイン未設定", "", "acme.example.com", "", "ws-default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupWorkspaceRouter(tt.baseDomain)
+			req := httptest.NewRequest(http.MethodGet, "/workspace", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(WorkspaceHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func TestWorkspace_UnknownSlug(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		header string
+	}{
+		{"ヘッダーで指定", "localhost", "unknown"},
+		{"サブドメインで指定", "unknown.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupWorkspaceRouter("example.com")
+			req := httptest.NewRequest(http.MethodGet, "/workspace", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(WorkspaceHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+			var problem Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "WORKSPACE_NOT_FOUND", problem.Code)
+		})
+	}
+}
@@ -2,20 +2,45 @@ package models
 
 import (
 	"time"
-
-	"gorm.io/datatypes"
 )
 
 // Character モデル
 type Character struct {
-	ID           string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	GroupID      string         `json:"groupId" gorm:"not null;type:varchar(36)" validate:"required"`
-	Name         string         `json:"name" gorm:"not null;size:255" validate:"required,max=255"`
-	Photo        *string        `json:"photo" gorm:"size:500"`
-	Information  string         `json:"information" gorm:"type:text"`
-	RelatedLinks datatypes.JSON `json:"relatedLinks" gorm:"type:json"`
-	CreatedAt    time.Time      `json:"createdAt" gorm:"autoCreateTime"`
-	UpdatedAt    time.Time      `json:"updatedAt" gorm:"autoUpdateTime"`
-	Group        Group          `json:"group,omitempty" gorm:"foreignKey:GroupID"`
-	Labels       []Label        `json:"labels,omitempty" gorm:"many2many:character_labels"`
-}
\ No newline at end of file
+	ID            string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	GroupID       string            `json:"groupId" gorm:"not null;type:varchar(36)" validate:"required"`
+	Name          string            `json:"name" gorm:"not null;size:255" validate:"required,max=255"`
+	Photo         *string           `json:"photo" gorm:"size:500"`            // ストレージのキー（レスポンスではハンドラーが配信用URLに置き換える）
+	PhotoVariants map[string]string `json:"photoVariants,omitempty" gorm:"-"` // サイズ別の配信用URL（レスポンスの作成時にハンドラーが設定）
+	PhotoCrop     *PhotoCrop        `json:"photoCrop,omitempty" gorm:"type:json;serializer:json"`
+	PhotoSize     int64             `json:"-" gorm:"not null;default:0"` // 写真の全てのバリアントの合計バイト数（ワークスペースの保存容量の計算に使う）
+	Information   string            `json:"information" gorm:"type:text"`
+	RelatedLinks  []RelatedLink     `json:"relatedLinks" gorm:"type:json;serializer:json"`
+	Version       uint              `json:"version" gorm:"not null;default:1"`
+	CreatedAt     time.Time         `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time         `json:"updatedAt" gorm:"autoUpdateTime"`
+	Group         Group             `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	Labels        []Label           `json:"labels,omitempty" gorm:"many2many:character_labels"`
+	Images        []CharacterImage  `json:"images,omitempty" gorm:"foreignKey:CharacterID;constraint:OnDelete:CASCADE"`
+	Aliases       []CharacterAlias  `json:"aliases,omitempty" gorm:"foreignKey:CharacterID;constraint:OnDelete:CASCADE"`
+}
+
+// PhotoCrop 写真の切り抜き範囲と注目点
+// 座標は正立させた元画像の幅・高さに対する 0〜1 の割合で、avatar と card のバリアントに適用する
+type PhotoCrop struct {
+	Rect  *CropRect   `json:"rect,omitempty"`
+	Focus *FocalPoint `json:"focus,omitempty"`
+}
+
+// CropRect 切り抜く範囲（左上の位置と大きさ）
+type CropRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// FocalPoint 正方形に切り抜く際に中心とする位置（顔の位置など）
+type FocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// 別名の種類
+const (
+	// AliasTypeAlias 幼名・諱・号などの別名
+	AliasTypeAlias = "alias"
+	// AliasTypeTitle 官職名・諡などの称号
+	AliasTypeTitle = "title"
+	// AliasTypeReading 名前の読み（ふりがな、一覧の並び替えに使う）
+	AliasTypeReading = "reading"
+	// AliasTypeRomanization ローマ字表記
+	AliasTypeRomanization = "romanization"
+)
+
+// CharacterAlias 人物の別名（幼名・諡・官職名・読み・ローマ字表記など）
+type CharacterAlias struct {
+	ID          string `json:"-" gorm:"primaryKey;type:varchar(36)"` // 更新のたびに作り直すため公開しない
+	CharacterID string `json:"-" gorm:"not null;index;type:varchar(36)"`
+	Type        string `json:"type" gorm:"not null;size:20" validate:"required,oneof=alias title reading romanization"`
+	Name        string `json:"name" gorm:"not null;size:255" validate:"required,max=255"`
+	Position    int    `json:"-" gorm:"not null;default:0"` // 登録した順
+}
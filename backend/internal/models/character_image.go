@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// CharacterImage 人物のギャラリーの画像（衣装違い・出典の異なる肖像など）
+// IsPrimary の画像は人物の photo と同じ画像で、photo を変更すると代表画像も置き換わる
+type CharacterImage struct {
+	ID          string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	CharacterID string            `json:"characterId" gorm:"not null;index;type:varchar(36)"`
+	Key         string            `json:"url" gorm:"column:image_key;not null;size:500"` // ストレージのキー（レスポンスではハンドラーが配信用URLに置き換える）
+	Variants    map[string]string `json:"variants,omitempty" gorm:"-"`                   // サイズ別の配信用URL（レスポンスの作成時にハンドラーが設定）
+	Crop        *PhotoCrop        `json:"crop,omitempty" gorm:"type:json;serializer:json"`
+	Position    int               `json:"position" gorm:"not null;default:0"`
+	IsPrimary   bool              `json:"isPrimary" gorm:"not null;default:false"`
+	Caption     string            `json:"caption" gorm:"type:text"`
+	Credit      string            `json:"credit" gorm:"size:255"`
+	SourceURL   string            `json:"sourceUrl" gorm:"size:2048"`
+	License     string            `json:"license" gorm:"size:100"`
+	Size        int64             `json:"-" gorm:"not null;default:0"` // 全てのバリアントの合計バイト数
+	CreatedAt   time.Time         `json:"createdAt" gorm:"autoCreateTime"`
+}
@@ -7,9 +7,13 @@ import (
 // Group モデル
 type Group struct {
 	ID          string      `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkspaceID string      `json:"workspaceId" gorm:"not null;index;type:varchar(36)"`
 	Name        string      `json:"name" gorm:"not null;size:255" validate:"required,max=255"`
 	Description *string     `json:"description" gorm:"type:text"`
+	LabelLimit  *int        `json:"labelLimit"` // 人物1人あたりのラベルの上限（null の場合は全体の既定値）
+	Version     uint        `json:"version" gorm:"not null;default:1"`
 	CreatedAt   time.Time   `json:"createdAt" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time   `json:"updatedAt" gorm:"autoUpdateTime"`
 	Characters  []Character `json:"characters,omitempty" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE"`
+	Labels      []Label     `json:"labels,omitempty" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE"` // グループ専用のラベル
 }
\ No newline at end of file
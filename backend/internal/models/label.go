@@ -4,10 +4,37 @@ import (
 	"time"
 )
 
-// Label モデル
+// Label モデル（ParentID で 職業 > 武将 > 大名 のような階層を作る）
+// GroupID を持つラベルはそのグループの人物専用で、名前はグループごと（全体のラベルはワークスペースごと）に一意
 type Label struct {
-	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	Name      string    `json:"name" gorm:"uniqueIndex;not null;size:100" validate:"required,max=100"`
-	Color     string    `json:"color" gorm:"not null;size:7" validate:"required,hexcolor"`
-	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
-}
\ No newline at end of file
+	ID          string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkspaceID string         `json:"workspaceId" gorm:"uniqueIndex:idx_labels_workspace_scope_name;not null;type:varchar(36)"`
+	GroupID     *string        `json:"groupId" gorm:"index;type:varchar(36)"`                                            // 所属するグループ（null の場合は全体のラベル）
+	Scope       string         `json:"-" gorm:"uniqueIndex:idx_labels_workspace_scope_name;not null;size:36;default:''"` // 名前の一意制約の範囲（グループのラベルはグループID、全体のラベルは空文字）
+	Name        string         `json:"name" gorm:"uniqueIndex:idx_labels_workspace_scope_name;not null;size:100" validate:"required,max=100"`
+	Color       string         `json:"color" gorm:"not null;size:7" validate:"required,hexcolor"`
+	ParentID    *string        `json:"parentId" gorm:"index;type:varchar(36)"`   // 親ラベル（null の場合は最上位）
+	CategoryID  *string        `json:"categoryId" gorm:"index;type:varchar(36)"` // 分類（null の場合は分類なし）
+	Category    *LabelCategory `json:"-" gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
+	Version     uint           `json:"version" gorm:"not null;default:1"`
+	CreatedAt   time.Time      `json:"createdAt" gorm:"autoCreateTime"`
+	TextColor   string         `json:"textColor" gorm:"-"` // 背景色に対してコントラスト比が高い方の文字色（黒または白、レスポンスを作る時に設定する）
+	// ColorWarnings 作成・更新した色に近い色の既存のラベル（作成・更新のレスポンスのみ）
+	ColorWarnings []LabelColorWarning `json:"colorWarnings,omitempty" gorm:"-"`
+}
+
+// LabelColorWarning 色が近い既存のラベル（CIEDE2000 の色差が palette.MinDistance 未満）
+type LabelColorWarning struct {
+	LabelID  string  `json:"labelId"`
+	Name     string  `json:"name"`
+	Color    string  `json:"color"`
+	Distance float64 `json:"distance"` // 色差（ΔE00）
+}
+
+// LabelScope ラベル名の一意制約の範囲（グループのラベルはグループID、全体のラベルは空文字）
+func LabelScope(groupID *string) string {
+	if groupID == nil {
+		return ""
+	}
+	return *groupID
+}
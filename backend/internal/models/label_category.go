@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// LabelCategory ラベルの分類（陣営・属性など）
+// 人物1人に付けられる同じ分類のラベルは MaxPerCharacter 個まで
+type LabelCategory struct {
+	ID              string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkspaceID     string    `json:"workspaceId" gorm:"uniqueIndex:idx_label_categories_workspace_name;not null;type:varchar(36)"`
+	Name            string    `json:"name" gorm:"uniqueIndex:idx_label_categories_workspace_name;not null;size:100" validate:"required,max=100"`
+	MaxPerCharacter int       `json:"maxPerCharacter" gorm:"not null;default:1" validate:"min=1,max=100"`
+	Version         uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
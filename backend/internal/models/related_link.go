@@ -0,0 +1,40 @@
+package models
+
+import "encoding/json"
+
+// 関連リンクの種類
+const (
+	// LinkKindWikipedia Wikipedia の記事
+	LinkKindWikipedia = "wikipedia"
+	// LinkKindOfficial 公式サイト
+	LinkKindOfficial = "official"
+	// LinkKindSNS SNS のアカウント・投稿
+	LinkKindSNS = "sns"
+	// LinkKindSource 出典・参考資料
+	LinkKindSource = "source"
+	// LinkKindOther その他
+	LinkKindOther = "other"
+)
+
+// RelatedLink 人物の関連リンク
+type RelatedLink struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	// Kind wikipedia・official・sns・source・other のいずれか（省略時は URL から推定する）
+	Kind string `json:"kind"`
+	// Language リンク先の言語（BCP 47 の言語タグ、例: ja・en）
+	Language string `json:"language,omitempty"`
+	// FaviconURL リンク先のファビコン（リンク情報の取得が有効な場合に設定）
+	FaviconURL string `json:"faviconUrl,omitempty"`
+}
+
+// UnmarshalJSON URL の文字列だけのリンクも受け付ける（関連リンクが URL の配列だった頃のデータ・リクエストとの互換）
+func (l *RelatedLink) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*l = RelatedLink{URL: url}
+		return nil
+	}
+	type relatedLink RelatedLink
+	return json.Unmarshal(data, (*relatedLink)(l))
+}
@@ -12,6 +12,7 @@ type Relationship struct {
 	Character2ID     string    `json:"character2Id" gorm:"not null;type:varchar(36)" validate:"required"`
 	RelationshipType string    `json:"relationshipType" gorm:"not null;size:100" validate:"required,max=100"`
 	Description      *string   `json:"description" gorm:"type:text"`
+	Version          uint      `json:"version" gorm:"not null;default:1"`
 	CreatedAt        time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	Group            Group     `json:"group,omitempty" gorm:"foreignKey:GroupID"`
 	Character1       Character `json:"character1,omitempty" gorm:"foreignKey:Character1ID"`
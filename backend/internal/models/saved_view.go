@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedView 保存した絞り込み（ビュー）
+// Query の条件式（人物一覧の q と同じ構文）で人物を動的に選び、グループIDの代わりに一覧・関係図の取得に使える
+type SavedView struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkspaceID string    `json:"workspaceId" gorm:"uniqueIndex:idx_saved_views_workspace_name;not null;type:varchar(36)"`
+	Name        string    `json:"name" gorm:"uniqueIndex:idx_saved_views_workspace_name;not null;size:100" validate:"required,max=100"`
+	Description *string   `json:"description" gorm:"type:text"`
+	Query       string    `json:"query" gorm:"not null;type:text" validate:"required,max=1000"`
+	Version     uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
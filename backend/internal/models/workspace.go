@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultWorkspaceSlug テナント指定がないリクエストで使用するワークスペース
+const DefaultWorkspaceSlug = "default"
+
+// Workspace モデル（グループとラベルを所有するテナント）
+type Workspace struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null;size:63" validate:"required,max=63"`
+	Name      string    `json:"name" gorm:"not null;size:255" validate:"required,max=255"`
+	Version   uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,34 @@
+// Package openapi は API の OpenAPI 3.1 仕様とドキュメントページを配信する
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec OpenAPI 3.1 ドキュメント（openapi.json）
+// ルートを追加・変更した場合はこのファイルも更新する
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// SpecHandler OpenAPI ドキュメントを返すハンドラー
+func SpecHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", Spec)
+	}
+}
+
+// DocsHandler 仕様を読み込んで表示するドキュメントページのハンドラー
+// ページは同じディレクトリの openapi.json を取得する（外部の CDN には依存しない）
+func DocsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'; script-src 'self' 'unsafe-inline'")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	}
+}
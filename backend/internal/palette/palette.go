@@ -0,0 +1,316 @@
+// Package palette はラベルの色の読みやすさと見分けやすさを計算する
+//
+// 文字色は WCAG 2.x のコントラスト比、色の近さは CIEDE2000 の色差（ΔE00）で判定し、
+// 新しい色の候補は CIELCh の明度・彩度をそろえて色相をずらしたパレットから選ぶ。
+package palette
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 文字色
+const (
+	// Black 明るい背景に使う文字色
+	Black = "#000000"
+	// White 暗い背景に使う文字色
+	White = "#FFFFFF"
+)
+
+// MinDistance 見分けられる色とみなす色差（ΔE00）の下限
+const MinDistance = 10.0
+
+// RGB sRGB の色（各成分は 0〜255）
+type RGB struct {
+	R, G, B uint8
+}
+
+// Lab CIELAB の色（D65）
+type Lab struct {
+	L, A, B float64
+}
+
+// Parse #RGB・#RRGGBB 形式（#RGBA・#RRGGBBAA の透明度は無視する）の色を解析する
+func Parse(hex string) (RGB, error) {
+	s := strings.TrimPrefix(hex, "#")
+	if len(s) != len(hex)-1 {
+		return RGB{}, fmt.Errorf("invalid color %q", hex)
+	}
+	switch len(s) {
+	case 3, 4:
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	case 6, 8:
+		s = s[:6]
+	default:
+		return RGB{}, fmt.Errorf("invalid color %q", hex)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid color %q", hex)
+	}
+	return RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// Hex #RRGGBB 形式（大文字）の文字列
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// Luminance WCAG の相対輝度（0〜1）
+func (c RGB) Luminance() float64 {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// ContrastRatio 2色の WCAG のコントラスト比（1〜21）
+func ContrastRatio(a, b RGB) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// TextColor 背景色に対してコントラスト比が高い方の文字色（黒または白）
+// 解析できない色の場合は空文字
+func TextColor(background string) string {
+	c, err := Parse(background)
+	if err != nil {
+		return ""
+	}
+	if ContrastRatio(c, RGB{}) >= ContrastRatio(c, RGB{255, 255, 255}) {
+		return Black
+	}
+	return White
+}
+
+// linearize sRGB の成分をリニアな値（0〜1）に変換する
+func linearize(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// gamutTolerance 変換の丸め誤差として sRGB の範囲内とみなす幅
+const gamutTolerance = 1e-4
+
+// delinearize リニアな値を sRGB の成分に変換する（範囲外の場合は false）
+func delinearize(c float64) (uint8, bool) {
+	if c < -gamutTolerance || c > 1+gamutTolerance {
+		return 0, false
+	}
+	c = math.Min(math.Max(c, 0), 1)
+	if c <= 0.0031308 {
+		c *= 12.92
+	} else {
+		c = 1.055*math.Pow(c, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(c * 255)), true
+}
+
+// D65 の白色点
+const (
+	whiteX = 0.95047
+	whiteY = 1.0
+	whiteZ = 1.08883
+)
+
+// CIELAB の変換定数
+const (
+	labEpsilon = 216.0 / 24389.0
+	labKappa   = 24389.0 / 27.0
+)
+
+// Lab CIELAB に変換する
+func (c RGB) Lab() Lab {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / whiteX
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / whiteY
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / whiteZ
+
+	f := func(t float64) float64 {
+		if t > labEpsilon {
+			return math.Cbrt(t)
+		}
+		return (labKappa*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return Lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// RGB sRGB に変換する（sRGB の範囲外の場合は false）
+func (c Lab) RGB() (RGB, bool) {
+	fy := (c.L + 16) / 116
+	fx := fy + c.A/500
+	fz := fy - c.B/200
+
+	finv := func(f float64) float64 {
+		if t := f * f * f; t > labEpsilon {
+			return t
+		}
+		return (116*f - 16) / labKappa
+	}
+	x, y, z := finv(fx)*whiteX, finv(fy)*whiteY, finv(fz)*whiteZ
+
+	r, okR := delinearize(3.2404542*x - 1.5371385*y - 0.4985314*z)
+	g, okG := delinearize(-0.9692660*x + 1.8760108*y + 0.0415560*z)
+	b, okB := delinearize(0.0556434*x - 0.2040259*y + 1.0572252*z)
+	return RGB{R: r, G: g, B: b}, okR && okG && okB
+}
+
+// DeltaE2000 2色の CIEDE2000 の色差（ΔE00）
+func DeltaE2000(x, y Lab) float64 {
+	const pow25to7 = 6103515625.0 // 25^7
+
+	c1 := math.Hypot(x.A, x.B)
+	c2 := math.Hypot(y.A, y.B)
+	cBar7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cBar7/(cBar7+pow25to7)))
+
+	a1, a2 := (1+g)*x.A, (1+g)*y.A
+	c1p, c2p := math.Hypot(a1, x.B), math.Hypot(a2, y.B)
+	h1p, h2p := hueAngle(x.B, a1), hueAngle(y.B, a2)
+
+	dLp := y.L - x.L
+	dCp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(radians(dhp/2))
+
+	lBarp := (x.L + y.L) / 2
+	cBarp := (c1p + c2p) / 2
+	hBarp := h1p + h2p
+	if c1p*c2p != 0 {
+		switch {
+		case math.Abs(h1p-h2p) <= 180:
+			hBarp /= 2
+		case h1p+h2p < 360:
+			hBarp = (hBarp + 360) / 2
+		default:
+			hBarp = (hBarp - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos(radians(hBarp-30)) + 0.24*math.Cos(radians(2*hBarp)) +
+		0.32*math.Cos(radians(3*hBarp+6)) - 0.20*math.Cos(radians(4*hBarp-63))
+	dTheta := 30 * math.Exp(-math.Pow((hBarp-275)/25, 2))
+	cBarp7 := math.Pow(cBarp, 7)
+	rc := 2 * math.Sqrt(cBarp7/(cBarp7+pow25to7))
+	sl := 1 + 0.015*math.Pow(lBarp-50, 2)/math.Sqrt(20+math.Pow(lBarp-50, 2))
+	sc := 1 + 0.045*cBarp
+	sh := 1 + 0.015*cBarp*t
+	rt := -math.Sin(radians(2*dTheta)) * rc
+
+	l, c, h := dLp/sl, dCp/sc, dHp/sh
+	return math.Sqrt(l*l + c*c + h*h + rt*c*h)
+}
+
+// hueAngle 色相角（度、0〜360）
+func hueAngle(b, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Distance 2つの色（#RRGGBB 形式）の色差（ΔE00）
+func Distance(a, b string) (float64, error) {
+	ca, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	cb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return DeltaE2000(ca.Lab(), cb.Lab()), nil
+}
+
+// パレットの明度・彩度と色相の数
+var (
+	paletteLightness = []float64{60, 45, 75}
+	paletteChroma    = 55.0
+	paletteHues      = 12
+)
+
+// Colors ラベルの色の候補（明度ごとに色相を一周する順）
+var Colors = buildPalette()
+
+// buildPalette CIELCh の明度・彩度をそろえて色相をずらした色を作る
+// sRGB の範囲外になる色は範囲内に収まるまで彩度を下げる
+func buildPalette() []RGB {
+	var colors []RGB
+	for i, lightness := range paletteLightness {
+		// 明度ごとに色相の開始位置をずらして前の明度の色と重ならないようにする
+		offset := float64(i) * 360 / float64(paletteHues*len(paletteLightness))
+		for j := 0; j < paletteHues; j++ {
+			hue := radians(offset + float64(j)*360/float64(paletteHues))
+			for chroma := paletteChroma; chroma >= 0; chroma-- {
+				c, ok := Lab{L: lightness, A: chroma * math.Cos(hue), B: chroma * math.Sin(hue)}.RGB()
+				if ok {
+					colors = append(colors, c)
+					break
+				}
+			}
+		}
+	}
+	return colors
+}
+
+// Suggestion パレットから選んだ色
+type Suggestion struct {
+	Color string `json:"color"`
+	// TextColor 色に対する文字色
+	TextColor string `json:"textColor"`
+	// Distance 既存の色のうち最も近い色との色差（既存の色がない場合は nil）
+	Distance *float64 `json:"distance"`
+}
+
+// Suggest 既存の色から最も離れたパレットの色を選ぶ（同じ距離の場合はパレットの順）
+// 解析できない既存の色は無視する
+func Suggest(existing []string) Suggestion {
+	var labs []Lab
+	for _, hex := range existing {
+		if c, err := Parse(hex); err == nil {
+			labs = append(labs, c.Lab())
+		}
+	}
+
+	best := Colors[0]
+	if len(labs) == 0 {
+		return Suggestion{Color: best.Hex(), TextColor: TextColor(best.Hex())}
+	}
+
+	bestDistance := -1.0
+	for _, candidate := range Colors {
+		lab := candidate.Lab()
+		nearest := math.Inf(1)
+		for _, other := range labs {
+			nearest = math.Min(nearest, DeltaE2000(lab, other))
+		}
+		if nearest > bestDistance {
+			best, bestDistance = candidate, nearest
+		}
+	}
+	distance := math.Round(bestDistance*100) / 100
+	return Suggestion{Color: best.Hex(), TextColor: TextColor(best.Hex()), Distance: &distance}
+}
@@ -0,0 +1,124 @@
+package palette
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected RGB
+	}{
+		{"6桁", "#3B82F6", RGB{0x3B, 0x82, 0xF6}},
+		{"小文字", "#3b82f6", RGB{0x3B, 0x82, 0xF6}},
+		{"3桁の省略形", "#fa0", RGB{0xFF, 0xAA, 0x00}},
+		{"透明度は無視", "#3B82F680", RGB{0x3B, 0x82, 0xF6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, c)
+		})
+	}
+
+	for _, input := range []string{"", "3B82F6", "#3B82F", "#GGGGGG"} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestTextColor(t *testing.T) {
+	tests := []struct {
+		background string
+		expected   string
+	}{
+		{"#FFFFFF", Black},
+		{"#FDE047", Black},
+		{"#000000", White},
+		{"#1E3A8A", White},
+		// YIQ の明度では白になるがコントラスト比は黒の方が高い色
+		{"#3B82F6", Black},
+		{"invalid", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.background, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TextColor(tt.background))
+		})
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	assert.InDelta(t, 21.0, ContrastRatio(RGB{255, 255, 255}, RGB{}), 0.001)
+	assert.InDelta(t, 1.0, ContrastRatio(RGB{0x3B, 0x82, 0xF6}, RGB{0x3B, 0x82, 0xF6}), 0.001)
+}
+
+func TestDeltaE2000(t *testing.T) {
+	// Sharma, Wu, Dalal (2005) の検証用データ
+	tests := []struct {
+		a, b     Lab
+		expected float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, -1.3802, -84.2814}, Lab{50, 0, -82.7485}, 1.0000},
+		{Lab{50, 2.5, 0}, Lab{50, 0, -2.5}, 4.3065},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+		{Lab{60.2574, -34.0099, 36.2677}, Lab{60.4626, -34.1751, 39.4387}, 1.2644},
+		{Lab{22.7233, 20.0904, -46.6940}, Lab{23.0331, 14.9730, -42.5619}, 2.0373},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.expected, DeltaE2000(tt.a, tt.b), 0.0001)
+		assert.InDelta(t, tt.expected, DeltaE2000(tt.b, tt.a), 0.0001)
+	}
+}
+
+func TestLabRoundTrip(t *testing.T) {
+	for _, c := range []RGB{{0, 0, 0}, {255, 255, 255}, {0x3B, 0x82, 0xF6}, {255, 0, 0}} {
+		back, ok := c.Lab().RGB()
+		require.True(t, ok, c.Hex())
+		assert.Equal(t, c, back)
+	}
+}
+
+func TestColors(t *testing.T) {
+	require.Len(t, Colors, paletteHues*len(paletteLightness))
+	for i, a := range Colors {
+		for _, b := range Colors[i+1:] {
+			assert.GreaterOrEqual(t, DeltaE2000(a.Lab(), b.Lab()), 3.0, "%s %s", a.Hex(), b.Hex())
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Run("既存の色がない場合はパレットの先頭", func(t *testing.T) {
+		suggestion := Suggest(nil)
+
+		assert.Equal(t, Colors[0].Hex(), suggestion.Color)
+		assert.Equal(t, TextColor(suggestion.Color), suggestion.TextColor)
+		assert.Nil(t, suggestion.Distance)
+	})
+
+	t.Run("既存の色から最も離れた色", func(t *testing.T) {
+		existing := []string{Colors[0].Hex(), Colors[1].Hex(), "invalid"}
+
+		suggestion := Suggest(existing)
+
+		require.NotNil(t, suggestion.Distance)
+		for _, c := range Colors {
+			d0, _ := Distance(c.Hex(), existing[0])
+			d1, _ := Distance(c.Hex(), existing[1])
+			nearest := d0
+			if d1 < nearest {
+				nearest = d1
+			}
+			assert.LessOrEqual(t, nearest, *suggestion.Distance+0.01)
+		}
+	})
+}
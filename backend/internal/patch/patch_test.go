@@ -0,0 +1,102 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	// RFC 7396 Appendix A のテストケース
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"値の置き換え", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"値の追加", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"nullで削除", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"他の値は保持", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"配列は丸ごと置き換え", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"ネストしたオブジェクト", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"配列内のオブジェクトはマージしない", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"オブジェクト以外のパッチ", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"nullの値は追加しない", `{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{"オブジェクト以外のターゲット", `["a","b"]`, `{"a":"b"}`, `{"a":"b"}`},
+		{"深いnullの削除", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyMergePatch([]byte(tt.doc), []byte(tt.patch))
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+
+	t.Run("不正なパッチ", func(t *testing.T) {
+		_, err := ApplyMergePatch([]byte(`{}`), []byte(`{"a":`))
+
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+	})
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	// RFC 6902 Appendix A を中心としたテストケース
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"オブジェクトへの追加", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"配列への挿入", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"配列末尾への追加", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{"オブジェクトの削除", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"配列要素の削除", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"値の置き換え", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"値の移動", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"配列要素の移動", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"値のコピー", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`},
+		{"testの成功", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"エスケープされたパス", `{"/":9,"~1":10}`, `[{"op":"replace","path":"/~01","value":1},{"op":"remove","path":"/~1"}]`, `{"~1":1}`},
+		{"ドキュメント全体の置き換え", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+
+	errorTests := []struct {
+		name  string
+		doc   string
+		patch string
+	}{
+		{"存在しないパスの削除", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{"存在しないパスの置き換え", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`},
+		{"親が存在しない追加", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{"範囲外のインデックス", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`},
+		{"先頭ゼロのインデックス", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`},
+		{"testの失敗", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{"valueのないadd", `{}`, `[{"op":"add","path":"/a"}]`},
+		{"不明なop", `{}`, `[{"op":"merge","path":"/a","value":1}]`},
+		{"自身の子への移動", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`},
+		{"配列でないパッチ", `{}`, `{"op":"add","path":"/a","value":1}`},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+		})
+	}
+}
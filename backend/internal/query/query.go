@@ -0,0 +1,327 @@
+// Package query は人物一覧の絞り込みに使う条件式を解析する
+//
+// 構文:
+//
+//	式     = 論理和
+//	論理和 = 論理積 { "OR" 論理積 }
+//	論理積 = 否定 { ["AND"] 否定 }          （AND を省略して並べても論理積）
+//	否定   = "NOT" 否定 | 要素
+//	要素   = "(" 論理和 ")" | 条件
+//	条件   = [ 項目 ":" ] 値                 （項目は label・name・group・info・relation・related、省略時は label）
+//	値     = 空白・括弧・引用符・コロンを含まない語 | "..."（\" と \\ でエスケープ）
+//
+// 例: (武将 OR 公家) AND NOT 故人、label:"戦国:大名" name:信
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 条件の項目
+const (
+	// FieldLabel 指定した名前のラベルが付いている
+	FieldLabel = "label"
+	// FieldName 人物名または別名に値を含む
+	FieldName = "name"
+	// FieldGroup 指定した名前のグループに所属する
+	FieldGroup = "group"
+	// FieldInfo 人物の情報（information）に値を含む
+	FieldInfo = "info"
+	// FieldRelation 指定した種類の関係を持つ
+	FieldRelation = "relation"
+	// FieldRelated 指定した名前の人物と関係を持つ
+	FieldRelated = "related"
+)
+
+// MaxLength 条件式の最大文字数
+const MaxLength = 1000
+
+// maxDepth 括弧と NOT の入れ子の上限
+const maxDepth = 32
+
+// Expr 条件式の節（And・Or・Not・Term のいずれか）
+type Expr interface {
+	expr()
+}
+
+// And 両方の条件を満たす
+type And struct {
+	Left, Right Expr
+}
+
+// Or いずれかの条件を満たす
+type Or struct {
+	Left, Right Expr
+}
+
+// Not 条件を満たさない
+type Not struct {
+	Expr Expr
+}
+
+// Term 項目と値の条件
+type Term struct {
+	Field string
+	Value string
+	// Position 条件の開始位置（先頭からの文字数、0始まり）
+	Position int
+}
+
+func (*And) expr()  {}
+func (*Or) expr()   {}
+func (*Not) expr()  {}
+func (*Term) expr() {}
+
+// SyntaxError 条件式の構文エラー
+type SyntaxError struct {
+	// Position エラーの位置（先頭からの文字数、0始まり）
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Position, e.Message)
+}
+
+// Parse 条件式を解析する
+func Parse(input string) (Expr, error) {
+	if n := len([]rune(input)); n > MaxLength {
+		return nil, &SyntaxError{Position: MaxLength, Message: fmt.Sprintf("query must be at most %d characters", MaxLength)}
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &SyntaxError{Position: 0, Message: "empty query"}
+	}
+
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, unexpected(tok)
+	}
+	return expr, nil
+}
+
+// tokenKind 字句の種類
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenColon
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenWord
+	tokenString
+)
+
+// token 字句
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize 条件式を字句に分ける
+func tokenize(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ':':
+			tokens = append(tokens, token{kind: tokenColon, text: ":", pos: i})
+			i++
+		case r == '"':
+			start := i
+			var value strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, &SyntaxError{Position: start, Message: "unterminated string"}
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value.String(), pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`():"`, runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			kind := tokenWord
+			switch word {
+			case "AND":
+				kind = tokenAnd
+			case "OR":
+				kind = tokenOr
+			case "NOT":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// parser 字句の並びを再帰下降で解析する
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr(depth int) (Expr, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Expr, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenNot, tokenLParen, tokenWord, tokenString:
+			// AND の省略
+		default:
+			return left, nil
+		}
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseNot(depth int) (Expr, error) {
+	if p.peek().kind != tokenNot {
+		return p.parsePrimary(depth)
+	}
+	tok := p.next()
+	if depth >= maxDepth {
+		return nil, &SyntaxError{Position: tok.pos, Message: "query is nested too deeply"}
+	}
+	expr, err := p.parseNot(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Not{Expr: expr}, nil
+}
+
+func (p *parser) parsePrimary(depth int) (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		if depth >= maxDepth {
+			return nil, &SyntaxError{Position: tok.pos, Message: "query is nested too deeply"}
+		}
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != tokenRParen {
+			if closing.kind == tokenEOF {
+				return nil, &SyntaxError{Position: tok.pos, Message: `missing ")"`}
+			}
+			return nil, unexpected(closing)
+		}
+		p.next()
+		return expr, nil
+	case tokenWord:
+		if p.peek().kind != tokenColon {
+			return &Term{Field: FieldLabel, Value: tok.text, Position: tok.pos}, nil
+		}
+		if !isField(tok.text) {
+			return nil, &SyntaxError{Position: tok.pos, Message: fmt.Sprintf("unknown field %q (expected label, name, group, info, relation or related)", tok.text)}
+		}
+		p.next()
+		value := p.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			if value.kind == tokenEOF {
+				return nil, &SyntaxError{Position: value.pos, Message: fmt.Sprintf("missing value for %q", tok.text)}
+			}
+			return nil, unexpected(value)
+		}
+		if value.text == "" {
+			return nil, &SyntaxError{Position: value.pos, Message: fmt.Sprintf("empty value for %q", tok.text)}
+		}
+		return &Term{Field: tok.text, Value: value.text, Position: tok.pos}, nil
+	case tokenString:
+		if tok.text == "" {
+			return nil, &SyntaxError{Position: tok.pos, Message: "empty value"}
+		}
+		return &Term{Field: FieldLabel, Value: tok.text, Position: tok.pos}, nil
+	default:
+		return nil, unexpected(tok)
+	}
+}
+
+// isField 条件の項目名か
+func isField(name string) bool {
+	switch name {
+	case FieldLabel, FieldName, FieldGroup, FieldInfo, FieldRelation, FieldRelated:
+		return true
+	}
+	return false
+}
+
+// unexpected 予期しない字句のエラー
+func unexpected(tok token) *SyntaxError {
+	if tok.kind == tokenEOF {
+		return &SyntaxError{Position: tok.pos, Message: "unexpected end of query"}
+	}
+	return &SyntaxError{Position: tok.pos, Message: fmt.Sprintf("unexpected %q", tok.text)}
+}
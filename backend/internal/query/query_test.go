@@ -0,0 +1,96 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func label(value string, pos int) *Term {
+	return &Term{Field: FieldLabel, Value: value, Position: pos}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Expr
+	}{
+		{"ラベル名のみ", "武将", label("武将", 0)},
+		{"項目の指定", "name:信長", &Term{Field: FieldName, Value: "信長", Position: 0}},
+		{"引用符で空白や記号を含む値", `group:"織田 家" label:"戦国:大名"`, &And{
+			Left:  &Term{Field: FieldGroup, Value: "織田 家", Position: 0},
+			Right: &Term{Field: FieldLabel, Value: "戦国:大名", Position: 13},
+		}},
+		{"エスケープ", `"a\"b\\c"`, label(`a"b\c`, 0)},
+		{"AND は OR より優先", "武将 OR 公家 AND 故人", &Or{
+			Left:  label("武将", 0),
+			Right: &And{Left: label("公家", 6), Right: label("故人", 13)},
+		}},
+		{"括弧と NOT", "(武将 OR 公家) AND NOT 故人", &And{
+			Left:  &Or{Left: label("武将", 1), Right: label("公家", 7)},
+			Right: &Not{Expr: label("故人", 19)},
+		}},
+		{"AND の省略", "武将 NOT 故人", &And{Left: label("武将", 0), Right: &Not{Expr: label("故人", 7)}}},
+		{"小文字の and は値", "and", label("and", 0)},
+		{"関係の条件", "relation:主従 OR related:豊臣秀吉", &Or{
+			Left:  &Term{Field: FieldRelation, Value: "主従", Position: 0},
+			Right: &Term{Field: FieldRelated, Value: "豊臣秀吉", Position: 15},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+}
+
+func TestParse_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		position int
+		message  string
+	}{
+		{"空の条件式", "  ", 0, "empty query"},
+		{"閉じ括弧がない", "(武将 OR 公家", 0, `missing ")"`},
+		{"余分な閉じ括弧", "武将)", 2, `unexpected ")"`},
+		{"演算子の後に条件がない", "武将 AND", 6, "unexpected end of query"},
+		{"演算子が続く", "武将 OR OR 公家", 6, `unexpected "OR"`},
+		{"不明な項目", "武将 age:20", 3, `unknown field "age"`},
+		{"値がない", "name:", 5, `missing value for "name"`},
+		{"空の値", `label:""`, 6, `empty value for "label"`},
+		{"閉じていない引用符", `武将 "公家`, 3, "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			var syntaxError *SyntaxError
+			require.ErrorAs(t, err, &syntaxError)
+			assert.Equal(t, tt.position, syntaxError.Position)
+			assert.Contains(t, syntaxError.Message, tt.message)
+		})
+	}
+
+	t.Run("入れ子が深すぎる", func(t *testing.T) {
+		_, err := Parse(strings.Repeat("(", 40) + "武将" + strings.Repeat(")", 40))
+
+		var syntaxError *SyntaxError
+		require.ErrorAs(t, err, &syntaxError)
+		assert.Equal(t, maxDepth, syntaxError.Position)
+	})
+
+	t.Run("長すぎる条件式", func(t *testing.T) {
+		_, err := Parse(strings.Repeat("a", MaxLength+1))
+
+		var syntaxError *SyntaxError
+		require.ErrorAs(t, err, &syntaxError)
+	})
+}
@@ -2,9 +2,16 @@ package repositories
 
 import (
 	"character-management-app/internal/models"
+	"character-management-app/internal/query"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CharacterRepository 人物リポジトリのインターフェース
@@ -13,18 +20,52 @@ type CharacterRepository interface {
 	GetByID(id string) (*models.Character, error)
 	GetAll() ([]models.Character, error)
 	GetByGroupID(groupID string) ([]models.Character, error)
+	Find(filter CharacterFilter) ([]models.Character, error)
 	Update(character *models.Character) error
 	Delete(id string) error
 	ExistsByID(id string) (bool, error)
-	AddLabel(characterID, labelID string) error
+	AddLabel(characterID, labelID string, check func(character *models.Character) error) error
 	RemoveLabel(characterID, labelID string) error
 	GetLabelsCount(characterID string) (int64, error)
 	HasLabel(characterID, labelID string) (bool, error)
+	ReplaceLabels(characterID string, labelIDs []string, check func(character *models.Character) error) error
+	UpdateLabelAssignments(labelID string, characterIDs []string, plan LabelAssignmentPlan) error
+	CountByPhoto(keys ...string) (int64, error)
+	GetPhotoKeys() ([]string, error)
+	GetWithPhoto() ([]models.Character, error)
+	GetAllImages() ([]models.CharacterImage, error)
+	ReplacePhoto(newKey string, oldKeys ...string) error
+	GetPhotoSizes(excludeCharacterID string) (map[string]int64, error)
+	GetPhotoKeysWithoutSize() ([]string, error)
+	SetPhotoSize(key string, size int64) error
+	GetWithRelatedLinks() ([]models.Character, error)
+	SetRelatedLinks(id string, links []models.RelatedLink) error
+	GetImages(characterID string) ([]models.CharacterImage, error)
+	GetImage(characterID, imageID string) (*models.CharacterImage, error)
+	AddImage(image *models.CharacterImage) error
+	UpdateImage(image *models.CharacterImage) error
+	SetPrimaryImage(characterID, imageID string) error
+	ReorderImages(characterID string, imageIDs []string) error
+	DeleteImage(characterID, imageID string) error
+	WithWorkspace(workspaceID string) CharacterRepository
+}
+
+// CharacterFilter 人物の検索条件（空の条件は絞り込まない）
+type CharacterFilter struct {
+	// IDs 指定した ID の人物
+	IDs []string
+	// GroupID 所属するグループ
+	GroupID string
+	// LabelIDs いずれかのラベルが付いている人物
+	LabelIDs []string
+	// Query 条件式に一致する人物
+	Query query.Expr
 }
 
 // characterRepository 人物リポジトリの実装
 type characterRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	workspaceID string
 }
 
 // NewCharacterRepository 人物リポジトリのコンストラクタ
@@ -32,18 +73,75 @@ func NewCharacterRepository(db *gorm.DB) CharacterRepository {
 	return &characterRepository{db: db}
 }
 
+// WithWorkspace 指定したワークスペースに限定したリポジトリを返す
+func (r *characterRepository) WithWorkspace(workspaceID string) CharacterRepository {
+	return &characterRepository{db: r.db, workspaceID: workspaceID}
+}
+
+// scoped ワークスペースに属するグループの人物に限定したクエリを返す
+func (r *characterRepository) scoped() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("group_id IN (?)", groupIDsInWorkspace(r.db, r.workspaceID))
+}
+
+// scopedImages ワークスペースの人物のギャラリーの画像に限定したクエリを返す
+func (r *characterRepository) scopedImages() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("character_id IN (?)", r.scoped().Model(&models.Character{}).Select("id"))
+}
+
+// withDB 同じワークスペースに限定したまま、トランザクション等の別の接続を使うリポジトリを返す
+func (r *characterRepository) withDB(db *gorm.DB) *characterRepository {
+	return &characterRepository{db: db, workspaceID: r.workspaceID}
+}
+
+// scopedLabels ワークスペースのラベルに限定したクエリを返す
+func (r *characterRepository) scopedLabels() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("workspace_id = ?", r.workspaceID)
+}
+
 // Create 人物を作成
 func (r *characterRepository) Create(character *models.Character) error {
 	// UUIDを生成
 	character.ID = uuid.New().String()
+	character.Version = 1
 	
-	return r.db.Create(character).Error
+	prepareAliases(character)
+	
+	// 写真がある場合は代表画像としてギャラリーにも追加する
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(character).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, character)
+	})
+}
+
+// prepareAliases 別名にIDと登録順を設定する
+func prepareAliases(character *models.Character) {
+	for i := range character.Aliases {
+		character.Aliases[i].ID = uuid.New().String()
+		character.Aliases[i].CharacterID = character.ID
+		character.Aliases[i].Position = i
+	}
+}
+
+// orderAliases 別名を登録順に並べる
+func orderAliases(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
 }
 
 // GetByID IDで人物を取得
 func (r *characterRepository) GetByID(id string) (*models.Character, error) {
 	var character models.Character
-	err := r.db.Preload("Group").Preload("Labels").First(&character, "id = ?", id).Error
+	err := r.scoped().Preload("Group").Preload("Labels").Preload("Images", orderImages).Preload("Aliases", orderAliases).First(&character, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -53,68 +151,225 @@ func (r *characterRepository) GetByID(id string) (*models.Character, error) {
 // GetAll 全ての人物を取得
 func (r *characterRepository) GetAll() ([]models.Character, error) {
 	var characters []models.Character
-	err := r.db.Preload("Group").Preload("Labels").Find(&characters).Error
+	err := r.scoped().Preload("Group").Preload("Labels").Preload("Aliases", orderAliases).Find(&characters).Error
 	return characters, err
 }
 
 // GetByGroupID グループIDで人物を取得
 func (r *characterRepository) GetByGroupID(groupID string) ([]models.Character, error) {
 	var characters []models.Character
-	err := r.db.Preload("Group").Preload("Labels").Where("group_id = ?", groupID).Find(&characters).Error
+	err := r.scoped().Preload("Group").Preload("Labels").Preload("Aliases", orderAliases).Where("group_id = ?", groupID).Find(&characters).Error
 	return characters, err
 }
 
-// Update 人物を更新
+// Find 条件に一致する人物を取得
+func (r *characterRepository) Find(filter CharacterFilter) ([]models.Character, error) {
+	query := r.scoped().Preload("Group").Preload("Labels").Preload("Aliases", orderAliases)
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if filter.GroupID != "" {
+		query = query.Where("group_id = ?", filter.GroupID)
+	}
+	if len(filter.LabelIDs) > 0 {
+		query = query.Where("id IN (?)", r.db.Table("character_labels").Select("character_id").Where("label_id IN ?", filter.LabelIDs))
+	}
+	if filter.Query != nil {
+		sql, args := r.compileQuery(filter.Query)
+		query = query.Where(sql, args...)
+	}
+
+	var characters []models.Character
+	err := query.Find(&characters).Error
+	return characters, err
+}
+
+// compileQuery 条件式を WHERE 句の SQL とその引数に変換する
+// ラベル・グループ・関係の種類・関係する人物は名前の完全一致、人物名（別名を含む）と情報は部分一致で比較する（ワークスペースのラベル・グループのみ）
+func (r *characterRepository) compileQuery(expr query.Expr) (string, []interface{}) {
+	switch e := expr.(type) {
+	case *query.And:
+		left, leftArgs := r.compileQuery(e.Left)
+		right, rightArgs := r.compileQuery(e.Right)
+		return fmt.Sprintf("(%s AND %s)", left, right), append(leftArgs, rightArgs...)
+	case *query.Or:
+		left, leftArgs := r.compileQuery(e.Left)
+		right, rightArgs := r.compileQuery(e.Right)
+		return fmt.Sprintf("(%s OR %s)", left, right), append(leftArgs, rightArgs...)
+	case *query.Not:
+		inner, args := r.compileQuery(e.Expr)
+		return fmt.Sprintf("NOT %s", inner), args
+	case *query.Term:
+		return r.compileTerm(e)
+	}
+	panic(fmt.Sprintf("unknown query expression %T", expr))
+}
+
+// compileTerm 条件を SQL に変換する
+func (r *characterRepository) compileTerm(term *query.Term) (string, []interface{}) {
+	workspace := ""
+	args := []interface{}{}
+	switch term.Field {
+	case query.FieldName:
+		pattern := "%" + escapeLike(term.Value) + "%"
+		return "(characters.name LIKE ? ESCAPE '!' OR characters.id IN (SELECT character_id FROM character_aliases WHERE name LIKE ? ESCAPE '!'))", []interface{}{pattern, pattern}
+	case query.FieldInfo:
+		return "(characters.information LIKE ? ESCAPE '!')", []interface{}{"%" + escapeLike(term.Value) + "%"}
+	case query.FieldRelation:
+		inWorkspace, workspaceArgs := r.inWorkspaceGroups("relationships.group_id")
+		args = append(args, term.Value)
+		args = append(args, workspaceArgs...)
+		args = append(args, term.Value)
+		args = append(args, workspaceArgs...)
+		return "(characters.id IN (SELECT character1_id FROM relationships WHERE relationship_type = ?" + inWorkspace +
+			" UNION SELECT character2_id FROM relationships WHERE relationship_type = ?" + inWorkspace + "))", args
+	case query.FieldRelated:
+		inWorkspace, workspaceArgs := r.inWorkspaceGroups("other.group_id")
+		args = append(args, term.Value)
+		args = append(args, workspaceArgs...)
+		args = append(args, term.Value)
+		args = append(args, workspaceArgs...)
+		return "(characters.id IN (SELECT relationships.character1_id FROM relationships" +
+			" JOIN characters AS other ON other.id = relationships.character2_id WHERE other.name = ?" + inWorkspace +
+			" UNION SELECT relationships.character2_id FROM relationships" +
+			" JOIN characters AS other ON other.id = relationships.character1_id WHERE other.name = ?" + inWorkspace + "))", args
+	case query.FieldGroup:
+		args = append(args, term.Value)
+		if r.workspaceID != "" {
+			workspace = " AND workspace_id = ?"
+			args = append(args, r.workspaceID)
+		}
+		return "(characters.group_id IN (SELECT id FROM `groups` WHERE name = ?" + workspace + "))", args
+	default:
+		args = append(args, term.Value)
+		if r.workspaceID != "" {
+			workspace = " AND labels.workspace_id = ?"
+			args = append(args, r.workspaceID)
+		}
+		return "(characters.id IN (SELECT character_labels.character_id FROM character_labels" +
+			" JOIN labels ON labels.id = character_labels.label_id WHERE labels.name = ?" + workspace + "))", args
+	}
+}
+
+// inWorkspaceGroups column のグループがワークスペースに属することを表す条件とその引数を返す（ワークスペースを限定しない場合は空）
+func (r *characterRepository) inWorkspaceGroups(column string) (string, []interface{}) {
+	if r.workspaceID == "" {
+		return "", nil
+	}
+	return " AND " + column + " IN (SELECT id FROM `groups` WHERE workspace_id = ?)", []interface{}{r.workspaceID}
+}
+
+// escapeLike LIKE の特殊文字を ESCAPE '!' でエスケープする
+// バックスラッシュは MySQL の SQL モードやデータベースによって扱いが変わるため、エスケープ文字には ! を使う
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// Update 人物を更新（バージョンが一致しない場合は ErrVersionConflict）
+// photo を変更した場合は代表画像も置き換え、photo を削除した場合は代表画像をギャラリーから削除して残りの先頭の画像を代表画像にする
+// Aliases が nil の場合は別名を変更せず、nil でなければ（空でも）置き換える
 func (r *characterRepository) Update(character *models.Character) error {
-	return r.db.Save(character).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(r.withDB(tx).scoped(), character, &character.Version); err != nil {
+			return err
+		}
+		if character.Aliases != nil {
+			if err := replaceAliases(tx, character); err != nil {
+				return err
+			}
+		}
+		return syncPrimaryImage(tx, character)
+	})
 }
 
-// Delete 人物を削除
+// replaceAliases 人物の別名を置き換える
+func replaceAliases(tx *gorm.DB, character *models.Character) error {
+	if err := tx.Where("character_id = ?", character.ID).Delete(&models.CharacterAlias{}).Error; err != nil {
+		return err
+	}
+	if len(character.Aliases) == 0 {
+		return nil
+	}
+	prepareAliases(character)
+	return tx.Create(&character.Aliases).Error
+}
+
+// Delete 人物をギャラリーの画像・別名とともに削除
 func (r *characterRepository) Delete(id string) error {
-	return r.db.Delete(&models.Character{}, "id = ?", id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := r.withDB(tx).scoped().Delete(&models.Character{}, "id = ?", id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Where("character_id = ?", id).Delete(&models.CharacterAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("character_id = ?", id).Delete(&models.CharacterImage{}).Error
+	})
 }
 
 // ExistsByID 人物が存在するかチェック
 func (r *characterRepository) ExistsByID(id string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Character{}).Where("id = ?", id).Count(&count).Error
+	err := r.scoped().Model(&models.Character{}).Where("id = ?", id).Count(&count).Error
 	return count > 0, err
 }
 
-// AddLabel 人物にラベルを追加
-func (r *characterRepository) AddLabel(characterID, labelID string) error {
-	var character models.Character
-	if err := r.db.First(&character, "id = ?", characterID).Error; err != nil {
-		return err
-	}
-	
-	var label models.Label
-	if err := r.db.First(&label, "id = ?", labelID).Error; err != nil {
-		return err
-	}
-	
-	return r.db.Model(&character).Association("Labels").Append(&label)
+// AddLabel 人物にラベルを追加（1つのトランザクションで行う）
+// check は人物の行をロックして読み直した後に呼び、エラーを返した場合は何も変更しない
+func (r *characterRepository) AddLabel(characterID, labelID string, check func(character *models.Character) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		scoped := r.withDB(tx)
+		characters, err := scoped.lockForLabels([]string{characterID})
+		if err != nil {
+			return err
+		}
+		if len(characters) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var label models.Label
+		if err := scoped.scopedLabels().First(&label, "id = ?", labelID).Error; err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(&characters[0]); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Table("character_labels").Create(map[string]interface{}{"character_id": characterID, "label_id": labelID}).Error; err != nil {
+			return err
+		}
+
+		// ラベルの付け替えも人物の変更としてバージョンを進める
+		return touchVersion(tx, &models.Character{}, characterID)
+	})
 }
 
 // RemoveLabel 人物からラベルを削除
 func (r *characterRepository) RemoveLabel(characterID, labelID string) error {
 	var character models.Character
-	if err := r.db.First(&character, "id = ?", characterID).Error; err != nil {
+	if err := r.scoped().First(&character, "id = ?", characterID).Error; err != nil {
 		return err
 	}
 	
 	var label models.Label
-	if err := r.db.First(&label, "id = ?", labelID).Error; err != nil {
+	if err := r.scopedLabels().First(&label, "id = ?", labelID).Error; err != nil {
 		return err
 	}
 	
-	return r.db.Model(&character).Association("Labels").Delete(&label)
+	if err := r.db.Model(&character).Association("Labels").Delete(&label); err != nil {
+		return err
+	}
+
+	return touchVersion(r.db, &models.Character{}, characterID)
 }
 
 // GetLabelsCount 人物のラベル数を取得
 func (r *characterRepository) GetLabelsCount(characterID string) (int64, error) {
 	var character models.Character
-	if err := r.db.First(&character, "id = ?", characterID).Error; err != nil {
+	if err := r.scoped().First(&character, "id = ?", characterID).Error; err != nil {
 		return 0, err
 	}
 	
@@ -125,8 +380,448 @@ func (r *characterRepository) GetLabelsCount(characterID string) (int64, error)
 func (r *characterRepository) HasLabel(characterID, labelID string) (bool, error) {
 	var count int64
 	err := r.db.Table("character_labels").
-		Where("character_id = ? AND label_id = ?", characterID, labelID).
+		Where("character_id IN (?)", r.scoped().Model(&models.Character{}).Select("id").Where("id = ?", characterID)).
+		Where("label_id IN (?)", r.scopedLabels().Model(&models.Label{}).Select("id").Where("id = ?", labelID)).
 		Count(&count).Error
-	
+
 	return count > 0, err
-}
\ No newline at end of file
+}
+
+// LabelAssignmentPlan ロックして読み直した人物から、ラベルを付ける人物と外す人物を決める
+type LabelAssignmentPlan func(characters []models.Character) (addIDs, removeIDs []string)
+
+// lockForLabels 人物の行をロックし、ラベルの確認に使うグループと付いているラベルを読み込む（トランザクション内で使う）
+// 同じ人物のラベルを変更する他のリクエストはロックの解放を待つため、確認してから変更するまでの間にラベルは変わらない
+func (r *characterRepository) lockForLabels(ids []string) ([]models.Character, error) {
+	var characters []models.Character
+	err := r.scoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Group").Preload("Labels").
+		Where("id IN ?", ids).Order("id").Find(&characters).Error
+	return characters, err
+}
+
+// ReplaceLabels 人物のラベルを labelIDs に置き換える（1つのトランザクションで行う）
+// check は人物の行をロックして読み直した後に呼び、エラーを返した場合は何も変更しない
+func (r *characterRepository) ReplaceLabels(characterID string, labelIDs []string, check func(character *models.Character) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		characters, err := r.withDB(tx).lockForLabels([]string{characterID})
+		if err != nil {
+			return err
+		}
+		if len(characters) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if check != nil {
+			if err := check(&characters[0]); err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM character_labels WHERE character_id = ?", characterID).Error; err != nil {
+			return err
+		}
+		if len(labelIDs) > 0 {
+			rows := make([]map[string]interface{}, len(labelIDs))
+			for i, labelID := range labelIDs {
+				rows[i] = map[string]interface{}{"character_id": characterID, "label_id": labelID}
+			}
+			if err := tx.Table("character_labels").Create(rows).Error; err != nil {
+				return err
+			}
+		}
+		return touchVersion(tx, &models.Character{}, characterID)
+	})
+}
+
+// UpdateLabelAssignments characterIDs の人物の行をロックして読み直し、plan が決めた人物にラベルを付け・外す（1つのトランザクションで行う）
+// 存在しない人物は plan に渡さない
+func (r *characterRepository) UpdateLabelAssignments(labelID string, characterIDs []string, plan LabelAssignmentPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		characters, err := r.withDB(tx).lockForLabels(characterIDs)
+		if err != nil {
+			return err
+		}
+		addIDs, removeIDs := plan(characters)
+
+		if len(addIDs) > 0 {
+			rows := make([]map[string]interface{}, len(addIDs))
+			for i, characterID := range addIDs {
+				rows[i] = map[string]interface{}{"character_id": characterID, "label_id": labelID}
+			}
+			if err := tx.Table("character_labels").Create(rows).Error; err != nil {
+				return err
+			}
+		}
+		if len(removeIDs) > 0 {
+			if err := tx.Exec("DELETE FROM character_labels WHERE label_id = ? AND character_id IN ?", labelID, removeIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		// ラベルの付け替えも人物の変更としてバージョンを進める
+		changed := append(append([]string{}, addIDs...), removeIDs...)
+		if len(changed) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Character{}).Where("id IN ?", changed).
+			UpdateColumn("version", gorm.Expr("version + 1")).Error
+	})
+}
+
+// orderImages ギャラリーの画像を表示順に並べる
+func orderImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, created_at")
+}
+
+// GetImages 人物のギャラリーの画像を表示順に取得
+func (r *characterRepository) GetImages(characterID string) ([]models.CharacterImage, error) {
+	var images []models.CharacterImage
+	err := orderImages(r.scopedImages()).Where("character_id = ?", characterID).Find(&images).Error
+	return images, err
+}
+
+// GetImage 人物のギャラリーの画像を取得
+func (r *characterRepository) GetImage(characterID, imageID string) (*models.CharacterImage, error) {
+	var image models.CharacterImage
+	err := r.scopedImages().First(&image, "id = ? AND character_id = ?", imageID, characterID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// GetAllImages ギャラリーの画像の ID・人物・キー・代表画像かを取得
+func (r *characterRepository) GetAllImages() ([]models.CharacterImage, error) {
+	var images []models.CharacterImage
+	err := r.scopedImages().Select("id", "character_id", "image_key", "is_primary").
+		Order("character_id, position").Find(&images).Error
+	return images, err
+}
+
+// AddImage ギャラリーの末尾に画像を追加し、人物のバージョンを進める
+// IsPrimary の場合と、人物に写真がない場合は代表画像にして photo を置き換える
+func (r *characterRepository) AddImage(image *models.CharacterImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var character models.Character
+		if err := r.withDB(tx).scoped().First(&character, "id = ?", image.CharacterID).Error; err != nil {
+			return err
+		}
+
+		image.IsPrimary = image.IsPrimary || character.Photo == nil
+		if err := createImage(tx, image); err != nil {
+			return err
+		}
+		if image.IsPrimary {
+			return setPrimary(tx, image)
+		}
+		return touchVersion(tx, &models.Character{}, image.CharacterID)
+	})
+}
+
+// UpdateImage 画像の説明・クレジット・出典・ライセンスを更新し、人物のバージョンを進める
+func (r *characterRepository) UpdateImage(image *models.CharacterImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := r.withDB(tx).scopedImages().Model(&models.CharacterImage{}).
+			Where("id = ? AND character_id = ?", image.ID, image.CharacterID).
+			Select("caption", "credit", "source_url", "license").Updates(image)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return touchVersion(tx, &models.Character{}, image.CharacterID)
+	})
+}
+
+// SetPrimaryImage 画像を代表画像にして人物の photo を置き換える
+func (r *characterRepository) SetPrimaryImage(characterID, imageID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var image models.CharacterImage
+		if err := r.withDB(tx).scopedImages().First(&image, "id = ? AND character_id = ?", imageID, characterID).Error; err != nil {
+			return err
+		}
+		return setPrimary(tx, &image)
+	})
+}
+
+// ReorderImages ギャラリーの画像を imageIDs の順に並べ替え、人物のバージョンを進める
+func (r *characterRepository) ReorderImages(characterID string, imageIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for position, imageID := range imageIDs {
+			if err := r.withDB(tx).scopedImages().Model(&models.CharacterImage{}).
+				Where("id = ? AND character_id = ?", imageID, characterID).
+				UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return touchVersion(tx, &models.Character{}, characterID)
+	})
+}
+
+// DeleteImage ギャラリーから画像を削除し、人物のバージョンを進める
+// 代表画像を削除した場合は表示順で最初の画像を代表画像にする（残りがなければ photo を削除する）
+func (r *characterRepository) DeleteImage(characterID, imageID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var image models.CharacterImage
+		if err := r.withDB(tx).scopedImages().First(&image, "id = ? AND character_id = ?", imageID, characterID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+		if !image.IsPrimary {
+			return touchVersion(tx, &models.Character{}, characterID)
+		}
+
+		var next models.CharacterImage
+		err := orderImages(tx).Where("character_id = ?", characterID).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return updatePhoto(tx, characterID, nil)
+		}
+		if err != nil {
+			return err
+		}
+		return setPrimary(tx, &next)
+	})
+}
+
+// createImage 画像をギャラリーの末尾に作成
+func createImage(tx *gorm.DB, image *models.CharacterImage) error {
+	var last struct{ Position *int }
+	if err := tx.Model(&models.CharacterImage{}).Select("MAX(position) AS position").
+		Where("character_id = ?", image.CharacterID).Scan(&last).Error; err != nil {
+		return err
+	}
+	image.ID = uuid.New().String()
+	image.Position = 0
+	if last.Position != nil {
+		image.Position = *last.Position + 1
+	}
+	return tx.Create(image).Error
+}
+
+// setPrimary 画像を人物の代表画像にし、photo・切り抜き範囲・大きさを画像に合わせる
+func setPrimary(tx *gorm.DB, image *models.CharacterImage) error {
+	if err := tx.Model(&models.CharacterImage{}).Where("character_id = ?", image.CharacterID).
+		UpdateColumn("is_primary", gorm.Expr("id = ?", image.ID)).Error; err != nil {
+		return err
+	}
+	image.IsPrimary = true
+	return updatePhoto(tx, image.CharacterID, image)
+}
+
+// updatePhoto 人物の photo を代表画像に合わせてバージョンを進める（image が nil の場合は photo を削除する）
+func updatePhoto(tx *gorm.DB, characterID string, image *models.CharacterImage) error {
+	if err := writePhoto(tx, characterID, image); err != nil {
+		return err
+	}
+	return touchVersion(tx, &models.Character{}, characterID)
+}
+
+// writePhoto 人物の photo・切り抜き範囲・大きさを代表画像に合わせる（バージョンは進めない）
+func writePhoto(tx *gorm.DB, characterID string, image *models.CharacterImage) error {
+	photo := &models.Character{}
+	if image != nil {
+		photo.Photo = &image.Key
+		photo.PhotoCrop = image.Crop
+		photo.PhotoSize = image.Size
+	}
+	return tx.Model(&models.Character{}).Where("id = ?", characterID).
+		Select("photo", "photo_crop", "photo_size").Updates(photo).Error
+}
+
+// promoteNextImage 代表画像を削除した人物の、表示順で先頭の画像を代表画像にする（画像がなければ何もしない）
+// 人物の更新の中で呼ぶため、バージョンは進めずに character の photo も代表画像に合わせる
+func promoteNextImage(tx *gorm.DB, character *models.Character) error {
+	var next models.CharacterImage
+	err := orderImages(tx).Where("character_id = ?", character.ID).First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&next).UpdateColumn("is_primary", true).Error; err != nil {
+		return err
+	}
+	if err := writePhoto(tx, character.ID, &next); err != nil {
+		return err
+	}
+	character.Photo = &next.Key
+	character.PhotoCrop = next.Crop
+	character.PhotoSize = next.Size
+	return nil
+}
+
+// syncPrimaryImage 人物の photo に合わせて代表画像を作成・置き換え・削除する
+// photo を削除した場合は残りの画像の表示順で先頭の画像を新しい代表画像にする
+func syncPrimaryImage(tx *gorm.DB, character *models.Character) error {
+	var primary models.CharacterImage
+	err := tx.Where("character_id = ? AND is_primary = ?", character.ID, true).First(&primary).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	found := err == nil
+
+	switch {
+	case character.Photo == nil:
+		if !found {
+			return nil
+		}
+		if err := tx.Delete(&primary).Error; err != nil {
+			return err
+		}
+		return promoteNextImage(tx, character)
+	case !found:
+		return createImage(tx, &models.CharacterImage{
+			CharacterID: character.ID,
+			Key:         *character.Photo,
+			Crop:        character.PhotoCrop,
+			Size:        character.PhotoSize,
+			IsPrimary:   true,
+		})
+	default:
+		primary.Key = *character.Photo
+		primary.Crop = character.PhotoCrop
+		primary.Size = character.PhotoSize
+		return tx.Model(&primary).Select("image_key", "crop", "size").Updates(&primary).Error
+	}
+}
+
+// CountByPhoto 指定した画像のキーを photo に持つ人物とギャラリーの画像の数を取得
+// ワークスペースに限定しない場合は全てのワークスペースを数える（共有された画像の参照数）
+func (r *characterRepository) CountByPhoto(keys ...string) (int64, error) {
+	var characters, images int64
+	if err := r.scoped().Model(&models.Character{}).Where("photo IN ?", keys).Count(&characters).Error; err != nil {
+		return 0, err
+	}
+	if err := r.scopedImages().Model(&models.CharacterImage{}).Where("image_key IN ?", keys).Count(&images).Error; err != nil {
+		return 0, err
+	}
+	return characters + images, nil
+}
+
+// GetPhotoKeys 人物の photo とギャラリーの画像のキーを重複なく取得
+func (r *characterRepository) GetPhotoKeys() ([]string, error) {
+	var photos, images []string
+	if err := r.scoped().Model(&models.Character{}).
+		Where("photo IS NOT NULL AND photo <> ''").
+		Distinct().Pluck("photo", &photos).Error; err != nil {
+		return nil, err
+	}
+	if err := r.scopedImages().Model(&models.CharacterImage{}).
+		Distinct().Pluck("image_key", &images).Error; err != nil {
+		return nil, err
+	}
+	return mergeKeys(photos, images), nil
+}
+
+// GetWithPhoto photo を持つ人物の ID・グループ・名前・photo を取得（関連は読み込まない）
+func (r *characterRepository) GetWithPhoto() ([]models.Character, error) {
+	var characters []models.Character
+	err := r.scoped().Select("id", "group_id", "name", "photo").
+		Where("photo IS NOT NULL AND photo <> ''").
+		Order("id").Find(&characters).Error
+	return characters, err
+}
+
+// ReplacePhoto photo・ギャラリーの画像が oldKeys のいずれかのものを全て newKey に置き換え、人物のバージョンを進める
+// 置き換えた写真の大きさは記録し直すため、photo_size・size を 0 に戻す
+func (r *characterRepository) ReplacePhoto(newKey string, oldKeys ...string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		repo := r.withDB(tx)
+		if err := repo.scoped().Model(&models.Character{}).Where("photo IN ?", oldKeys).
+			Updates(map[string]interface{}{"photo": newKey, "photo_size": 0, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+
+		var characterIDs []string
+		if err := repo.scopedImages().Model(&models.CharacterImage{}).Where("image_key IN ?", oldKeys).
+			Distinct().Pluck("character_id", &characterIDs).Error; err != nil || len(characterIDs) == 0 {
+			return err
+		}
+		if err := repo.scopedImages().Model(&models.CharacterImage{}).Where("image_key IN ?", oldKeys).
+			Updates(map[string]interface{}{"image_key": newKey, "size": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Character{}).Where("id IN ? AND (photo IS NULL OR photo <> ?)", characterIDs, newKey).
+			UpdateColumn("version", gorm.Expr("version + 1")).Error
+	})
+}
+
+// GetPhotoSizes ワークスペースのギャラリーの画像のキーごとの大きさを重複なく取得
+// 代表画像は人物の photo と同じ画像のため、photo も含まれる。excludeCharacterID の人物の代表画像は除く
+func (r *characterRepository) GetPhotoSizes(excludeCharacterID string) (map[string]int64, error) {
+	var rows []struct {
+		ImageKey string
+		Size     int64
+	}
+	err := r.scopedImages().Model(&models.CharacterImage{}).
+		Select("image_key, MAX(size) AS size").
+		Where("NOT (character_id = ? AND is_primary = ?)", excludeCharacterID, true).
+		Group("image_key").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		sizes[row.ImageKey] = row.Size
+	}
+	return sizes, nil
+}
+
+// GetPhotoKeysWithoutSize 大きさが記録されていない photo・ギャラリーの画像のキーを重複なく取得
+func (r *characterRepository) GetPhotoKeysWithoutSize() ([]string, error) {
+	var photos, images []string
+	if err := r.scoped().Model(&models.Character{}).
+		Where("photo IS NOT NULL AND photo <> '' AND photo_size = 0").
+		Distinct().Pluck("photo", &photos).Error; err != nil {
+		return nil, err
+	}
+	if err := r.scopedImages().Model(&models.CharacterImage{}).Where("size = 0").
+		Distinct().Pluck("image_key", &images).Error; err != nil {
+		return nil, err
+	}
+	return mergeKeys(photos, images), nil
+}
+
+// SetPhotoSize photo・ギャラリーの画像が key のものに大きさを記録（内部の集計用のためバージョンは進めない）
+func (r *characterRepository) SetPhotoSize(key string, size int64) error {
+	if err := r.scoped().Model(&models.Character{}).Where("photo = ?", key).UpdateColumn("photo_size", size).Error; err != nil {
+		return err
+	}
+	return r.scopedImages().Model(&models.CharacterImage{}).Where("image_key = ?", key).UpdateColumn("size", size).Error
+}
+
+// GetWithRelatedLinks 関連リンクのある人物の ID と関連リンクを取得
+func (r *characterRepository) GetWithRelatedLinks() ([]models.Character, error) {
+	var characters []models.Character
+	err := r.scoped().Select("id", "related_links").Where("related_links IS NOT NULL").Find(&characters).Error
+	return characters, err
+}
+
+// SetRelatedLinks 人物の関連リンクを書き換える（バージョン・更新日時は変えない）
+func (r *characterRepository) SetRelatedLinks(id string, links []models.RelatedLink) error {
+	data, err := json.Marshal(links)
+	if err != nil {
+		return fmt.Errorf("failed to marshal related links: %w", err)
+	}
+	return r.scoped().Model(&models.Character{}).Where("id = ?", id).UpdateColumn("related_links", string(data)).Error
+}
+
+// mergeKeys 複数のキーの一覧を重複なく並べ替えて結合
+func mergeKeys(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, list := range lists {
+		for _, key := range list {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,325 @@
+package repositories
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/query"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// characterNames 人物の名前（名前順）
+func characterNames(characters []models.Character) []string {
+	names := make([]string, len(characters))
+	for i, character := range characters {
+		names[i] = character.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCharacterRepository_FindQuery(t *testing.T) {
+	f := newTestFixture(t)
+
+	// 同じ名前のグループ・ラベル・人物を持つ2つのワークスペース
+	tokugawa := f.workspace("tokugawa")
+	toyotomi := f.workspace("toyotomi")
+	mikawa := f.group(tokugawa.ID, "三河")
+	otherMikawa := f.group(toyotomi.ID, "三河")
+	warlord := f.label(tokugawa.ID, "武将")
+	otherWarlord := f.label(toyotomi.ID, "武将")
+
+	ieyasu := f.character(mikawa.ID, "徳川家康", warlord)
+	tadakatsu := f.character(mikawa.ID, "本多忠勝")
+	f.character(mikawa.ID, "100%_勝率")
+	f.relationship(mikawa.ID, ieyasu, tadakatsu, "主従")
+	require.NoError(t, f.db.Create(&models.CharacterAlias{ID: "alias-1", CharacterID: ieyasu.ID, Type: models.AliasTypeAlias, Name: "竹千代"}).Error)
+
+	otherIeyasu := f.character(otherMikawa.ID, "徳川家康", otherWarlord)
+	hanzo := f.character(otherMikawa.ID, "服部半蔵")
+	f.relationship(otherMikawa.ID, otherIeyasu, hanzo, "主従")
+
+	repo := NewCharacterRepository(f.db).WithWorkspace(tokugawa.ID)
+	find := func(t *testing.T, input string) []string {
+		t.Helper()
+		expr, err := query.Parse(input)
+		require.NoError(t, err)
+		characters, err := repo.Find(CharacterFilter{Query: expr})
+		require.NoError(t, err)
+		return characterNames(characters)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"ラベル名はワークスペースのラベルだけ", "label:武将", []string{"徳川家康"}},
+		{"グループ名はワークスペースのグループだけ", "group:三河", []string{"100%_勝率", "徳川家康", "本多忠勝"}},
+		{"関係の種類はワークスペースの関係だけ", "relation:主従", []string{"徳川家康", "本多忠勝"}},
+		{"関係する人物の名前", "related:徳川家康", []string{"本多忠勝"}},
+		{"別名も名前として部分一致", "name:竹千", []string{"徳川家康"}},
+		{"% はワイルドカードにしない", "name:%", []string{"100%_勝率"}},
+		{"_ はワイルドカードにしない", "name:_", []string{"100%_勝率"}},
+		{"NOT と AND", "group:三河 AND NOT label:武将", []string{"100%_勝率", "本多忠勝"}},
+		{"OR", "name:忠勝 OR label:武将", []string{"徳川家康", "本多忠勝"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, find(t, tt.query))
+		})
+	}
+
+	t.Run("他のワークスペースの関係する人物は含めない", func(t *testing.T) {
+		otherRepo := NewCharacterRepository(f.db).WithWorkspace(toyotomi.ID)
+		expr, err := query.Parse("related:徳川家康")
+		require.NoError(t, err)
+
+		characters, err := otherRepo.Find(CharacterFilter{Query: expr})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"服部半蔵"}, characterNames(characters))
+	})
+}
+
+func TestCharacterRepository_CompileTerm(t *testing.T) {
+	repo := &characterRepository{workspaceID: "ws-1"}
+
+	t.Run("関係の種類にワークスペースの条件を付ける", func(t *testing.T) {
+		sql, args := repo.compileTerm(&query.Term{Field: query.FieldRelation, Value: "主従"})
+
+		assert.Contains(t, sql, "relationships.group_id IN (SELECT id FROM `groups` WHERE workspace_id = ?)")
+		assert.Equal(t, []interface{}{"主従", "ws-1", "主従", "ws-1"}, args)
+	})
+
+	t.Run("関係する人物にワークスペースの条件を付ける", func(t *testing.T) {
+		sql, args := repo.compileTerm(&query.Term{Field: query.FieldRelated, Value: "徳川家康"})
+
+		assert.Contains(t, sql, "other.group_id IN (SELECT id FROM `groups` WHERE workspace_id = ?)")
+		assert.Equal(t, []interface{}{"徳川家康", "ws-1", "徳川家康", "ws-1"}, args)
+	})
+
+	t.Run("ワークスペースに限定しない場合は条件を付けない", func(t *testing.T) {
+		sql, args := (&characterRepository{}).compileTerm(&query.Term{Field: query.FieldRelated, Value: "徳川家康"})
+
+		assert.NotContains(t, sql, "workspace_id")
+		assert.Equal(t, []interface{}{"徳川家康", "徳川家康"}, args)
+	})
+
+	t.Run("部分一致の特殊文字をエスケープする", func(t *testing.T) {
+		_, args := repo.compileTerm(&query.Term{Field: query.FieldInfo, Value: "100%_!"})
+
+		assert.Equal(t, []interface{}{"%100!%!_!!%"}, args)
+	})
+}
+
+func TestCharacterRepository_HasLabel(t *testing.T) {
+	f := newTestFixture(t)
+	tokugawa := f.workspace("tokugawa")
+	toyotomi := f.workspace("toyotomi")
+	otherWarlord := f.label(toyotomi.ID, "武将")
+	hideyoshi := f.character(f.group(toyotomi.ID, "尾張").ID, "豊臣秀吉", otherWarlord)
+
+	has, err := NewCharacterRepository(f.db).WithWorkspace(toyotomi.ID).HasLabel(hideyoshi.ID, otherWarlord.ID)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// 他のワークスペースの人物とラベルは見えない
+	has, err = NewCharacterRepository(f.db).WithWorkspace(tokugawa.ID).HasLabel(hideyoshi.ID, otherWarlord.ID)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestCharacterRepository_ReplaceLabels(t *testing.T) {
+	errRejected := errors.New("rejected")
+
+	setup := func(t *testing.T) (*testFixture, CharacterRepository, *models.Character, *models.Label, *models.Label) {
+		f := newTestFixture(t)
+		workspace := f.workspace("tokugawa")
+		limit := 3
+		group := &models.Group{Name: "三河", LabelLimit: &limit}
+		require.NoError(t, NewGroupRepository(f.db).WithWorkspace(workspace.ID).Create(group))
+		warlord := f.label(workspace.ID, "武将")
+		daimyo := f.label(workspace.ID, "大名")
+		ieyasu := f.character(group.ID, "徳川家康", warlord)
+		return f, NewCharacterRepository(f.db).WithWorkspace(workspace.ID), ieyasu, warlord, daimyo
+	}
+
+	t.Run("確認にはグループと現在のラベルを読み込んだ人物を渡す", func(t *testing.T) {
+		f, repo, ieyasu, warlord, daimyo := setup(t)
+
+		var checked *models.Character
+		err := repo.ReplaceLabels(ieyasu.ID, []string{daimyo.ID}, func(character *models.Character) error {
+			checked = character
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, checked)
+		assert.Equal(t, 3, *checked.Group.LabelLimit)
+		require.Len(t, checked.Labels, 1)
+		assert.Equal(t, warlord.ID, checked.Labels[0].ID)
+		assert.Equal(t, []string{daimyo.ID}, f.labelIDs(ieyasu.ID))
+		assert.Equal(t, uint(2), f.version(ieyasu.ID))
+	})
+
+	t.Run("確認でエラーになった場合は何も変更しない", func(t *testing.T) {
+		f, repo, ieyasu, warlord, daimyo := setup(t)
+
+		err := repo.ReplaceLabels(ieyasu.ID, []string{daimyo.ID}, func(*models.Character) error {
+			return errRejected
+		})
+
+		assert.ErrorIs(t, err, errRejected)
+		assert.Equal(t, []string{warlord.ID}, f.labelIDs(ieyasu.ID))
+		assert.Equal(t, uint(1), f.version(ieyasu.ID))
+	})
+
+	t.Run("存在しない人物", func(t *testing.T) {
+		_, repo, _, _, daimyo := setup(t)
+
+		err := repo.ReplaceLabels("missing", []string{daimyo.ID}, nil)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestCharacterRepository_AddLabel(t *testing.T) {
+	errRejected := errors.New("rejected")
+
+	setup := func(t *testing.T) (*testFixture, CharacterRepository, *models.Character, *models.Label, *models.Label) {
+		f := newTestFixture(t)
+		workspace := f.workspace("tokugawa")
+		warlord := f.label(workspace.ID, "武将")
+		daimyo := f.label(workspace.ID, "大名")
+		ieyasu := f.character(f.group(workspace.ID, "三河").ID, "徳川家康", warlord)
+		return f, NewCharacterRepository(f.db).WithWorkspace(workspace.ID), ieyasu, warlord, daimyo
+	}
+
+	t.Run("確認の後にラベルを追加してバージョンを進める", func(t *testing.T) {
+		f, repo, ieyasu, warlord, daimyo := setup(t)
+
+		var count int
+		err := repo.AddLabel(ieyasu.ID, daimyo.ID, func(character *models.Character) error {
+			count = len(character.Labels)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.ElementsMatch(t, []string{warlord.ID, daimyo.ID}, f.labelIDs(ieyasu.ID))
+		assert.Equal(t, uint(2), f.version(ieyasu.ID))
+	})
+
+	t.Run("確認でエラーになった場合は追加しない", func(t *testing.T) {
+		f, repo, ieyasu, warlord, daimyo := setup(t)
+
+		err := repo.AddLabel(ieyasu.ID, daimyo.ID, func(*models.Character) error {
+			return errRejected
+		})
+
+		assert.ErrorIs(t, err, errRejected)
+		assert.Equal(t, []string{warlord.ID}, f.labelIDs(ieyasu.ID))
+		assert.Equal(t, uint(1), f.version(ieyasu.ID))
+	})
+
+	t.Run("他のワークスペースのラベルは追加できない", func(t *testing.T) {
+		f, repo, ieyasu, _, _ := setup(t)
+		otherLabel := f.label(f.workspace("toyotomi").ID, "武将")
+
+		err := repo.AddLabel(ieyasu.ID, otherLabel.ID, nil)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestCharacterRepository_UpdateLabelAssignments(t *testing.T) {
+	f := newTestFixture(t)
+	workspace := f.workspace("tokugawa")
+	group := f.group(workspace.ID, "三河")
+	warlord := f.label(workspace.ID, "武将")
+	ieyasu := f.character(group.ID, "徳川家康", warlord)
+	tadakatsu := f.character(group.ID, "本多忠勝")
+	repo := NewCharacterRepository(f.db).WithWorkspace(workspace.ID)
+
+	var planned []models.Character
+	err := repo.UpdateLabelAssignments(warlord.ID, []string{ieyasu.ID, tadakatsu.ID, "missing"}, func(characters []models.Character) ([]string, []string) {
+		planned = characters
+		return []string{tadakatsu.ID}, []string{ieyasu.ID}
+	})
+
+	require.NoError(t, err)
+	// 存在しない人物は計画に渡さず、人物は現在のラベルとともに渡す
+	require.Len(t, planned, 2)
+	assert.Equal(t, []string{"徳川家康", "本多忠勝"}, characterNames(planned))
+	for _, character := range planned {
+		if character.ID == ieyasu.ID {
+			assert.Len(t, character.Labels, 1)
+		}
+	}
+	assert.Empty(t, f.labelIDs(ieyasu.ID))
+	assert.Equal(t, []string{warlord.ID}, f.labelIDs(tadakatsu.ID))
+	assert.Equal(t, uint(2), f.version(ieyasu.ID))
+	assert.Equal(t, uint(2), f.version(tadakatsu.ID))
+}
+
+func TestCharacterRepository_UpdateRemovePhoto(t *testing.T) {
+	f := newTestFixture(t)
+	workspace := f.workspace("tokugawa")
+	repo := NewCharacterRepository(f.db).WithWorkspace(workspace.ID)
+
+	photo := "aaaa/full.jpg"
+	ieyasu := &models.Character{GroupID: f.group(workspace.ID, "三河").ID, Name: "徳川家康", Photo: &photo, PhotoSize: 100}
+	require.NoError(t, repo.Create(ieyasu))
+	armor := &models.CharacterImage{CharacterID: ieyasu.ID, Key: "bbbb/full.jpg", Size: 200}
+	require.NoError(t, repo.AddImage(armor))
+	portrait := &models.CharacterImage{CharacterID: ieyasu.ID, Key: "cccc/full.jpg", Size: 300}
+	require.NoError(t, repo.AddImage(portrait))
+	images, err := repo.GetImages(ieyasu.ID)
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+	// 肖像を甲冑より前に並べる
+	require.NoError(t, repo.ReorderImages(ieyasu.ID, []string{images[0].ID, portrait.ID, armor.ID}))
+
+	character, err := repo.GetByID(ieyasu.ID)
+	require.NoError(t, err)
+	version := character.Version
+	character.Photo = nil
+	character.PhotoSize = 0
+	character.Aliases = nil
+	require.NoError(t, repo.Update(character))
+
+	updated, err := repo.GetByID(ieyasu.ID)
+	require.NoError(t, err)
+	// 表示順で先頭の残りの画像が代表画像になり、photo も合わせる
+	require.NotNil(t, updated.Photo)
+	assert.Equal(t, "cccc/full.jpg", *updated.Photo)
+	assert.Equal(t, int64(300), updated.PhotoSize)
+	require.Len(t, updated.Images, 2)
+	for _, image := range updated.Images {
+		assert.Equal(t, image.ID == portrait.ID, image.IsPrimary, image.Key)
+	}
+	assert.Equal(t, version+1, updated.Version)
+}
+
+func TestCharacterRepository_UpdateRemoveOnlyPhoto(t *testing.T) {
+	f := newTestFixture(t)
+	workspace := f.workspace("tokugawa")
+	repo := NewCharacterRepository(f.db).WithWorkspace(workspace.ID)
+
+	photo := "aaaa/full.jpg"
+	ieyasu := &models.Character{GroupID: f.group(workspace.ID, "三河").ID, Name: "徳川家康", Photo: &photo}
+	require.NoError(t, repo.Create(ieyasu))
+
+	ieyasu.Photo = nil
+	require.NoError(t, repo.Update(ieyasu))
+
+	updated, err := repo.GetByID(ieyasu.ID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.Photo)
+	assert.Empty(t, updated.Images)
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"character-management-app/internal/models"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB テスト用の SQLite データベースを作成してマイグレーションする
+// 行のロック（FOR UPDATE）は SQLite では付かないため、ロックの後の確認と変更の内容だけを検証する
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Workspace{},
+		&models.Group{},
+		&models.LabelCategory{},
+		&models.Label{},
+		&models.Character{},
+		&models.CharacterImage{},
+		&models.CharacterAlias{},
+		&models.Relationship{},
+		&models.SavedView{},
+	))
+	return db
+}
+
+// testFixture テストデータを作成するヘルパー
+type testFixture struct {
+	t  *testing.T
+	db *gorm.DB
+}
+
+func newTestFixture(t *testing.T) *testFixture {
+	return &testFixture{t: t, db: newTestDB(t)}
+}
+
+// workspace ワークスペースを作成
+func (f *testFixture) workspace(slug string) *models.Workspace {
+	workspace := &models.Workspace{Slug: slug, Name: slug}
+	require.NoError(f.t, NewWorkspaceRepository(f.db).Create(workspace))
+	return workspace
+}
+
+// group ワークスペースにグループを作成
+func (f *testFixture) group(workspaceID, name string) *models.Group {
+	group := &models.Group{Name: name}
+	require.NoError(f.t, NewGroupRepository(f.db).WithWorkspace(workspaceID).Create(group))
+	return group
+}
+
+// label ワークスペースに全体のラベルを作成
+func (f *testFixture) label(workspaceID, name string) *models.Label {
+	label := &models.Label{Name: name, Color: "#3B82F6"}
+	require.NoError(f.t, NewLabelRepository(f.db).WithWorkspace(workspaceID).Create(label))
+	return label
+}
+
+// character グループに人物を作成し、ラベルを付ける
+func (f *testFixture) character(groupID, name string, labels ...*models.Label) *models.Character {
+	character := &models.Character{GroupID: groupID, Name: name}
+	require.NoError(f.t, NewCharacterRepository(f.db).Create(character))
+	for _, label := range labels {
+		require.NoError(f.t, f.db.Table("character_labels").Create(map[string]interface{}{
+			"character_id": character.ID,
+			"label_id":     label.ID,
+		}).Error)
+	}
+	return character
+}
+
+// relationship 2人の人物の関係を作成
+func (f *testFixture) relationship(groupID string, character1, character2 *models.Character, relationshipType string) *models.Relationship {
+	relationship := &models.Relationship{
+		GroupID:          groupID,
+		Character1ID:     character1.ID,
+		Character2ID:     character2.ID,
+		RelationshipType: relationshipType,
+	}
+	require.NoError(f.t, NewRelationshipRepository(f.db).Create(relationship))
+	return relationship
+}
+
+// labelIDs 人物に付いているラベルのID
+func (f *testFixture) labelIDs(characterID string) []string {
+	var ids []string
+	require.NoError(f.t, f.db.Table("character_labels").Where("character_id = ?", characterID).Order("label_id").Pluck("label_id", &ids).Error)
+	return ids
+}
+
+// version 人物の現在のバージョン
+func (f *testFixture) version(characterID string) uint {
+	var character models.Character
+	require.NoError(f.t, f.db.Select("version").First(&character, "id = ?", characterID).Error)
+	return character.Version
+}
@@ -15,11 +15,13 @@ type GroupRepository interface {
 	Update(group *models.Group) error
 	Delete(id string) error
 	ExistsByID(id string) (bool, error)
+	WithWorkspace(workspaceID string) GroupRepository
 }
 
 // groupRepository グループリポジトリの実装
 type groupRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	workspaceID string
 }
 
 // NewGroupRepository グループリポジトリのコンストラクタ
@@ -27,10 +29,27 @@ func NewGroupRepository(db *gorm.DB) GroupRepository {
 	return &groupRepository{db: db}
 }
 
+// WithWorkspace 指定したワークスペースに限定したリポジトリを返す
+func (r *groupRepository) WithWorkspace(workspaceID string) GroupRepository {
+	return &groupRepository{db: r.db, workspaceID: workspaceID}
+}
+
+// scoped ワークスペース条件を付与したクエリを返す
+func (r *groupRepository) scoped() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("workspace_id = ?", r.workspaceID)
+}
+
 // Create グループを作成
 func (r *groupRepository) Create(group *models.Group) error {
 	// UUIDを生成
 	group.ID = uuid.New().String()
+	if r.workspaceID != "" {
+		group.WorkspaceID = r.workspaceID
+	}
+	group.Version = 1
 	
 	return r.db.Create(group).Error
 }
@@ -38,7 +57,7 @@ func (r *groupRepository) Create(group *models.Group) error {
 // GetByID IDでグループを取得
 func (r *groupRepository) GetByID(id string) (*models.Group, error) {
 	var group models.Group
-	err := r.db.Preload("Characters").First(&group, "id = ?", id).Error
+	err := r.scoped().Preload("Characters").First(&group, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -48,23 +67,43 @@ func (r *groupRepository) GetByID(id string) (*models.Group, error) {
 // GetAll 全てのグループを取得
 func (r *groupRepository) GetAll() ([]models.Group, error) {
 	var groups []models.Group
-	err := r.db.Preload("Characters").Find(&groups).Error
+	err := r.scoped().Preload("Characters").Find(&groups).Error
 	return groups, err
 }
 
-// Update グループを更新
+// Update グループを更新（バージョンが一致しない場合は ErrVersionConflict）
+// 人物と関係のレスポンスはグループを含むため、所属する人物と関係のバージョンも進める
 func (r *groupRepository) Update(group *models.Group) error {
-	return r.db.Save(group).Error
+	if r.workspaceID != "" {
+		group.WorkspaceID = r.workspaceID
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		scoped := &groupRepository{db: tx, workspaceID: r.workspaceID}
+		if err := updateVersioned(scoped.scoped(), group, &group.Version); err != nil {
+			return err
+		}
+		return touchGroupMembers(tx, group.ID)
+	})
+}
+
+// touchGroupMembers グループに所属する人物と関係のバージョンを進める
+func touchGroupMembers(tx *gorm.DB, groupID string) error {
+	if err := tx.Model(&models.Character{}).Where("group_id = ?", groupID).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Relationship{}).Where("group_id = ?", groupID).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
 }
 
 // Delete グループを削除
 func (r *groupRepository) Delete(id string) error {
-	return r.db.Delete(&models.Group{}, "id = ?", id).Error
+	return r.scoped().Delete(&models.Group{}, "id = ?", id).Error
 }
 
 // ExistsByID グループが存在するかチェック
 func (r *groupRepository) ExistsByID(id string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Group{}).Where("id = ?", id).Count(&count).Error
+	err := r.scoped().Model(&models.Group{}).Where("id = ?", id).Count(&count).Error
 	return count > 0, err
 }
\ No newline at end of file
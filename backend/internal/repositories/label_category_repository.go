@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"character-management-app/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LabelCategoryRepository ラベルの分類リポジトリのインターフェース
+type LabelCategoryRepository interface {
+	Create(category *models.LabelCategory) error
+	GetByID(id string) (*models.LabelCategory, error)
+	GetAll() ([]models.LabelCategory, error)
+	Update(category *models.LabelCategory) error
+	Delete(id string) error
+	ExistsByID(id string) (bool, error)
+	ExistsByName(name string) (bool, error)
+	WithWorkspace(workspaceID string) LabelCategoryRepository
+}
+
+// labelCategoryRepository ラベルの分類リポジトリの実装
+type labelCategoryRepository struct {
+	db          *gorm.DB
+	workspaceID string
+}
+
+// NewLabelCategoryRepository ラベルの分類リポジトリのコンストラクタ
+func NewLabelCategoryRepository(db *gorm.DB) LabelCategoryRepository {
+	return &labelCategoryRepository{db: db}
+}
+
+// WithWorkspace 指定したワークスペースに限定したリポジトリを返す
+func (r *labelCategoryRepository) WithWorkspace(workspaceID string) LabelCategoryRepository {
+	return &labelCategoryRepository{db: r.db, workspaceID: workspaceID}
+}
+
+// scoped ワークスペース条件を付与したクエリを返す
+func (r *labelCategoryRepository) scoped() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("workspace_id = ?", r.workspaceID)
+}
+
+// Create 分類を作成
+func (r *labelCategoryRepository) Create(category *models.LabelCategory) error {
+	// UUIDを生成
+	category.ID = uuid.New().String()
+	if r.workspaceID != "" {
+		category.WorkspaceID = r.workspaceID
+	}
+	category.Version = 1
+
+	return r.db.Create(category).Error
+}
+
+// GetByID IDで分類を取得
+func (r *labelCategoryRepository) GetByID(id string) (*models.LabelCategory, error) {
+	var category models.LabelCategory
+	err := r.scoped().First(&category, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetAll 全ての分類を名前順に取得
+func (r *labelCategoryRepository) GetAll() ([]models.LabelCategory, error) {
+	var categories []models.LabelCategory
+	err := r.scoped().Order("name").Find(&categories).Error
+	return categories, err
+}
+
+// Update 分類を更新（バージョンが一致しない場合は ErrVersionConflict）
+func (r *labelCategoryRepository) Update(category *models.LabelCategory) error {
+	if r.workspaceID != "" {
+		category.WorkspaceID = r.workspaceID
+	}
+	return updateVersioned(r.scoped(), category, &category.Version)
+}
+
+// Delete 分類を削除（分類のラベルは分類なしになる）
+func (r *labelCategoryRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		scoped := &labelCategoryRepository{db: tx, workspaceID: r.workspaceID}
+		var category models.LabelCategory
+		if err := scoped.scoped().First(&category, "id = ?", id).Error; err != nil {
+			return err
+		}
+		// 分類が外れるラベルのバージョンを進める
+		if err := tx.Model(&models.Label{}).Where("category_id = ?", id).
+			UpdateColumns(map[string]interface{}{
+				"category_id": nil,
+				"version":     gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+}
+
+// ExistsByID 分類が存在するかチェック
+func (r *labelCategoryRepository) ExistsByID(id string) (bool, error) {
+	var count int64
+	err := r.scoped().Model(&models.LabelCategory{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// ExistsByName 名前で分類が存在するかチェック
+func (r *labelCategoryRepository) ExistsByName(name string) (bool, error) {
+	var count int64
+	err := r.scoped().Model(&models.LabelCategory{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
@@ -5,22 +5,40 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LabelRepository ラベルリポジトリのインターフェース
 type LabelRepository interface {
 	Create(label *models.Label) error
 	GetByID(id string) (*models.Label, error)
+	GetByIDs(ids []string) ([]models.Label, error)
 	GetAll() ([]models.Label, error)
+	GetForGroup(groupID string) ([]models.Label, error)
 	Update(label *models.Label) error
 	Delete(id string) error
+	Merge(sourceID, targetID string, check LabelMergeCheck) error
+	GetUsage() (map[string]LabelUsage, error)
 	ExistsByID(id string) (bool, error)
-	ExistsByName(name string) (bool, error)
+	ExistsByName(name string, groupID *string) (bool, error)
+	WithWorkspace(workspaceID string) LabelRepository
+}
+
+// LabelMergeCheck ロックして読み直した統合元のラベルが付いた人物を確認し、統合できない場合はエラーを返す
+type LabelMergeCheck func(characters []models.Character) error
+
+// LabelUsage ラベルの利用状況
+type LabelUsage struct {
+	// CharacterCount ラベルが付いている人物の数
+	CharacterCount int64
+	// GroupCount ラベルが付いている人物が所属するグループの数
+	GroupCount int64
 }
 
 // labelRepository ラベルリポジトリの実装
 type labelRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	workspaceID string
 }
 
 // NewLabelRepository ラベルリポジトリのコンストラクタ
@@ -28,51 +46,205 @@ func NewLabelRepository(db *gorm.DB) LabelRepository {
 	return &labelRepository{db: db}
 }
 
+// WithWorkspace 指定したワークスペースに限定したリポジトリを返す
+func (r *labelRepository) WithWorkspace(workspaceID string) LabelRepository {
+	return &labelRepository{db: r.db, workspaceID: workspaceID}
+}
+
+// scoped ワークスペース条件を付与したクエリを返す
+func (r *labelRepository) scoped() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("workspace_id = ?", r.workspaceID)
+}
+
 // Create ラベルを作成
 func (r *labelRepository) Create(label *models.Label) error {
 	// UUIDを生成
 	label.ID = uuid.New().String()
+	if r.workspaceID != "" {
+		label.WorkspaceID = r.workspaceID
+	}
+	label.Scope = models.LabelScope(label.GroupID)
+	label.Version = 1
 	
 	return r.db.Create(label).Error
 }
 
-// GetByID IDでラベルを取得
+// GetByID IDでラベルを取得（分類の上限の確認のため分類も読み込む）
 func (r *labelRepository) GetByID(id string) (*models.Label, error) {
 	var label models.Label
-	err := r.db.First(&label, "id = ?", id).Error
+	err := r.scoped().Preload("Category").First(&label, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &label, nil
 }
 
+// GetByIDs 指定した ID のラベルを分類とともに取得（存在しない ID は無視する）
+func (r *labelRepository) GetByIDs(ids []string) ([]models.Label, error) {
+	var labels []models.Label
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	err := r.scoped().Preload("Category").Where("id IN ?", ids).Find(&labels).Error
+	return labels, err
+}
+
 // GetAll 全てのラベルを取得
 func (r *labelRepository) GetAll() ([]models.Label, error) {
 	var labels []models.Label
-	err := r.db.Find(&labels).Error
+	err := r.scoped().Find(&labels).Error
+	return labels, err
+}
+
+// GetForGroup グループの人物に付けられるラベル（全体のラベルとグループ専用のラベル）を取得
+func (r *labelRepository) GetForGroup(groupID string) ([]models.Label, error) {
+	var labels []models.Label
+	err := r.scoped().Where("group_id IS NULL OR group_id = ?", groupID).Find(&labels).Error
 	return labels, err
 }
 
-// Update ラベルを更新
+// Update ラベルを更新（バージョンが一致しない場合は ErrVersionConflict）
+// 人物のレスポンスはラベルの名前・色を含むため、ラベルが付いた人物のバージョンも進めて ETag を変える
 func (r *labelRepository) Update(label *models.Label) error {
-	return r.db.Save(label).Error
+	if r.workspaceID != "" {
+		label.WorkspaceID = r.workspaceID
+	}
+	label.Scope = models.LabelScope(label.GroupID)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		scoped := &labelRepository{db: tx, workspaceID: r.workspaceID}
+		if err := updateVersioned(scoped.scoped(), label, &label.Version); err != nil {
+			return err
+		}
+		return touchLabeledCharacters(tx, label.ID)
+	})
+}
+
+// touchLabeledCharacters ラベルが付いた人物のバージョンを進める
+func touchLabeledCharacters(tx *gorm.DB, labelID string) error {
+	return tx.Model(&models.Character{}).
+		Where("id IN (?)", tx.Table("character_labels").Select("character_id").Where("label_id = ?", labelID)).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
 }
 
 // Delete ラベルを削除
+// 子ラベルは削除するラベルの親に付け替える（最上位のラベルの場合は子ラベルが最上位になる）
 func (r *labelRepository) Delete(id string) error {
-	return r.db.Delete(&models.Label{}, "id = ?", id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		scoped := &labelRepository{db: tx, workspaceID: r.workspaceID}
+		var label models.Label
+		if err := scoped.scoped().First(&label, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return deleteLabel(tx, &label)
+	})
+}
+
+// Merge sourceID のラベルが付いた人物に targetID のラベルを付け替えて sourceID のラベルを削除する（1つのトランザクションで行う）
+// 両方のラベルが付いている人物は targetID のラベルだけが残り、sourceID の子ラベルは Delete と同じく sourceID の親に付け替わる
+// check は統合元のラベルが付いた人物の行をロックして読み直した後に呼び、エラーを返した場合は何も変更しない
+func (r *labelRepository) Merge(sourceID, targetID string, check LabelMergeCheck) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		scoped := &labelRepository{db: tx, workspaceID: r.workspaceID}
+		var source models.Label
+		if err := scoped.scoped().First(&source, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if err := scoped.scoped().First(&models.Label{}, "id = ?", targetID).Error; err != nil {
+			return err
+		}
+
+		// 付け替える人物の行をロックし、確認してから付け替えるまでの間に他のリクエストがラベルを変更できないようにする
+		var characters []models.Character
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Group").Preload("Labels").
+			Where("id IN (?)", tx.Table("character_labels").Select("character_id").Where("label_id = ?", sourceID)).
+			Order("id").Find(&characters).Error; err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(characters); err != nil {
+				return err
+			}
+		}
+
+		// ラベルの付け替えも人物の変更としてバージョンを進める
+		if err := touchLabeledCharacters(tx, sourceID); err != nil {
+			return err
+		}
+
+		var targetHolders []string
+		if err := tx.Table("character_labels").Where("label_id = ?", targetID).Pluck("character_id", &targetHolders).Error; err != nil {
+			return err
+		}
+
+		// 統合先のラベルが付いていない人物の行だけを付け替え、残りの行は削除する
+		move := tx.Table("character_labels").Where("label_id = ?", sourceID)
+		if len(targetHolders) > 0 {
+			move = move.Where("character_id NOT IN ?", targetHolders)
+		}
+		if err := move.Update("label_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM character_labels WHERE label_id = ?", sourceID).Error; err != nil {
+			return err
+		}
+
+		return deleteLabel(tx, &source)
+	})
+}
+
+// deleteLabel 子ラベルをラベルの親に付け替えてからラベルを削除する（ラベルが付いた人物のバージョンも進める）
+func deleteLabel(tx *gorm.DB, label *models.Label) error {
+	if err := touchLabeledCharacters(tx, label.ID); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Label{}).Where("parent_id = ?", label.ID).
+		UpdateColumns(map[string]interface{}{
+			"parent_id": label.ParentID,
+			"version":   gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(label).Error
+}
+
+// GetUsage ラベルごとの利用状況を取得（どの人物にも付いていないラベルは含まない）
+func (r *labelRepository) GetUsage() (map[string]LabelUsage, error) {
+	var rows []struct {
+		LabelID        string
+		CharacterCount int64
+		GroupCount     int64
+	}
+	err := r.db.Table("character_labels").
+		Select("character_labels.label_id, COUNT(DISTINCT character_labels.character_id) AS character_count, COUNT(DISTINCT characters.group_id) AS group_count").
+		Joins("JOIN characters ON characters.id = character_labels.character_id").
+		Where("character_labels.label_id IN (?)", r.scoped().Model(&models.Label{}).Select("id")).
+		Group("character_labels.label_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]LabelUsage, len(rows))
+	for _, row := range rows {
+		usage[row.LabelID] = LabelUsage{CharacterCount: row.CharacterCount, GroupCount: row.GroupCount}
+	}
+	return usage, nil
 }
 
 // ExistsByID ラベルが存在するかチェック
 func (r *labelRepository) ExistsByID(id string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Label{}).Where("id = ?", id).Count(&count).Error
+	err := r.scoped().Model(&models.Label{}).Where("id = ?", id).Count(&count).Error
 	return count > 0, err
 }
 
-// ExistsByName 名前でラベルが存在するかチェック
-func (r *labelRepository) ExistsByName(name string) (bool, error) {
+// ExistsByName 同じ範囲（groupID のグループ、nil の場合は全体のラベル）に名前のラベルが存在するかチェック
+func (r *labelRepository) ExistsByName(name string, groupID *string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Label{}).Where("name = ?", name).Count(&count).Error
+	err := r.scoped().Model(&models.Label{}).Where("scope = ? AND name = ?", models.LabelScope(groupID), name).Count(&count).Error
 	return count > 0, err
 }
\ No newline at end of file
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"character-management-app/internal/models"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLabelRepository_Merge(t *testing.T) {
+	errRejected := errors.New("rejected")
+
+	setup := func(t *testing.T) (*testFixture, LabelRepository, *models.Label, *models.Label, *models.Character, *models.Character) {
+		f := newTestFixture(t)
+		workspace := f.workspace("tokugawa")
+		group := f.group(workspace.ID, "三河")
+		daimio := f.label(workspace.ID, "大明")
+		daimyo := f.label(workspace.ID, "大名")
+		ieyasu := f.character(group.ID, "徳川家康", daimio)
+		tadakatsu := f.character(group.ID, "本多忠勝", daimio, daimyo)
+		return f, NewLabelRepository(f.db).WithWorkspace(workspace.ID), daimio, daimyo, ieyasu, tadakatsu
+	}
+
+	t.Run("確認には統合元のラベルが付いた人物を現在のラベルとともに渡す", func(t *testing.T) {
+		f, repo, daimio, daimyo, ieyasu, tadakatsu := setup(t)
+
+		var checked []models.Character
+		err := repo.Merge(daimio.ID, daimyo.ID, func(characters []models.Character) error {
+			checked = characters
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"徳川家康", "本多忠勝"}, characterNames(checked))
+		for _, character := range checked {
+			if character.ID == tadakatsu.ID {
+				assert.Len(t, character.Labels, 2)
+			}
+		}
+		assert.Equal(t, []string{daimyo.ID}, f.labelIDs(ieyasu.ID))
+		assert.Equal(t, []string{daimyo.ID}, f.labelIDs(tadakatsu.ID))
+		assert.ErrorIs(t, f.db.First(&models.Label{}, "id = ?", daimio.ID).Error, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("確認でエラーになった場合は何も変更しない", func(t *testing.T) {
+		f, repo, daimio, daimyo, ieyasu, tadakatsu := setup(t)
+
+		err := repo.Merge(daimio.ID, daimyo.ID, func([]models.Character) error {
+			return errRejected
+		})
+
+		assert.ErrorIs(t, err, errRejected)
+		assert.Equal(t, []string{daimio.ID}, f.labelIDs(ieyasu.ID))
+		assert.ElementsMatch(t, []string{daimio.ID, daimyo.ID}, f.labelIDs(tadakatsu.ID))
+		assert.Equal(t, uint(1), f.version(ieyasu.ID))
+		assert.NoError(t, f.db.First(&models.Label{}, "id = ?", daimio.ID).Error)
+	})
+}
@@ -14,15 +14,18 @@ type RelationshipRepository interface {
 	GetAll() ([]models.Relationship, error)
 	GetByGroupID(groupID string) ([]models.Relationship, error)
 	GetByCharacterID(characterID string) ([]models.Relationship, error)
+	GetAmongCharacters(characterIDs []string) ([]models.Relationship, error)
 	Update(relationship *models.Relationship) error
 	Delete(id string) error
 	ExistsByID(id string) (bool, error)
 	ExistsBetweenCharacters(character1ID, character2ID string) (bool, error)
+	WithWorkspace(workspaceID string) RelationshipRepository
 }
 
 // relationshipRepository 関係リポジトリの実装
 type relationshipRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	workspaceID string
 }
 
 // NewRelationshipRepository 関係リポジトリのコンストラクタ
@@ -30,10 +33,24 @@ func NewRelationshipRepository(db *gorm.DB) RelationshipRepository {
 	return &relationshipRepository{db: db}
 }
 
+// WithWorkspace 指定したワークスペースに限定したリポジトリを返す
+func (r *relationshipRepository) WithWorkspace(workspaceID string) RelationshipRepository {
+	return &relationshipRepository{db: r.db, workspaceID: workspaceID}
+}
+
+// scoped ワークスペースに属するグループの関係に限定したクエリを返す
+func (r *relationshipRepository) scoped() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("group_id IN (?)", groupIDsInWorkspace(r.db, r.workspaceID))
+}
+
 // Create 関係を作成
 func (r *relationshipRepository) Create(relationship *models.Relationship) error {
 	// UUIDを生成
 	relationship.ID = uuid.New().String()
+	relationship.Version = 1
 	
 	// IDの順序を保証（小さいIDをCharacter1IDに）
 	if relationship.Character1ID > relationship.Character2ID {
@@ -46,7 +63,7 @@ func (r *relationshipRepository) Create(relationship *models.Relationship) error
 // GetByID IDで関係を取得
 func (r *relationshipRepository) GetByID(id string) (*models.Relationship, error) {
 	var relationship models.Relationship
-	err := r.db.Preload("Group").Preload("Character1").Preload("Character2").First(&relationship, "id = ?", id).Error
+	err := r.scoped().Preload("Group").Preload("Character1").Preload("Character2").First(&relationship, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -56,45 +73,57 @@ func (r *relationshipRepository) GetByID(id string) (*models.Relationship, error
 // GetAll 全ての関係を取得
 func (r *relationshipRepository) GetAll() ([]models.Relationship, error) {
 	var relationships []models.Relationship
-	err := r.db.Preload("Group").Preload("Character1").Preload("Character2").Find(&relationships).Error
+	err := r.scoped().Preload("Group").Preload("Character1").Preload("Character2").Find(&relationships).Error
 	return relationships, err
 }
 
 // GetByGroupID グループIDで関係を取得
 func (r *relationshipRepository) GetByGroupID(groupID string) ([]models.Relationship, error) {
 	var relationships []models.Relationship
-	err := r.db.Preload("Group").Preload("Character1").Preload("Character2").Where("group_id = ?", groupID).Find(&relationships).Error
+	err := r.scoped().Preload("Group").Preload("Character1").Preload("Character2").Where("group_id = ?", groupID).Find(&relationships).Error
 	return relationships, err
 }
 
 // GetByCharacterID 人物IDで関係を取得（その人物が関わる全ての関係）
 func (r *relationshipRepository) GetByCharacterID(characterID string) ([]models.Relationship, error) {
 	var relationships []models.Relationship
-	err := r.db.Preload("Group").Preload("Character1").Preload("Character2").
+	err := r.scoped().Preload("Group").Preload("Character1").Preload("Character2").
 		Where("character1_id = ? OR character2_id = ?", characterID, characterID).
 		Find(&relationships).Error
 	return relationships, err
 }
 
-// Update 関係を更新
+// GetAmongCharacters 指定した人物同士の関係を取得（両方の人物が含まれる関係のみ）
+func (r *relationshipRepository) GetAmongCharacters(characterIDs []string) ([]models.Relationship, error) {
+	relationships := []models.Relationship{}
+	if len(characterIDs) == 0 {
+		return relationships, nil
+	}
+	err := r.scoped().Preload("Group").Preload("Character1").Preload("Character2").
+		Where("character1_id IN ? AND character2_id IN ?", characterIDs, characterIDs).
+		Find(&relationships).Error
+	return relationships, err
+}
+
+// Update 関係を更新（バージョンが一致しない場合は ErrVersionConflict）
 func (r *relationshipRepository) Update(relationship *models.Relationship) error {
 	// IDの順序を保証（小さいIDをCharacter1IDに）
 	if relationship.Character1ID > relationship.Character2ID {
 		relationship.Character1ID, relationship.Character2ID = relationship.Character2ID, relationship.Character1ID
 	}
 	
-	return r.db.Save(relationship).Error
+	return updateVersioned(r.scoped(), relationship, &relationship.Version)
 }
 
 // Delete 関係を削除
 func (r *relationshipRepository) Delete(id string) error {
-	return r.db.Delete(&models.Relationship{}, "id = ?", id).Error
+	return r.scoped().Delete(&models.Relationship{}, "id = ?", id).Error
 }
 
 // ExistsByID 関係が存在するかチェック
 func (r *relationshipRepository) ExistsByID(id string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Relationship{}).Where("id = ?", id).Count(&count).Error
+	err := r.scoped().Model(&models.Relationship{}).Where("id = ?", id).Count(&count).Error
 	return count > 0, err
 }
 
@@ -106,7 +135,7 @@ func (r *relationshipRepository) ExistsBetweenCharacters(character1ID, character
 	}
 	
 	var count int64
-	err := r.db.Model(&models.Relationship{}).
+	err := r.scoped().Model(&models.Relationship{}).
 		Where("character1_id = ? AND character2_id = ?", character1ID, character2ID).
 		Count(&count).Error
 	return count > 0, err
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"character-management-app/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SavedViewRepository 保存したビューリポジトリのインターフェース
+type SavedViewRepository interface {
+	Create(view *models.SavedView) error
+	GetByID(id string) (*models.SavedView, error)
+	GetAll() ([]models.SavedView, error)
+	Update(view *models.SavedView) error
+	Delete(id string) error
+	ExistsByName(name string) (bool, error)
+	WithWorkspace(workspaceID string) SavedViewRepository
+}
+
+// savedViewRepository 保存したビューリポジトリの実装
+type savedViewRepository struct {
+	db          *gorm.DB
+	workspaceID string
+}
+
+// NewSavedViewRepository 保存したビューリポジトリのコンストラクタ
+func NewSavedViewRepository(db *gorm.DB) SavedViewRepository {
+	return &savedViewRepository{db: db}
+}
+
+// WithWorkspace 指定したワークスペースに限定したリポジトリを返す
+func (r *savedViewRepository) WithWorkspace(workspaceID string) SavedViewRepository {
+	return &savedViewRepository{db: r.db, workspaceID: workspaceID}
+}
+
+// scoped ワークスペース条件を付与したクエリを返す
+func (r *savedViewRepository) scoped() *gorm.DB {
+	if r.workspaceID == "" {
+		return r.db
+	}
+	return r.db.Where("workspace_id = ?", r.workspaceID)
+}
+
+// Create ビューを作成
+func (r *savedViewRepository) Create(view *models.SavedView) error {
+	// UUIDを生成
+	view.ID = uuid.New().String()
+	if r.workspaceID != "" {
+		view.WorkspaceID = r.workspaceID
+	}
+	view.Version = 1
+
+	return r.db.Create(view).Error
+}
+
+// GetByID IDでビューを取得
+func (r *savedViewRepository) GetByID(id string) (*models.SavedView, error) {
+	var view models.SavedView
+	err := r.scoped().First(&view, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// GetAll 全てのビューを名前順に取得
+func (r *savedViewRepository) GetAll() ([]models.SavedView, error) {
+	var views []models.SavedView
+	err := r.scoped().Order("name").Find(&views).Error
+	return views, err
+}
+
+// Update ビューを更新（バージョンが一致しない場合は ErrVersionConflict）
+func (r *savedViewRepository) Update(view *models.SavedView) error {
+	if r.workspaceID != "" {
+		view.WorkspaceID = r.workspaceID
+	}
+	return updateVersioned(r.scoped(), view, &view.Version)
+}
+
+// Delete ビューを削除
+func (r *savedViewRepository) Delete(id string) error {
+	var view models.SavedView
+	if err := r.scoped().First(&view, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&view).Error
+}
+
+// ExistsByName 名前でビューが存在するかチェック
+func (r *savedViewRepository) ExistsByName(name string) (bool, error) {
+	var count int64
+	err := r.scoped().Model(&models.SavedView{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict 更新対象のバージョンが既に進んでいる場合のエラー
+var ErrVersionConflict = errors.New("version conflict")
+
+// updateVersioned バージョンが一致する場合のみレコードを更新し、バージョンを1つ進める
+// 不一致（他のリクエストが先に更新した）の場合は ErrVersionConflict を返す
+func updateVersioned(db *gorm.DB, value interface{}, version *uint) error {
+	expected := *version
+	*version = expected + 1
+
+	result := db.Model(value).Where("version = ?", expected).
+		Select("*").Omit(clause.Associations, "created_at").
+		Updates(value)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = expected
+		return result.Error
+	}
+	return nil
+}
+
+// touchVersion 関連の変更などでレコードのバージョンだけを進める
+func touchVersion(db *gorm.DB, model interface{}, id string) error {
+	return db.Model(model).Where("id = ?", id).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"character-management-app/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkspaceRepository ワークスペースリポジトリのインターフェース
+type WorkspaceRepository interface {
+	Create(workspace *models.Workspace) error
+	GetByID(id string) (*models.Workspace, error)
+	GetBySlug(slug string) (*models.Workspace, error)
+	GetAll() ([]models.Workspace, error)
+	ExistsBySlug(slug string) (bool, error)
+}
+
+// workspaceRepository ワークスペースリポジトリの実装
+type workspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository ワークスペースリポジトリのコンストラクタ
+func NewWorkspaceRepository(db *gorm.DB) WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+// Create ワークスペースを作成
+func (r *workspaceRepository) Create(workspace *models.Workspace) error {
+	// UUIDを生成
+	workspace.ID = uuid.New().String()
+	workspace.Version = 1
+
+	return r.db.Create(workspace).Error
+}
+
+// GetByID IDでワークスペースを取得
+func (r *workspaceRepository) GetByID(id string) (*models.Workspace, error) {
+	var workspace models.Workspace
+	err := r.db.First(&workspace, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// GetBySlug スラッグでワークスペースを取得
+func (r *workspaceRepository) GetBySlug(slug string) (*models.Workspace, error) {
+	var workspace models.Workspace
+	err := r.db.First(&workspace, "slug = ?", slug).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// GetAll 全てのワークスペースを取得
+func (r *workspaceRepository) GetAll() ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.Order("slug").Find(&workspaces).Error
+	return workspaces, err
+}
+
+// ExistsBySlug スラッグでワークスペースが存在するかチェック
+func (r *workspaceRepository) ExistsBySlug(slug string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Workspace{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+// groupIDsInWorkspace ワークスペースに属するグループIDのサブクエリ
+func groupIDsInWorkspace(db *gorm.DB, workspaceID string) *gorm.DB {
+	return db.Model(&models.Group{}).Select("id").Where("workspace_id = ?", workspaceID)
+}
@@ -0,0 +1,178 @@
+// Package router は API のルーティングを組み立てる
+package router
+
+import (
+	"character-management-app/internal/handlers"
+	"character-management-app/internal/middleware"
+	"character-management-app/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config ルーターの構築に必要なハンドラーと設定
+type Config struct {
+	WorkspaceHandler     *handlers.WorkspaceHandler
+	GroupHandler         *handlers.GroupHandler
+	CharacterHandler     *handlers.CharacterHandler
+	LabelHandler         *handlers.LabelHandler
+	LabelCategoryHandler *handlers.LabelCategoryHandler
+	RelationshipHandler  *handlers.RelationshipHandler
+	SavedViewHandler     *handlers.SavedViewHandler
+	UploadHandler        *handlers.UploadHandler
+	AdminHandler         *handlers.AdminHandler
+
+	// ResolveWorkspace ワークスペースのスラッグからIDを解決する
+	ResolveWorkspace middleware.WorkspaceResolver
+	// WorkspaceBaseDomain サブドメインでワークスペースを解決する場合のベースドメイン
+	WorkspaceBaseDomain string
+	// RequireIfMatch 更新・削除で If-Match を必須にする
+	RequireIfMatch bool
+	// HealthCheck データベース接続の確認
+	HealthCheck func() error
+	// AdminToken 管理用エンドポイントの Bearer トークン（空の場合は管理用エンドポイントを無効にする）
+	AdminToken string
+	// MaxBodySize リクエストボディの上限バイト数（0 以下の場合は制限しない）
+	MaxBodySize int64
+}
+
+// New ミドルウェアと全てのルートを登録したエンジンを作成
+// ルートを追加した場合は internal/openapi/openapi.json にも記述する
+func New(cfg Config) *gin.Engine {
+	r := gin.Default()
+
+	// ミドルウェアの設定
+	r.Use(middleware.CORS())
+	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.BodyLimit(cfg.MaxBodySize))
+
+	// 404と405のハンドラー設定
+	r.NoRoute(middleware.NotFoundHandler())
+	r.NoMethod(middleware.MethodNotAllowedHandler())
+
+	// ヘルスチェックエンドポイント
+	r.GET("/health", func(c *gin.Context) {
+		if err := cfg.HealthCheck(); err != nil {
+			c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"status": "ok", "message": "Database connection is healthy"})
+	})
+
+	// API仕様とドキュメント
+	r.GET("/api/openapi.json", openapi.SpecHandler())
+	r.GET("/api/docs", openapi.DocsHandler())
+
+	// ルートの設定
+	api := r.Group("/api/v1")
+	{
+		// ワークスペース関連のルート（テナント解決の対象外）
+		workspaces := api.Group("/workspaces")
+		{
+			workspaces.GET("", cfg.WorkspaceHandler.GetWorkspaces)
+			workspaces.POST("", cfg.WorkspaceHandler.CreateWorkspace)
+			workspaces.GET("/:slug", cfg.WorkspaceHandler.GetWorkspace)
+		}
+
+		// 管理用のルート（全てのワークスペースが対象）
+		admin := api.Group("/admin", middleware.AdminToken(cfg.AdminToken))
+		{
+			admin.POST("/uploads/gc", cfg.AdminHandler.CollectUploads)
+		}
+
+		// 以降のルートはヘッダーまたはサブドメインで指定されたワークスペースに限定
+		scoped := api.Group("", middleware.Workspace(cfg.ResolveWorkspace, cfg.WorkspaceBaseDomain))
+
+		// 更新・削除で If-Match を必須にする（未設定時は指定された場合のみ検証）
+		if cfg.RequireIfMatch {
+			scoped.Use(middleware.RequireIfMatch())
+		}
+
+		// グループ関連のルート
+		groups := scoped.Group("/groups")
+		{
+			groups.GET("", cfg.GroupHandler.GetGroups)
+			groups.POST("", cfg.GroupHandler.CreateGroup)
+			groups.GET("/:id", cfg.GroupHandler.GetGroup)
+			groups.PUT("/:id", cfg.GroupHandler.UpdateGroup)
+			groups.PATCH("/:id", cfg.GroupHandler.PatchGroup)
+			groups.DELETE("/:id", cfg.GroupHandler.DeleteGroup)
+		}
+
+		// 人物関連のルート
+		characters := scoped.Group("/characters")
+		{
+			characters.GET("", cfg.CharacterHandler.GetCharacters)
+			characters.POST("", cfg.CharacterHandler.CreateCharacter)
+			characters.GET("/duplicates", cfg.CharacterHandler.FindDuplicateCharacters)
+			characters.GET("/:id", cfg.CharacterHandler.GetCharacter)
+			characters.PUT("/:id", cfg.CharacterHandler.UpdateCharacter)
+			characters.PATCH("/:id", cfg.CharacterHandler.PatchCharacter)
+			characters.DELETE("/:id", cfg.CharacterHandler.DeleteCharacter)
+			characters.PUT("/:id/photo/crop", cfg.CharacterHandler.CropCharacterPhoto)
+			characters.GET("/:id/images", cfg.CharacterHandler.GetCharacterImages)
+			characters.POST("/:id/images", cfg.CharacterHandler.AddCharacterImage)
+			characters.PUT("/:id/images/order", cfg.CharacterHandler.ReorderCharacterImages)
+			characters.GET("/:id/images/:imageId", cfg.CharacterHandler.GetCharacterImage)
+			characters.PATCH("/:id/images/:imageId", cfg.CharacterHandler.UpdateCharacterImage)
+			characters.DELETE("/:id/images/:imageId", cfg.CharacterHandler.DeleteCharacterImage)
+			characters.PUT("/:id/labels", cfg.CharacterHandler.ReplaceCharacterLabels)
+			characters.POST("/:id/labels/:labelId", cfg.CharacterHandler.AddLabelToCharacter)
+			characters.DELETE("/:id/labels/:labelId", cfg.CharacterHandler.RemoveLabelFromCharacter)
+		}
+
+		// ラベル関連のルート
+		labels := scoped.Group("/labels")
+		{
+			labels.GET("", cfg.LabelHandler.GetLabels)
+			labels.POST("", cfg.LabelHandler.CreateLabel)
+			labels.GET("/tree", cfg.LabelHandler.GetLabelTree)
+			labels.GET("/suggest-color", cfg.LabelHandler.SuggestLabelColor)
+			labels.GET("/:id", cfg.LabelHandler.GetLabel)
+			labels.PUT("/:id", cfg.LabelHandler.UpdateLabel)
+			labels.PATCH("/:id", cfg.LabelHandler.PatchLabel)
+			labels.DELETE("/:id", cfg.LabelHandler.DeleteLabel)
+			labels.POST("/:id/assign", cfg.CharacterHandler.AssignLabel)
+			labels.POST("/:id/merge-into/:targetId", cfg.CharacterHandler.MergeLabel)
+		}
+
+		// ラベルの分類関連のルート
+		labelCategories := scoped.Group("/label-categories")
+		{
+			labelCategories.GET("", cfg.LabelCategoryHandler.GetLabelCategories)
+			labelCategories.POST("", cfg.LabelCategoryHandler.CreateLabelCategory)
+			labelCategories.GET("/:id", cfg.LabelCategoryHandler.GetLabelCategory)
+			labelCategories.PUT("/:id", cfg.LabelCategoryHandler.UpdateLabelCategory)
+			labelCategories.PATCH("/:id", cfg.LabelCategoryHandler.PatchLabelCategory)
+			labelCategories.DELETE("/:id", cfg.LabelCategoryHandler.DeleteLabelCategory)
+		}
+
+		// 関係関連のルート
+		relationships := scoped.Group("/relationships")
+		{
+			relationships.GET("", cfg.RelationshipHandler.GetRelationships)
+			relationships.POST("", cfg.RelationshipHandler.CreateRelationship)
+			relationships.GET("/:id", cfg.RelationshipHandler.GetRelationship)
+			relationships.PUT("/:id", cfg.RelationshipHandler.UpdateRelationship)
+			relationships.PATCH("/:id", cfg.RelationshipHandler.PatchRelationship)
+			relationships.DELETE("/:id", cfg.RelationshipHandler.DeleteRelationship)
+		}
+
+		// 保存したビュー関連のルート（ビューのIDは groupId の代わりにも使える）
+		savedViews := scoped.Group("/saved-views")
+		{
+			savedViews.GET("", cfg.SavedViewHandler.GetSavedViews)
+			savedViews.POST("", cfg.SavedViewHandler.CreateSavedView)
+			savedViews.GET("/:id", cfg.SavedViewHandler.GetSavedView)
+			savedViews.PUT("/:id", cfg.SavedViewHandler.UpdateSavedView)
+			savedViews.PATCH("/:id", cfg.SavedViewHandler.PatchSavedView)
+			savedViews.DELETE("/:id", cfg.SavedViewHandler.DeleteSavedView)
+			savedViews.GET("/:id/characters", cfg.SavedViewHandler.GetSavedViewCharacters)
+		}
+	}
+
+	// アップロード画像の配信（保存先のストレージから読み出す）
+	r.GET("/uploads/*filepath", cfg.UploadHandler.GetUpload)
+	r.HEAD("/uploads/*filepath", cfg.UploadHandler.GetUpload)
+
+	return r
+}
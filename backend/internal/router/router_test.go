@@ -0,0 +1,162 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"character-management-app/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ginParam gin のパスパラメーター（:id, *filepath）
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+type specDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func setupRouter(t *testing.T) (*gin.Engine, specDocument) {
+	gin.SetMode(gin.TestMode)
+	engine := New(Config{
+		RequireIfMatch: true,
+		HealthCheck:    func() error { return nil },
+	})
+
+	var spec specDocument
+	require.NoError(t, json.Unmarshal(openapi.Spec, &spec))
+	return engine, spec
+}
+
+// registeredOperations エンジンに登録されたルートを "METHOD /path/{param}" の形式で返す
+func registeredOperations(engine *gin.Engine) []string {
+	var operations []string
+	for _, route := range engine.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		operations = append(operations, route.Method+" "+path)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// documentedOperations 仕様に記述された操作を "METHOD /path" の形式で返す
+func documentedOperations(spec specDocument) []string {
+	var operations []string
+	for path, item := range spec.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+				operations = append(operations, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	t.Run("OpenAPI 3.1 のドキュメント", func(t *testing.T) {
+		_, spec := setupRouter(t)
+		assert.Equal(t, "3.1.0", spec.OpenAPI)
+	})
+
+	t.Run("登録された全てのルートが仕様に記述されている", func(t *testing.T) {
+		engine, spec := setupRouter(t)
+		documented := documentedOperations(spec)
+
+		for _, operation := range registeredOperations(engine) {
+			assert.Contains(t, documented, operation, "route is not described in internal/openapi/openapi.json")
+		}
+	})
+
+	t.Run("仕様に存在しないルートが記述されていない", func(t *testing.T) {
+		engine, spec := setupRouter(t)
+		registered := registeredOperations(engine)
+
+		for _, operation := range documentedOperations(spec) {
+			assert.Contains(t, registered, operation, "operation in internal/openapi/openapi.json is not registered")
+		}
+	})
+
+	t.Run("$ref の参照先が全て存在する", func(t *testing.T) {
+		var document map[string]interface{}
+		require.NoError(t, json.Unmarshal(openapi.Spec, &document))
+
+		refs := regexp.MustCompile(`"\$ref":\s*"#/([^"]+)"`).FindAllStringSubmatch(string(openapi.Spec), -1)
+		require.NotEmpty(t, refs)
+		for _, ref := range refs {
+			var node interface{} = document
+			for _, key := range strings.Split(ref[1], "/") {
+				object, ok := node.(map[string]interface{})
+				require.True(t, ok, "invalid reference: %s", ref[1])
+				node = object[key]
+			}
+			assert.NotNil(t, node, "unresolved reference: %s", ref[1])
+		}
+	})
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	t.Run("仕様を JSON で返す", func(t *testing.T) {
+		engine, _ := setupRouter(t)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.JSONEq(t, string(openapi.Spec), w.Body.String())
+	})
+
+	t.Run("ドキュメントページを返す", func(t *testing.T) {
+		engine, _ := setupRouter(t)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/docs", nil)
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "openapi.json")
+	})
+}
+
+func TestAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("トークン未設定の場合は無効", func(t *testing.T) {
+		engine := New(Config{HealthCheck: func() error { return nil }})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/uploads/gc", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("トークンが一致しない場合は認証エラー", func(t *testing.T) {
+		engine := New(Config{HealthCheck: func() error { return nil }, AdminToken: "secret"})
+
+		for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/uploads/gc", nil)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, authorization)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CharacterDuplicate 名前・別名が一致する同じグループの人物
+type CharacterDuplicate struct {
+	// Names 一致した名前（正規化後）
+	Names      []string           `json:"names"`
+	Characters []models.Character `json:"characters"`
+}
+
+// normalizeName 照合・並び替え用に名前を正規化する
+// NFKC で全角英数字・半角カナを揃え、カタカナをひらがなに、英字を小文字にして空白と中黒を除く
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(norm.NFKC.String(name)) {
+		switch {
+		case unicode.IsSpace(r) || r == '・':
+			continue
+		case r >= 'ァ' && r <= 'ヶ':
+			r -= 'ァ' - 'ぁ'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// readingKey 並び替えに使う読み（読みの別名がなければ名前）
+func readingKey(character *models.Character) string {
+	for _, alias := range character.Aliases {
+		if alias.Type == models.AliasTypeReading {
+			return normalizeName(alias.Name)
+		}
+	}
+	return normalizeName(character.Name)
+}
+
+// sortCharactersByReading 人物を読みの五十音順に並べる（読みが同じ場合は名前・IDの順）
+func sortCharactersByReading(characters []models.Character) {
+	keys := make(map[string]string, len(characters))
+	for i := range characters {
+		keys[characters[i].ID] = readingKey(&characters[i])
+	}
+	sort.SliceStable(characters, func(i, j int) bool {
+		a, b := &characters[i], &characters[j]
+		if keys[a.ID] != keys[b.ID] {
+			return keys[a.ID] < keys[b.ID]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+}
+
+// findDuplicates 同じグループで名前・別名（正規化後）が一致する人物をまとめる
+// 名前が「信長」の人物と別名に「信長」を持つ人物、さらにその別名と一致する人物も同じまとまりになる
+func findDuplicates(characters []models.Character) []CharacterDuplicate {
+	parent := make([]int, len(characters))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// グループ・正規化した名前ごとに最初の人物と結ぶ
+	type nameKey struct{ groupID, name string }
+	first := make(map[nameKey]int)
+	shared := make(map[nameKey]bool)
+	for i := range characters {
+		names := []string{characters[i].Name}
+		for _, alias := range characters[i].Aliases {
+			names = append(names, alias.Name)
+		}
+		seen := make(map[string]bool)
+		for _, name := range names {
+			normalized := normalizeName(name)
+			if normalized == "" || seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+			key := nameKey{characters[i].GroupID, normalized}
+			if j, ok := first[key]; ok {
+				parent[find(i)] = find(j)
+				shared[key] = true
+				continue
+			}
+			first[key] = i
+		}
+	}
+
+	clusters := make(map[int]*CharacterDuplicate)
+	var roots []int
+	for i := range characters {
+		root := find(i)
+		cluster, ok := clusters[root]
+		if !ok {
+			cluster = &CharacterDuplicate{}
+			clusters[root] = cluster
+			roots = append(roots, root)
+		}
+		cluster.Characters = append(cluster.Characters, characters[i])
+	}
+	for key := range shared {
+		root := find(first[key])
+		clusters[root].Names = append(clusters[root].Names, key.name)
+	}
+
+	var duplicates []CharacterDuplicate
+	for _, root := range roots {
+		cluster := clusters[root]
+		if len(cluster.Characters) < 2 {
+			continue
+		}
+		sort.Strings(cluster.Names)
+		sortCharactersByReading(cluster.Characters)
+		duplicates = append(duplicates, *cluster)
+	}
+	// 各まとまりの先頭の人物の読み順
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return readingKey(&duplicates[i].Characters[0]) < readingKey(&duplicates[j].Characters[0])
+	})
+	return duplicates
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"カタカナはひらがなにする", "オダ ノブナガ", "おだのぶなが"},
+		{"半角カナ", "ｵﾀﾞ ﾉﾌﾞﾅｶﾞ", "おだのぶなが"},
+		{"中黒と空白を除く", "ルイス・フロイス", "るいすふろいす"},
+		{"全角英字は半角の小文字にする", "Ｏｄａ　Ｎｏｂｕｎａｇａ", "odanobunaga"},
+		{"漢字はそのまま", "織田信長", "織田信長"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeName(tt.input))
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"character-management-app/internal/linkpreview"
 	"character-management-app/internal/models"
+	"character-management-app/internal/query"
 	"character-management-app/internal/repositories"
 	"errors"
 	"fmt"
@@ -13,10 +15,83 @@ type CharacterService interface {
 	GetCharacterByID(id string) (*models.Character, error)
 	GetCharactersByGroupID(groupID string) ([]models.Character, error)
 	GetAllCharacters() ([]models.Character, error)
+	SearchCharacters(query CharacterQuery) ([]models.Character, error)
+	FindDuplicates(groupID string) ([]CharacterDuplicate, error)
 	UpdateCharacter(id string, character *models.Character) (*models.Character, error)
 	DeleteCharacter(id string) error
 	AddLabelToCharacter(characterID, labelID string) error
 	RemoveLabelFromCharacter(characterID, labelID string) error
+	ReplaceCharacterLabels(characterID string, labelIDs []string) (*models.Character, error)
+	AssignLabelToCharacters(labelID string, addIDs, removeIDs []string) ([]LabelAssignmentResult, error)
+	MergeLabel(sourceID, targetID string) (*LabelMergeResult, error)
+	GetCharacterImages(characterID string) ([]models.CharacterImage, error)
+	GetCharacterImage(characterID, imageID string) (*models.CharacterImage, error)
+	AddCharacterImage(characterID string, image *models.CharacterImage) (*models.CharacterImage, error)
+	UpdateCharacterImage(characterID string, image *models.CharacterImage) (*models.CharacterImage, error)
+	ReorderCharacterImages(characterID string, imageIDs []string) ([]models.CharacterImage, error)
+	DeleteCharacterImage(characterID, imageID string) (*models.CharacterImage, error)
+	MigrateRelatedLinks() (int, error)
+	WithWorkspace(workspaceID string) CharacterService
+}
+
+// CharacterQuery 人物の検索条件（空の条件は絞り込まない）
+type CharacterQuery struct {
+	// GroupID 所属するグループ
+	GroupID string
+	// LabelID 付いているラベル
+	LabelID string
+	// IncludeDescendants LabelID の子孫のラベルが付いている人物も含める
+	IncludeDescendants bool
+	// Q ラベル・人物名・グループの条件式（例: (武将 OR 公家) AND NOT 故人）
+	Q string
+}
+
+// LabelAssignmentResult ラベルの一括付け外しの人物ごとの結果
+type LabelAssignmentResult struct {
+	CharacterID string `json:"characterId"`
+	// Action add（付ける）または remove（外す）
+	Action string `json:"action"`
+	// Status added・removed・unchanged（既にその状態）・failed のいずれか
+	Status string `json:"status"`
+	// 失敗した場合のエラー（単体のラベルの追加と同じコード）
+	Code    string      `json:"code,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ラベルの一括付け外しの操作と結果
+const (
+	LabelActionAdd    = "add"
+	LabelActionRemove = "remove"
+
+	LabelStatusAdded     = "added"
+	LabelStatusRemoved   = "removed"
+	LabelStatusUnchanged = "unchanged"
+	LabelStatusFailed    = "failed"
+)
+
+// LabelMergeResult ラベルの統合の結果
+type LabelMergeResult struct {
+	// Target 統合先のラベル
+	Target *models.Label `json:"target"`
+	// Moved 統合先のラベルに付け替えた人物の数
+	Moved int `json:"moved"`
+	// Deduplicated 統合先のラベルが既に付いていた人物の数
+	Deduplicated int `json:"deduplicated"`
+}
+
+// DefaultLabelLimit 人物1人あたりのラベルの上限の既定値（CharacterOptions.LabelLimit が未設定の場合に使う）
+const DefaultLabelLimit = 5
+
+// MaxCharacterImages 人物1人あたりのギャラリーの画像の上限
+const MaxCharacterImages = 20
+
+// CharacterOptions 人物サービスの設定
+type CharacterOptions struct {
+	// LabelLimit 人物1人あたりのラベルの上限（グループの labelLimit が未設定の場合に使う。0 以下の場合は DefaultLabelLimit）
+	LabelLimit int
+	// LinkFetcher 関連リンクのタイトルとファビコンを取得する（nil の場合は取得しない）
+	LinkFetcher linkpreview.Fetcher
 }
 
 // characterService 人物サービスの実装
@@ -24,14 +99,32 @@ type characterService struct {
 	characterRepo repositories.CharacterRepository
 	groupRepo     repositories.GroupRepository
 	labelRepo     repositories.LabelRepository
+	viewRepo      repositories.SavedViewRepository
+	options       CharacterOptions
 }
 
 // NewCharacterService 人物サービスのコンストラクタ
-func NewCharacterService(characterRepo repositories.CharacterRepository, groupRepo repositories.GroupRepository, labelRepo repositories.LabelRepository) CharacterService {
+func NewCharacterService(characterRepo repositories.CharacterRepository, groupRepo repositories.GroupRepository, labelRepo repositories.LabelRepository, viewRepo repositories.SavedViewRepository, options CharacterOptions) CharacterService {
+	if options.LabelLimit <= 0 {
+		options.LabelLimit = DefaultLabelLimit
+	}
 	return &characterService{
 		characterRepo: characterRepo,
 		groupRepo:     groupRepo,
 		labelRepo:     labelRepo,
+		viewRepo:      viewRepo,
+		options:       options,
+	}
+}
+
+// WithWorkspace 指定したワークスペースに限定したサービスを返す
+func (s *characterService) WithWorkspace(workspaceID string) CharacterService {
+	return &characterService{
+		characterRepo: s.characterRepo.WithWorkspace(workspaceID),
+		groupRepo:     s.groupRepo.WithWorkspace(workspaceID),
+		labelRepo:     s.labelRepo.WithWorkspace(workspaceID),
+		viewRepo:      s.viewRepo.WithWorkspace(workspaceID),
+		options:       s.options,
 	}
 }
 
@@ -43,8 +136,14 @@ func (s *characterService) CreateCharacter(character *models.Character) (*models
 		return nil, fmt.Errorf("failed to check group existence: %w", err)
 	}
 	if !exists {
-		return nil, errors.New("group not found")
+		return nil, errGroupNotFound()
+	}
+
+	// 関連リンクを検証し、リンク先のタイトルとファビコンを補う
+	if err := normalizeRelatedLinks(character.RelatedLinks, nil); err != nil {
+		return nil, err
 	}
+	enrichRelatedLinks(s.options.LinkFetcher, character.RelatedLinks, nil)
 
 	// 人物を作成
 	if err := s.characterRepo.Create(character); err != nil {
@@ -59,12 +158,12 @@ func (s *characterService) CreateCharacter(character *models.Character) (*models
 func (s *characterService) GetCharacterByID(id string) (*models.Character, error) {
 	character, err := s.characterRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get character: %w", err)
+		return nil, lookupError(err, errCharacterNotFound(), "failed to get character")
 	}
 	return character, nil
 }
 
-// GetCharactersByGroupID グループIDで人物を取得
+// GetCharactersByGroupID グループIDで人物を取得（保存したビューのIDの場合は条件式に一致する人物）
 func (s *characterService) GetCharactersByGroupID(groupID string) ([]models.Character, error) {
 	// グループの存在確認
 	exists, err := s.groupRepo.ExistsByID(groupID)
@@ -72,31 +171,151 @@ func (s *characterService) GetCharactersByGroupID(groupID string) ([]models.Char
 		return nil, fmt.Errorf("failed to check group existence: %w", err)
 	}
 	if !exists {
-		return nil, errors.New("group not found")
+		expr, err := savedViewQuery(s.viewRepo, groupID)
+		if err != nil {
+			return nil, err
+		}
+		if expr == nil {
+			return nil, errGroupNotFound()
+		}
+		characters, err := s.characterRepo.Find(repositories.CharacterFilter{Query: expr})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get characters by saved view: %w", err)
+		}
+		sortCharactersByReading(characters)
+		return characters, nil
 	}
 
 	characters, err := s.characterRepo.GetByGroupID(groupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get characters by group: %w", err)
 	}
+	sortCharactersByReading(characters)
 	return characters, nil
 }
 
-// GetAllCharacters 全ての人物を取得
+// GetAllCharacters 全ての人物を読みの五十音順に取得
 func (s *characterService) GetAllCharacters() ([]models.Character, error) {
 	characters, err := s.characterRepo.GetAll()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all characters: %w", err)
 	}
+	sortCharactersByReading(characters)
 	return characters, nil
 }
 
+// SearchCharacters 条件に一致する人物を取得（条件は全て満たす必要がある）
+// IncludeDescendants の場合は「武将」で「大名」など子孫のラベルが付いた人物も含める
+func (s *characterService) SearchCharacters(criteria CharacterQuery) ([]models.Character, error) {
+	filter := repositories.CharacterFilter{GroupID: criteria.GroupID}
+
+	if criteria.LabelID != "" {
+		labels, err := s.labelRepo.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get labels: %w", err)
+		}
+		found := false
+		for _, label := range labels {
+			found = found || label.ID == criteria.LabelID
+		}
+		if !found {
+			return nil, errLabelNotFound()
+		}
+
+		filter.LabelIDs = []string{criteria.LabelID}
+		if criteria.IncludeDescendants {
+			filter.LabelIDs = labelDescendants(labels, criteria.LabelID)
+		}
+	}
+
+	if criteria.Q != "" {
+		expr, err := parseCharacterQuery(criteria.Q)
+		if err != nil {
+			return nil, err
+		}
+		filter.Query = expr
+	}
+
+	// GroupID はグループか保存したビュー（GetCharactersByGroupID と同じく、どちらでもない場合は404）
+	// 保存したビューの場合はビューの条件式も満たす人物に絞り込む
+	if criteria.GroupID != "" {
+		exists, err := s.groupRepo.ExistsByID(criteria.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check group existence: %w", err)
+		}
+		if !exists {
+			viewExpr, err := savedViewQuery(s.viewRepo, criteria.GroupID)
+			if err != nil {
+				return nil, err
+			}
+			if viewExpr == nil {
+				return nil, errGroupNotFound()
+			}
+			filter.GroupID = ""
+			if filter.Query != nil {
+				viewExpr = &query.And{Left: viewExpr, Right: filter.Query}
+			}
+			filter.Query = viewExpr
+		}
+	}
+
+	characters, err := s.characterRepo.Find(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search characters: %w", err)
+	}
+	sortCharactersByReading(characters)
+	return characters, nil
+}
+
+// FindDuplicates 名前・別名が一致する同じグループの人物をまとめて取得（groupID が空の場合は全てのグループ）
+func (s *characterService) FindDuplicates(groupID string) ([]CharacterDuplicate, error) {
+	var characters []models.Character
+	if groupID != "" {
+		exists, err := s.groupRepo.ExistsByID(groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check group existence: %w", err)
+		}
+		if !exists {
+			return nil, errGroupNotFound()
+		}
+		characters, err = s.characterRepo.GetByGroupID(groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get characters by group: %w", err)
+		}
+	} else {
+		var err error
+		characters, err = s.characterRepo.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get all characters: %w", err)
+		}
+	}
+
+	duplicates := findDuplicates(characters)
+	if duplicates == nil {
+		duplicates = []CharacterDuplicate{}
+	}
+	return duplicates, nil
+}
+
+// parseCharacterQuery 条件式を解析する（構文エラーは位置を含む検証エラーにする）
+func parseCharacterQuery(q string) (query.Expr, error) {
+	expr, err := query.Parse(q)
+	var syntaxError *query.SyntaxError
+	if errors.As(err, &syntaxError) {
+		return nil, NewValidationError("INVALID_QUERY_SYNTAX", syntaxError.Error(), map[string]interface{}{
+			"position": syntaxError.Position,
+			"reason":   syntaxError.Message,
+		})
+	}
+	return expr, err
+}
+
 // UpdateCharacter 人物を更新
 func (s *characterService) UpdateCharacter(id string, character *models.Character) (*models.Character, error) {
 	// 既存の人物を取得
 	existing, err := s.characterRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get existing character: %w", err)
+		return nil, lookupError(err, errCharacterNotFound(), "failed to get existing character")
 	}
 
 	// グループIDが変更される場合は、新しいグループの存在確認
@@ -106,14 +325,37 @@ func (s *characterService) UpdateCharacter(id string, character *models.Characte
 			return nil, fmt.Errorf("failed to check group existence: %w", err)
 		}
 		if !exists {
-			return nil, errors.New("group not found")
+			return nil, errGroupNotFound()
+		}
+
+		// 元のグループ専用のラベルが付いている場合は移動できない
+		var mismatched []string
+		for _, label := range existing.Labels {
+			if label.GroupID != nil && *label.GroupID != character.GroupID {
+				mismatched = append(mismatched, label.ID)
+			}
+		}
+		if len(mismatched) > 0 {
+			return nil, errLabelGroupMismatch(mismatched)
 		}
 	}
 
+	// 関連リンクを検証し、新しく追加したリンクのタイトルとファビコンを補う
+	known := relatedLinkURLs(existing.RelatedLinks)
+	if err := normalizeRelatedLinks(character.RelatedLinks, known); err != nil {
+		return nil, err
+	}
+	enrichRelatedLinks(s.options.LinkFetcher, character.RelatedLinks, known)
+
 	// IDと作成日時は変更しない
 	character.ID = existing.ID
 	character.CreatedAt = existing.CreatedAt
 
+	// 期待するバージョンの指定がなければ現在のバージョンに対して更新する
+	if character.Version == 0 {
+		character.Version = existing.Version
+	}
+
 	// 人物を更新
 	if err := s.characterRepo.Update(character); err != nil {
 		return nil, fmt.Errorf("failed to update character: %w", err)
@@ -131,7 +373,7 @@ func (s *characterService) DeleteCharacter(id string) error {
 		return fmt.Errorf("failed to check character existence: %w", err)
 	}
 	if !exists {
-		return errors.New("character not found")
+		return errCharacterNotFound()
 	}
 
 	// 人物を削除
@@ -143,49 +385,90 @@ func (s *characterService) DeleteCharacter(id string) error {
 }
 
 // AddLabelToCharacter 人物にラベルを追加
+// ラベルの数はグループの labelLimit（未設定の場合は LabelLimit の設定）まで、分類のあるラベルは分類ごとの上限までに制限する
 func (s *characterService) AddLabelToCharacter(characterID, labelID string) error {
-	// 人物の存在確認
-	exists, err := s.characterRepo.ExistsByID(characterID)
+	// ラベルの存在確認
+	label, err := s.labelRepo.GetByID(labelID)
 	if err != nil {
-		return fmt.Errorf("failed to check character existence: %w", err)
-	}
-	if !exists {
-		return errors.New("character not found")
+		return lookupError(err, errLabelNotFound(), "failed to get label")
 	}
 
-	// ラベルの存在確認
-	exists, err = s.labelRepo.ExistsByID(labelID)
+	// 確認は追加と同じトランザクションで、ロックして読み直した人物に対して行う
+	err = s.characterRepo.AddLabel(characterID, labelID, func(character *models.Character) error {
+		return s.checkAddedLabel(character, label)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to check label existence: %w", err)
-	}
-	if !exists {
-		return errors.New("label not found")
+		var appError *Error
+		if errors.As(err, &appError) {
+			return appError
+		}
+		return lookupError(err, errCharacterNotFound(), "failed to add label to character")
 	}
+	return nil
+}
 
-	// 既に同じラベルが付いているかチェック
-	hasLabel, err := s.characterRepo.HasLabel(characterID, labelID)
-	if err != nil {
-		return fmt.Errorf("failed to check if character has label: %w", err)
+// checkAddedLabel 人物に label を追加できるか、グループ・重複・ラベル数と分類ごとの上限を確認する
+func (s *characterService) checkAddedLabel(character *models.Character, label *models.Label) error {
+	// グループ専用のラベルは同じグループの人物にのみ付けられる
+	if label.GroupID != nil && *label.GroupID != character.GroupID {
+		return errLabelGroupMismatch([]string{label.ID})
 	}
-	if hasLabel {
-		return errors.New("character already has this label")
+	if hasLabel(character, label.ID) {
+		return NewConflictError("LABEL_ALREADY_ASSIGNED", "character already has this label")
 	}
+	return s.checkLabelLimits(character, label, len(character.Labels))
+}
 
-	// ラベル数の制限チェック（最大5つ）
-	count, err := s.characterRepo.GetLabelsCount(characterID)
-	if err != nil {
-		return fmt.Errorf("failed to get labels count: %w", err)
+// checkLabelLimits count 個のラベルが付いている人物に label を追加できるか、ラベル数と分類ごとの上限を確認する
+func (s *characterService) checkLabelLimits(character *models.Character, label *models.Label, count int) error {
+	limit := s.labelLimit(character)
+	if count >= limit {
+		return errLabelLimitExceeded(limit)
 	}
-	if count >= 5 {
-		return errors.New("character cannot have more than 5 labels")
+
+	// 分類ごとの上限チェック（陣営は1つまで、など）
+	if category := label.Category; category != nil {
+		if countInCategory(character.Labels, category.ID) >= category.MaxPerCharacter {
+			return errLabelCategoryLimitExceeded(category)
+		}
 	}
+	return nil
+}
 
-	// ラベルを追加
-	if err := s.characterRepo.AddLabel(characterID, labelID); err != nil {
-		return fmt.Errorf("failed to add label to character: %w", err)
+// labelLimit 人物に付けられるラベルの数の上限（グループの labelLimit、未設定の場合は LabelLimit の設定）
+func (s *characterService) labelLimit(character *models.Character) int {
+	if character.Group.LabelLimit != nil {
+		return *character.Group.LabelLimit
 	}
+	return s.options.LabelLimit
+}
 
-	return nil
+// countInCategory 分類に属するラベルの数
+func countInCategory(labels []models.Label, categoryID string) int {
+	count := 0
+	for _, label := range labels {
+		if label.CategoryID != nil && *label.CategoryID == categoryID {
+			count++
+		}
+	}
+	return count
+}
+
+// errLabelLimitExceeded 人物のラベルの数の上限を超えるエラー
+func errLabelLimitExceeded(limit int) *Error {
+	return NewLimitExceededError("LABEL_LIMIT_EXCEEDED", fmt.Sprintf("character cannot have more than %d labels", limit), map[string]int{"limit": limit})
+}
+
+// errLabelCategoryLimitExceeded 分類ごとのラベルの数の上限を超えるエラー
+func errLabelCategoryLimitExceeded(category *models.LabelCategory) *Error {
+	return NewLimitExceededError("LABEL_CATEGORY_LIMIT_EXCEEDED",
+		fmt.Sprintf("character cannot have more than %d labels in category %q", category.MaxPerCharacter, category.Name),
+		map[string]interface{}{"limit": category.MaxPerCharacter, "categoryId": category.ID, "category": category.Name})
+}
+
+// errLabelGroupMismatch 他のグループ専用のラベルを人物に付けようとしたエラー
+func errLabelGroupMismatch(labelIDs []string) *Error {
+	return NewValidationError("LABEL_GROUP_MISMATCH", "label belongs to another group", map[string][]string{"labelIds": labelIDs})
 }
 
 // RemoveLabelFromCharacter 人物からラベルを削除
@@ -196,7 +479,7 @@ func (s *characterService) RemoveLabelFromCharacter(characterID, labelID string)
 		return fmt.Errorf("failed to check character existence: %w", err)
 	}
 	if !exists {
-		return errors.New("character not found")
+		return errCharacterNotFound()
 	}
 
 	// ラベルの存在確認
@@ -205,7 +488,7 @@ func (s *characterService) RemoveLabelFromCharacter(characterID, labelID string)
 		return fmt.Errorf("failed to check label existence: %w", err)
 	}
 	if !exists {
-		return errors.New("label not found")
+		return errLabelNotFound()
 	}
 
 	// ラベルを削除
@@ -214,4 +497,377 @@ func (s *characterService) RemoveLabelFromCharacter(characterID, labelID string)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// ReplaceCharacterLabels 人物のラベルを labelIDs に置き換える（重複した ID は1つとして扱う）
+// 置き換え後のラベルの組み合わせで、グループ専用のラベル・ラベル数・分類ごとの上限を確認する
+func (s *characterService) ReplaceCharacterLabels(characterID string, labelIDs []string) (*models.Character, error) {
+	labelIDs = uniqueIDs(labelIDs)
+	labels, err := s.labelRepo.GetByIDs(labelIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+	if len(labels) != len(labelIDs) {
+		return nil, errLabelNotFound()
+	}
+
+	// 確認は置き換えと同じトランザクションで、ロックして読み直した人物に対して行う
+	err = s.characterRepo.ReplaceLabels(characterID, labelIDs, func(character *models.Character) error {
+		return s.checkReplacedLabels(character, labels)
+	})
+	if err != nil {
+		var appError *Error
+		if errors.As(err, &appError) {
+			return nil, appError
+		}
+		return nil, lookupError(err, errCharacterNotFound(), "failed to replace character labels")
+	}
+	return s.GetCharacterByID(characterID)
+}
+
+// checkReplacedLabels 人物のラベルを labels に置き換えられるか、グループ専用のラベル・ラベル数・分類ごとの上限を確認する
+func (s *characterService) checkReplacedLabels(character *models.Character, labels []models.Label) error {
+	// グループ専用のラベルは同じグループの人物にのみ付けられる
+	var mismatched []string
+	for _, label := range labels {
+		if label.GroupID != nil && *label.GroupID != character.GroupID {
+			mismatched = append(mismatched, label.ID)
+		}
+	}
+	if len(mismatched) > 0 {
+		return errLabelGroupMismatch(mismatched)
+	}
+
+	if limit := s.labelLimit(character); len(labels) > limit {
+		return errLabelLimitExceeded(limit)
+	}
+	for i := range labels {
+		category := labels[i].Category
+		if category != nil && countInCategory(labels, category.ID) > category.MaxPerCharacter {
+			return errLabelCategoryLimitExceeded(category)
+		}
+	}
+	return nil
+}
+
+// AssignLabelToCharacters ラベルを addIDs の人物に付け、removeIDs の人物から外す
+// 人物ごとに結果を返し、付け外しできる人物の変更だけを1つのトランザクションでまとめて行う
+func (s *characterService) AssignLabelToCharacters(labelID string, addIDs, removeIDs []string) ([]LabelAssignmentResult, error) {
+	label, err := s.labelRepo.GetByID(labelID)
+	if err != nil {
+		return nil, lookupError(err, errLabelNotFound(), "failed to get label")
+	}
+
+	addIDs, removeIDs = uniqueIDs(addIDs), uniqueIDs(removeIDs)
+	if both := intersectIDs(addIDs, removeIDs); len(both) > 0 {
+		return nil, NewValidationError("LABEL_ASSIGNMENT_CONFLICT", "characters cannot be in both add and remove", map[string][]string{"characterIds": both})
+	}
+
+	if len(addIDs)+len(removeIDs) == 0 {
+		return []LabelAssignmentResult{}, nil
+	}
+
+	// 確認は付け外しと同じトランザクションで、ロックして読み直した人物に対して行う
+	// （同時に付けても上限を超えたり、同じラベルが重複したりしない）
+	var results []LabelAssignmentResult
+	plan := func(characters []models.Character) (added, removed []string) {
+		results = s.planLabelAssignments(label, characters, addIDs, removeIDs)
+		for _, result := range results {
+			switch result.Status {
+			case LabelStatusAdded:
+				added = append(added, result.CharacterID)
+			case LabelStatusRemoved:
+				removed = append(removed, result.CharacterID)
+			}
+		}
+		return added, removed
+	}
+	characterIDs := append(append([]string{}, addIDs...), removeIDs...)
+	if err := s.characterRepo.UpdateLabelAssignments(labelID, characterIDs, plan); err != nil {
+		return nil, fmt.Errorf("failed to update label assignments: %w", err)
+	}
+	return results, nil
+}
+
+// planLabelAssignments 読み込んだ人物ごとに、ラベルを付ける・外す結果を決める
+func (s *characterService) planLabelAssignments(label *models.Label, characters []models.Character, addIDs, removeIDs []string) []LabelAssignmentResult {
+	byID := make(map[string]*models.Character, len(characters))
+	for i := range characters {
+		byID[characters[i].ID] = &characters[i]
+	}
+
+	results := make([]LabelAssignmentResult, 0, len(addIDs)+len(removeIDs))
+	for _, characterID := range addIDs {
+		result := LabelAssignmentResult{CharacterID: characterID, Action: LabelActionAdd}
+		character, ok := byID[characterID]
+		switch {
+		case !ok:
+			result.fail(errCharacterNotFound())
+		case hasLabel(character, label.ID):
+			result.Status = LabelStatusUnchanged
+		case label.GroupID != nil && *label.GroupID != character.GroupID:
+			result.fail(errLabelGroupMismatch([]string{label.ID}))
+		default:
+			if err := s.checkLabelLimits(character, label, len(character.Labels)); err != nil {
+				result.fail(err)
+				break
+			}
+			result.Status = LabelStatusAdded
+		}
+		results = append(results, result)
+	}
+	for _, characterID := range removeIDs {
+		result := LabelAssignmentResult{CharacterID: characterID, Action: LabelActionRemove}
+		character, ok := byID[characterID]
+		switch {
+		case !ok:
+			result.fail(errCharacterNotFound())
+		case !hasLabel(character, label.ID):
+			result.Status = LabelStatusUnchanged
+		default:
+			result.Status = LabelStatusRemoved
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// MergeLabel sourceID のラベルを targetID のラベルに統合して sourceID のラベルを削除する
+// 付け替える人物ごとに、統合先のラベルのグループと分類ごとの上限を確認する（付け替えでラベルの数は増えない）
+// 確認は統合のトランザクション内で人物の行をロックして読み直した後に行う
+func (s *characterService) MergeLabel(sourceID, targetID string) (*LabelMergeResult, error) {
+	source, err := s.labelRepo.GetByID(sourceID)
+	if err != nil {
+		return nil, lookupError(err, errLabelNotFound(), "failed to get label")
+	}
+	target, err := s.labelRepo.GetByID(targetID)
+	if err != nil {
+		return nil, lookupError(err, errLabelNotFound(), "failed to get target label")
+	}
+	if source.ID == target.ID {
+		return nil, NewValidationError("LABEL_MERGE_INTO_SELF", "label cannot be merged into itself", nil)
+	}
+
+	var result *LabelMergeResult
+	check := func(characters []models.Character) error {
+		result = &LabelMergeResult{Target: target}
+		return checkLabelMerge(source, target, characters, result)
+	}
+	if err := s.labelRepo.Merge(sourceID, targetID, check); err != nil {
+		return nil, lookupError(err, errLabelNotFound(), "failed to merge labels")
+	}
+	return result, nil
+}
+
+// checkLabelMerge ロックして読み直した統合元のラベルが付いた人物を確認し、付け替える人物と重複していた人物を result に数える
+func checkLabelMerge(source, target *models.Label, characters []models.Character, result *LabelMergeResult) error {
+	var mismatched, overCategory []string
+	for i := range characters {
+		character := &characters[i]
+		if hasLabel(character, target.ID) {
+			result.Deduplicated++
+			continue
+		}
+		result.Moved++
+
+		if target.GroupID != nil && *target.GroupID != character.GroupID {
+			mismatched = append(mismatched, character.ID)
+			continue
+		}
+		if category := target.Category; category != nil {
+			count := countInCategory(character.Labels, category.ID)
+			if source.CategoryID != nil && *source.CategoryID == category.ID {
+				count-- // 統合元のラベルは外れる
+			}
+			if count >= category.MaxPerCharacter {
+				overCategory = append(overCategory, character.ID)
+			}
+		}
+	}
+	if len(mismatched) > 0 {
+		return NewValidationError("LABEL_GROUP_MISMATCH", "target label belongs to another group than some characters", map[string][]string{"characterIds": mismatched})
+	}
+	if len(overCategory) > 0 {
+		category := target.Category
+		return NewLimitExceededError("LABEL_CATEGORY_LIMIT_EXCEEDED",
+			fmt.Sprintf("some characters would have more than %d labels in category %q", category.MaxPerCharacter, category.Name),
+			map[string]interface{}{"limit": category.MaxPerCharacter, "categoryId": category.ID, "category": category.Name, "characterIds": overCategory})
+	}
+	return nil
+}
+
+// fail 失敗した結果としてエラーの内容を設定する
+func (r *LabelAssignmentResult) fail(err error) {
+	r.Status = LabelStatusFailed
+	r.Message = err.Error()
+	var appError *Error
+	if errors.As(err, &appError) {
+		r.Code = appError.Code
+		r.Details = appError.Details
+	}
+}
+
+// hasLabel 人物にラベルが付いているか（読み込み済みのラベルで判定する）
+func hasLabel(character *models.Character, labelID string) bool {
+	for _, label := range character.Labels {
+		if label.ID == labelID {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueIDs 重複を除いた ID を指定順に返す
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// intersectIDs 両方に含まれる ID を a の順に返す
+func intersectIDs(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+	var both []string
+	for _, id := range a {
+		if inB[id] {
+			both = append(both, id)
+		}
+	}
+	return both
+}
+
+// GetCharacterImages 人物のギャラリーの画像を表示順に取得
+func (s *characterService) GetCharacterImages(characterID string) ([]models.CharacterImage, error) {
+	if err := s.ensureCharacterExists(characterID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.characterRepo.GetImages(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character images: %w", err)
+	}
+	return images, nil
+}
+
+// GetCharacterImage 人物のギャラリーの画像を取得
+func (s *characterService) GetCharacterImage(characterID, imageID string) (*models.CharacterImage, error) {
+	image, err := s.characterRepo.GetImage(characterID, imageID)
+	if err != nil {
+		return nil, lookupError(err, errCharacterImageNotFound(), "failed to get character image")
+	}
+	return image, nil
+}
+
+// AddCharacterImage 人物のギャラリーの末尾に画像を追加
+// 人物に写真がない場合は追加した画像が代表画像（photo）になる
+func (s *characterService) AddCharacterImage(characterID string, image *models.CharacterImage) (*models.CharacterImage, error) {
+	images, err := s.GetCharacterImages(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) >= MaxCharacterImages {
+		return nil, NewLimitExceededError("IMAGE_LIMIT_EXCEEDED",
+			fmt.Sprintf("character cannot have more than %d images", MaxCharacterImages), map[string]int{"limit": MaxCharacterImages})
+	}
+
+	image.CharacterID = characterID
+	if err := s.characterRepo.AddImage(image); err != nil {
+		return nil, fmt.Errorf("failed to add character image: %w", err)
+	}
+	return s.characterRepo.GetImage(characterID, image.ID)
+}
+
+// UpdateCharacterImage 画像の説明・クレジット・出典・ライセンスを更新し、IsPrimary の場合は代表画像にする
+// 代表画像の指定は他の画像を代表画像にすることでのみ外せる
+func (s *characterService) UpdateCharacterImage(characterID string, image *models.CharacterImage) (*models.CharacterImage, error) {
+	existing, err := s.GetCharacterImage(characterID, image.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.IsPrimary && !image.IsPrimary {
+		return nil, NewValidationError("PRIMARY_IMAGE_REQUIRED", "set another image as primary instead of unsetting the primary image", nil)
+	}
+
+	image.CharacterID = characterID
+	if err := s.characterRepo.UpdateImage(image); err != nil {
+		return nil, fmt.Errorf("failed to update character image: %w", err)
+	}
+	if image.IsPrimary && !existing.IsPrimary {
+		if err := s.characterRepo.SetPrimaryImage(characterID, image.ID); err != nil {
+			return nil, fmt.Errorf("failed to set primary image: %w", err)
+		}
+	}
+	return s.characterRepo.GetImage(characterID, image.ID)
+}
+
+// ReorderCharacterImages ギャラリーの画像を imageIDs の順に並べ替える（全ての画像を1回ずつ指定する）
+func (s *characterService) ReorderCharacterImages(characterID string, imageIDs []string) ([]models.CharacterImage, error) {
+	images, err := s.GetCharacterImages(characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[string]bool, len(images))
+	for _, image := range images {
+		remaining[image.ID] = true
+	}
+	for _, imageID := range imageIDs {
+		if !remaining[imageID] {
+			return nil, errInvalidImageOrder(images)
+		}
+		delete(remaining, imageID)
+	}
+	if len(remaining) > 0 {
+		return nil, errInvalidImageOrder(images)
+	}
+
+	if err := s.characterRepo.ReorderImages(characterID, imageIDs); err != nil {
+		return nil, fmt.Errorf("failed to reorder character images: %w", err)
+	}
+	return s.characterRepo.GetImages(characterID)
+}
+
+// DeleteCharacterImage ギャラリーから画像を削除し、削除した画像を返す（ファイルは呼び出し側で削除する）
+// 代表画像を削除した場合は表示順で最初の画像が代表画像になる
+func (s *characterService) DeleteCharacterImage(characterID, imageID string) (*models.CharacterImage, error) {
+	image, err := s.GetCharacterImage(characterID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.characterRepo.DeleteImage(characterID, imageID); err != nil {
+		return nil, lookupError(err, errCharacterImageNotFound(), "failed to delete character image")
+	}
+	return image, nil
+}
+
+// ensureCharacterExists 人物が存在しない場合は NotFound を返す
+func (s *characterService) ensureCharacterExists(characterID string) error {
+	exists, err := s.characterRepo.ExistsByID(characterID)
+	if err != nil {
+		return fmt.Errorf("failed to check character existence: %w", err)
+	}
+	if !exists {
+		return errCharacterNotFound()
+	}
+	return nil
+}
+
+// errInvalidImageOrder 並べ替えの指定が画像の一覧と一致しないエラー
+func errInvalidImageOrder(images []models.CharacterImage) *Error {
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		ids = append(ids, image.ID)
+	}
+	return NewValidationError("INVALID_IMAGE_ORDER", "imageIds must list every image of the character exactly once",
+		map[string]interface{}{"imageIds": ids})
+}
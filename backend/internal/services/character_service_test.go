@@ -2,14 +2,16 @@ package services
 
 import (
 	"character-management-app/internal/models"
-	"encoding/json"
+	"character-management-app/internal/query"
+	"character-management-app/internal/repositories"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"gorm.io/datatypes"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -19,16 +21,15 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		// テストデータ
-		relatedLinks := []string{"http://example.com"}
-		relatedLinksJSON, _ := json.Marshal(relatedLinks)
+		relatedLinks := []models.RelatedLink{{URL: "http://example.com"}}
 		
 		character := &models.Character{
 			GroupID:      "group-1",
 			Name:         "Test Character",
 			Information:  "Test Info",
-			RelatedLinks: datatypes.JSON(relatedLinksJSON),
+			RelatedLinks: relatedLinks,
 		}
 		
 		createdCharacter := &models.Character{
@@ -36,7 +37,7 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 			GroupID:      "group-1",
 			Name:         "Test Character",
 			Information:  "Test Info",
-			RelatedLinks: datatypes.JSON(relatedLinksJSON),
+			RelatedLinks: relatedLinks,
 		}
 		
 		// モックの設定
@@ -59,7 +60,7 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// テストデータ
 		photoPath := "uploads/characters/test.jpg"
@@ -100,7 +101,7 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		character := &models.Character{
 			GroupID: "nonexistent-group",
@@ -117,6 +118,7 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "group not found")
+		assert.ErrorIs(t, err, ErrNotFound)
 		mockGroupRepo.AssertExpectations(t)
 	})
 	
@@ -125,7 +127,7 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		character := &models.Character{
 			GroupID: "group-1",
@@ -150,7 +152,7 @@ func TestCharacterService_CreateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		character := &models.Character{
 			GroupID: "group-1",
@@ -178,7 +180,7 @@ func TestCharacterService_GetCharacterByID(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		character := &models.Character{
 			ID:      "char-1",
 			GroupID: "group-1",
@@ -201,7 +203,7 @@ func TestCharacterService_GetCharacterByID(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("GetByID", "nonexistent").Return((*models.Character)(nil), gorm.ErrRecordNotFound)
@@ -221,7 +223,7 @@ func TestCharacterService_UpdateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		existingCharacter := &models.Character{
 			ID:        "char-1",
 			GroupID:   "group-1",
@@ -257,11 +259,58 @@ func TestCharacterService_UpdateCharacter(t *testing.T) {
 		mockCharacterRepo.AssertExpectations(t)
 	})
 	
+	t.Run("バージョン未指定時は現在のバージョンで更新", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+		
+		existingCharacter := &models.Character{ID: "char-1", GroupID: "group-1", Name: "Old Name", Version: 3}
+		updateCharacter := &models.Character{GroupID: "group-1", Name: "New Name"}
+		
+		// モックの設定
+		mockCharacterRepo.On("GetByID", "char-1").Return(existingCharacter, nil)
+		mockCharacterRepo.On("Update", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Version == 3
+		})).Return(nil)
+		
+		// テスト実行
+		_, err := service.UpdateCharacter("char-1", updateCharacter)
+		
+		// 検証
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+	
+	t.Run("バージョン競合", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+		
+		existingCharacter := &models.Character{ID: "char-1", GroupID: "group-1", Name: "Old Name", Version: 3}
+		updateCharacter := &models.Character{GroupID: "group-1", Name: "New Name", Version: 2}
+		
+		// モックの設定
+		mockCharacterRepo.On("GetByID", "char-1").Return(existingCharacter, nil)
+		mockCharacterRepo.On("Update", mock.MatchedBy(func(char *models.Character) bool {
+			return char.Version == 2
+		})).Return(ErrVersionConflict)
+		
+		// テスト実行
+		result, err := service.UpdateCharacter("char-1", updateCharacter)
+		
+		// 検証
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+	
 	t.Run("グループ変更を伴うキャラクター更新", func(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		existingCharacter := &models.Character{
 			ID:      "char-1",
@@ -300,7 +349,7 @@ func TestCharacterService_UpdateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		updateCharacter := &models.Character{
 			GroupID: "group-1",
@@ -316,7 +365,7 @@ func TestCharacterService_UpdateCharacter(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "failed to get existing character")
+		assert.ErrorIs(t, err, ErrNotFound)
 		mockCharacterRepo.AssertExpectations(t)
 	})
 	
@@ -324,7 +373,7 @@ func TestCharacterService_UpdateCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		existingCharacter := &models.Character{
 			ID:      "char-1",
@@ -348,17 +397,46 @@ func TestCharacterService_UpdateCharacter(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "group not found")
+		assert.ErrorIs(t, err, ErrNotFound)
 		mockCharacterRepo.AssertExpectations(t)
 		mockGroupRepo.AssertExpectations(t)
 	})
 }
 
+func TestCharacterService_UpdateCharacter_GroupLabels(t *testing.T) {
+	t.Run("元のグループ専用のラベルが付いている場合は移動できない", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+		
+		groupID := "group-1"
+		existing := &models.Character{
+			ID:      "char-1",
+			GroupID: groupID,
+			Labels: []models.Label{
+				{ID: "label-global"},
+				{ID: "label-1", GroupID: &groupID},
+			},
+		}
+		mockCharacterRepo.On("GetByID", "char-1").Return(existing, nil)
+		mockGroupRepo.On("ExistsByID", "group-2").Return(true, nil)
+		
+		_, err := service.UpdateCharacter("char-1", &models.Character{GroupID: "group-2", Name: "Test"})
+		
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_GROUP_MISMATCH", serviceError.Code)
+		assert.Equal(t, map[string][]string{"labelIds": {"label-1"}}, serviceError.Details)
+		mockCharacterRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
 func TestCharacterService_DeleteCharacter(t *testing.T) {
 	t.Run("正常なキャラクター削除", func(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
@@ -376,7 +454,7 @@ func TestCharacterService_DeleteCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("ExistsByID", "nonexistent").Return(false, nil)
@@ -392,18 +470,25 @@ func TestCharacterService_DeleteCharacter(t *testing.T) {
 }
 
 func TestCharacterService_AddLabelToCharacter(t *testing.T) {
+	// withLabels n 個のラベルが付いた人物
+	withLabels := func(n int) []models.Label {
+		labels := make([]models.Label, n)
+		for i := range labels {
+			labels[i] = models.Label{ID: fmt.Sprintf("label-existing-%d", i)}
+		}
+		return labels
+	}
+
 	t.Run("正常なラベル追加", func(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
-		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
-		mockLabelRepo.On("ExistsByID", "label-1").Return(true, nil)
-		mockCharacterRepo.On("HasLabel", "char-1", "label-1").Return(false, nil)
-		mockCharacterRepo.On("GetLabelsCount", "char-1").Return(int64(3), nil)
-		mockCharacterRepo.On("AddLabel", "char-1", "label-1").Return(nil)
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1"}, nil)
+		mockCharacterRepo.On("AddLabel", "char-1", "label-1").Return(&models.Character{ID: "char-1", GroupID: "group-1", Labels: withLabels(3)}, nil)
+		mockCharacterRepo.On("ApplyAddLabel", "char-1", "label-1").Return()
 		
 		// テスト実行
 		err := service.AddLabelToCharacter("char-1", "label-1")
@@ -418,13 +503,11 @@ func TestCharacterService_AddLabelToCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
-		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
-		mockLabelRepo.On("ExistsByID", "label-1").Return(true, nil)
-		mockCharacterRepo.On("HasLabel", "char-1", "label-1").Return(false, nil)
-		mockCharacterRepo.On("GetLabelsCount", "char-1").Return(int64(5), nil)
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1"}, nil)
+		mockCharacterRepo.On("AddLabel", "char-1", "label-1").Return(&models.Character{ID: "char-1", GroupID: "group-1", Labels: withLabels(5)}, nil)
 		
 		// テスト実行
 		err := service.AddLabelToCharacter("char-1", "label-1")
@@ -432,8 +515,523 @@ func TestCharacterService_AddLabelToCharacter(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "cannot have more than 5 labels")
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyAddLabel", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("同じグループ専用のラベルを追加", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+		
+		groupID := "group-1"
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1", GroupID: &groupID}, nil)
+		mockCharacterRepo.On("AddLabel", "char-1", "label-1").Return(&models.Character{ID: "char-1", GroupID: "group-1"}, nil)
+		mockCharacterRepo.On("ApplyAddLabel", "char-1", "label-1").Return()
+		
+		err := service.AddLabelToCharacter("char-1", "label-1")
+		
+		assert.NoError(t, err)
 		mockCharacterRepo.AssertExpectations(t)
-		mockLabelRepo.AssertExpectations(t)
+	})
+	
+	t.Run("他のグループ専用のラベルは422", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+		
+		groupID := "group-2"
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1", GroupID: &groupID}, nil)
+		mockCharacterRepo.On("AddLabel", "char-1", "label-1").Return(&models.Character{ID: "char-1", GroupID: "group-1"}, nil)
+		
+		err := service.AddLabelToCharacter("char-1", "label-1")
+		
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, "LABEL_GROUP_MISMATCH", serviceError.Code)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyAddLabel", mock.Anything, mock.Anything)
+	})
+
+	t.Run("既に付いているラベルは409", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1"}, nil)
+		// ロックして読み直した時には他のリクエストで同じラベルが付いていた人物
+		mockCharacterRepo.On("AddLabel", "char-1", "label-1").Return(&models.Character{ID: "char-1", GroupID: "group-1", Labels: []models.Label{{ID: "label-1"}}}, nil)
+
+		err := service.AddLabelToCharacter("char-1", "label-1")
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.Equal(t, "LABEL_ALREADY_ASSIGNED", serviceError.Code)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyAddLabel", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("存在しないラベルは404", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+		
+		mockLabelRepo.On("GetByID", "missing").Return((*models.Label)(nil), gorm.ErrRecordNotFound)
+		
+		err := service.AddLabelToCharacter("char-1", "missing")
+		
+		assert.ErrorIs(t, err, ErrNotFound)
+		mockCharacterRepo.AssertNotCalled(t, "AddLabel", mock.Anything, mock.Anything)
+	})
+
+	t.Run("存在しない人物は404", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1"}, nil)
+		mockCharacterRepo.On("AddLabel", "missing", "label-1").Return((*models.Character)(nil), gorm.ErrRecordNotFound)
+
+		err := service.AddLabelToCharacter("missing", "label-1")
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "CHARACTER_NOT_FOUND", serviceError.Code)
+	})
+}
+
+
+func TestCharacterService_AddLabelToCharacter_Limits(t *testing.T) {
+	// newServiceWithOptions ロックして読み直した人物として character を返すモックで、設定を指定した人物サービスを作成
+	newServiceWithOptions := func(character *models.Character, label *models.Label, options CharacterOptions) (CharacterService, *MockCharacterRepository) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("GetByID", label.ID).Return(label, nil)
+		mockCharacterRepo.On("AddLabel", character.ID, label.ID).Return(character, nil)
+		mockCharacterRepo.On("ApplyAddLabel", character.ID, label.ID).Return().Maybe()
+		return NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), options), mockCharacterRepo
+	}
+	// newService ロックして読み直した人物として character を返すモックで、既定の設定の人物サービスを作成
+	newService := func(character *models.Character, label *models.Label) (CharacterService, *MockCharacterRepository) {
+		return newServiceWithOptions(character, label, CharacterOptions{})
+	}
+	limit := func(n int) *int { return &n }
+	// withLabels n 個のラベル
+	withLabels := func(n int) []models.Label {
+		labels := make([]models.Label, n)
+		for i := range labels {
+			labels[i] = models.Label{ID: fmt.Sprintf("label-existing-%d", i)}
+		}
+		return labels
+	}
+
+	t.Run("グループの上限まで追加できる", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: "group-1", Group: models.Group{ID: "group-1", LabelLimit: limit(10)}, Labels: withLabels(9)}
+		service, mockCharacterRepo := newService(character, &models.Label{ID: "label-1"})
+
+		err := service.AddLabelToCharacter("char-1", "label-1")
+
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertCalled(t, "ApplyAddLabel", "char-1", "label-1")
+	})
+
+	t.Run("グループの上限を超える場合は422", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: "group-1", Group: models.Group{ID: "group-1", LabelLimit: limit(1)}, Labels: withLabels(1)}
+		service, mockCharacterRepo := newService(character, &models.Label{ID: "label-1"})
+
+		err := service.AddLabelToCharacter("char-1", "label-1")
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.Equal(t, "character cannot have more than 1 labels", serviceError.Message)
+		assert.Equal(t, map[string]int{"limit": 1}, serviceError.Details)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyAddLabel", mock.Anything, mock.Anything)
+	})
+
+	t.Run("グループの上限が未設定の場合は全体の既定値", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: "group-1", Labels: withLabels(DefaultLabelLimit)}
+		service, _ := newService(character, &models.Label{ID: "label-1"})
+
+		err := service.AddLabelToCharacter("char-1", "label-1")
+
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.Contains(t, err.Error(), fmt.Sprintf("more than %d labels", DefaultLabelLimit))
+	})
+
+	t.Run("グループの上限が未設定の場合は設定した上限", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: "group-1", Labels: withLabels(3)}
+		service, _ := newServiceWithOptions(character, &models.Label{ID: "label-1"}, CharacterOptions{LabelLimit: 3})
+
+		err := service.AddLabelToCharacter("char-1", "label-1")
+
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.Contains(t, err.Error(), "more than 3 labels")
+	})
+
+	t.Run("分類の上限を超える場合は422", func(t *testing.T) {
+		categoryID := "category-faction"
+		category := &models.LabelCategory{ID: categoryID, Name: "陣営", MaxPerCharacter: 1}
+		character := &models.Character{ID: "char-1", GroupID: "group-1", Labels: []models.Label{
+			{ID: "label-oda", CategoryID: &categoryID},
+			{ID: "label-warlord"},
+		}}
+		service, mockCharacterRepo := newService(character, &models.Label{ID: "label-tokugawa", CategoryID: &categoryID, Category: category})
+
+		err := service.AddLabelToCharacter("char-1", "label-tokugawa")
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.Equal(t, "LABEL_CATEGORY_LIMIT_EXCEEDED", serviceError.Code)
+		assert.Equal(t, map[string]interface{}{"limit": 1, "categoryId": categoryID, "category": "陣営"}, serviceError.Details)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyAddLabel", mock.Anything, mock.Anything)
+	})
+
+	t.Run("分類の上限以内なら追加できる", func(t *testing.T) {
+		categoryID := "category-alignment"
+		category := &models.LabelCategory{ID: categoryID, Name: "属性", MaxPerCharacter: 2}
+		character := &models.Character{ID: "char-1", GroupID: "group-1", Labels: []models.Label{
+			{ID: "label-lawful", CategoryID: &categoryID},
+		}}
+		service, _ := newService(character, &models.Label{ID: "label-good", CategoryID: &categoryID, Category: category})
+
+		err := service.AddLabelToCharacter("char-1", "label-good")
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestCharacterService_ReplaceCharacterLabels(t *testing.T) {
+	groupID := "group-1"
+	otherGroupID := "group-2"
+	categoryID := "category-faction"
+	faction := &models.LabelCategory{ID: categoryID, Name: "陣営", MaxPerCharacter: 1}
+	limit := func(n int) *int { return &n }
+
+	newService := func(character *models.Character, labelIDs []string, labels []models.Label) (CharacterService, *MockCharacterRepository) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		// character はトランザクション内で読み直した人物として確認に使う
+		mockCharacterRepo.On("GetByID", character.ID).Return(character, nil).Maybe()
+		mockLabelRepo.On("GetByIDs", labelIDs).Return(labels, nil)
+		mockCharacterRepo.On("ReplaceLabels", character.ID, labelIDs).Return(character, nil).Maybe()
+		return NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{}), mockCharacterRepo
+	}
+
+	t.Run("ラベルの組み合わせを置き換えて更新後の人物を返す", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID, Version: 2}
+		labels := []models.Label{{ID: "label-1"}, {ID: "label-2", GroupID: &groupID}}
+		service, mockCharacterRepo := newService(character, []string{"label-1", "label-2"}, labels)
+
+		result, err := service.ReplaceCharacterLabels("char-1", []string{"label-1", "label-2", "label-1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, character, result)
+		mockCharacterRepo.AssertCalled(t, "ReplaceLabels", "char-1", []string{"label-1", "label-2"})
+	})
+
+	t.Run("空の配列で全て外す", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID}
+		service, mockCharacterRepo := newService(character, []string{}, []models.Label{})
+
+		_, err := service.ReplaceCharacterLabels("char-1", []string{})
+
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertCalled(t, "ReplaceLabels", "char-1", []string{})
+	})
+
+	t.Run("存在しないラベルを含む場合は404", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID}
+		service, mockCharacterRepo := newService(character, []string{"label-1", "missing"}, []models.Label{{ID: "label-1"}})
+
+		_, err := service.ReplaceCharacterLabels("char-1", []string{"label-1", "missing"})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		mockCharacterRepo.AssertNotCalled(t, "ReplaceLabels", mock.Anything, mock.Anything)
+	})
+
+	t.Run("存在しない人物は404", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetByIDs", []string{"label-1"}).Return([]models.Label{{ID: "label-1"}}, nil)
+		mockCharacterRepo.On("ReplaceLabels", "missing", []string{"label-1"}).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := service.ReplaceCharacterLabels("missing", []string{"label-1"})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "CHARACTER_NOT_FOUND", serviceError.Code)
+	})
+
+	t.Run("他のグループ専用のラベルを含む場合は422", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID}
+		labels := []models.Label{{ID: "label-1"}, {ID: "label-other", GroupID: &otherGroupID}}
+		service, mockCharacterRepo := newService(character, []string{"label-1", "label-other"}, labels)
+
+		_, err := service.ReplaceCharacterLabels("char-1", []string{"label-1", "label-other"})
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_GROUP_MISMATCH", serviceError.Code)
+		assert.Equal(t, map[string][]string{"labelIds": {"label-other"}}, serviceError.Details)
+		// 確認に失敗した場合は置き換えない（更新後の人物も取得しない）
+		mockCharacterRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+
+	t.Run("グループの上限を超える場合は422", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID, Group: models.Group{ID: groupID, LabelLimit: limit(1)}}
+		labels := []models.Label{{ID: "label-1"}, {ID: "label-2"}}
+		service, mockCharacterRepo := newService(character, []string{"label-1", "label-2"}, labels)
+
+		_, err := service.ReplaceCharacterLabels("char-1", []string{"label-1", "label-2"})
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_LIMIT_EXCEEDED", serviceError.Code)
+		assert.Equal(t, map[string]int{"limit": 1}, serviceError.Details)
+		mockCharacterRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+
+	t.Run("分類の上限を超える場合は422", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID}
+		labels := []models.Label{
+			{ID: "label-oda", CategoryID: &categoryID, Category: faction},
+			{ID: "label-tokugawa", CategoryID: &categoryID, Category: faction},
+		}
+		service, mockCharacterRepo := newService(character, []string{"label-oda", "label-tokugawa"}, labels)
+
+		_, err := service.ReplaceCharacterLabels("char-1", []string{"label-oda", "label-tokugawa"})
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_CATEGORY_LIMIT_EXCEEDED", serviceError.Code)
+		mockCharacterRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+
+	t.Run("付いているラベルと入れ替える場合は分類の上限を超えない", func(t *testing.T) {
+		character := &models.Character{ID: "char-1", GroupID: groupID, Labels: []models.Label{
+			{ID: "label-oda", CategoryID: &categoryID},
+		}}
+		labels := []models.Label{{ID: "label-tokugawa", CategoryID: &categoryID, Category: faction}}
+		service, _ := newService(character, []string{"label-tokugawa"}, labels)
+
+		_, err := service.ReplaceCharacterLabels("char-1", []string{"label-tokugawa"})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestCharacterService_AssignLabelToCharacters(t *testing.T) {
+	groupID := "group-1"
+	otherGroupID := "group-2"
+	limit := func(n int) *int { return &n }
+
+	t.Run("人物ごとの結果を返し、変更する人物だけをまとめて更新する", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		label := &models.Label{ID: "label-1"}
+		characters := []models.Character{
+			{ID: "char-1", GroupID: groupID},
+			{ID: "char-2", GroupID: groupID, Labels: []models.Label{{ID: "label-1"}}},
+			{ID: "char-3", GroupID: groupID, Group: models.Group{ID: groupID, LabelLimit: limit(1)}, Labels: []models.Label{{ID: "label-9"}}},
+			{ID: "char-4", GroupID: groupID, Labels: []models.Label{{ID: "label-1"}}},
+			{ID: "char-5", GroupID: groupID},
+		}
+		mockLabelRepo.On("GetByID", "label-1").Return(label, nil)
+		mockCharacterRepo.On("UpdateLabelAssignments", "label-1", []string{"char-1", "char-2", "char-3", "missing", "char-4", "char-5"}).Return(characters, nil)
+		mockCharacterRepo.On("ApplyLabelAssignments", "label-1", []string{"char-1"}, []string{"char-4"}).Return()
+
+		results, err := service.AssignLabelToCharacters("label-1", []string{"char-1", "char-2", "char-3", "missing", "char-1"}, []string{"char-4", "char-5"})
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 6)
+		assert.Equal(t, LabelAssignmentResult{CharacterID: "char-1", Action: LabelActionAdd, Status: LabelStatusAdded}, results[0])
+		assert.Equal(t, LabelStatusUnchanged, results[1].Status)
+		assert.Equal(t, LabelStatusFailed, results[2].Status)
+		assert.Equal(t, "LABEL_LIMIT_EXCEEDED", results[2].Code)
+		assert.Equal(t, map[string]int{"limit": 1}, results[2].Details)
+		assert.Equal(t, LabelStatusFailed, results[3].Status)
+		assert.Equal(t, "CHARACTER_NOT_FOUND", results[3].Code)
+		assert.Equal(t, LabelAssignmentResult{CharacterID: "char-4", Action: LabelActionRemove, Status: LabelStatusRemoved}, results[4])
+		assert.Equal(t, LabelStatusUnchanged, results[5].Status)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("グループ専用のラベルは他のグループの人物には付かない", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1", GroupID: &groupID}, nil)
+		mockCharacterRepo.On("UpdateLabelAssignments", "label-1", []string{"char-1"}).
+			Return([]models.Character{{ID: "char-1", GroupID: otherGroupID}}, nil)
+
+		results, err := service.AssignLabelToCharacters("label-1", []string{"char-1"}, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "LABEL_GROUP_MISMATCH", results[0].Code)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyLabelAssignments", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("トランザクション内で読み直したラベルで確認する", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{LabelLimit: 1})
+
+		// 他のリクエストが先にラベルを付けた後の人物
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1"}, nil)
+		mockCharacterRepo.On("UpdateLabelAssignments", "label-1", []string{"char-1", "char-2"}).Return([]models.Character{
+			{ID: "char-1", GroupID: groupID, Labels: []models.Label{{ID: "label-1"}}},
+			{ID: "char-2", GroupID: groupID, Labels: []models.Label{{ID: "label-2"}}},
+		}, nil)
+
+		results, err := service.AssignLabelToCharacters("label-1", []string{"char-1", "char-2"}, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, LabelStatusUnchanged, results[0].Status)
+		assert.Equal(t, "LABEL_LIMIT_EXCEEDED", results[1].Code)
+		mockCharacterRepo.AssertNotCalled(t, "ApplyLabelAssignments", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("同じ人物を付ける・外すの両方に指定した場合は422", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1"}, nil)
+
+		_, err := service.AssignLabelToCharacters("label-1", []string{"char-1", "char-2"}, []string{"char-2"})
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, "LABEL_ASSIGNMENT_CONFLICT", serviceError.Code)
+		assert.Equal(t, map[string][]string{"characterIds": {"char-2"}}, serviceError.Details)
+		mockCharacterRepo.AssertNotCalled(t, "UpdateLabelAssignments", mock.Anything, mock.Anything)
+	})
+
+	t.Run("存在しないラベルの場合は404", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetByID", "missing").Return((*models.Label)(nil), gorm.ErrRecordNotFound)
+
+		_, err := service.AssignLabelToCharacters("missing", []string{"char-1"}, nil)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestCharacterService_MergeLabel(t *testing.T) {
+	groupID := "group-1"
+	otherGroupID := "group-2"
+	categoryID := "category-faction"
+	faction := &models.LabelCategory{ID: categoryID, Name: "陣営", MaxPerCharacter: 1}
+
+	newService := func(source, target *models.Label, characters []models.Character) (CharacterService, *MockLabelRepository) {
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("GetByID", source.ID).Return(source, nil)
+		mockLabelRepo.On("GetByID", target.ID).Return(target, nil)
+		mockLabelRepo.On("Merge", source.ID, target.ID).Return(characters, nil)
+		mockLabelRepo.On("ApplyMerge", source.ID, target.ID).Return().Maybe()
+		return NewCharacterService(new(MockCharacterRepository), new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{}), mockLabelRepo
+	}
+
+	t.Run("付け替えた人物と重複していた人物の数を返す", func(t *testing.T) {
+		source := &models.Label{ID: "label-daimio"}
+		target := &models.Label{ID: "label-daimyo"}
+		service, mockLabelRepo := newService(source, target, []models.Character{
+			{ID: "char-1", GroupID: groupID, Labels: []models.Label{{ID: "label-daimio"}}},
+			{ID: "char-2", GroupID: groupID, Labels: []models.Label{{ID: "label-daimio"}, {ID: "label-daimyo"}}},
+			{ID: "char-3", GroupID: otherGroupID, Labels: []models.Label{{ID: "label-daimio"}}},
+		})
+
+		result, err := service.MergeLabel("label-daimio", "label-daimyo")
+
+		require.NoError(t, err)
+		assert.Equal(t, &LabelMergeResult{Target: target, Moved: 2, Deduplicated: 1}, result)
+		mockLabelRepo.AssertCalled(t, "ApplyMerge", "label-daimio", "label-daimyo")
+	})
+
+	t.Run("自分自身への統合は422", func(t *testing.T) {
+		label := &models.Label{ID: "label-1"}
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("GetByID", "label-1").Return(label, nil)
+		service := NewCharacterService(new(MockCharacterRepository), new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		_, err := service.MergeLabel("label-1", "label-1")
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_MERGE_INTO_SELF", serviceError.Code)
+		mockLabelRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("統合先が他のグループ専用のラベルの場合は422", func(t *testing.T) {
+		source := &models.Label{ID: "label-global"}
+		target := &models.Label{ID: "label-scoped", GroupID: &groupID}
+		service, mockLabelRepo := newService(source, target, []models.Character{
+			{ID: "char-1", GroupID: groupID, Labels: []models.Label{{ID: "label-global"}}},
+			{ID: "char-2", GroupID: otherGroupID, Labels: []models.Label{{ID: "label-global"}}},
+		})
+
+		_, err := service.MergeLabel("label-global", "label-scoped")
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_GROUP_MISMATCH", serviceError.Code)
+		assert.Equal(t, map[string][]string{"characterIds": {"char-2"}}, serviceError.Details)
+		mockLabelRepo.AssertNotCalled(t, "ApplyMerge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("統合先の分類の上限を超える人物がいる場合は422", func(t *testing.T) {
+		source := &models.Label{ID: "label-ieyasu-fan"}
+		target := &models.Label{ID: "label-tokugawa", CategoryID: &categoryID, Category: faction}
+		service, mockLabelRepo := newService(source, target, []models.Character{
+			{ID: "char-1", GroupID: groupID, Labels: []models.Label{{ID: "label-ieyasu-fan"}, {ID: "label-oda", CategoryID: &categoryID}}},
+			{ID: "char-2", GroupID: groupID, Labels: []models.Label{{ID: "label-ieyasu-fan"}}},
+		})
+
+		_, err := service.MergeLabel("label-ieyasu-fan", "label-tokugawa")
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.Equal(t, "LABEL_CATEGORY_LIMIT_EXCEEDED", serviceError.Code)
+		assert.Equal(t, []string{"char-1"}, serviceError.Details.(map[string]interface{})["characterIds"])
+		mockLabelRepo.AssertNotCalled(t, "ApplyMerge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("同じ分類のラベル同士の統合は上限を超えない", func(t *testing.T) {
+		source := &models.Label{ID: "label-tokugawa-old", CategoryID: &categoryID, Category: faction}
+		target := &models.Label{ID: "label-tokugawa", CategoryID: &categoryID, Category: faction}
+		service, _ := newService(source, target, []models.Character{
+			{ID: "char-1", GroupID: groupID, Labels: []models.Label{{ID: "label-tokugawa-old", CategoryID: &categoryID}}},
+		})
+
+		_, err := service.MergeLabel("label-tokugawa-old", "label-tokugawa")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("統合のトランザクション内で読み直したラベルで上限を確認する", func(t *testing.T) {
+		source := &models.Label{ID: "label-ieyasu-fan"}
+		target := &models.Label{ID: "label-tokugawa", CategoryID: &categoryID, Category: faction}
+		// 統合を始めた後に他のリクエストで陣営のラベルが付いた人物
+		service, mockLabelRepo := newService(source, target, []models.Character{
+			{ID: "char-1", GroupID: groupID, Labels: []models.Label{{ID: "label-ieyasu-fan"}, {ID: "label-toyotomi", CategoryID: &categoryID}}},
+		})
+
+		_, err := service.MergeLabel("label-ieyasu-fan", "label-tokugawa")
+
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		mockLabelRepo.AssertNotCalled(t, "ApplyMerge", mock.Anything, mock.Anything)
 	})
 }
 
@@ -442,7 +1040,7 @@ func TestCharacterService_GetCharactersByGroupID(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		characters := []models.Character{
 			{ID: "char-1", GroupID: "group-1", Name: "Character 1"},
@@ -467,10 +1065,12 @@ func TestCharacterService_GetCharactersByGroupID(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, mockViewRepo, CharacterOptions{})
 		
 		// モックの設定
 		mockGroupRepo.On("ExistsByID", "nonexistent").Return(false, nil)
+		mockViewRepo.On("GetByID", "nonexistent").Return((*models.SavedView)(nil), gorm.ErrRecordNotFound)
 		
 		// テスト実行
 		result, err := service.GetCharactersByGroupID("nonexistent")
@@ -479,8 +1079,30 @@ func TestCharacterService_GetCharactersByGroupID(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "group not found")
+		assert.ErrorIs(t, err, ErrNotFound)
 		mockGroupRepo.AssertExpectations(t)
 	})
+
+	t.Run("保存したビューのIDでは条件式に一致する人物を取得", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, new(MockLabelRepository), mockViewRepo, CharacterOptions{})
+
+		characters := []models.Character{{ID: "char-1", Name: "織田信長"}}
+		mockGroupRepo.On("ExistsByID", "view-1").Return(false, nil)
+		mockViewRepo.On("GetByID", "view-1").Return(&models.SavedView{ID: "view-1", Query: "武将"}, nil)
+		mockCharacterRepo.On("Find", repositories.CharacterFilter{
+			Query: &query.Term{Field: query.FieldLabel, Value: "武将", Position: 0},
+		}).Return(characters, nil)
+
+		result, err := service.GetCharactersByGroupID("view-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, characters, result)
+		mockCharacterRepo.AssertNotCalled(t, "GetByGroupID", mock.Anything)
+		mockCharacterRepo.AssertExpectations(t)
+	})
 }
 
 func TestCharacterService_GetAllCharacters(t *testing.T) {
@@ -488,7 +1110,7 @@ func TestCharacterService_GetAllCharacters(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		characters := []models.Character{
 			{ID: "char-1", GroupID: "group-1", Name: "Character 1"},
@@ -511,7 +1133,7 @@ func TestCharacterService_GetAllCharacters(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("GetAll").Return([]models.Character{}, errors.New("database error"))
@@ -525,6 +1147,162 @@ func TestCharacterService_GetAllCharacters(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to get all characters")
 		mockCharacterRepo.AssertExpectations(t)
 	})
+
+	t.Run("読みの五十音順に並べる", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		// 名前の UTF-8 の順では 徳川家康・織田信長・豊臣秀吉
+		mockCharacterRepo.On("GetAll").Return([]models.Character{
+			{ID: "char-1", Name: "徳川家康", Aliases: []models.CharacterAlias{{Type: models.AliasTypeReading, Name: "とくがわ いえやす"}}},
+			{ID: "char-2", Name: "織田信長", Aliases: []models.CharacterAlias{
+				{Type: models.AliasTypeAlias, Name: "吉法師"},
+				{Type: models.AliasTypeReading, Name: "オダ ノブナガ"},
+			}},
+			{ID: "char-3", Name: "豊臣秀吉", Aliases: []models.CharacterAlias{{Type: models.AliasTypeReading, Name: "とよとみ ひでよし"}}},
+			{ID: "char-4", Name: "あけち みつひで"},
+		}, nil)
+
+		result, err := service.GetAllCharacters()
+
+		require.NoError(t, err)
+		var ids []string
+		for _, character := range result {
+			ids = append(ids, character.ID)
+		}
+		assert.Equal(t, []string{"char-4", "char-2", "char-1", "char-3"}, ids)
+	})
+}
+
+func TestCharacterService_SearchCharacters(t *testing.T) {
+	t.Run("子孫のラベルも含めて検索", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		characters := []models.Character{{ID: "char-1", Name: "織田信長"}}
+		mockLabelRepo.On("GetAll").Return(labelHierarchy(), nil)
+		mockGroupRepo.On("ExistsByID", "group-1").Return(true, nil)
+		mockCharacterRepo.On("Find", repositories.CharacterFilter{
+			GroupID:  "group-1",
+			LabelIDs: []string{"label-daimyo", "label-sengoku"},
+		}).Return(characters, nil)
+
+		result, err := service.SearchCharacters(CharacterQuery{GroupID: "group-1", LabelID: "label-daimyo", IncludeDescendants: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, characters, result)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("子孫を含めない場合は指定したラベルのみ", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetAll").Return(labelHierarchy(), nil)
+		mockCharacterRepo.On("Find", repositories.CharacterFilter{LabelIDs: []string{"label-daimyo"}}).Return([]models.Character{}, nil)
+
+		_, err := service.SearchCharacters(CharacterQuery{LabelID: "label-daimyo"})
+
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しないラベルは404", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockLabelRepo := new(MockLabelRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
+
+		mockLabelRepo.On("GetAll").Return(labelHierarchy(), nil)
+
+		_, err := service.SearchCharacters(CharacterQuery{LabelID: "missing", IncludeDescendants: true})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		mockCharacterRepo.AssertNotCalled(t, "Find", mock.Anything)
+	})
+
+	t.Run("条件式を解析してリポジトリに渡す", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		mockGroupRepo.On("ExistsByID", "group-1").Return(true, nil)
+		mockCharacterRepo.On("Find", repositories.CharacterFilter{
+			GroupID: "group-1",
+			Query: &query.And{
+				Left:  &query.Or{Left: &query.Term{Field: query.FieldLabel, Value: "武将", Position: 1}, Right: &query.Term{Field: query.FieldLabel, Value: "公家", Position: 7}},
+				Right: &query.Not{Expr: &query.Term{Field: query.FieldLabel, Value: "故人", Position: 19}},
+			},
+		}).Return([]models.Character{}, nil)
+
+		_, err := service.SearchCharacters(CharacterQuery{GroupID: "group-1", Q: "(武将 OR 公家) AND NOT 故人"})
+
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("保存したビューのIDは条件式と組み合わせる", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, new(MockLabelRepository), mockViewRepo, CharacterOptions{})
+
+		mockGroupRepo.On("ExistsByID", "view-1").Return(false, nil)
+		mockViewRepo.On("GetByID", "view-1").Return(&models.SavedView{ID: "view-1", Query: "group:織田家"}, nil)
+		mockCharacterRepo.On("Find", repositories.CharacterFilter{
+			Query: &query.And{
+				Left:  &query.Term{Field: query.FieldGroup, Value: "織田家", Position: 0},
+				Right: &query.Term{Field: query.FieldName, Value: "信", Position: 0},
+			},
+		}).Return([]models.Character{}, nil)
+
+		_, err := service.SearchCharacters(CharacterQuery{GroupID: "view-1", Q: "name:信"})
+
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しないグループはラベル・条件式と組み合わせても404", func(t *testing.T) {
+		tests := []CharacterQuery{
+			{GroupID: "missing", LabelID: "label-daimyo"},
+			{GroupID: "missing", Q: "武将"},
+		}
+		for _, criteria := range tests {
+			mockCharacterRepo := new(MockCharacterRepository)
+			mockGroupRepo := new(MockGroupRepository)
+			mockLabelRepo := new(MockLabelRepository)
+			mockViewRepo := new(MockSavedViewRepository)
+			service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, mockViewRepo, CharacterOptions{})
+
+			mockLabelRepo.On("GetAll").Return(labelHierarchy(), nil).Maybe()
+			mockGroupRepo.On("ExistsByID", "missing").Return(false, nil)
+			mockViewRepo.On("GetByID", "missing").Return((*models.SavedView)(nil), gorm.ErrRecordNotFound)
+
+			_, err := service.SearchCharacters(criteria)
+
+			var serviceError *Error
+			require.ErrorAs(t, err, &serviceError)
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.Equal(t, "GROUP_NOT_FOUND", serviceError.Code)
+			mockCharacterRepo.AssertNotCalled(t, "Find", mock.Anything)
+		}
+	})
+
+	t.Run("条件式の構文エラーは位置を含む422", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		_, err := service.SearchCharacters(CharacterQuery{Q: "武将 AND (公家 OR"})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, "INVALID_QUERY_SYNTAX", serviceError.Code)
+		assert.Equal(t, 13, serviceError.Details.(map[string]interface{})["position"])
+		mockCharacterRepo.AssertNotCalled(t, "Find", mock.Anything)
+	})
 }
 
 func TestCharacterService_RemoveLabelFromCharacter(t *testing.T) {
@@ -532,7 +1310,7 @@ func TestCharacterService_RemoveLabelFromCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
@@ -552,7 +1330,7 @@ func TestCharacterService_RemoveLabelFromCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("ExistsByID", "nonexistent").Return(false, nil)
@@ -570,7 +1348,7 @@ func TestCharacterService_RemoveLabelFromCharacter(t *testing.T) {
 		mockCharacterRepo := new(MockCharacterRepository)
 		mockGroupRepo := new(MockGroupRepository)
 		mockLabelRepo := new(MockLabelRepository)
-		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, mockLabelRepo, new(MockSavedViewRepository), CharacterOptions{})
 		
 		// モックの設定
 		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
@@ -582,7 +1360,218 @@ func TestCharacterService_RemoveLabelFromCharacter(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "label not found")
+		assert.ErrorIs(t, err, ErrNotFound)
 		mockCharacterRepo.AssertExpectations(t)
 		mockLabelRepo.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+func TestCharacterService_CharacterImages(t *testing.T) {
+	newService := func() (*MockCharacterRepository, CharacterService) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		return mockCharacterRepo, NewCharacterService(mockCharacterRepo, new(MockGroupRepository), new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+	}
+	galleryOf := func(n int) []models.CharacterImage {
+		images := make([]models.CharacterImage, n)
+		for i := range images {
+			images[i] = models.CharacterImage{ID: fmt.Sprintf("image-%d", i+1), CharacterID: "char-1", Position: i, IsPrimary: i == 0}
+		}
+		return images
+	}
+
+	t.Run("画像を追加", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+		image := &models.CharacterImage{Key: "aaaa/full.jpg", Caption: "衣装違い"}
+
+		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
+		mockCharacterRepo.On("GetImages", "char-1").Return(galleryOf(1), nil)
+		mockCharacterRepo.On("AddImage", mock.MatchedBy(func(img *models.CharacterImage) bool {
+			img.ID = "image-2"
+			return img.CharacterID == "char-1" && img.Key == "aaaa/full.jpg"
+		})).Return(nil)
+		mockCharacterRepo.On("GetImage", "char-1", "image-2").
+			Return(&models.CharacterImage{ID: "image-2", CharacterID: "char-1", Key: "aaaa/full.jpg", Position: 1}, nil)
+
+		result, err := service.AddCharacterImage("char-1", image)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Position)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("上限を超える追加は LimitExceeded", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+
+		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
+		mockCharacterRepo.On("GetImages", "char-1").Return(galleryOf(MaxCharacterImages), nil)
+
+		_, err := service.AddCharacterImage("char-1", &models.CharacterImage{Key: "aaaa/full.jpg"})
+
+		var serviceError *Error
+		assert.True(t, errors.As(err, &serviceError))
+		assert.Equal(t, ErrLimitExceeded, serviceError.Kind)
+		assert.Equal(t, "IMAGE_LIMIT_EXCEEDED", serviceError.Code)
+		mockCharacterRepo.AssertNotCalled(t, "AddImage", mock.Anything)
+	})
+
+	t.Run("存在しない人物への追加は NotFound", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+
+		mockCharacterRepo.On("ExistsByID", "missing").Return(false, nil)
+
+		_, err := service.AddCharacterImage("missing", &models.CharacterImage{Key: "aaaa/full.jpg"})
+
+		var serviceError *Error
+		assert.True(t, errors.As(err, &serviceError))
+		assert.Equal(t, ErrNotFound, serviceError.Kind)
+	})
+
+	t.Run("代表画像を切り替え", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+		images := galleryOf(2)
+
+		mockCharacterRepo.On("GetImage", "char-1", "image-2").Return(&images[1], nil).Once()
+		mockCharacterRepo.On("UpdateImage", mock.AnythingOfType("*models.CharacterImage")).Return(nil)
+		mockCharacterRepo.On("SetPrimaryImage", "char-1", "image-2").Return(nil)
+		mockCharacterRepo.On("GetImage", "char-1", "image-2").
+			Return(&models.CharacterImage{ID: "image-2", CharacterID: "char-1", IsPrimary: true}, nil)
+
+		result, err := service.UpdateCharacterImage("char-1", &models.CharacterImage{ID: "image-2", IsPrimary: true})
+
+		assert.NoError(t, err)
+		assert.True(t, result.IsPrimary)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("代表画像の指定を外すと ValidationError", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+		images := galleryOf(2)
+
+		mockCharacterRepo.On("GetImage", "char-1", "image-1").Return(&images[0], nil)
+
+		_, err := service.UpdateCharacterImage("char-1", &models.CharacterImage{ID: "image-1", IsPrimary: false})
+
+		var serviceError *Error
+		assert.True(t, errors.As(err, &serviceError))
+		assert.Equal(t, "PRIMARY_IMAGE_REQUIRED", serviceError.Code)
+		mockCharacterRepo.AssertNotCalled(t, "UpdateImage", mock.Anything)
+	})
+
+	t.Run("並べ替え", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+
+		mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
+		mockCharacterRepo.On("GetImages", "char-1").Return(galleryOf(3), nil)
+		mockCharacterRepo.On("ReorderImages", "char-1", []string{"image-3", "image-1", "image-2"}).Return(nil)
+
+		_, err := service.ReorderCharacterImages("char-1", []string{"image-3", "image-1", "image-2"})
+
+		assert.NoError(t, err)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("全ての画像を1回ずつ指定しない並べ替えは ValidationError", func(t *testing.T) {
+		for name, imageIDs := range map[string][]string{
+			"不足":      {"image-1", "image-2"},
+			"重複":      {"image-1", "image-2", "image-2"},
+			"存在しない画像": {"image-1", "image-2", "image-3", "image-4"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockCharacterRepo, service := newService()
+
+				mockCharacterRepo.On("ExistsByID", "char-1").Return(true, nil)
+				mockCharacterRepo.On("GetImages", "char-1").Return(galleryOf(3), nil)
+
+				_, err := service.ReorderCharacterImages("char-1", imageIDs)
+
+				var serviceError *Error
+				assert.True(t, errors.As(err, &serviceError))
+				assert.Equal(t, "INVALID_IMAGE_ORDER", serviceError.Code)
+				mockCharacterRepo.AssertNotCalled(t, "ReorderImages", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("削除した画像を返す", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+		image := &models.CharacterImage{ID: "image-2", CharacterID: "char-1", Key: "bbbb/full.jpg"}
+
+		mockCharacterRepo.On("GetImage", "char-1", "image-2").Return(image, nil)
+		mockCharacterRepo.On("DeleteImage", "char-1", "image-2").Return(nil)
+
+		deleted, err := service.DeleteCharacterImage("char-1", "image-2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bbbb/full.jpg", deleted.Key)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しない画像の削除は NotFound", func(t *testing.T) {
+		mockCharacterRepo, service := newService()
+
+		mockCharacterRepo.On("GetImage", "char-1", "missing").Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := service.DeleteCharacterImage("char-1", "missing")
+
+		var serviceError *Error
+		assert.True(t, errors.As(err, &serviceError))
+		assert.Equal(t, "CHARACTER_IMAGE_NOT_FOUND", serviceError.Code)
+	})
+}
+
+func TestCharacterService_FindDuplicates(t *testing.T) {
+	characters := []models.Character{
+		{ID: "char-1", GroupID: "group-1", Name: "織田信長", Aliases: []models.CharacterAlias{
+			{Type: models.AliasTypeAlias, Name: "吉法師"},
+			{Type: models.AliasTypeReading, Name: "おだ のぶなが"},
+		}},
+		{ID: "char-2", GroupID: "group-1", Name: "吉法師"},
+		{ID: "char-3", GroupID: "group-1", Name: "三郎", Aliases: []models.CharacterAlias{{Type: models.AliasTypeReading, Name: "オダ ノブナガ"}}},
+		{ID: "char-4", GroupID: "group-2", Name: "織田信長"},
+		{ID: "char-5", GroupID: "group-1", Name: "豊臣秀吉"},
+	}
+
+	t.Run("同じグループで名前・別名が一致する人物をまとめる", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		mockCharacterRepo.On("GetAll").Return(characters, nil)
+
+		result, err := service.FindDuplicates("")
+
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, []string{"おだのぶなが", "吉法師"}, result[0].Names)
+		var ids []string
+		for _, character := range result[0].Characters {
+			ids = append(ids, character.ID)
+		}
+		// 別のグループの同名の人物は含めない
+		assert.ElementsMatch(t, []string{"char-1", "char-2", "char-3"}, ids)
+	})
+
+	t.Run("グループを指定", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		mockGroupRepo.On("ExistsByID", "group-2").Return(true, nil)
+		mockCharacterRepo.On("GetByGroupID", "group-2").Return(characters[3:4], nil)
+
+		result, err := service.FindDuplicates("group-2")
+
+		require.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NotNil(t, result)
+	})
+
+	t.Run("存在しないグループ", func(t *testing.T) {
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewCharacterService(new(MockCharacterRepository), mockGroupRepo, new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		mockGroupRepo.On("ExistsByID", "missing").Return(false, nil)
+
+		_, err := service.FindDuplicates("missing")
+
+		assert.Equal(t, errGroupNotFound(), err)
+	})
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"character-management-app/internal/repositories"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// エラーの分類（errors.Is で判定する）
+var (
+	// ErrNotFound 対象のリソースが存在しない
+	ErrNotFound = errors.New("resource not found")
+	// ErrConflict 既存のリソースと重複・矛盾する
+	ErrConflict = errors.New("resource conflict")
+	// ErrValidation 入力値が不正
+	ErrValidation = errors.New("validation failed")
+	// ErrLimitExceeded 上限を超える操作
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrTooLarge アップロードが大きすぎる（画像の画素数・保存容量の上限）
+	ErrTooLarge = errors.New("payload too large")
+	// ErrForbidden アクセスが許可されていない（署名付きURLの署名の誤り・期限切れ）
+	ErrForbidden = errors.New("forbidden")
+)
+
+// ErrVersionConflict 楽観的ロックによる更新競合（If-Match のバージョン不一致を含む）
+var ErrVersionConflict = repositories.ErrVersionConflict
+
+// Error サービスが返す分類付きのエラー
+// Kind は上記の分類のいずれかで、errors.Is(err, ErrNotFound) のように判定できる
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is 分類との比較
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap 原因となったエラーを返す
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewNotFoundError リソースが存在しないエラーを作成
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+// NewConflictError リソースの重複・矛盾エラーを作成
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+// NewValidationError 入力値のエラーを作成
+func NewValidationError(code, message string, details interface{}) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message, Details: details}
+}
+
+// NewLimitExceededError 上限超過エラーを作成
+func NewLimitExceededError(code, message string, details interface{}) *Error {
+	return &Error{Kind: ErrLimitExceeded, Code: code, Message: message, Details: details}
+}
+
+// NewTooLargeError アップロードが大きすぎるエラーを作成
+func NewTooLargeError(code, message string, details interface{}) *Error {
+	return &Error{Kind: ErrTooLarge, Code: code, Message: message, Details: details}
+}
+
+// NewForbiddenError アクセスが許可されていないエラーを作成
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+// validationFailed 構造体の検証エラーを入力値のエラーに変換
+func validationFailed(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	return &Error{Kind: ErrValidation, Code: "VALIDATION_ERROR", Message: "Validation failed", Err: validationErrors}
+}
+
+// lookupError リポジトリの取得エラーを変換（レコードがなければ元のエラーを包んだ notFound を返す）
+func lookupError(err error, notFound *Error, action string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		notFound.Err = err
+		return notFound
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// リソースごとの NotFound エラー
+func errCharacterNotFound() *Error {
+	return NewNotFoundError("CHARACTER_NOT_FOUND", "character not found")
+}
+
+func errCharacterImageNotFound() *Error {
+	return NewNotFoundError("CHARACTER_IMAGE_NOT_FOUND", "character image not found")
+}
+
+func errGroupNotFound() *Error {
+	return NewNotFoundError("GROUP_NOT_FOUND", "group not found")
+}
+
+func errLabelNotFound() *Error {
+	return NewNotFoundError("LABEL_NOT_FOUND", "label not found")
+}
+
+func errLabelCategoryNotFound() *Error {
+	return NewNotFoundError("LABEL_CATEGORY_NOT_FOUND", "label category not found")
+}
+
+func errSavedViewNotFound() *Error {
+	return NewNotFoundError("SAVED_VIEW_NOT_FOUND", "saved view not found")
+}
+
+func errRelationshipNotFound() *Error {
+	return NewNotFoundError("RELATIONSHIP_NOT_FOUND", "relationship not found")
+}
+
+func errWorkspaceNotFound() *Error {
+	return NewNotFoundError("WORKSPACE_NOT_FOUND", "workspace not found")
+}
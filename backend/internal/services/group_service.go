@@ -15,18 +15,28 @@ type GroupService interface {
 	GetAllGroups() ([]models.Group, error)
 	UpdateGroup(id string, req *UpdateGroupRequest) (*models.Group, error)
 	DeleteGroup(id string) error
+	WithWorkspace(workspaceID string) GroupService
 }
 
 // CreateGroupRequest グループ作成リクエスト
+// LabelLimit を省略した場合は人物1人あたりのラベルの上限に全体の既定値を使う
 type CreateGroupRequest struct {
 	Name        string  `json:"name" validate:"required,max=255"`
 	Description *string `json:"description"`
+	LabelLimit  *int    `json:"labelLimit" validate:"omitempty,min=1,max=100"`
 }
 
 // UpdateGroupRequest グループ更新リクエスト
+// Version を指定した場合は、そのバージョンから変更されていないときのみ更新する
 type UpdateGroupRequest struct {
 	Name        *string `json:"name" validate:"omitempty,max=255"`
 	Description *string `json:"description"`
+	LabelLimit  *int    `json:"labelLimit" validate:"omitempty,min=1,max=100"`
+	Version     uint    `json:"version,omitempty"`
+	// ClearDescription 説明を削除する（PATCH で null を指定した場合。Description より優先）
+	ClearDescription bool `json:"-"`
+	// ClearLabelLimit ラベルの上限の設定を削除して全体の既定値に戻す（PATCH で null を指定した場合。LabelLimit より優先）
+	ClearLabelLimit bool `json:"-"`
 }
 
 // groupService グループサービスの実装
@@ -43,17 +53,26 @@ func NewGroupService(groupRepo repositories.GroupRepository) GroupService {
 	}
 }
 
+// WithWorkspace 指定したワークスペースに限定したサービスを返す
+func (s *groupService) WithWorkspace(workspaceID string) GroupService {
+	return &groupService{
+		groupRepo: s.groupRepo.WithWorkspace(workspaceID),
+		validator: s.validator,
+	}
+}
+
 // CreateGroup グループを作成
 func (s *groupService) CreateGroup(req *CreateGroupRequest) (*models.Group, error) {
 	// バリデーション
 	if err := s.validator.Struct(req); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
 
 	// グループモデルを作成
 	group := &models.Group{
 		Name:        req.Name,
 		Description: req.Description,
+		LabelLimit:  req.LabelLimit,
 	}
 
 	// データベースに保存
@@ -67,12 +86,12 @@ func (s *groupService) CreateGroup(req *CreateGroupRequest) (*models.Group, erro
 // GetGroup グループを取得
 func (s *groupService) GetGroup(id string) (*models.Group, error) {
 	if id == "" {
-		return nil, fmt.Errorf("group ID is required")
+		return nil, NewValidationError("INVALID_ID", "group ID is required", nil)
 	}
 
 	group, err := s.groupRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get group: %w", err)
+		return nil, lookupError(err, errGroupNotFound(), "failed to get group")
 	}
 
 	return group, nil
@@ -91,27 +110,38 @@ func (s *groupService) GetAllGroups() ([]models.Group, error) {
 // UpdateGroup グループを更新
 func (s *groupService) UpdateGroup(id string, req *UpdateGroupRequest) (*models.Group, error) {
 	if id == "" {
-		return nil, fmt.Errorf("group ID is required")
+		return nil, NewValidationError("INVALID_ID", "group ID is required", nil)
 	}
 
 	// バリデーション
 	if err := s.validator.Struct(req); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
 
 	// 既存のグループを取得
 	group, err := s.groupRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get group: %w", err)
+		return nil, lookupError(err, errGroupNotFound(), "failed to get group")
 	}
 
 	// フィールドを更新
 	if req.Name != nil {
 		group.Name = *req.Name
 	}
-	if req.Description != nil {
+	if req.ClearDescription {
+		group.Description = nil
+	} else if req.Description != nil {
 		group.Description = req.Description
 	}
+	// 上限を下げても既に付いているラベルは外さない
+	if req.ClearLabelLimit {
+		group.LabelLimit = nil
+	} else if req.LabelLimit != nil {
+		group.LabelLimit = req.LabelLimit
+	}
+	if req.Version != 0 {
+		group.Version = req.Version
+	}
 
 	// データベースを更新
 	if err := s.groupRepo.Update(group); err != nil {
@@ -124,7 +154,7 @@ func (s *groupService) UpdateGroup(id string, req *UpdateGroupRequest) (*models.
 // DeleteGroup グループを削除
 func (s *groupService) DeleteGroup(id string) error {
 	if id == "" {
-		return fmt.Errorf("group ID is required")
+		return NewValidationError("INVALID_ID", "group ID is required", nil)
 	}
 
 	// グループが存在するかチェック
@@ -133,7 +163,7 @@ func (s *groupService) DeleteGroup(id string) error {
 		return fmt.Errorf("failed to check group existence: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("group not found")
+		return errGroupNotFound()
 	}
 
 	// グループを削除
@@ -9,6 +9,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -59,7 +60,8 @@ func TestGroupService_CreateGroup(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.IsType(t, validator.ValidationErrors{}, err)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.ErrorAs(t, err, &validator.ValidationErrors{})
 	})
 
 	t.Run("バリデーションエラー - 名前が長すぎる", func(t *testing.T) {
@@ -80,7 +82,8 @@ func TestGroupService_CreateGroup(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.IsType(t, validator.ValidationErrors{}, err)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.ErrorAs(t, err, &validator.ValidationErrors{})
 	})
 
 	t.Run("リポジトリエラー", func(t *testing.T) {
@@ -155,7 +158,8 @@ func TestGroupService_GetGroup(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "failed to get group")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -269,6 +273,47 @@ func TestGroupService_UpdateGroup(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("ラベルの上限の設定と削除", func(t *testing.T) {
+		mockRepo := new(MockGroupRepository)
+		service := NewGroupService(mockRepo)
+
+		limit := 10
+		existingGroup := &models.Group{ID: "test-id", Name: "Name"}
+		mockRepo.On("GetByID", "test-id").Return(existingGroup, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*models.Group")).Return(nil)
+
+		result, err := service.UpdateGroup("test-id", &UpdateGroupRequest{LabelLimit: &limit})
+		assert.NoError(t, err)
+		assert.Equal(t, &limit, result.LabelLimit)
+
+		// 削除を指定すると全体の既定値に戻す
+		result, err = service.UpdateGroup("test-id", &UpdateGroupRequest{ClearLabelLimit: true})
+		assert.NoError(t, err)
+		assert.Nil(t, result.LabelLimit)
+	})
+
+	t.Run("ラベルの上限の 0 は範囲外", func(t *testing.T) {
+		mockRepo := new(MockGroupRepository)
+		service := NewGroupService(mockRepo)
+
+		unset := 0
+		_, err := service.UpdateGroup("test-id", &UpdateGroupRequest{LabelLimit: &unset})
+
+		assert.ErrorIs(t, err, ErrValidation)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("ラベルの上限が範囲外", func(t *testing.T) {
+		mockRepo := new(MockGroupRepository)
+		service := NewGroupService(mockRepo)
+
+		limit := 101
+		_, err := service.UpdateGroup("test-id", &UpdateGroupRequest{LabelLimit: &limit})
+
+		assert.ErrorIs(t, err, ErrValidation)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
 	t.Run("部分更新 - 名前のみ", func(t *testing.T) {
 		mockRepo := new(MockGroupRepository)
 		service := NewGroupService(mockRepo)
@@ -297,6 +342,60 @@ func TestGroupService_UpdateGroup(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("空文字の説明は空文字のまま保存", func(t *testing.T) {
+		mockRepo := new(MockGroupRepository)
+		service := NewGroupService(mockRepo)
+		
+		oldDescription := "Old Description"
+		existingGroup := &models.Group{
+			ID:          "test-id",
+			Name:        "Name",
+			Description: &oldDescription,
+		}
+		empty := ""
+
+		mockRepo.On("GetByID", "test-id").Return(existingGroup, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*models.Group")).Return(nil)
+
+		result, err := service.UpdateGroup("test-id", &UpdateGroupRequest{Description: &empty})
+
+		assert.NoError(t, err)
+		require.NotNil(t, result.Description)
+		assert.Equal(t, "", *result.Description)
+	})
+
+	t.Run("説明とラベルの上限の削除を指定", func(t *testing.T) {
+		mockRepo := new(MockGroupRepository)
+		service := NewGroupService(mockRepo)
+		
+		// テストデータ
+		oldDescription := "Old Description"
+		existingGroup := &models.Group{
+			ID:          "test-id",
+			Name:        "Name",
+			Description: &oldDescription,
+		}
+		limit := 3
+		existingGroup.LabelLimit = &limit
+		req := &UpdateGroupRequest{
+			ClearDescription: true,
+			ClearLabelLimit:  true,
+		}
+
+		// モックの設定
+		mockRepo.On("GetByID", "test-id").Return(existingGroup, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*models.Group")).Return(nil)
+
+		// テスト実行
+		result, err := service.UpdateGroup("test-id", req)
+
+		// 検証
+		assert.NoError(t, err)
+		assert.Nil(t, result.Description)
+		assert.Nil(t, result.LabelLimit)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("IDが空の場合", func(t *testing.T) {
 		mockRepo := new(MockGroupRepository)
 		service := NewGroupService(mockRepo)
@@ -332,7 +431,8 @@ func TestGroupService_UpdateGroup(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.IsType(t, validator.ValidationErrors{}, err)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.ErrorAs(t, err, &validator.ValidationErrors{})
 	})
 
 	t.Run("グループが見つからない場合", func(t *testing.T) {
@@ -352,7 +452,8 @@ func TestGroupService_UpdateGroup(t *testing.T) {
 		// 検証
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "failed to get group")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+
+	"character-management-app/internal/models"
+
+	"github.com/nfnt/resize"
+	"golang.org/x/image/webp"
+)
+
+// ImageFormat 保存する画像の形式の方針
+type ImageFormat string
+
+const (
+	// ImageFormatOriginal JPEG・PNG・GIF はそのままの形式、WebP は PNG で保存する
+	ImageFormatOriginal ImageFormat = "original"
+	// ImageFormatJPEG 全て JPEG に変換する（透過部分は白で塗りつぶす）
+	ImageFormatJPEG ImageFormat = "jpeg"
+	// ImageFormatPNG 全て PNG に変換する
+	ImageFormatPNG ImageFormat = "png"
+)
+
+// allowedImageTypes アップロードを受け付ける画像の種類（内容から判定した MIME タイプと形式）
+var allowedImageTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// DefaultMaxImagePixels デコードを許可する画素数（幅 x 高さ）の既定値
+// アニメーションGIFは全てのフレームを合成して保持するため、フレーム数を掛けた値で判定する
+const DefaultMaxImagePixels = 50_000_000
+
+// decodedImage デコードした画像
+// アニメーションGIFの場合は各フレームを前のフレームに重ねた状態で保持する
+type decodedImage struct {
+	format     string
+	frames     []image.Image
+	palettes   []color.Palette
+	delays     []int
+	loopCount  int
+	iccProfile []byte
+}
+
+// animated 複数フレームの画像か
+func (d *decodedImage) animated() bool {
+	return len(d.frames) > 1
+}
+
+// detectImageFormat 内容から画像の形式を判定（ファイル名の拡張子は使わない）
+func detectImageFormat(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	format, ok := allowedImageTypes[contentType]
+	if !ok {
+		return "", NewValidationError("UNSUPPORTED_IMAGE_TYPE", "unsupported image type: "+contentType,
+			map[string]interface{}{"allowed": []string{"image/jpeg", "image/png", "image/gif", "image/webp"}})
+	}
+	return format, nil
+}
+
+// checkImageSize ヘッダーの幅・高さだけを読み込み、画素数が上限を超える画像を全体のデコード前に拒否する
+// 小さなファイルで巨大な画素数を宣言する画像（解凍爆弾）でメモリを使い切らないようにする
+func checkImageSize(data []byte, format string, maxPixels int64) error {
+	reader := bytes.NewReader(data)
+
+	var config image.Config
+	var err error
+	switch format {
+	case "gif":
+		config, err = gif.DecodeConfig(reader)
+	case "jpeg":
+		config, err = jpeg.DecodeConfig(reader)
+	case "png":
+		config, err = png.DecodeConfig(reader)
+	case "webp":
+		config, err = webp.DecodeConfig(reader)
+	default:
+		err = fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		invalid := NewValidationError("INVALID_IMAGE", "failed to decode image", nil)
+		invalid.Err = err
+		return invalid
+	}
+
+	if int64(config.Width)*int64(config.Height) > maxPixels {
+		return errImageTooLarge(config.Width, config.Height, 1, maxPixels)
+	}
+	return nil
+}
+
+// errImageTooLarge 画素数の上限を超えた画像のエラー
+func errImageTooLarge(width, height, frames int, maxPixels int64) *Error {
+	details := map[string]interface{}{"width": width, "height": height, "maxPixels": maxPixels}
+	if frames > 1 {
+		details["frames"] = frames
+	}
+	return NewTooLargeError("IMAGE_TOO_LARGE",
+		fmt.Sprintf("image is too large: %dx%d pixels x %d frames exceeds %d pixels", width, height, frames, maxPixels), details)
+}
+
+// decodeImage 判定した形式のデコーダーで画像を読み込む
+// 画素数の上限は checkImageSize で確認済みとし、ここではアニメーションGIFのフレーム数のみ確認する
+func decodeImage(data []byte, format string, maxPixels int64) (*decodedImage, error) {
+	reader := bytes.NewReader(data)
+
+	var img image.Image
+	var err error
+	switch format {
+	case "gif":
+		return decodeGIF(data, maxPixels)
+	case "jpeg":
+		img, err = jpeg.Decode(reader)
+	case "png":
+		img, err = png.Decode(reader)
+	case "webp":
+		img, err = webp.Decode(reader)
+	default:
+		err = fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &decodedImage{format: format, frames: []image.Image{img}}, nil
+}
+
+// decodeGIF GIFの全フレームを読み込み、破棄方法に従って合成する
+// 合成したフレームは全体の大きさで保持するため、合成前に画素数 x フレーム数を上限と比較する
+func decodeGIF(data []byte, maxPixels int64) (*decodedImage, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("gif has no frames")
+	}
+	if int64(g.Config.Width)*int64(g.Config.Height)*int64(len(g.Image)) > maxPixels {
+		return nil, errImageTooLarge(g.Config.Width, g.Config.Height, len(g.Image), maxPixels)
+	}
+
+	decoded := &decodedImage{format: "gif", delays: g.Delay, loopCount: g.LoopCount}
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		decoded.frames = append(decoded.frames, cloneRGBA(canvas))
+		decoded.palettes = append(decoded.palettes, frame.Palette)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return decoded, nil
+}
+
+// outputFormat 方針に従って保存する形式を決める
+func outputFormat(policy ImageFormat, source string) string {
+	switch policy {
+	case ImageFormatJPEG:
+		return "jpeg"
+	case ImageFormatPNG:
+		return "png"
+	default:
+		// WebP のエンコーダーはないため、透過を保てる PNG で保存する
+		if source == "webp" {
+			return "png"
+		}
+		return source
+	}
+}
+
+// resizeImage バリアントのサイズに縮小（元画像より大きくはしない）
+// 切り抜き対象のバリアントには切り抜き範囲を適用し、正方形の場合は注目点を中心に切り抜く
+func resizeImage(img image.Image, variant ImageVariant, crop *models.PhotoCrop) image.Image {
+	var focus *image.Point
+	if variant.Cropped && crop != nil {
+		// 注目点は切り抜き前の画像に対する位置
+		if crop.Focus != nil {
+			point := focalPoint(img.Bounds(), crop.Focus)
+			focus = &point
+		}
+		if crop.Rect != nil {
+			img = subImage(img, cropRectangle(img.Bounds(), crop.Rect))
+		}
+	}
+	if variant.Square {
+		img = cropSquare(img, focus)
+	}
+
+	bounds := img.Bounds()
+	if uint(bounds.Dx()) <= variant.Width && uint(bounds.Dy()) <= variant.Height {
+		return img
+	}
+	return resize.Thumbnail(variant.Width, variant.Height, img, resize.Lanczos3)
+}
+
+// cropRectangle 割合で指定された切り抜き範囲をピクセル座標に変換（最低1ピクセル）
+func cropRectangle(bounds image.Rectangle, rect *models.CropRect) image.Rectangle {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	r := image.Rect(
+		bounds.Min.X+int(math.Round(rect.X*w)),
+		bounds.Min.Y+int(math.Round(rect.Y*h)),
+		bounds.Min.X+int(math.Round((rect.X+rect.Width)*w)),
+		bounds.Min.Y+int(math.Round((rect.Y+rect.Height)*h)),
+	).Intersect(bounds)
+
+	if r.Dx() < 1 || r.Dy() < 1 {
+		x := clamp(r.Min.X, bounds.Min.X, bounds.Max.X-1)
+		y := clamp(r.Min.Y, bounds.Min.Y, bounds.Max.Y-1)
+		r = image.Rect(x, y, x+1, y+1)
+	}
+	return r
+}
+
+// focalPoint 割合で指定された注目点をピクセル座標に変換
+func focalPoint(bounds image.Rectangle, focus *models.FocalPoint) image.Point {
+	return image.Pt(
+		bounds.Min.X+int(math.Round(focus.X*float64(bounds.Dx()))),
+		bounds.Min.Y+int(math.Round(focus.Y*float64(bounds.Dy()))),
+	)
+}
+
+// cropSquare 画像を正方形に切り抜く
+// focus を指定した場合はその位置を中心にし、画像からはみ出す場合は内側に寄せる（未指定は中央）
+func cropSquare(img image.Image, focus *image.Point) image.Image {
+	bounds := img.Bounds()
+	size := bounds.Dx()
+	if bounds.Dy() < size {
+		size = bounds.Dy()
+	}
+
+	center := image.Pt(bounds.Min.X+bounds.Dx()/2, bounds.Min.Y+bounds.Dy()/2)
+	if focus != nil {
+		center = *focus
+	}
+	x := clamp(center.X-size/2, bounds.Min.X, bounds.Max.X-size)
+	y := clamp(center.Y-size/2, bounds.Min.Y, bounds.Max.Y-size)
+	return subImage(img, image.Rect(x, y, x+size, y+size))
+}
+
+// subImage 画像の一部を切り出す
+func subImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped
+}
+
+// clamp 値を lower 以上 upper 以下に収める
+func clamp(value, lower, upper int) int {
+	if value < lower {
+		return lower
+	}
+	if value > upper {
+		return upper
+	}
+	return value
+}
+
+// encodeVariant バリアントのサイズに縮小して指定の形式でエンコード
+// アニメーションGIFを GIF で保存する場合のみ全フレームを残し、それ以外は先頭フレームを使う
+func encodeVariant(img *decodedImage, variant ImageVariant, format string, crop *models.PhotoCrop) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "gif":
+		if img.animated() {
+			if err := gif.EncodeAll(&buf, resizeAnimation(img, variant, crop)); err != nil {
+				return nil, "", err
+			}
+			return buf.Bytes(), "image/gif", nil
+		}
+		if err := gif.Encode(&buf, resizeImage(img.frames[0], variant, crop), nil); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/gif", nil
+	case "png":
+		if err := png.Encode(&buf, resizeImage(img.frames[0], variant, crop)); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	default:
+		// JPEG は透過を扱えないため白の背景に重ねる
+		if err := jpeg.Encode(&buf, flatten(resizeImage(img.frames[0], variant, crop)), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+}
+
+// resizeAnimation 全フレームを縮小し、元のパレットで減色したGIFを作る
+func resizeAnimation(img *decodedImage, variant ImageVariant, crop *models.PhotoCrop) *gif.GIF {
+	out := &gif.GIF{Delay: img.delays, LoopCount: img.loopCount}
+	for i, frame := range img.frames {
+		resized := resizeImage(frame, variant, crop)
+		bounds := image.Rect(0, 0, resized.Bounds().Dx(), resized.Bounds().Dy())
+
+		palette := img.palettes[i]
+		if len(palette) == 0 {
+			palette = color.Palette{color.Transparent, color.Black, color.White}
+		}
+		paletted := image.NewPaletted(bounds, palette)
+		draw.FloydSteinberg.Draw(paletted, bounds, resized, resized.Bounds().Min)
+
+		out.Image = append(out.Image, paletted)
+		// 各フレームは合成済みの全体画像なので、次のフレームの前に消去する
+		out.Disposal = append(out.Disposal, gif.DisposalBackground)
+		out.Config.Width, out.Config.Height = bounds.Dx(), bounds.Dy()
+	}
+	return out
+}
+
+// flatten 透過部分を白で塗りつぶす
+func flatten(img image.Image) image.Image {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return flat
+}
+
+// cloneRGBA 画像のコピー
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := image.NewRGBA(img.Bounds())
+	copy(clone.Pix, img.Pix)
+	return clone
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"io"
+)
+
+// imageMetadata アップロード画像から読み取ったメタデータ
+// 再エンコードした画像には Go のエンコーダーの仕様上 EXIF・XMP・ICC は含まれないため、
+// 向きの補正に使う orientation と、設定で残す場合の ICC プロファイルだけを保持する
+type imageMetadata struct {
+	orientation int
+	iccProfile  []byte
+}
+
+// exifOrientationTag EXIF の Orientation タグ
+const exifOrientationTag = 0x0112
+
+// maxICCSegmentSize JPEG の APP2 セグメント1つに入る ICC プロファイルの最大サイズ
+const maxICCSegmentSize = 65535 - 2 - 14
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegICCHeader  = []byte("ICC_PROFILE\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// readMetadata 形式ごとに EXIF の向きと ICC プロファイルを読み取る（読めない場合は既定値）
+func readMetadata(data []byte, format string) imageMetadata {
+	metadata := imageMetadata{orientation: 1}
+	switch format {
+	case "jpeg":
+		readJPEGMetadata(data, &metadata)
+	case "png":
+		readPNGMetadata(data, &metadata)
+	case "webp":
+		readWebPMetadata(data, &metadata)
+	}
+	return metadata
+}
+
+// readJPEGMetadata APP1 (Exif) と APP2 (ICC_PROFILE) セグメントを読む
+func readJPEGMetadata(data []byte, metadata *imageMetadata) {
+	iccChunks := map[byte][]byte{}
+	var iccCount byte
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// SOS 以降は画像データ
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(segment, jpegExifHeader):
+			metadata.orientation = exifOrientation(segment[len(jpegExifHeader):])
+		case marker == 0xE2 && bytes.HasPrefix(segment, jpegICCHeader) && len(segment) > len(jpegICCHeader)+2:
+			seq := segment[len(jpegICCHeader)]
+			iccCount = segment[len(jpegICCHeader)+1]
+			iccChunks[seq] = segment[len(jpegICCHeader)+2:]
+		}
+		i += 2 + length
+	}
+
+	// ICC プロファイルは連番のセグメントに分割されている
+	var profile []byte
+	for seq := byte(1); seq <= iccCount && seq != 0; seq++ {
+		chunk, ok := iccChunks[seq]
+		if !ok {
+			return
+		}
+		profile = append(profile, chunk...)
+	}
+	metadata.iccProfile = profile
+}
+
+// readPNGMetadata eXIf と iCCP チャンクを読む
+func readPNGMetadata(data []byte, metadata *imageMetadata) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return
+	}
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		if length < 0 || i+12+length > len(data) {
+			return
+		}
+		chunk := data[i+8 : i+8+length]
+
+		switch chunkType {
+		case "eXIf":
+			metadata.orientation = exifOrientation(chunk)
+		case "iCCP":
+			// プロファイル名 \0 圧縮方式(1byte) zlib圧縮データ
+			if nameEnd := bytes.IndexByte(chunk, 0); nameEnd >= 0 && nameEnd+2 <= len(chunk) {
+				if reader, err := zlib.NewReader(bytes.NewReader(chunk[nameEnd+2:])); err == nil {
+					if profile, err := io.ReadAll(reader); err == nil {
+						metadata.iccProfile = profile
+					}
+				}
+			}
+		case "IEND":
+			return
+		}
+		i += 12 + length
+	}
+}
+
+// readWebPMetadata EXIF と ICCP チャンクを読む
+func readWebPMetadata(data []byte, metadata *imageMetadata) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return
+	}
+	for i := 12; i+8 <= len(data); {
+		chunkType := string(data[i : i+4])
+		length := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if length < 0 || i+8+length > len(data) {
+			return
+		}
+		chunk := data[i+8 : i+8+length]
+
+		switch chunkType {
+		case "EXIF":
+			metadata.orientation = exifOrientation(bytes.TrimPrefix(chunk, jpegExifHeader))
+		case "ICCP":
+			metadata.iccProfile = chunk
+		}
+		// チャンクは偶数バイトに揃えられている
+		i += 8 + length + length%2
+	}
+}
+
+// exifOrientation TIFF 形式の EXIF から IFD0 の Orientation を読む（1〜8 以外は 1）
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		// 型が SHORT (3) の Orientation のみ扱う
+		if order.Uint16(tiff[entry:]) == exifOrientationTag && order.Uint16(tiff[entry+2:]) == 3 {
+			if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation EXIF の向きに従って画像を正立させる
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	outW, outH := w, h
+	if orientation >= 5 {
+		outW, outH = h, w
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outW, outH))
+	for y := 0; y < outH; y++ {
+		for x := 0; x < outW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 左右反転
+				sx, sy = w-1-x, y
+			case 3: // 180度回転
+				sx, sy = w-1-x, h-1-y
+			case 4: // 上下反転
+				sx, sy = x, h-1-y
+			case 5: // 左上と右下を結ぶ対角線で反転
+				sx, sy = y, x
+			case 6: // 時計回りに90度回転
+				sx, sy = y, h-1-x
+			case 7: // 右上と左下を結ぶ対角線で反転
+				sx, sy = w-1-y, h-1-x
+			case 8: // 反時計回りに90度回転
+				sx, sy = w-1-y, x
+			}
+			out.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return out
+}
+
+// embedICCProfile エンコード済みの画像に ICC プロファイルを埋め込む（JPEG・PNG のみ）
+func embedICCProfile(data []byte, format string, profile []byte) []byte {
+	if len(profile) == 0 {
+		return data
+	}
+	switch format {
+	case "jpeg":
+		return embedJPEGICCProfile(data, profile)
+	case "png":
+		return embedPNGICCProfile(data, profile)
+	default:
+		return data
+	}
+}
+
+// embedJPEGICCProfile SOI の直後に APP2 (ICC_PROFILE) セグメントを挿入
+func embedJPEGICCProfile(data []byte, profile []byte) []byte {
+	if len(data) < 2 {
+		return data
+	}
+	count := (len(profile) + maxICCSegmentSize - 1) / maxICCSegmentSize
+	if count > 255 {
+		return data
+	}
+
+	var buf bytes.Buffer
+	buf.Write(data[:2])
+	for i := 0; i < count; i++ {
+		chunk := profile[i*maxICCSegmentSize:]
+		if len(chunk) > maxICCSegmentSize {
+			chunk = chunk[:maxICCSegmentSize]
+		}
+		buf.Write([]byte{0xFF, 0xE2})
+		binary.Write(&buf, binary.BigEndian, uint16(2+len(jpegICCHeader)+2+len(chunk)))
+		buf.Write(jpegICCHeader)
+		buf.Write([]byte{byte(i + 1), byte(count)})
+		buf.Write(chunk)
+	}
+	buf.Write(data[2:])
+	return buf.Bytes()
+}
+
+// embedPNGICCProfile IHDR の直後に iCCP チャンクを挿入
+func embedPNGICCProfile(data []byte, profile []byte) []byte {
+	// シグネチャ(8) + IHDR チャンク(4+4+13+4)
+	ihdrEnd := len(pngSignature) + 25
+	if len(data) < ihdrEnd {
+		return data
+	}
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(profile)
+	writer.Close()
+
+	chunk := append([]byte("icc\x00\x00"), compressed.Bytes()...)
+
+	var buf bytes.Buffer
+	buf.Write(data[:ihdrEnd])
+	binary.Write(&buf, binary.BigEndian, uint32(len(chunk)))
+	typeAndData := append([]byte("iCCP"), chunk...)
+	buf.Write(typeAndData)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(typeAndData))
+	buf.Write(data[ihdrEnd:])
+	return buf.Bytes()
+}
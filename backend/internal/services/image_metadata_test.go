@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"character-management-app/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gpsMarker テスト用の EXIF に含める位置情報の文字列
+const gpsMarker = "GPS 35.6895N 139.6917E"
+
+// exifWithOrientation Orientation と位置情報を含む TIFF 形式の EXIF を作る
+func exifWithOrientation(orientation int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("MM\x00\x2a")
+	binary.Write(&buf, binary.BigEndian, uint32(8))
+	// IFD0: Orientation (SHORT) と GPSInfo (LONG) の2エントリ
+	binary.Write(&buf, binary.BigEndian, uint16(2))
+	binary.Write(&buf, binary.BigEndian, []uint16{exifOrientationTag, 3})
+	binary.Write(&buf, binary.BigEndian, uint32(1))
+	binary.Write(&buf, binary.BigEndian, []uint16{uint16(orientation), 0})
+	binary.Write(&buf, binary.BigEndian, []uint16{0x8825, 4})
+	binary.Write(&buf, binary.BigEndian, uint32(1))
+	binary.Write(&buf, binary.BigEndian, uint32(38))
+	binary.Write(&buf, binary.BigEndian, uint32(0))
+	buf.WriteString(gpsMarker)
+	return buf.Bytes()
+}
+
+// withJPEGSegment JPEG の SOI の直後にセグメントを挿入
+func withJPEGSegment(data []byte, marker byte, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(data[:2])
+	buf.Write([]byte{0xFF, marker})
+	binary.Write(&buf, binary.BigEndian, uint16(len(payload)+2))
+	buf.Write(payload)
+	buf.Write(data[2:])
+	return buf.Bytes()
+}
+
+// withPNGChunk PNG の IHDR の直後にチャンクを挿入
+func withPNGChunk(data []byte, chunkType string, payload []byte) []byte {
+	ihdrEnd := len(pngSignature) + 25
+	var buf bytes.Buffer
+	buf.Write(data[:ihdrEnd])
+	binary.Write(&buf, binary.BigEndian, uint32(len(payload)))
+	typeAndData := append([]byte(chunkType), payload...)
+	buf.Write(typeAndData)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(typeAndData))
+	buf.Write(data[ihdrEnd:])
+	return buf.Bytes()
+}
+
+// orientedFixture 正立時に左上が赤い 40x20 の画像を、指定の Orientation で保存された状態で作る
+func orientedFixture(orientation int) image.Image {
+	// Orientation ごとの保存時の赤いブロックの位置（右端か・下端か）
+	corners := map[int][2]bool{
+		1: {false, false}, 2: {true, false}, 3: {true, true}, 4: {false, true},
+		5: {false, false}, 6: {false, true}, 7: {true, true}, 8: {true, false},
+	}
+
+	w, h := 40, 20
+	if orientation >= 5 {
+		w, h = 20, 40
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+
+	x, y := 0, 0
+	if corners[orientation][0] {
+		x = w - 10
+	}
+	if corners[orientation][1] {
+		y = h - 10
+	}
+	draw.Draw(img, image.Rect(x, y, x+10, y+10), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+	return img
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xc000 && g < 0x4000 && b < 0x4000
+}
+
+func TestImageService_SaveImage_Orientation(t *testing.T) {
+	testDir := t.TempDir()
+
+	service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+	for orientation := 1; orientation <= 8; orientation++ {
+		t.Run("JPEG Orientation "+strconv.Itoa(orientation), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, jpeg.Encode(&buf, orientedFixture(orientation), &jpeg.Options{Quality: 95}))
+			data := withJPEGSegment(buf.Bytes(), 0xE1, append([]byte("Exif\x00\x00"), exifWithOrientation(orientation)...))
+
+			key, err := service.SaveImage(bytes.NewReader(data), nil)
+			require.NoError(t, err)
+			defer service.DeleteImage(key)
+
+			saved, err := os.ReadFile(filepath.Join(testDir, key))
+			require.NoError(t, err)
+			img, err := jpeg.Decode(bytes.NewReader(saved))
+			require.NoError(t, err)
+
+			// 正立した 40x20 の画像で、左上だけが赤い
+			assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+			assert.True(t, isRed(img.At(5, 5)), "top-left should be red")
+			assert.False(t, isRed(img.At(35, 5)), "top-right should not be red")
+			assert.False(t, isRed(img.At(5, 15)), "bottom-left should not be red")
+
+			// EXIF（位置情報を含む）は削除されている
+			assert.Equal(t, 1, readMetadata(saved, "jpeg").orientation)
+			assert.NotContains(t, string(saved), "Exif")
+			assert.NotContains(t, string(saved), gpsMarker)
+		})
+	}
+
+	t.Run("PNG の eXIf チャンク", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, orientedFixture(6)))
+		data := withPNGChunk(buf.Bytes(), "eXIf", exifWithOrientation(6))
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		saved, err := os.ReadFile(filepath.Join(testDir, key))
+		require.NoError(t, err)
+		img, err := png.Decode(bytes.NewReader(saved))
+		require.NoError(t, err)
+
+		assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+		assert.True(t, isRed(img.At(5, 5)))
+		assert.NotContains(t, string(saved), "eXIf")
+		assert.NotContains(t, string(saved), gpsMarker)
+	})
+}
+
+func TestImageService_SaveImage_ColorProfile(t *testing.T) {
+	testDir := t.TempDir()
+
+	profile := bytes.Repeat([]byte("fake icc profile "), 10)
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, orientedFixture(1), nil))
+	data := embedJPEGICCProfile(buf.Bytes(), profile)
+	data = withJPEGSegment(data, 0xE1, append([]byte("Exif\x00\x00"), exifWithOrientation(1)...))
+
+	t.Run("既定では ICC プロファイルも削除", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		saved, err := os.ReadFile(filepath.Join(testDir, key))
+		require.NoError(t, err)
+		assert.Empty(t, readMetadata(saved, "jpeg").iccProfile)
+		assert.NotContains(t, string(saved), gpsMarker)
+	})
+
+	t.Run("設定により ICC プロファイルのみ残す", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{KeepColorProfile: true})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		saved, err := os.ReadFile(filepath.Join(testDir, key))
+		require.NoError(t, err)
+		assert.Equal(t, profile, readMetadata(saved, "jpeg").iccProfile)
+		assert.NotContains(t, string(saved), "Exif")
+		assert.NotContains(t, string(saved), gpsMarker)
+	})
+
+	t.Run("PNG に変換しても ICC プロファイルを残す", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{OutputFormat: ImageFormatPNG, KeepColorProfile: true})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		saved, err := os.ReadFile(filepath.Join(testDir, key))
+		require.NoError(t, err)
+		assert.Equal(t, profile, readMetadata(saved, "png").iccProfile)
+
+		_, err = png.Decode(bytes.NewReader(saved))
+		assert.NoError(t, err)
+	})
+}
+
+func TestImageMetadata_ICCProfileSegments(t *testing.T) {
+	// APP2 セグメント1つに収まらない大きさのプロファイル
+	profile := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7}, 20000)
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+	data := embedJPEGICCProfile(buf.Bytes(), profile)
+
+	assert.Equal(t, profile, readMetadata(data, "jpeg").iccProfile)
+	_, err := jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+}
+
+func TestImageMetadata_exifOrientation(t *testing.T) {
+	tests := []struct {
+		name     string
+		tiff     []byte
+		expected int
+	}{
+		{"ビッグエンディアン", exifWithOrientation(6), 6},
+		{"範囲外の値", exifWithOrientation(9), 1},
+		{"短すぎるデータ", []byte("MM"), 1},
+		{"不正なバイトオーダー", []byte("XX\x00\x2a\x00\x00\x00\x08"), 1},
+		{"IFD のオフセットが範囲外", []byte("II\x2a\x00\xff\x00\x00\x00"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, exifOrientation(tt.tiff))
+		})
+	}
+}
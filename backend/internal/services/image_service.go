@@ -1,138 +1,647 @@
 package services
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
-	"os"
-	"path/filepath"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/nfnt/resize"
+	"character-management-app/internal/models"
+	"character-management-app/internal/repositories"
+	"character-management-app/internal/storage"
 )
 
+// legacyUploadPrefix ストレージ導入前に Character.Photo へ保存していた配信パスの接頭辞
+const legacyUploadPrefix = "/uploads/"
+
+// contentDirPattern 内容のハッシュで管理するディレクトリ名（SHA-256 の16進数）
+var contentDirPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ImageVariant 保存時に生成する画像のサイズ
+// Cropped の場合は写真の切り抜き範囲を適用し、Square の場合は注目点（未指定は中央）を中心に
+// 正方形に切り抜いてから Width x Height に縮小する
+type ImageVariant struct {
+	Name    string
+	Width   uint
+	Height  uint
+	Square  bool
+	Cropped bool
+}
+
+// PrimaryImageVariant photo として扱うバリアント名
+const PrimaryImageVariant = "full"
+
+// ImageVariants 保存時に生成するバリアント（グラフのアイコン・カード・詳細表示用）
+// full は切り抜かずに残し、切り抜き直す際の元画像として使う
+var ImageVariants = []ImageVariant{
+	{Name: "avatar", Width: 64, Height: 64, Square: true, Cropped: true},
+	{Name: "card", Width: 256, Height: 256, Cropped: true},
+	{Name: PrimaryImageVariant, Width: 1200, Height: 1200},
+}
+
 // ImageService 画像サービスのインターフェース
+// SaveImage・CropImage は photo として保存するキー（full バリアントのキー）を返し、他のメソッドはそのキーを受け取る
+// 画像は内容のハッシュのディレクトリに保存するため、同じ画像は複数の人物で共有される
 type ImageService interface {
-	SaveImage(file io.Reader, filename string, maxWidth, maxHeight uint) (string, error)
-	DeleteImage(filePath string) error
-	ValidateImageFile(filename string) error
+	SaveImage(file io.Reader, crop *models.PhotoCrop) (string, error)
+	CropImage(key string, crop *models.PhotoCrop) (string, error)
+	DeleteImage(key string) error
+	OpenImage(key string) (*storage.Object, error)
+	URL(key string) string
+	VariantURLs(key string) map[string]string
+	VerifyURL(key, expires, signature string) (time.Time, error)
+	URLExpiry() time.Time
+	CheckQuota(workspaceID, characterID, key string) (int64, error)
+	MigrateContentKeys() (int, error)
+	RecordPhotoSizes() (int, error)
+}
+
+// ImageOptions 画像サービスの設定
+type ImageOptions struct {
+	// OutputFormat 保存する形式の方針（未指定の場合は ImageFormatOriginal）
+	OutputFormat ImageFormat
+	// KeepColorProfile ICC プロファイルを残す（それ以外の EXIF・XMP 等のメタデータは常に削除する）
+	KeepColorProfile bool
+	// MaxPixels デコードを許可する画素数（0 以下の場合は DefaultMaxImagePixels）
+	MaxPixels int64
+	// WorkspaceQuota ワークスペースごとに保存できる写真の合計バイト数（0 以下の場合は無制限）
+	WorkspaceQuota int64
+	// URLSecret 配信用URLに付ける HMAC 署名の秘密鍵（空の場合は署名せず、誰でも画像を取得できる）
+	URLSecret []byte
+	// URLTTL 署名付きURLの有効期間（0 以下の場合は DefaultSignedURLTTL）
+	URLTTL time.Duration
+	// Clock 署名付きURLの有効期限の計算・確認と、削除の猶予の判定に使う現在時刻（nil の場合は time.Now）
+	Clock func() time.Time
+	// GracePeriod 保存・再利用して間もない画像を DeleteImage で削除しない期間（0 以下の場合は DefaultUploadGracePeriod）
+	// 人物の保存前の画像を削除しないためで、参照されないまま残った画像は孤立したファイルの削除で消える
+	GracePeriod time.Duration
 }
 
 // imageService 画像サービスの実装
 type imageService struct {
-	uploadDir string
+	storage       storage.Storage
+	characterRepo repositories.CharacterRepository
+	options       ImageOptions
+	signer        *urlSigner
+	// mu 既存の画像の再利用（更新日時の更新）と削除を排他する
+	mu sync.Mutex
 }
 
 // NewImageService 画像サービスのコンストラクタ
-func NewImageService(uploadDir string) ImageService {
+// characterRepo は画像の参照数の確認に使う（ワークスペースに限定しないリポジトリを渡す）
+// nil の場合は参照を確認せずに削除する
+func NewImageService(store storage.Storage, characterRepo repositories.CharacterRepository, options ImageOptions) ImageService {
+	if options.OutputFormat == "" {
+		options.OutputFormat = ImageFormatOriginal
+	}
+	if options.MaxPixels <= 0 {
+		options.MaxPixels = DefaultMaxImagePixels
+	}
+	if options.Clock == nil {
+		options.Clock = time.Now
+	}
+	if options.GracePeriod <= 0 {
+		options.GracePeriod = DefaultUploadGracePeriod
+	}
 	return &imageService{
-		uploadDir: uploadDir,
+		storage:       store,
+		characterRepo: characterRepo,
+		options:       options,
+		signer:        newURLSigner(options.URLSecret, options.URLTTL, options.Clock),
 	}
 }
 
-// SaveImage 画像を保存（リサイズ機能付き）
-func (s *imageService) SaveImage(file io.Reader, filename string, maxWidth, maxHeight uint) (string, error) {
-	// ファイル拡張子を検証
-	if err := s.ValidateImageFile(filename); err != nil {
+// SaveImage 画像を保存（バリアントごとにリサイズして同じディレクトリに保存）
+// 受け付ける形式はファイル名ではなく内容から判定する
+// 同じ画像を同じ条件で保存済みの場合は変換せずに既存のキーを返す
+func (s *imageService) SaveImage(file io.Reader, crop *models.PhotoCrop) (string, error) {
+	if err := validatePhotoCrop(crop); err != nil {
 		return "", err
 	}
 
-	// アップロードディレクトリを作成
-	if err := os.MkdirAll(s.uploadDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
 	}
 
-	// 画像をデコード
-	img, format, err := image.Decode(file)
+	format, err := detectImageFormat(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode image: %w", err)
+		return "", err
+	}
+	output := outputFormat(s.options.OutputFormat, format)
+	dir := s.contentDir(data, output, crop)
+	if key, err := s.existingKey(dir, output); key != "" || err != nil {
+		return key, err
 	}
 
-	// 画像をリサイズ（必要な場合）
-	bounds := img.Bounds()
-	width := uint(bounds.Dx())
-	height := uint(bounds.Dy())
+	img, _, err := s.decode(data)
+	if err != nil {
+		return "", err
+	}
+	return s.storeVariants(img, dir, output, crop, nil)
+}
 
-	if width > maxWidth || height > maxHeight {
-		img = resize.Thumbnail(maxWidth, maxHeight, img, resize.Lanczos3)
+// CropImage 保存済みの画像を切り抜き直して新しいキーに保存（元の画像は削除しない）
+// 切り抜いていない full バリアントから avatar・card を作り直すため、再アップロードは不要
+func (s *imageService) CropImage(key string, crop *models.PhotoCrop) (string, error) {
+	if err := validatePhotoCrop(crop); err != nil {
+		return "", err
 	}
 
-	// ユニークなファイル名を生成
-	ext := s.getExtensionFromFormat(format)
-	uniqueFilename := fmt.Sprintf("%d_%s%s", time.Now().Unix(), uuid.New().String()[:8], ext)
-	filePath := filepath.Join(s.uploadDir, uniqueFilename)
+	data, err := s.readImage(variantKeys(key)[PrimaryImageVariant])
+	if err != nil {
+		return "", err
+	}
 
-	// ファイルを作成
-	dst, err := os.Create(filePath)
+	img, metadata, err := s.decode(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+		return "", err
 	}
-	defer dst.Close()
 
-	// 画像を保存
-	switch format {
-	case "jpeg":
-		err = jpeg.Encode(dst, img, &jpeg.Options{Quality: 85})
-	case "png":
-		err = png.Encode(dst, img)
-	default:
-		// デフォルトはJPEG
-		err = jpeg.Encode(dst, img, &jpeg.Options{Quality: 85})
+	// 保存時の形式のままにし、正立済みであれば full は再エンコードせずに複製する（画質の劣化を防ぐ）
+	output := outputFormat(ImageFormatOriginal, img.format)
+	var full []byte
+	if output == img.format && metadata.orientation == 1 {
+		full = data
 	}
 
+	dir := s.contentDir(data, output, crop)
+	if existing, err := s.existingKey(dir, output); existing != "" || err != nil {
+		return existing, err
+	}
+	return s.storeVariants(img, dir, output, crop, full)
+}
+
+// contentDir 元画像の内容と変換の条件から保存先のディレクトリ名（SHA-256）を求める
+// バリアントの定義も含めるため、サイズを変更した場合は新しいディレクトリに保存し直される
+func (s *imageService) contentDir(data []byte, output string, crop *models.PhotoCrop) string {
+	cropJSON, _ := json.Marshal(crop)
+	hash := sha256.New()
+	hash.Write(data)
+	fmt.Fprintf(hash, "\x00%s\x00%t\x00%s\x00%v", output, s.options.KeepColorProfile, cropJSON, ImageVariants)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// existingKey ディレクトリに保存済みであれば full バリアントのキーを返す（未保存の場合は空文字）
+// 再利用する全てのバリアントの更新日時を現在時刻にし、人物の保存前に孤立したファイルの削除・DeleteImage で消されないようにする
+// 欠けているバリアントがある場合は未保存として扱い、保存し直させる
+func (s *imageService) existingKey(dir, output string) (string, error) {
+	key := variantKey(dir, PrimaryImageVariant, s.getExtensionFromFormat(output))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, variant := range variantKeys(key) {
+		err := s.storage.Touch(variant)
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check stored image: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// readImage 保存済みの画像を読み込む
+func (s *imageService) readImage(key string) ([]byte, error) {
+	object, err := s.OpenImage(key)
 	if err != nil {
-		os.Remove(filePath) // エラー時はファイルを削除
-		return "", fmt.Errorf("failed to encode image: %w", err)
+		return nil, err
 	}
+	defer object.Body.Close()
 
-	// 静的ファイル配信用の相対パスを返す
-	relativePath := "/uploads/" + uniqueFilename
-	return relativePath, nil
+	data, err := io.ReadAll(object.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return data, nil
 }
 
-// DeleteImage 画像ファイルを削除
-func (s *imageService) DeleteImage(filePath string) error {
-	if filePath == "" {
+// decode 内容から形式を判定してデコードし、EXIF の向きを補正する
+func (s *imageService) decode(data []byte) (*decodedImage, imageMetadata, error) {
+	// 内容から形式を判定
+	format, err := detectImageFormat(data)
+	if err != nil {
+		return nil, imageMetadata{}, err
+	}
+
+	// 全体をデコードする前に画素数を確認
+	if err := checkImageSize(data, format, s.options.MaxPixels); err != nil {
+		return nil, imageMetadata{}, err
+	}
+
+	// 画像をデコード
+	img, err := decodeImage(data, format, s.options.MaxPixels)
+	if err != nil {
+		var tooLarge *Error
+		if errors.As(err, &tooLarge) {
+			return nil, imageMetadata{}, err
+		}
+		invalid := NewValidationError("INVALID_IMAGE", "failed to decode image", nil)
+		invalid.Err = err
+		return nil, imageMetadata{}, invalid
+	}
+
+	// EXIF の向きを補正してから縮小する（メタデータは再エンコードで削除される）
+	metadata := readMetadata(data, format)
+	if !img.animated() {
+		img.frames[0] = applyOrientation(img.frames[0], metadata.orientation)
+	}
+	if s.options.KeepColorProfile {
+		img.iccProfile = metadata.iccProfile
+	}
+	return img, metadata, nil
+}
+
+// storeVariants 全てのバリアントをエンコードしてディレクトリに保存し、full バリアントのキーを返す
+// full を指定した場合は full バリアントとしてそのまま保存する
+func (s *imageService) storeVariants(img *decodedImage, dir, output string, crop *models.PhotoCrop, full []byte) (string, error) {
+	ext := s.getExtensionFromFormat(output)
+
+	var stored []string
+	for _, variant := range ImageVariants {
+		var data []byte
+		var contentType string
+		if variant.Name == PrimaryImageVariant && full != nil {
+			data, contentType = full, http.DetectContentType(full)
+		} else {
+			var err error
+			data, contentType, err = encodeVariant(img, variant, output, crop)
+			if err != nil {
+				s.deleteKeys(stored)
+				return "", fmt.Errorf("failed to encode image: %w", err)
+			}
+			data = embedICCProfile(data, output, img.iccProfile)
+		}
+
+		key := variantKey(dir, variant.Name, ext)
+		if err := s.storage.Put(key, data, contentType); err != nil {
+			s.deleteKeys(stored)
+			return "", fmt.Errorf("failed to store image: %w", err)
+		}
+		stored = append(stored, key)
+	}
+
+	return variantKey(dir, PrimaryImageVariant, ext), nil
+}
+
+// cropTolerance 切り抜き範囲の合計を比較する際の浮動小数点の誤差
+const cropTolerance = 1e-9
+
+// validatePhotoCrop 切り抜き範囲と注目点が画像の内側にあるか検証
+func validatePhotoCrop(crop *models.PhotoCrop) error {
+	if crop == nil {
 		return nil
 	}
 
-	// 相対パス（/uploads/filename）を絶対パスに変換
-	var actualPath string
-	if strings.HasPrefix(filePath, "/uploads/") {
-		filename := strings.TrimPrefix(filePath, "/uploads/")
-		actualPath = filepath.Join(s.uploadDir, filename)
-	} else {
-		actualPath = filePath
+	details := map[string]string{}
+	if rect := crop.Rect; rect != nil {
+		if rect.X < 0 || rect.Y < 0 || rect.Width <= 0 || rect.Height <= 0 ||
+			rect.X+rect.Width > 1+cropTolerance || rect.Y+rect.Height > 1+cropTolerance {
+			details["rect"] = "rect must have a positive size and lie within the image (0 to 1)"
+		}
+	}
+	if focus := crop.Focus; focus != nil {
+		if focus.X < 0 || focus.X > 1 || focus.Y < 0 || focus.Y > 1 {
+			details["focus"] = "focus must lie within the image (0 to 1)"
+		}
+	}
+	if len(details) > 0 {
+		return NewValidationError("INVALID_PHOTO_CROP", "invalid photo crop", details)
 	}
+	return nil
+}
+
+// variantKey バリアントのストレージキー（<dir>/<name><ext>）
+func variantKey(dir, name, ext string) string {
+	return dir + "/" + name + ext
+}
 
-	// ファイルが存在するかチェック
-	if _, err := os.Stat(actualPath); os.IsNotExist(err) {
-		return nil // ファイルが存在しない場合はエラーにしない
+// variantKeys photo のキーから全てのバリアントのキーを求める
+// バリアント導入前に保存した単一の画像の場合は全てのバリアントが元のキーになる
+func variantKeys(key string) map[string]string {
+	key = storageKey(key)
+	keys := make(map[string]string, len(ImageVariants))
+
+	dir, file := path.Split(key)
+	ext := path.Ext(file)
+	if dir == "" || strings.TrimSuffix(file, ext) != PrimaryImageVariant {
+		for _, variant := range ImageVariants {
+			keys[variant.Name] = key
+		}
+		return keys
 	}
 
-	return os.Remove(actualPath)
+	for _, variant := range ImageVariants {
+		keys[variant.Name] = variantKey(strings.TrimSuffix(dir, "/"), variant.Name, ext)
+	}
+	return keys
 }
 
-// ValidateImageFile 画像ファイルの拡張子を検証
-func (s *imageService) ValidateImageFile(filename string) error {
-	if filename == "" {
-		return fmt.Errorf("filename is required")
+// DeleteImage 画像を全てのバリアントとともに削除（存在しない場合はエラーにしない）
+// 人物の photo から参照されている間は他の人物と共有しているため削除しない
+// 保存・再利用して間もない画像は、保存中の人物から参照される可能性があるため削除せず、孤立したファイルの削除に任せる
+// 呼び出し側は人物の更新・削除を保存してから呼び出す
+func (s *imageService) DeleteImage(key string) error {
+	if key == "" {
+		return nil
 	}
+	key = storageKey(key)
 
-	ext := strings.ToLower(filepath.Ext(filename))
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+	// 参照数・更新日時の確認から削除までの間に、同じ画像を再利用させない
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	for _, allowedExt := range allowedExts {
-		if ext == allowedExt {
+	if s.characterRepo != nil {
+		references, err := s.characterRepo.CountByPhoto(key, legacyUploadPrefix+key)
+		if err != nil {
+			return fmt.Errorf("failed to count image references: %w", err)
+		}
+		if references > 0 {
+			return nil
+		}
+	}
+	// 再利用されるのは内容のハッシュに保存した画像のみ
+	if isContentKey(key) {
+		info, err := s.storage.Stat(key)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrInvalidKey) {
+			return fmt.Errorf("failed to stat image: %w", err)
+		}
+		if info != nil && info.ModTime.After(s.options.Clock().Add(-s.options.GracePeriod)) {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("unsupported file format: %s. Allowed formats: %v", ext, allowedExts)
+	var keys []string
+	for _, variantKey := range variantKeys(key) {
+		keys = append(keys, variantKey)
+	}
+	sort.Strings(keys)
+	return s.deleteKeys(keys)
+}
+
+// deleteKeys 複数のキーを削除（重複は1回だけ削除する）
+func (s *imageService) deleteKeys(keys []string) error {
+	var errs []error
+	deleted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if deleted[key] {
+			continue
+		}
+		deleted[key] = true
+		if err := s.storage.Delete(key); err != nil && !errors.Is(err, storage.ErrInvalidKey) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete image: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// OpenImage 画像を取得
+func (s *imageService) OpenImage(key string) (*storage.Object, error) {
+	object, err := s.storage.Open(storageKey(key))
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return nil, NewNotFoundError("IMAGE_NOT_FOUND", "image not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	return object, nil
+}
+
+// URL 画像の配信用URLを返す（URLSecret を設定した場合は有効期限付きの署名を付ける）
+func (s *imageService) URL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return s.signedURL(storageKey(key))
+}
+
+// VariantURLs バリアント名ごとの配信用URLを返す
+func (s *imageService) VariantURLs(key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+
+	urls := make(map[string]string, len(ImageVariants))
+	for name, variantKey := range variantKeys(key) {
+		urls[name] = s.signedURL(variantKey)
+	}
+	return urls
+}
+
+// signedURL ストレージのキーの配信用URL（署名する場合は有効期限と署名のクエリを付ける）
+func (s *imageService) signedURL(key string) string {
+	if s.signer == nil {
+		return s.storage.URL(key)
+	}
+	return s.signer.sign(s.storage.URL(key), key)
+}
+
+// VerifyURL 配信用URLの有効期限と署名を確認し、有効期限を返す
+// 署名しない設定の場合は常に成功し、ゼロ値を返す
+func (s *imageService) VerifyURL(key, expires, signature string) (time.Time, error) {
+	if s.signer == nil {
+		return time.Time{}, nil
+	}
+	return s.signer.verify(storageKey(key), expires, signature)
+}
+
+// URLExpiry 現在生成する署名付きURLの有効期限（署名しない設定の場合はゼロ値）
+// 同じ区切りの間は同じ値のため、配信用URLを含むレスポンスの ETag に使える
+func (s *imageService) URLExpiry() time.Time {
+	if s.signer == nil {
+		return time.Time{}
+	}
+	return time.Unix(s.signer.expires(), 0)
+}
+
+// CheckQuota 画像をワークスペースの写真に加えてもワークスペースの保存容量を超えないか確認し、画像の大きさを返す
+// 大きさは全てのバリアントの合計で、人物の PhotoSize に保存する。使用量には characterID の人物の現在の写真を含めず、
+// ワークスペースの他の人物が同じ画像を使っている場合は増えないものとして扱う
+func (s *imageService) CheckQuota(workspaceID, characterID, key string) (int64, error) {
+	size, err := s.imageSize(key)
+	if err != nil {
+		return 0, err
+	}
+	if s.options.WorkspaceQuota <= 0 || s.characterRepo == nil {
+		return size, nil
+	}
+
+	sizes, err := s.characterRepo.WithWorkspace(workspaceID).GetPhotoSizes(characterID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get photo sizes: %w", err)
+	}
+	var used int64
+	for _, photoSize := range sizes {
+		used += photoSize
+	}
+	if _, shared := sizes[storageKey(key)]; !shared && used+size > s.options.WorkspaceQuota {
+		return 0, NewTooLargeError("STORAGE_QUOTA_EXCEEDED", "workspace storage quota exceeded", map[string]int64{
+			"quota": s.options.WorkspaceQuota,
+			"used":  used,
+			"size":  size,
+		})
+	}
+	return size, nil
+}
+
+// imageSize 画像の全てのバリアントの合計バイト数
+func (s *imageService) imageSize(key string) (int64, error) {
+	var size int64
+	counted := make(map[string]bool, len(ImageVariants))
+	for _, variantKey := range variantKeys(key) {
+		if counted[variantKey] {
+			continue
+		}
+		counted[variantKey] = true
+
+		info, err := s.storage.Stat(variantKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat image %s: %w", variantKey, err)
+		}
+		size += info.Size
+	}
+	return size, nil
+}
+
+// RecordPhotoSizes PhotoSize が記録されていない人物の写真の大きさを記録する
+// 保存容量の導入前の写真と、MigrateContentKeys で置き換えた写真が対象。記録した画像の数を返す
+func (s *imageService) RecordPhotoSizes() (int, error) {
+	if s.characterRepo == nil {
+		return 0, nil
+	}
+
+	keys, err := s.characterRepo.GetPhotoKeysWithoutSize()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get photo keys: %w", err)
+	}
+
+	recorded := 0
+	var errs []error
+	for _, key := range keys {
+		size, err := s.imageSize(key)
+		if err == nil {
+			err = s.characterRepo.SetPhotoSize(key, size)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		recorded++
+	}
+
+	if len(errs) > 0 {
+		return recorded, fmt.Errorf("failed to record photo sizes: %w", errors.Join(errs...))
+	}
+	return recorded, nil
+}
+
+// MigrateContentKeys 内容のハッシュの導入前に保存した画像をハッシュのディレクトリに移し、人物の photo を置き換える
+// 移行済みのキーは読み飛ばすため、起動のたびに実行してよい。移行した画像の数を返す
+// 人物の photo・ギャラリーの画像から参照されていない移行前のファイルは移さず、
+// 孤立したファイルとして UploadMaintenanceService.CheckUploads が猶予期間の後に削除する
+func (s *imageService) MigrateContentKeys() (int, error) {
+	if s.characterRepo == nil {
+		return 0, nil
+	}
+
+	keys, err := s.characterRepo.GetPhotoKeys()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get photo keys: %w", err)
+	}
+
+	migrated := 0
+	done := make(map[string]bool, len(keys))
+	var errs []error
+	for _, stored := range keys {
+		key := storageKey(stored)
+		if isContentKey(key) || done[key] {
+			continue
+		}
+		done[key] = true
+
+		newKey, err := s.rehash(key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		// 旧形式の /uploads/ 付きのパスも同じ画像として置き換える
+		if err := s.characterRepo.ReplacePhoto(newKey, key, legacyUploadPrefix+key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		if err := s.DeleteImage(key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+		migrated++
+	}
+
+	if len(errs) > 0 {
+		return migrated, fmt.Errorf("failed to migrate images: %w", errors.Join(errs...))
+	}
+	return migrated, nil
+}
+
+// rehash 移行前の画像を内容のハッシュのディレクトリに保存し直し、新しいキーを返す
+func (s *imageService) rehash(key string) (string, error) {
+	keys := variantKeys(key)
+	primary := keys[PrimaryImageVariant]
+
+	// バリアント導入前の単一の画像は元画像として扱い、バリアントを生成する
+	if keys[ImageVariants[0].Name] == primary {
+		data, err := s.readImage(primary)
+		if err != nil {
+			return "", err
+		}
+		return s.SaveImage(bytes.NewReader(data), nil)
+	}
+
+	// 生成済みのバリアントはそのまま複製し、全てのバリアントの内容からディレクトリ名を求める
+	contents := make(map[string][]byte, len(ImageVariants))
+	hash := sha256.New()
+	for _, variant := range ImageVariants {
+		data, err := s.readImage(keys[variant.Name])
+		if err != nil {
+			return "", err
+		}
+		contents[variant.Name] = data
+		fmt.Fprintf(hash, "%s\x00%d\x00", variant.Name, len(data))
+		hash.Write(data)
+	}
+	dir := hex.EncodeToString(hash.Sum(nil))
+	ext := path.Ext(primary)
+
+	for _, variant := range ImageVariants {
+		data := contents[variant.Name]
+		if err := s.storage.Put(variantKey(dir, variant.Name, ext), data, http.DetectContentType(data)); err != nil {
+			return "", fmt.Errorf("failed to store image: %w", err)
+		}
+	}
+	return variantKey(dir, PrimaryImageVariant, ext), nil
+}
+
+// isContentKey 内容のハッシュのディレクトリに保存されたキーか
+func isContentKey(key string) bool {
+	dir, _ := path.Split(key)
+	return contentDirPattern.MatchString(strings.TrimSuffix(dir, "/"))
+}
+
+// storageKey 保存された値をストレージのキーに変換（旧形式の /uploads/ 付きのパスも受け付ける）
+func storageKey(key string) string {
+	return strings.TrimPrefix(key, legacyUploadPrefix)
 }
 
 // getExtensionFromFormat フォーマットから拡張子を取得
@@ -144,6 +653,8 @@ func (s *imageService) getExtensionFromFormat(format string) string {
 		return ".png"
 	case "gif":
 		return ".gif"
+	case "webp":
+		return ".webp"
 	default:
 		return ".jpg"
 	}
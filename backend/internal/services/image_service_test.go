@@ -2,41 +2,81 @@ package services
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
 	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
 	"image/jpeg"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"character-management-app/internal/models"
+	"character-management-app/internal/storage"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-func TestImageService_ValidateImageFile(t *testing.T) {
-	service := NewImageService("test_uploads")
-	
+// テスト用の WebP 画像（1x1）
+const (
+	losslessWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+	lossyWebP    = "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA"
+)
+
+func encodeTestImage(t *testing.T, format string, img image.Image) []byte {
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		assert.NoError(t, png.Encode(&buf, img))
+	case "gif":
+		assert.NoError(t, gif.Encode(&buf, img, nil))
+	case "webp":
+		data, err := base64.StdEncoding.DecodeString(lossyWebP)
+		assert.NoError(t, err)
+		return data
+	default:
+		assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	}
+	return buf.Bytes()
+}
+
+func TestImageService_detectImageFormat(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
 	tests := []struct {
 		name     string
-		filename string
+		data     []byte
+		expected string
 		wantErr  bool
 	}{
-		{"正常なJPEGファイル", "test.jpg", false},
-		{"正常なJPEGファイル（大文字）", "test.JPEG", false},
-		{"正常なPNGファイル", "test.png", false},
-		{"正常なGIFファイル", "test.gif", false},
-		{"正常なWebPファイル", "test.webp", false},
-		{"サポートされていない拡張子", "test.bmp", true},
-		{"拡張子なし", "test", true},
-		{"空のファイル名", "", true},
+		{"JPEG", encodeTestImage(t, "jpeg", img), "jpeg", false},
+		{"PNG", encodeTestImage(t, "png", img), "png", false},
+		{"GIF", encodeTestImage(t, "gif", img), "gif", false},
+		{"WebP", encodeTestImage(t, "webp", img), "webp", false},
+		{"BMP", []byte("BM\x00\x00\x00\x00\x00\x00\x00\x00"), "", true},
+		{"テキスト", []byte("<html><body>not an image</body></html>"), "", true},
+		{"空", []byte{}, "", true},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.ValidateImageFile(tt.filename)
+			format, err := detectImageFormat(tt.data)
 			if tt.wantErr {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, ErrValidation)
+				var serviceError *Error
+				assert.ErrorAs(t, err, &serviceError)
+				assert.Equal(t, "UNSUPPORTED_IMAGE_TYPE", serviceError.Code)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, format)
 			}
 		})
 	}
@@ -44,10 +84,9 @@ func TestImageService_ValidateImageFile(t *testing.T) {
 
 func TestImageService_SaveImage(t *testing.T) {
 	// テスト用のディレクトリを作成
-	testDir := "test_uploads"
-	defer os.RemoveAll(testDir) // テスト後にクリーンアップ
+	testDir := t.TempDir()
 	
-	service := NewImageService(testDir)
+	service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
 	
 	t.Run("正常な画像保存", func(t *testing.T) {
 		// テスト用の画像を作成
@@ -56,70 +95,620 @@ func TestImageService_SaveImage(t *testing.T) {
 		jpeg.Encode(&buf, img, nil)
 		
 		// 画像を保存
-		filePath, err := service.SaveImage(&buf, "test.jpg", 800, 600)
+		key, err := service.SaveImage(&buf, nil)
 		
 		// 検証
 		assert.NoError(t, err)
-		assert.NotEmpty(t, filePath)
-		assert.True(t, strings.HasPrefix(filePath, testDir))
-		assert.True(t, strings.HasSuffix(filePath, ".jpg"))
+		assert.NotEmpty(t, key)
+		assert.True(t, strings.HasSuffix(key, "/full.jpg"))
+		assert.Equal(t, "/uploads/"+key, service.URL(key))
 		
-		// ファイルが実際に作成されているかチェック
-		_, err = os.Stat(filePath)
-		assert.NoError(t, err)
+		// 全てのバリアントが同じディレクトリに作成されているかチェック
+		dir := filepath.Dir(filepath.Join(testDir, key))
+		for _, name := range []string{"avatar.jpg", "card.jpg", "full.jpg"} {
+			_, err = os.Stat(filepath.Join(dir, name))
+			assert.NoError(t, err, name)
+		}
+
+		urls := service.VariantURLs(key)
+		assert.Len(t, urls, 3)
+		assert.Equal(t, "/uploads/"+filepath.ToSlash(filepath.Base(dir))+"/avatar.jpg", urls["avatar"])
+		assert.Equal(t, service.URL(key), urls["full"])
 		
 		// クリーンアップ
-		os.Remove(filePath)
+		service.DeleteImage(key)
 	})
 	
 	t.Run("画像リサイズ機能", func(t *testing.T) {
-		// 大きな画像を作成（1000x1000）
-		img := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
+		// 横長の大きな画像を作成（1600x800）
+		img := image.NewRGBA(image.Rect(0, 0, 1600, 800))
 		var buf bytes.Buffer
 		jpeg.Encode(&buf, img, nil)
 		
-		// 画像を保存（最大サイズ500x500に制限）
-		filePath, err := service.SaveImage(&buf, "large.jpg", 500, 500)
+		// 画像を保存
+		key, err := service.SaveImage(&buf, nil)
 		
 		// 検証
 		assert.NoError(t, err)
-		assert.NotEmpty(t, filePath)
+		assert.NotEmpty(t, key)
 		
-		// 保存された画像のサイズをチェック
-		file, err := os.Open(filePath)
-		assert.NoError(t, err)
-		defer file.Close()
-		
-		savedImg, _, err := image.Decode(file)
-		assert.NoError(t, err)
-		
-		bounds := savedImg.Bounds()
-		assert.True(t, bounds.Dx() <= 500)
-		assert.True(t, bounds.Dy() <= 500)
+		// 保存された各バリアントのサイズをチェック
+		dir := filepath.Dir(filepath.Join(testDir, key))
+		sizes := map[string]image.Point{
+			"avatar.jpg": {64, 64},
+			"card.jpg":   {256, 128},
+			"full.jpg":   {1200, 600},
+		}
+		for name, size := range sizes {
+			file, err := os.Open(filepath.Join(dir, name))
+			assert.NoError(t, err)
+			config, _, err := image.DecodeConfig(file)
+			file.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, size, image.Point{config.Width, config.Height}, name)
+		}
 		
 		// クリーンアップ
-		os.Remove(filePath)
+		service.DeleteImage(key)
 	})
 	
+	t.Run("小さい画像は拡大しない", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, img, nil)
+
+		key, err := service.SaveImage(&buf, nil)
+		assert.NoError(t, err)
+
+		file, err := os.Open(filepath.Join(testDir, key))
+		assert.NoError(t, err)
+		config, _, err := image.DecodeConfig(file)
+		file.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, 40, config.Width)
+		assert.Equal(t, 30, config.Height)
+
+		service.DeleteImage(key)
+	})
+
+	t.Run("拡張子ではなく内容で形式を判定", func(t *testing.T) {
+		// PNG の内容は PNG として保存される
+		data := encodeTestImage(t, "png", image.NewRGBA(image.Rect(0, 0, 10, 10)))
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(key, "/full.png"))
+
+		service.DeleteImage(key)
+	})
+
+	t.Run("壊れた画像データ", func(t *testing.T) {
+		// JPEG のヘッダーのみ
+		data := encodeTestImage(t, "jpeg", image.NewRGBA(image.Rect(0, 0, 10, 10)))[:20]
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		assert.Empty(t, key)
+
+		var serviceError *Error
+		assert.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "INVALID_IMAGE", serviceError.Code)
+	})
+
 	t.Run("無効な画像データ", func(t *testing.T) {
 		// 無効なデータ
 		invalidData := bytes.NewReader([]byte("invalid image data"))
 		
 		// 画像保存を試行
-		filePath, err := service.SaveImage(invalidData, "invalid.jpg", 800, 600)
+		filePath, err := service.SaveImage(invalidData, nil)
 		
 		// 検証
 		assert.Error(t, err)
 		assert.Empty(t, filePath)
-		assert.Contains(t, err.Error(), "failed to decode image")
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+}
+
+func TestImageService_SaveImage_Formats(t *testing.T) {
+	testDir := t.TempDir()
+
+	decodeSaved := func(t *testing.T, key string) (image.Image, string) {
+		file, err := os.Open(filepath.Join(testDir, key))
+		assert.NoError(t, err)
+		defer file.Close()
+		img, format, err := image.Decode(file)
+		assert.NoError(t, err)
+		return img, format
+	}
+
+	t.Run("WebP は PNG で保存", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		for _, encoded := range []string{losslessWebP, lossyWebP} {
+			data, _ := base64.StdEncoding.DecodeString(encoded)
+			key, err := service.SaveImage(bytes.NewReader(data), nil)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasSuffix(key, "/full.png"))
+
+			_, format := decodeSaved(t, key)
+			assert.Equal(t, "png", format)
+			service.DeleteImage(key)
+		}
+	})
+
+	t.Run("静止画のGIFはGIFのまま保存", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		data := encodeTestImage(t, "gif", image.NewRGBA(image.Rect(0, 0, 300, 100)))
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(key, "/full.gif"))
+
+		img, format := decodeSaved(t, key)
+		assert.Equal(t, "gif", format)
+		assert.Equal(t, 300, img.Bounds().Dx())
+		service.DeleteImage(key)
+	})
+
+	t.Run("アニメーションGIFは全フレームを縮小して保存", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		pal := color.Palette{color.Transparent, color.Black, color.White, color.RGBA{255, 0, 0, 255}}
+		animation := &gif.GIF{LoopCount: 0}
+		for i := 0; i < 3; i++ {
+			frame := image.NewPaletted(image.Rect(0, 0, 400, 200), pal)
+			for x := 0; x < 400; x++ {
+				frame.SetColorIndex(x, i*50, uint8(i+1))
+			}
+			animation.Image = append(animation.Image, frame)
+			animation.Delay = append(animation.Delay, 10*(i+1))
+		}
+		var buf bytes.Buffer
+		assert.NoError(t, gif.EncodeAll(&buf, animation))
+
+		key, err := service.SaveImage(&buf, nil)
+		assert.NoError(t, err)
+
+		dir := filepath.Dir(filepath.Join(testDir, key))
+		for name, width := range map[string]int{"card.gif": 256, "avatar.gif": 64} {
+			file, err := os.Open(filepath.Join(dir, name))
+			assert.NoError(t, err)
+			saved, err := gif.DecodeAll(file)
+			file.Close()
+			assert.NoError(t, err)
+			assert.Len(t, saved.Image, 3, name)
+			assert.Equal(t, []int{10, 20, 30}, saved.Delay, name)
+			assert.Equal(t, width, saved.Config.Width, name)
+		}
+		service.DeleteImage(key)
+	})
+
+	t.Run("JPEG に統一", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{OutputFormat: ImageFormatJPEG})
+
+		// 透過を含む PNG
+		img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+		data := encodeTestImage(t, "png", img)
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(key, "/full.jpg"))
+
+		saved, format := decodeSaved(t, key)
+		assert.Equal(t, "jpeg", format)
+		// 透過部分は白になる
+		r, g, b, _ := saved.At(5, 5).RGBA()
+		assert.True(t, r > 0xf000 && g > 0xf000 && b > 0xf000)
+		service.DeleteImage(key)
+	})
+
+	t.Run("PNG に統一", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{OutputFormat: ImageFormatPNG})
+
+		data := encodeTestImage(t, "jpeg", image.NewRGBA(image.Rect(0, 0, 10, 10)))
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(key, "/full.png"))
+		service.DeleteImage(key)
+	})
+}
+
+// leftRedFixture 左端 40px だけが赤い 200x100 の画像
+func leftRedFixture() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 40, 100), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+	return img
+}
+
+// decodeVariant 保存されたバリアントを PNG としてデコード
+func decodeVariant(t *testing.T, testDir, key, name string) image.Image {
+	file, err := os.Open(filepath.Join(testDir, variantKeys(key)[name]))
+	require.NoError(t, err)
+	defer file.Close()
+	img, err := png.Decode(file)
+	require.NoError(t, err)
+	return img
+}
+
+func TestImageService_SaveImage_Crop(t *testing.T) {
+	testDir := t.TempDir()
+
+	service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+	data := encodeTestImage(t, "png", leftRedFixture())
+
+	t.Run("指定がなければ中央を正方形に切り抜く", func(t *testing.T) {
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		avatar := decodeVariant(t, testDir, key, "avatar")
+		assert.Equal(t, image.Rect(0, 0, 64, 64), avatar.Bounds())
+		assert.False(t, isRed(avatar.At(2, 32)))
+	})
+
+	t.Run("注目点を中心に avatar を切り抜く", func(t *testing.T) {
+		crop := &models.PhotoCrop{Focus: &models.FocalPoint{X: 0.1, Y: 0.5}}
+		key, err := service.SaveImage(bytes.NewReader(data), crop)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		// 左端に寄せた 100x100 の範囲（左 40% が赤）
+		avatar := decodeVariant(t, testDir, key, "avatar")
+		assert.Equal(t, image.Rect(0, 0, 64, 64), avatar.Bounds())
+		assert.True(t, isRed(avatar.At(2, 32)))
+		assert.False(t, isRed(avatar.At(40, 32)))
+
+		// full は切り抜かない
+		full := decodeVariant(t, testDir, key, "full")
+		assert.Equal(t, image.Rect(0, 0, 200, 100), full.Bounds())
+	})
+
+	t.Run("切り抜き範囲を avatar と card に適用", func(t *testing.T) {
+		crop := &models.PhotoCrop{Rect: &models.CropRect{X: 0, Y: 0, Width: 0.2, Height: 0.5}}
+		key, err := service.SaveImage(bytes.NewReader(data), crop)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		card := decodeVariant(t, testDir, key, "card")
+		assert.Equal(t, image.Rect(0, 0, 40, 50), card.Bounds())
+		assert.True(t, isRed(card.At(39, 49)))
+
+		avatar := decodeVariant(t, testDir, key, "avatar")
+		assert.Equal(t, image.Rect(0, 0, 40, 40), avatar.Bounds())
+		assert.True(t, isRed(avatar.At(20, 20)))
+	})
+
+	t.Run("範囲外の指定", func(t *testing.T) {
+		crops := map[string]*models.PhotoCrop{
+			"幅が0":     {Rect: &models.CropRect{X: 0, Y: 0, Width: 0, Height: 1}},
+			"右端を超える":  {Rect: &models.CropRect{X: 0.5, Y: 0, Width: 0.6, Height: 1}},
+			"負の位置":    {Rect: &models.CropRect{X: -0.1, Y: 0, Width: 0.5, Height: 1}},
+			"注目点が範囲外": {Focus: &models.FocalPoint{X: 1.5, Y: 0.5}},
+		}
+		for name, crop := range crops {
+			t.Run(name, func(t *testing.T) {
+				key, err := service.SaveImage(bytes.NewReader(data), crop)
+				assert.Empty(t, key)
+				assert.ErrorIs(t, err, ErrValidation)
+				var serviceError *Error
+				require.ErrorAs(t, err, &serviceError)
+				assert.Equal(t, "INVALID_PHOTO_CROP", serviceError.Code)
+			})
+		}
+	})
+}
+
+func TestImageService_CropImage(t *testing.T) {
+	testDir := t.TempDir()
+
+	service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+	focus := &models.PhotoCrop{Focus: &models.FocalPoint{X: 0.1, Y: 0.5}}
+
+	t.Run("保存済みの画像を新しいキーに切り抜き直す", func(t *testing.T) {
+		key, err := service.SaveImage(bytes.NewReader(encodeTestImage(t, "png", leftRedFixture())), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+
+		cropped, err := service.CropImage(key, focus)
+		require.NoError(t, err)
+		defer service.DeleteImage(cropped)
+		assert.NotEqual(t, key, cropped)
+
+		avatar := decodeVariant(t, testDir, cropped, "avatar")
+		assert.True(t, isRed(avatar.At(2, 32)))
+
+		// full は再エンコードせずに複製し、元の画像は残す
+		original, err := os.ReadFile(filepath.Join(testDir, key))
+		require.NoError(t, err)
+		copied, err := os.ReadFile(filepath.Join(testDir, cropped))
+		require.NoError(t, err)
+		assert.Equal(t, original, copied)
+	})
+
+	t.Run("バリアント導入前の単一の画像", func(t *testing.T) {
+		store := storage.NewLocal(testDir, "/uploads")
+		require.NoError(t, store.Put("legacy.png", encodeTestImage(t, "png", leftRedFixture()), "image/png"))
+		defer store.Delete("legacy.png")
+
+		cropped, err := service.CropImage("/uploads/legacy.png", focus)
+		require.NoError(t, err)
+		defer service.DeleteImage(cropped)
+		assert.True(t, strings.HasSuffix(cropped, "/full.png"))
+
+		avatar := decodeVariant(t, testDir, cropped, "avatar")
+		assert.True(t, isRed(avatar.At(2, 32)))
+	})
+
+	t.Run("存在しない画像", func(t *testing.T) {
+		_, err := service.CropImage("missing/full.png", focus)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestImageService_ContentAddressed(t *testing.T) {
+	testDir := t.TempDir()
+
+	data := encodeTestImage(t, "png", leftRedFixture())
+
+	t.Run("同じ画像は同じキーに保存して再利用", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		defer service.DeleteImage(key)
+		assert.True(t, isContentKey(key), key)
+
+		again, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, key, again)
+
+		// 切り抜きの指定が異なる場合は別のキー
+		cropped, err := service.SaveImage(bytes.NewReader(data), &models.PhotoCrop{Focus: &models.FocalPoint{X: 0.1, Y: 0.5}})
+		require.NoError(t, err)
+		defer service.DeleteImage(cropped)
+		assert.NotEqual(t, key, cropped)
+	})
+
+	t.Run("再利用した画像の更新日時を更新", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		old := time.Now().Add(-2 * time.Hour)
+		for _, variant := range variantKeys(key) {
+			require.NoError(t, os.Chtimes(filepath.Join(testDir, variant), old, old))
+		}
+
+		again, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, key, again)
+		for _, variant := range variantKeys(key) {
+			info, err := os.Stat(filepath.Join(testDir, variant))
+			require.NoError(t, err)
+			assert.True(t, info.ModTime().After(old), variant)
+		}
+
+		// 欠けたバリアントは保存し直す
+		require.NoError(t, os.Remove(filepath.Join(testDir, variantKeys(key)["avatar"])))
+		again, err = service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, key, again)
+		_, err = os.Stat(filepath.Join(testDir, variantKeys(key)["avatar"]))
+		assert.NoError(t, err)
+	})
+
+	t.Run("参照されている画像は削除しない", func(t *testing.T) {
+		characterRepo := new(MockCharacterRepository)
+		now := time.Now()
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), characterRepo, ImageOptions{
+			Clock: func() time.Time { return now },
+		})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+
+		characterRepo.On("CountByPhoto", []string{key, "/uploads/" + key}).Return(int64(1), nil).Once()
+		assert.NoError(t, service.DeleteImage(key))
+		_, err = os.Stat(filepath.Join(testDir, key))
+		assert.NoError(t, err)
+
+		// 保存・再利用して間もない画像は保存中の人物から参照される可能性があるため削除しない
+		characterRepo.On("CountByPhoto", []string{key, "/uploads/" + key}).Return(int64(0), nil).Once()
+		assert.NoError(t, service.DeleteImage(key))
+		_, err = os.Stat(filepath.Join(testDir, key))
+		assert.NoError(t, err)
+
+		now = now.Add(DefaultUploadGracePeriod + time.Minute)
+		characterRepo.On("CountByPhoto", []string{key, "/uploads/" + key}).Return(int64(0), nil).Once()
+		assert.NoError(t, service.DeleteImage(key))
+		_, err = os.Stat(filepath.Join(testDir, key))
+		assert.True(t, os.IsNotExist(err))
+
+		characterRepo.AssertExpectations(t)
+	})
+
+	t.Run("参照数を確認できない場合は削除しない", func(t *testing.T) {
+		characterRepo := new(MockCharacterRepository)
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), characterRepo, ImageOptions{})
+
+		key, err := service.SaveImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+
+		characterRepo.On("CountByPhoto", mock.Anything).Return(int64(0), assert.AnError)
+		assert.Error(t, service.DeleteImage(key))
+		_, err = os.Stat(filepath.Join(testDir, key))
+		assert.NoError(t, err)
+	})
+}
+
+func TestImageService_MigrateContentKeys(t *testing.T) {
+	testDir := t.TempDir()
+
+	store := storage.NewLocal(testDir, "/uploads")
+	characterRepo := new(MockCharacterRepository)
+	service := NewImageService(store, characterRepo, ImageOptions{})
+
+	// バリアント導入後・ハッシュ導入前のディレクトリ
+	for _, name := range []string{"avatar", "card", "full"} {
+		require.NoError(t, store.Put("1700000000_abcd1234/"+name+".png", encodeTestImage(t, "png", image.NewRGBA(image.Rect(0, 0, 8, 8))), "image/png"))
+	}
+	// バリアント導入前の単一の画像（旧形式の /uploads/ 付きのパスでも参照されている）
+	require.NoError(t, store.Put("old.png", encodeTestImage(t, "png", leftRedFixture()), "image/png"))
+	// どの人物からも参照されていない移行前の画像（移行せず、孤立したファイルの削除に任せる）
+	require.NoError(t, store.Put("1600000000_deadbeef.png", encodeTestImage(t, "png", leftRedFixture()), "image/png"))
+	// 移行済みの画像
+	migratedKey, err := service.SaveImage(bytes.NewReader(encodeTestImage(t, "png", image.NewRGBA(image.Rect(0, 0, 4, 4)))), nil)
+	require.NoError(t, err)
+
+	var newKeys []string
+	characterRepo.On("GetPhotoKeys").Return([]string{"/uploads/old.png", "1700000000_abcd1234/full.png", migratedKey, "old.png"}, nil)
+	characterRepo.On("ReplacePhoto", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		newKeys = append(newKeys, args.String(0))
+	}).Return(nil)
+	characterRepo.On("CountByPhoto", mock.Anything).Return(int64(0), nil)
+
+	migrated, err := service.MigrateContentKeys()
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated)
+
+	characterRepo.AssertCalled(t, "ReplacePhoto", mock.Anything, []string{"old.png", "/uploads/old.png"})
+	characterRepo.AssertCalled(t, "ReplacePhoto", mock.Anything, []string{"1700000000_abcd1234/full.png", "/uploads/1700000000_abcd1234/full.png"})
+	characterRepo.AssertNumberOfCalls(t, "ReplacePhoto", 2)
+
+	// 新しいキーには全てのバリアントがあり、移行前のファイルは削除されている
+	for _, key := range newKeys {
+		assert.True(t, isContentKey(key), key)
+		for _, variantKey := range variantKeys(key) {
+			_, err := os.Stat(filepath.Join(testDir, variantKey))
+			assert.NoError(t, err, variantKey)
+		}
+	}
+	for _, key := range []string{"old.png", "1700000000_abcd1234/avatar.png", "1700000000_abcd1234/full.png"} {
+		_, err := os.Stat(filepath.Join(testDir, key))
+		assert.True(t, os.IsNotExist(err), key)
+	}
+	_, err = os.Stat(filepath.Join(testDir, "1600000000_deadbeef.png"))
+	assert.NoError(t, err)
+}
+
+// pngWithSize PNG の IHDR の幅・高さを書き換える（画素データは元の画像のまま）
+func pngWithSize(t *testing.T, width, height uint32) []byte {
+	data := encodeTestImage(t, "png", image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	// シグネチャ(8) + 長さ(4) + "IHDR"(4) の後に幅・高さ、IHDR のデータ(13) の後に CRC
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestImageService_MaxPixels(t *testing.T) {
+	testDir := t.TempDir()
+
+	t.Run("宣言された画素数が上限を超える画像はデコードしない", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+		key, err := service.SaveImage(bytes.NewReader(pngWithSize(t, 50000, 50000)), nil)
+		assert.Empty(t, key)
+		assert.ErrorIs(t, err, ErrTooLarge)
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "IMAGE_TOO_LARGE", serviceError.Code)
+	})
+
+	t.Run("上限を設定", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{MaxPixels: 100})
+
+		_, err := service.SaveImage(bytes.NewReader(encodeTestImage(t, "jpeg", image.NewRGBA(image.Rect(0, 0, 20, 10)))), nil)
+		assert.ErrorIs(t, err, ErrTooLarge)
+
+		key, err := service.SaveImage(bytes.NewReader(encodeTestImage(t, "jpeg", image.NewRGBA(image.Rect(0, 0, 10, 10)))), nil)
+		require.NoError(t, err)
+		service.DeleteImage(key)
+	})
+
+	t.Run("アニメーションGIFはフレーム数を含めて判定", func(t *testing.T) {
+		service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{MaxPixels: 250})
+
+		animation := &gif.GIF{}
+		for i := 0; i < 3; i++ {
+			animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 10, 10), color.Palette{color.Black, color.White}))
+			animation.Delay = append(animation.Delay, 10)
+		}
+		var buf bytes.Buffer
+		require.NoError(t, gif.EncodeAll(&buf, animation))
+
+		_, err := service.SaveImage(&buf, nil)
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "IMAGE_TOO_LARGE", serviceError.Code)
+		assert.Equal(t, 3, serviceError.Details.(map[string]interface{})["frames"])
+	})
+}
+
+func TestImageService_CheckQuota(t *testing.T) {
+	testDir := t.TempDir()
+
+	store := storage.NewLocal(testDir, "/uploads")
+	for name, size := range map[string]int{"avatar": 10, "card": 20, "full": 70} {
+		require.NoError(t, store.Put("photo/"+name+".png", make([]byte, size), "image/png"))
+	}
+	require.NoError(t, store.Put("legacy.png", make([]byte, 40), "image/png"))
+
+	t.Run("全てのバリアントの合計を返す", func(t *testing.T) {
+		service := NewImageService(store, new(MockCharacterRepository), ImageOptions{})
+
+		size, err := service.CheckQuota("ws-1", "", "photo/full.png")
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), size)
+
+		size, err = service.CheckQuota("ws-1", "", "legacy.png")
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), size)
+	})
+
+	t.Run("上限を超える場合はエラー", func(t *testing.T) {
+		characterRepo := new(MockCharacterRepository)
+		service := NewImageService(store, characterRepo, ImageOptions{WorkspaceQuota: 150})
+		characterRepo.On("GetPhotoSizes", "char-1").Return(map[string]int64{"other/full.png": 60}, nil)
+
+		_, err := service.CheckQuota("ws-1", "char-1", "photo/full.png")
+		assert.ErrorIs(t, err, ErrTooLarge)
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "STORAGE_QUOTA_EXCEEDED", serviceError.Code)
+		assert.Equal(t, map[string]int64{"quota": 150, "used": 60, "size": 100}, serviceError.Details)
+	})
+
+	t.Run("ワークスペースで使用中の画像は使用量を増やさない", func(t *testing.T) {
+		characterRepo := new(MockCharacterRepository)
+		service := NewImageService(store, characterRepo, ImageOptions{WorkspaceQuota: 150})
+		characterRepo.On("GetPhotoSizes", "").Return(map[string]int64{"photo/full.png": 100, "other/full.png": 60}, nil)
+
+		size, err := service.CheckQuota("ws-1", "", "photo/full.png")
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), size)
+	})
+
+	t.Run("大きさが記録されていない写真を記録", func(t *testing.T) {
+		characterRepo := new(MockCharacterRepository)
+		service := NewImageService(store, characterRepo, ImageOptions{})
+		characterRepo.On("GetPhotoKeysWithoutSize").Return([]string{"/uploads/legacy.png", "photo/full.png", "missing/full.png"}, nil)
+		characterRepo.On("SetPhotoSize", "/uploads/legacy.png", int64(40)).Return(nil)
+		characterRepo.On("SetPhotoSize", "photo/full.png", int64(100)).Return(nil)
+
+		recorded, err := service.RecordPhotoSizes()
+		assert.Error(t, err)
+		assert.Equal(t, 2, recorded)
+		characterRepo.AssertExpectations(t)
 	})
 }
 
 func TestImageService_DeleteImage(t *testing.T) {
-	testDir := "test_uploads"
-	defer os.RemoveAll(testDir)
+	testDir := t.TempDir()
 	
-	service := NewImageService(testDir)
+	// 保存して間もない画像は削除しないため、猶予期間の後の時刻で削除する
+	service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{
+		Clock: func() time.Time { return time.Now().Add(DefaultUploadGracePeriod + time.Minute) },
+	})
 	
 	t.Run("正常なファイル削除", func(t *testing.T) {
 		// テストファイルを作成
@@ -134,7 +723,7 @@ func TestImageService_DeleteImage(t *testing.T) {
 		assert.NoError(t, err)
 		
 		// ファイルを削除
-		err = service.DeleteImage(testFile)
+		err = service.DeleteImage("test.jpg")
 		assert.NoError(t, err)
 		
 		// ファイルが削除されていることを確認
@@ -150,6 +739,49 @@ func TestImageService_DeleteImage(t *testing.T) {
 		assert.NoError(t, err)
 	})
 	
+	t.Run("全てのバリアントを削除", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, img, nil)
+
+		key, err := service.SaveImage(&buf, nil)
+		assert.NoError(t, err)
+
+		err = service.DeleteImage(key)
+		assert.NoError(t, err)
+
+		entries, err := os.ReadDir(filepath.Dir(filepath.Join(testDir, key)))
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("旧形式のパス", func(t *testing.T) {
+		os.MkdirAll(testDir, 0755)
+		testFile := filepath.Join(testDir, "legacy.jpg")
+		file, err := os.Create(testFile)
+		assert.NoError(t, err)
+		file.Close()
+
+		// ストレージ導入前の /uploads/ 付きのパスでも削除できる
+		err = service.DeleteImage("/uploads/legacy.jpg")
+		assert.NoError(t, err)
+
+		_, err = os.Stat(testFile)
+		assert.True(t, os.IsNotExist(err))
+		assert.Equal(t, "/uploads/legacy.jpg", service.URL("/uploads/legacy.jpg"))
+
+		// バリアント導入前の画像は全てのバリアントが元の画像を指す
+		urls := service.VariantURLs("/uploads/legacy.jpg")
+		assert.Equal(t, "/uploads/legacy.jpg", urls["avatar"])
+		assert.Equal(t, "/uploads/legacy.jpg", urls["card"])
+	})
+
+	t.Run("ディレクトリ外のパス", func(t *testing.T) {
+		// ストレージの外を指すパスは何もしない
+		err := service.DeleteImage("../outside.jpg")
+		assert.NoError(t, err)
+	})
+	
 	t.Run("空のパス", func(t *testing.T) {
 		// 空のパスで削除
 		err := service.DeleteImage("")
@@ -159,6 +791,37 @@ func TestImageService_DeleteImage(t *testing.T) {
 	})
 }
 
+func TestImageService_OpenImage(t *testing.T) {
+	testDir := t.TempDir()
+
+	service := NewImageService(storage.NewLocal(testDir, "/uploads"), nil, ImageOptions{})
+
+	t.Run("保存した画像を取得", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, img, nil)
+
+		key, err := service.SaveImage(&buf, nil)
+		assert.NoError(t, err)
+
+		object, err := service.OpenImage(key)
+		assert.NoError(t, err)
+		defer object.Body.Close()
+		assert.Equal(t, "image/jpeg", object.ContentType)
+		assert.True(t, object.Size > 0)
+	})
+
+	t.Run("存在しない画像", func(t *testing.T) {
+		_, err := service.OpenImage("missing.jpg")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("ディレクトリ外のパス", func(t *testing.T) {
+		_, err := service.OpenImage("../outside.jpg")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
 func TestImageService_getExtensionFromFormat(t *testing.T) {
 	service := &imageService{}
 	
@@ -169,6 +832,7 @@ func TestImageService_getExtensionFromFormat(t *testing.T) {
 		{"jpeg", ".jpg"},
 		{"png", ".png"},
 		{"gif", ".gif"},
+		{"webp", ".webp"},
 		{"unknown", ".jpg"}, // デフォルト
 	}
 	
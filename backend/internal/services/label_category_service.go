@@ -0,0 +1,116 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/repositories"
+	"fmt"
+)
+
+// LabelCategoryService ラベルの分類サービスのインターフェース
+type LabelCategoryService interface {
+	CreateCategory(category *models.LabelCategory) (*models.LabelCategory, error)
+	GetCategoryByID(id string) (*models.LabelCategory, error)
+	GetAllCategories() ([]models.LabelCategory, error)
+	UpdateCategory(id string, category *models.LabelCategory) (*models.LabelCategory, error)
+	DeleteCategory(id string) error
+	WithWorkspace(workspaceID string) LabelCategoryService
+}
+
+// labelCategoryService ラベルの分類サービスの実装
+type labelCategoryService struct {
+	categoryRepo repositories.LabelCategoryRepository
+}
+
+// NewLabelCategoryService ラベルの分類サービスのコンストラクタ
+func NewLabelCategoryService(categoryRepo repositories.LabelCategoryRepository) LabelCategoryService {
+	return &labelCategoryService{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// WithWorkspace 指定したワークスペースに限定したサービスを返す
+func (s *labelCategoryService) WithWorkspace(workspaceID string) LabelCategoryService {
+	return &labelCategoryService{
+		categoryRepo: s.categoryRepo.WithWorkspace(workspaceID),
+	}
+}
+
+// CreateCategory 分類を作成
+func (s *labelCategoryService) CreateCategory(category *models.LabelCategory) (*models.LabelCategory, error) {
+	// 名前の重複チェック
+	exists, err := s.categoryRepo.ExistsByName(category.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check label category name existence: %w", err)
+	}
+	if exists {
+		return nil, NewConflictError("LABEL_CATEGORY_NAME_TAKEN", "label category with this name already exists")
+	}
+
+	if err := s.categoryRepo.Create(category); err != nil {
+		return nil, fmt.Errorf("failed to create label category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetCategoryByID IDで分類を取得
+func (s *labelCategoryService) GetCategoryByID(id string) (*models.LabelCategory, error) {
+	category, err := s.categoryRepo.GetByID(id)
+	if err != nil {
+		return nil, lookupError(err, errLabelCategoryNotFound(), "failed to get label category")
+	}
+	return category, nil
+}
+
+// GetAllCategories 全ての分類を取得
+func (s *labelCategoryService) GetAllCategories() ([]models.LabelCategory, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get label categories: %w", err)
+	}
+	return categories, nil
+}
+
+// UpdateCategory 分類を更新
+// 上限を下げても既に付いているラベルは外さない（以降のラベルの追加で上限を確認する）
+func (s *labelCategoryService) UpdateCategory(id string, category *models.LabelCategory) (*models.LabelCategory, error) {
+	existing, err := s.categoryRepo.GetByID(id)
+	if err != nil {
+		return nil, lookupError(err, errLabelCategoryNotFound(), "failed to get existing label category")
+	}
+
+	// 名前が変更されている場合、重複チェック
+	if existing.Name != category.Name {
+		exists, err := s.categoryRepo.ExistsByName(category.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check label category name existence: %w", err)
+		}
+		if exists {
+			return nil, NewConflictError("LABEL_CATEGORY_NAME_TAKEN", "label category with this name already exists")
+		}
+	}
+
+	// ID・ワークスペース・作成日時を保持
+	category.ID = existing.ID
+	category.WorkspaceID = existing.WorkspaceID
+	category.CreatedAt = existing.CreatedAt
+
+	// 期待するバージョンの指定がなければ現在のバージョンに対して更新する
+	if category.Version == 0 {
+		category.Version = existing.Version
+	}
+
+	if err := s.categoryRepo.Update(category); err != nil {
+		return nil, fmt.Errorf("failed to update label category: %w", err)
+	}
+
+	return category, nil
+}
+
+// DeleteCategory 分類を削除（分類のラベルは削除せず分類なしにする）
+func (s *labelCategoryService) DeleteCategory(id string) error {
+	if err := s.categoryRepo.Delete(id); err != nil {
+		return lookupError(err, errLabelCategoryNotFound(), "failed to delete label category")
+	}
+	return nil
+}
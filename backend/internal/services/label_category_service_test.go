@@ -0,0 +1,86 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLabelCategoryService_CreateCategory(t *testing.T) {
+	t.Run("分類を作成", func(t *testing.T) {
+		mockRepo := new(MockLabelCategoryRepository)
+		service := NewLabelCategoryService(mockRepo)
+
+		category := &models.LabelCategory{Name: "陣営", MaxPerCharacter: 1}
+		mockRepo.On("ExistsByName", "陣営").Return(false, nil)
+		mockRepo.On("Create", category).Return(nil)
+
+		result, err := service.CreateCategory(category)
+
+		assert.NoError(t, err)
+		assert.Equal(t, category, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("同じ名前の分類は409", func(t *testing.T) {
+		mockRepo := new(MockLabelCategoryRepository)
+		service := NewLabelCategoryService(mockRepo)
+
+		mockRepo.On("ExistsByName", "陣営").Return(true, nil)
+
+		_, err := service.CreateCategory(&models.LabelCategory{Name: "陣営", MaxPerCharacter: 1})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.Equal(t, "LABEL_CATEGORY_NAME_TAKEN", serviceError.Code)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestLabelCategoryService_UpdateCategory(t *testing.T) {
+	t.Run("上限を変更", func(t *testing.T) {
+		mockRepo := new(MockLabelCategoryRepository)
+		service := NewLabelCategoryService(mockRepo)
+
+		mockRepo.On("GetByID", "category-1").Return(&models.LabelCategory{ID: "category-1", WorkspaceID: "ws-1", Name: "陣営", MaxPerCharacter: 1, Version: 2}, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(category *models.LabelCategory) bool {
+			return category.ID == "category-1" && category.WorkspaceID == "ws-1" && category.MaxPerCharacter == 2 && category.Version == 2
+		})).Return(nil)
+
+		result, err := service.UpdateCategory("category-1", &models.LabelCategory{Name: "陣営", MaxPerCharacter: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.MaxPerCharacter)
+		mockRepo.AssertNotCalled(t, "ExistsByName", mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しない分類は404", func(t *testing.T) {
+		mockRepo := new(MockLabelCategoryRepository)
+		service := NewLabelCategoryService(mockRepo)
+
+		mockRepo.On("GetByID", "missing").Return((*models.LabelCategory)(nil), gorm.ErrRecordNotFound)
+
+		_, err := service.UpdateCategory("missing", &models.LabelCategory{Name: "陣営", MaxPerCharacter: 1})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestLabelCategoryService_DeleteCategory(t *testing.T) {
+	t.Run("存在しない分類は404", func(t *testing.T) {
+		mockRepo := new(MockLabelCategoryRepository)
+		service := NewLabelCategoryService(mockRepo)
+
+		mockRepo.On("Delete", "missing").Return(gorm.ErrRecordNotFound)
+
+		err := service.DeleteCategory("missing")
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
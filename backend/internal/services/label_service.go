@@ -2,9 +2,11 @@ package services
 
 import (
 	"character-management-app/internal/models"
+	"character-management-app/internal/palette"
 	"character-management-app/internal/repositories"
-	"errors"
 	"fmt"
+	"math"
+	"sort"
 )
 
 // LabelService ラベルサービスのインターフェース
@@ -12,31 +14,92 @@ type LabelService interface {
 	CreateLabel(label *models.Label) (*models.Label, error)
 	GetLabelByID(id string) (*models.Label, error)
 	GetAllLabels() ([]models.Label, error)
+	GetLabelsForGroup(groupID string) ([]models.Label, error)
+	GetLabelTree() ([]*LabelNode, error)
 	UpdateLabel(id string, label *models.Label) (*models.Label, error)
 	DeleteLabel(id string) error
+	AttachLabelUsage(labels []models.Label) ([]LabelWithUsage, error)
+	SuggestColor(groupID string) (*palette.Suggestion, error)
+	WithWorkspace(workspaceID string) LabelService
+}
+
+// LabelNode ラベルの階層の節（子ラベルは名前順）
+type LabelNode struct {
+	models.Label
+	Children []*LabelNode `json:"children"`
+}
+
+// LabelWithUsage 利用状況付きのラベル
+type LabelWithUsage struct {
+	models.Label
+	Usage LabelUsage `json:"usage"`
+}
+
+// LabelUsage ラベルの利用状況（どちらも0のラベルは使われていない）
+type LabelUsage struct {
+	// Characters ラベルが付いている人物の数
+	Characters int64 `json:"characters"`
+	// Groups ラベルが付いている人物が所属するグループの数
+	Groups int64 `json:"groups"`
 }
 
 // labelService ラベルサービスの実装
 type labelService struct {
-	labelRepo repositories.LabelRepository
+	labelRepo    repositories.LabelRepository
+	groupRepo    repositories.GroupRepository
+	categoryRepo repositories.LabelCategoryRepository
 }
 
 // NewLabelService ラベルサービスのコンストラクタ
-func NewLabelService(labelRepo repositories.LabelRepository) LabelService {
+func NewLabelService(labelRepo repositories.LabelRepository, groupRepo repositories.GroupRepository, categoryRepo repositories.LabelCategoryRepository) LabelService {
+	return &labelService{
+		labelRepo:    labelRepo,
+		groupRepo:    groupRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// WithWorkspace 指定したワークスペースに限定したサービスを返す
+func (s *labelService) WithWorkspace(workspaceID string) LabelService {
 	return &labelService{
-		labelRepo: labelRepo,
+		labelRepo:    s.labelRepo.WithWorkspace(workspaceID),
+		groupRepo:    s.groupRepo.WithWorkspace(workspaceID),
+		categoryRepo: s.categoryRepo.WithWorkspace(workspaceID),
 	}
 }
 
-// CreateLabel ラベルを作成
+// CreateLabel ラベルを作成（GroupID を指定した場合はそのグループ専用のラベル）
 func (s *labelService) CreateLabel(label *models.Label) (*models.Label, error) {
-	// 名前の重複チェック
-	exists, err := s.labelRepo.ExistsByName(label.Name)
+	// グループ専用のラベルの場合はグループの存在確認
+	if label.GroupID != nil {
+		if err := s.checkGroup(*label.GroupID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 名前の重複チェック（グループ専用のラベルはグループ内、全体のラベルは全体のラベルの中で一意）
+	exists, err := s.labelRepo.ExistsByName(label.Name, label.GroupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check label name existence: %w", err)
 	}
 	if exists {
-		return nil, errors.New("label with this name already exists")
+		return nil, NewConflictError("LABEL_NAME_TAKEN", "label with this name already exists")
+	}
+
+	// 親ラベルの確認
+	if err := s.validateParent("", label.ParentID, label.GroupID); err != nil {
+		return nil, err
+	}
+
+	// 分類の確認
+	if err := s.checkCategory(label.CategoryID); err != nil {
+		return nil, err
+	}
+
+	// 色が近い既存のラベル（作成は拒否せず警告として返す）
+	warnings, err := s.colorWarnings(label)
+	if err != nil {
+		return nil, err
 	}
 
 	// ラベルを作成
@@ -44,6 +107,8 @@ func (s *labelService) CreateLabel(label *models.Label) (*models.Label, error) {
 		return nil, fmt.Errorf("failed to create label: %w", err)
 	}
 
+	label.TextColor = palette.TextColor(label.Color)
+	label.ColorWarnings = warnings
 	return label, nil
 }
 
@@ -51,8 +116,9 @@ func (s *labelService) CreateLabel(label *models.Label) (*models.Label, error) {
 func (s *labelService) GetLabelByID(id string) (*models.Label, error) {
 	label, err := s.labelRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get label: %w", err)
+		return nil, lookupError(err, errLabelNotFound(), "failed to get label")
 	}
+	label.TextColor = palette.TextColor(label.Color)
 	return label, nil
 }
 
@@ -62,37 +128,82 @@ func (s *labelService) GetAllLabels() ([]models.Label, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get labels: %w", err)
 	}
+	setTextColors(labels)
+	return labels, nil
+}
+
+// GetLabelsForGroup グループの人物に付けられるラベル（全体のラベルとグループ専用のラベル）を取得
+func (s *labelService) GetLabelsForGroup(groupID string) ([]models.Label, error) {
+	if err := s.checkGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	labels, err := s.labelRepo.GetForGroup(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels for group: %w", err)
+	}
+	setTextColors(labels)
 	return labels, nil
 }
 
-// UpdateLabel ラベルを更新
+// UpdateLabel ラベルを更新（所属するグループは作成時から変更できない）
 func (s *labelService) UpdateLabel(id string, label *models.Label) (*models.Label, error) {
 	// 既存のラベルを取得
 	existingLabel, err := s.labelRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("label not found: %w", err)
+		return nil, lookupError(err, errLabelNotFound(), "failed to get existing label")
 	}
 
+	// 所属するグループは変更しない（付いている人物がグループの条件を満たさなくなるため）
+	label.GroupID = existingLabel.GroupID
+
 	// 名前が変更されている場合、重複チェック
 	if existingLabel.Name != label.Name {
-		exists, err := s.labelRepo.ExistsByName(label.Name)
+		exists, err := s.labelRepo.ExistsByName(label.Name, label.GroupID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check label name existence: %w", err)
 		}
 		if exists {
-			return nil, errors.New("label with this name already exists")
+			return nil, NewConflictError("LABEL_NAME_TAKEN", "label with this name already exists")
 		}
 	}
 
-	// IDと作成日時を保持
+	// 親ラベルの確認（自分自身・子孫を親にすると循環するため拒否）
+	if err := s.validateParent(existingLabel.ID, label.ParentID, label.GroupID); err != nil {
+		return nil, err
+	}
+
+	// 分類の確認（分類を変更しても既に付いている人物のラベルは外さない）
+	if err := s.checkCategory(label.CategoryID); err != nil {
+		return nil, err
+	}
+
+	// ID・ワークスペース・作成日時を保持
 	label.ID = existingLabel.ID
+	label.WorkspaceID = existingLabel.WorkspaceID
 	label.CreatedAt = existingLabel.CreatedAt
 
+	// 期待するバージョンの指定がなければ現在のバージョンに対して更新する
+	if label.Version == 0 {
+		label.Version = existingLabel.Version
+	}
+
+	// 色を変更した場合は色が近い既存のラベルを警告として返す
+	var warnings []models.LabelColorWarning
+	if !sameColor(existingLabel.Color, label.Color) {
+		warnings, err = s.colorWarnings(label)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// ラベルを更新
 	if err := s.labelRepo.Update(label); err != nil {
 		return nil, fmt.Errorf("failed to update label: %w", err)
 	}
 
+	label.TextColor = palette.TextColor(label.Color)
+	label.ColorWarnings = warnings
 	return label, nil
 }
 
@@ -104,13 +215,248 @@ func (s *labelService) DeleteLabel(id string) error {
 		return fmt.Errorf("failed to check label existence: %w", err)
 	}
 	if !exists {
-		return errors.New("label not found")
+		return errLabelNotFound()
 	}
 
 	// ラベルを削除（GORM の many2many 関係により、character_labels テーブルからも自動削除される）
+	// 子ラベルは削除したラベルの親に付け替わる
 	if err := s.labelRepo.Delete(id); err != nil {
-		return fmt.Errorf("failed to delete label: %w", err)
+		return lookupError(err, errLabelNotFound(), "failed to delete label")
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// AttachLabelUsage ラベルに利用状況を付ける
+func (s *labelService) AttachLabelUsage(labels []models.Label) ([]LabelWithUsage, error) {
+	usage, err := s.labelRepo.GetUsage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get label usage: %w", err)
+	}
+
+	result := make([]LabelWithUsage, len(labels))
+	for i, label := range labels {
+		result[i] = LabelWithUsage{
+			Label: label,
+			Usage: LabelUsage{
+				Characters: usage[label.ID].CharacterCount,
+				Groups:     usage[label.ID].GroupCount,
+			},
+		}
+	}
+	return result, nil
+}
+
+// SuggestColor 既存のラベルの色から最も見分けやすいパレットの色を選ぶ
+// groupID を指定した場合はそのグループの人物に付けられるラベル（全体のラベルとグループ専用のラベル）の色と比べる
+func (s *labelService) SuggestColor(groupID string) (*palette.Suggestion, error) {
+	var labels []models.Label
+	var err error
+	if groupID != "" {
+		labels, err = s.GetLabelsForGroup(groupID)
+	} else {
+		labels, err = s.GetAllLabels()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make([]string, len(labels))
+	for i, label := range labels {
+		colors[i] = label.Color
+	}
+	suggestion := palette.Suggest(colors)
+	return &suggestion, nil
+}
+
+// GetLabelTree ラベルを階層の木として取得（最上位のラベルと子ラベルはそれぞれ名前順）
+func (s *labelService) GetLabelTree() ([]*LabelNode, error) {
+	labels, err := s.GetAllLabels()
+	if err != nil {
+		return nil, err
+	}
+	return buildLabelTree(labels), nil
+}
+
+// colorWarnings ラベルと一緒に表示されうるラベル（全体のラベルとラベルのグループ専用のラベル）のうち色が近いもの（近い順）
+func (s *labelService) colorWarnings(label *models.Label) ([]models.LabelColorWarning, error) {
+	var labels []models.Label
+	var err error
+	if label.GroupID != nil {
+		labels, err = s.labelRepo.GetForGroup(*label.GroupID)
+	} else {
+		labels, err = s.labelRepo.GetAll()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	var warnings []models.LabelColorWarning
+	for _, other := range labels {
+		if other.ID == label.ID {
+			continue
+		}
+		distance, err := palette.Distance(label.Color, other.Color)
+		if err != nil || distance >= palette.MinDistance {
+			continue
+		}
+		warnings = append(warnings, models.LabelColorWarning{
+			LabelID:  other.ID,
+			Name:     other.Name,
+			Color:    other.Color,
+			Distance: math.Round(distance*100) / 100,
+		})
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Distance < warnings[j].Distance
+	})
+	return warnings, nil
+}
+
+// setTextColors ラベルごとに背景色に対する文字色を設定する
+func setTextColors(labels []models.Label) {
+	for i := range labels {
+		labels[i].TextColor = palette.TextColor(labels[i].Color)
+	}
+}
+
+// sameColor 2つの色が同じか（大文字・小文字や #RGB の省略形の違いは同じ色とみなす）
+func sameColor(a, b string) bool {
+	ca, errA := palette.Parse(a)
+	cb, errB := palette.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ca == cb
+}
+
+// checkGroup グループの存在確認
+func (s *labelService) checkGroup(groupID string) error {
+	exists, err := s.groupRepo.ExistsByID(groupID)
+	if err != nil {
+		return fmt.Errorf("failed to check group existence: %w", err)
+	}
+	if !exists {
+		return errGroupNotFound()
+	}
+	return nil
+}
+
+// checkCategory 分類の存在確認（分類なしの場合は確認しない）
+func (s *labelService) checkCategory(categoryID *string) error {
+	if categoryID == nil {
+		return nil
+	}
+	exists, err := s.categoryRepo.ExistsByID(*categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to check label category existence: %w", err)
+	}
+	if !exists {
+		return errLabelCategoryNotFound()
+	}
+	return nil
+}
+
+// validateParent groupID に所属する labelID のラベルの親に parentID を指定できるか確認する（作成時の labelID は空）
+// 親ラベルが存在しない場合、親が全体のラベルでも同じグループのラベルでもない場合と、
+// 親をたどって labelID に戻る（循環する）場合はエラー
+func (s *labelService) validateParent(labelID string, parentID, groupID *string) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == labelID {
+		return errLabelCycle()
+	}
+
+	labels, err := s.labelRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to get labels: %w", err)
+	}
+	byID := make(map[string]*models.Label, len(labels))
+	for i := range labels {
+		byID[labels[i].ID] = &labels[i]
+	}
+
+	if byID[*parentID] == nil {
+		return NewValidationError("LABEL_PARENT_NOT_FOUND", "parent label not found", map[string]string{"parentId": *parentID})
+	}
+	if parentGroupID := byID[*parentID].GroupID; parentGroupID != nil && models.LabelScope(parentGroupID) != models.LabelScope(groupID) {
+		return NewValidationError("LABEL_PARENT_GROUP_MISMATCH", "parent label must be global or belong to the same group", map[string]string{"parentId": *parentID})
+	}
+	visited := make(map[string]bool)
+	for current := byID[*parentID]; current != nil && !visited[current.ID]; current = parentOf(byID, current) {
+		if current.ID == labelID {
+			return errLabelCycle()
+		}
+		visited[current.ID] = true
+	}
+	return nil
+}
+
+// errLabelCycle ラベルの親子関係が循環するエラー
+func errLabelCycle() *Error {
+	return NewValidationError("LABEL_CYCLE", "label cannot be its own ancestor", nil)
+}
+
+// parentOf ラベルの親（最上位・親が見つからない場合は nil）
+func parentOf(byID map[string]*models.Label, label *models.Label) *models.Label {
+	if label.ParentID == nil {
+		return nil
+	}
+	return byID[*label.ParentID]
+}
+
+// buildLabelTree ラベルの一覧から階層の木を作る
+// 親が見つからないラベルは最上位として扱う
+func buildLabelTree(labels []models.Label) []*LabelNode {
+	nodes := make(map[string]*LabelNode, len(labels))
+	for _, label := range labels {
+		nodes[label.ID] = &LabelNode{Label: label, Children: []*LabelNode{}}
+	}
+
+	roots := []*LabelNode{}
+	for _, label := range labels {
+		node := nodes[label.ID]
+		if label.ParentID != nil && nodes[*label.ParentID] != nil {
+			parent := nodes[*label.ParentID]
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	sortLabelNodes(roots)
+	return roots
+}
+
+// sortLabelNodes 節を名前順に並べる（子孫も含む）
+func sortLabelNodes(nodes []*LabelNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortLabelNodes(node.Children)
+	}
+}
+
+// labelDescendants labelID のラベルとその子孫のラベルの ID
+func labelDescendants(labels []models.Label, labelID string) []string {
+	children := make(map[string][]string)
+	for _, label := range labels {
+		if label.ParentID != nil {
+			children[*label.ParentID] = append(children[*label.ParentID], label.ID)
+		}
+	}
+
+	ids := []string{labelID}
+	visited := map[string]bool{labelID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/palette"
+	"character-management-app/internal/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// labelHierarchy 武将 > 大名 > 戦国大名 と、親のない 公家 のラベル
+func labelHierarchy() []models.Label {
+	parent := func(id string) *string { return &id }
+	return []models.Label{
+		{ID: "label-warlord", Name: "武将", Color: "#123456", Version: 1},
+		{ID: "label-daimyo", Name: "大名", ParentID: parent("label-warlord"), Color: "#123456", Version: 1},
+		{ID: "label-sengoku", Name: "戦国大名", ParentID: parent("label-daimyo"), Color: "#123456", Version: 1},
+		{ID: "label-retainer", Name: "家臣", ParentID: parent("label-warlord"), Color: "#123456", Version: 1},
+		{ID: "label-noble", Name: "公家", Color: "#123456", Version: 1},
+	}
+}
+
+func TestLabelService_CreateLabel_Parent(t *testing.T) {
+	t.Run("親ラベルを指定して作成", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		parentID := "label-warlord"
+		label := &models.Label{Name: "旗本", Color: "#123456", ParentID: &parentID}
+		mockRepo.On("ExistsByName", "旗本", (*string)(nil)).Return(false, nil)
+		mockRepo.On("GetAll").Return(labelHierarchy(), nil)
+		mockRepo.On("Create", label).Return(nil)
+
+		result, err := service.CreateLabel(label)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &parentID, result.ParentID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しない親ラベルは422", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		parentID := "missing"
+		mockRepo.On("ExistsByName", "旗本", (*string)(nil)).Return(false, nil)
+		mockRepo.On("GetAll").Return(labelHierarchy(), nil)
+
+		_, err := service.CreateLabel(&models.Label{Name: "旗本", Color: "#123456", ParentID: &parentID})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, "LABEL_PARENT_NOT_FOUND", serviceError.Code)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestLabelService_GroupLabels(t *testing.T) {
+	groupID := "group-1"
+
+	t.Run("グループ専用のラベルを作成", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewLabelService(mockRepo, mockGroupRepo, new(MockLabelCategoryRepository))
+
+		label := &models.Label{Name: "旗本", Color: "#123456", GroupID: &groupID}
+		mockGroupRepo.On("ExistsByID", groupID).Return(true, nil)
+		mockRepo.On("ExistsByName", "旗本", &groupID).Return(false, nil)
+		mockRepo.On("GetForGroup", groupID).Return([]models.Label{}, nil)
+		mockRepo.On("Create", label).Return(nil)
+
+		_, err := service.CreateLabel(label)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しないグループは404", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewLabelService(mockRepo, mockGroupRepo, new(MockLabelCategoryRepository))
+
+		mockGroupRepo.On("ExistsByID", groupID).Return(false, nil)
+
+		_, err := service.CreateLabel(&models.Label{Name: "旗本", Color: "#123456", GroupID: &groupID})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("他のグループのラベルは親にできない", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewLabelService(mockRepo, mockGroupRepo, new(MockLabelCategoryRepository))
+
+		otherGroupID := "group-2"
+		parentID := "label-other"
+		labels := append(labelHierarchy(), models.Label{ID: parentID, Name: "他作品", GroupID: &otherGroupID})
+		mockGroupRepo.On("ExistsByID", groupID).Return(true, nil)
+		mockRepo.On("ExistsByName", "旗本", &groupID).Return(false, nil)
+		mockRepo.On("GetAll").Return(labels, nil)
+
+		_, err := service.CreateLabel(&models.Label{Name: "旗本", Color: "#123456", GroupID: &groupID, ParentID: &parentID})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_PARENT_GROUP_MISMATCH", serviceError.Code)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("全体のラベルを親にできる", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewLabelService(mockRepo, mockGroupRepo, new(MockLabelCategoryRepository))
+
+		parentID := "label-warlord"
+		label := &models.Label{Name: "旗本", Color: "#123456", GroupID: &groupID, ParentID: &parentID}
+		mockGroupRepo.On("ExistsByID", groupID).Return(true, nil)
+		mockRepo.On("ExistsByName", "旗本", &groupID).Return(false, nil)
+		mockRepo.On("GetAll").Return(labelHierarchy(), nil)
+		mockRepo.On("GetForGroup", groupID).Return(labelHierarchy(), nil)
+		mockRepo.On("Create", label).Return(nil)
+
+		_, err := service.CreateLabel(label)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("更新ではグループを変更しない", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		mockRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1", Name: "旗本", Color: "#123456", GroupID: &groupID, Version: 1}, nil)
+		mockRepo.On("ExistsByName", "御家人", &groupID).Return(false, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(label *models.Label) bool {
+			return label.GroupID != nil && *label.GroupID == groupID
+		})).Return(nil)
+
+		_, err := service.UpdateLabel("label-1", &models.Label{Name: "御家人", Color: "#123456"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("グループに付けられるラベルを取得", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewLabelService(mockRepo, mockGroupRepo, new(MockLabelCategoryRepository))
+
+		labels := []models.Label{{ID: "label-global", Name: "武将", Color: "#111827"}, {ID: "label-1", Name: "旗本", Color: "#FDE68A", GroupID: &groupID}}
+		mockGroupRepo.On("ExistsByID", groupID).Return(true, nil)
+		mockRepo.On("GetForGroup", groupID).Return(labels, nil)
+
+		result, err := service.GetLabelsForGroup(groupID)
+
+		assert.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "label-global", result[0].ID)
+		// 文字色はレスポンスを返す時に設定する
+		assert.Equal(t, palette.White, result[0].TextColor)
+		assert.Equal(t, palette.Black, result[1].TextColor)
+	})
+}
+
+func TestLabelService_Category(t *testing.T) {
+	t.Run("存在しない分類は404", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockCategoryRepo := new(MockLabelCategoryRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), mockCategoryRepo)
+
+		categoryID := "missing"
+		mockRepo.On("ExistsByName", "徳川方", (*string)(nil)).Return(false, nil)
+		mockCategoryRepo.On("ExistsByID", categoryID).Return(false, nil)
+
+		_, err := service.CreateLabel(&models.Label{Name: "徳川方", Color: "#123456", CategoryID: &categoryID})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "LABEL_CATEGORY_NOT_FOUND", serviceError.Code)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("分類を指定して作成", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		mockCategoryRepo := new(MockLabelCategoryRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), mockCategoryRepo)
+
+		categoryID := "category-faction"
+		label := &models.Label{Name: "徳川方", Color: "#123456", CategoryID: &categoryID}
+		mockRepo.On("ExistsByName", "徳川方", (*string)(nil)).Return(false, nil)
+		mockCategoryRepo.On("ExistsByID", categoryID).Return(true, nil)
+		mockRepo.On("GetAll").Return([]models.Label{}, nil)
+		mockRepo.On("Create", label).Return(nil)
+
+		_, err := service.CreateLabel(label)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestLabelService_ColorWarnings(t *testing.T) {
+	t.Run("近い色の既存のラベルを近い順に警告", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		label := &models.Label{Name: "徳川方", Color: "#3B82F6"}
+		mockRepo.On("ExistsByName", "徳川方", (*string)(nil)).Return(false, nil)
+		mockRepo.On("GetAll").Return([]models.Label{
+			{ID: "label-red", Name: "豊臣方", Color: "#EF4444"},
+			{ID: "label-blue", Name: "織田方", Color: "#3B83F6"},
+			{ID: "label-sky", Name: "公家", Color: "#3F7FFF"},
+		}, nil)
+		mockRepo.On("Create", label).Return(nil)
+
+		result, err := service.CreateLabel(label)
+
+		require.NoError(t, err)
+		assert.Equal(t, palette.Black, result.TextColor)
+		require.Len(t, result.ColorWarnings, 2)
+		assert.Equal(t, "label-blue", result.ColorWarnings[0].LabelID)
+		assert.Equal(t, "label-sky", result.ColorWarnings[1].LabelID)
+		assert.Less(t, result.ColorWarnings[0].Distance, result.ColorWarnings[1].Distance)
+	})
+
+	t.Run("色を変更しない更新では警告しない", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		mockRepo.On("GetByID", "label-1").Return(&models.Label{ID: "label-1", Name: "徳川方", Color: "#3b82f6", Version: 1}, nil)
+		mockRepo.On("ExistsByName", "松平方", (*string)(nil)).Return(false, nil)
+		mockRepo.On("Update", mock.Anything).Return(nil)
+
+		result, err := service.UpdateLabel("label-1", &models.Label{Name: "松平方", Color: "#3B82F6"})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.ColorWarnings)
+		mockRepo.AssertNotCalled(t, "GetAll")
+	})
+}
+
+func TestLabelService_SuggestColor(t *testing.T) {
+	t.Run("既存のラベルの色から離れた色を選ぶ", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		labels := []models.Label{{ID: "label-1", Color: "#3B82F6"}, {ID: "label-2", Color: "#EF4444"}}
+		mockRepo.On("GetAll").Return(labels, nil)
+
+		result, err := service.SuggestColor("")
+
+		require.NoError(t, err)
+		require.NotNil(t, result.Distance)
+		assert.GreaterOrEqual(t, *result.Distance, palette.MinDistance)
+		for _, label := range labels {
+			assert.NotEqual(t, label.Color, result.Color)
+		}
+	})
+
+	t.Run("存在しないグループは404", func(t *testing.T) {
+		mockGroupRepo := new(MockGroupRepository)
+		service := NewLabelService(new(MockLabelRepository), mockGroupRepo, new(MockLabelCategoryRepository))
+
+		mockGroupRepo.On("ExistsByID", "missing").Return(false, nil)
+
+		_, err := service.SuggestColor("missing")
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestLabelService_AttachLabelUsage(t *testing.T) {
+	mockRepo := new(MockLabelRepository)
+	service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+	mockRepo.On("GetUsage").Return(map[string]repositories.LabelUsage{
+		"label-warlord": {CharacterCount: 12, GroupCount: 3},
+	}, nil)
+
+	result, err := service.AttachLabelUsage([]models.Label{{ID: "label-warlord"}, {ID: "label-noble"}})
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "label-warlord", result[0].ID)
+	assert.Equal(t, LabelUsage{Characters: 12, Groups: 3}, result[0].Usage)
+	assert.Equal(t, LabelUsage{}, result[1].Usage, "どの人物にも付いていないラベルは0件")
+}
+
+func TestLabelService_UpdateLabel_Parent(t *testing.T) {
+	cases := map[string]struct {
+		labelID, parentID string
+	}{
+		"自分自身を親にする": {"label-daimyo", "label-daimyo"},
+		"子を親にする":    {"label-warlord", "label-daimyo"},
+		"孫を親にする":    {"label-warlord", "label-sengoku"},
+	}
+	for name, tc := range cases {
+		t.Run(name+"と循環するため422", func(t *testing.T) {
+			mockRepo := new(MockLabelRepository)
+			service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+			labels := labelHierarchy()
+			var existing models.Label
+			for _, label := range labels {
+				if label.ID == tc.labelID {
+					existing = label
+				}
+			}
+			parentID := tc.parentID
+			mockRepo.On("GetByID", tc.labelID).Return(&existing, nil)
+			mockRepo.On("GetAll").Return(labels, nil).Maybe()
+
+			_, err := service.UpdateLabel(tc.labelID, &models.Label{Name: existing.Name, Color: "#123456", ParentID: &parentID})
+
+			var serviceError *Error
+			require.ErrorAs(t, err, &serviceError)
+			assert.ErrorIs(t, err, ErrValidation)
+			assert.Equal(t, "LABEL_CYCLE", serviceError.Code)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		})
+	}
+
+	t.Run("別の枝に移動", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		labels := labelHierarchy()
+		parentID := "label-noble"
+		mockRepo.On("GetByID", "label-daimyo").Return(&labels[1], nil)
+		mockRepo.On("GetAll").Return(labels, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(label *models.Label) bool {
+			return label.ID == "label-daimyo" && label.ParentID != nil && *label.ParentID == "label-noble"
+		})).Return(nil)
+
+		_, err := service.UpdateLabel("label-daimyo", &models.Label{Name: "大名", Color: "#123456", ParentID: &parentID})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("親を外して最上位にする", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		labels := labelHierarchy()
+		mockRepo.On("GetByID", "label-daimyo").Return(&labels[1], nil)
+		mockRepo.On("Update", mock.MatchedBy(func(label *models.Label) bool {
+			return label.ParentID == nil
+		})).Return(nil)
+
+		_, err := service.UpdateLabel("label-daimyo", &models.Label{Name: "大名", Color: "#123456"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "GetAll")
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestLabelService_GetLabelTree(t *testing.T) {
+	t.Run("名前順の木を作る", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		mockRepo.On("GetAll").Return(labelHierarchy(), nil)
+
+		tree, err := service.GetLabelTree()
+
+		require.NoError(t, err)
+		require.Len(t, tree, 2)
+		assert.Equal(t, "公家", tree[0].Name)
+		assert.Empty(t, tree[0].Children)
+		assert.Equal(t, "武将", tree[1].Name)
+		require.Len(t, tree[1].Children, 2)
+		assert.Equal(t, "大名", tree[1].Children[0].Name)
+		assert.Equal(t, "家臣", tree[1].Children[1].Name)
+		require.Len(t, tree[1].Children[0].Children, 1)
+		assert.Equal(t, "戦国大名", tree[1].Children[0].Children[0].Name)
+	})
+
+	t.Run("親が見つからないラベルは最上位", func(t *testing.T) {
+		mockRepo := new(MockLabelRepository)
+		service := NewLabelService(mockRepo, new(MockGroupRepository), new(MockLabelCategoryRepository))
+
+		missing := "deleted"
+		mockRepo.On("GetAll").Return([]models.Label{{ID: "label-1", Name: "孤児", ParentID: &missing}}, nil)
+
+		tree, err := service.GetLabelTree()
+
+		require.NoError(t, err)
+		require.Len(t, tree, 1)
+		assert.Equal(t, "孤児", tree[0].Name)
+	})
+}
+
+func TestLabelDescendants(t *testing.T) {
+	assert.Equal(t, []string{"label-warlord", "label-daimyo", "label-retainer", "label-sengoku"}, labelDescendants(labelHierarchy(), "label-warlord"))
+	assert.Equal(t, []string{"label-sengoku"}, labelDescendants(labelHierarchy(), "label-sengoku"))
+}
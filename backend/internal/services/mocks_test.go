@@ -2,7 +2,7 @@ package services
 
 import (
 	"character-management-app/internal/models"
-	"io"
+	"character-management-app/internal/repositories"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -32,6 +32,11 @@ func (m *MockCharacterRepository) GetByGroupID(groupID string) ([]models.Charact
 	return args.Get(0).([]models.Character), args.Error(1)
 }
 
+func (m *MockCharacterRepository) Find(filter repositories.CharacterFilter) ([]models.Character, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Character), args.Error(1)
+}
+
 func (m *MockCharacterRepository) Update(character *models.Character) error {
 	args := m.Called(character)
 	return args.Error(0)
@@ -47,9 +52,17 @@ func (m *MockCharacterRepository) ExistsByID(id string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockCharacterRepository) AddLabel(characterID, labelID string) error {
+// AddLabel 期待値の人物（トランザクション内で読み直した人物の代わり）で check を呼ぶ
+// check が通った場合は ApplyAddLabel の呼び出しとして記録する
+func (m *MockCharacterRepository) AddLabel(characterID, labelID string, check func(character *models.Character) error) error {
 	args := m.Called(characterID, labelID)
-	return args.Error(0)
+	if character, ok := args.Get(0).(*models.Character); ok && character != nil && check != nil {
+		if err := check(character); err != nil {
+			return err
+		}
+		m.MethodCalled("ApplyAddLabel", characterID, labelID)
+	}
+	return args.Error(1)
 }
 
 func (m *MockCharacterRepository) RemoveLabel(characterID, labelID string) error {
@@ -67,6 +80,130 @@ func (m *MockCharacterRepository) HasLabel(characterID, labelID string) (bool, e
 	return args.Bool(0), args.Error(1)
 }
 
+// ReplaceLabels 期待値の人物（トランザクション内で読み直した人物の代わり）で check を呼び、エラーの場合はそのまま返す
+func (m *MockCharacterRepository) ReplaceLabels(characterID string, labelIDs []string, check func(character *models.Character) error) error {
+	args := m.Called(characterID, labelIDs)
+	if character, ok := args.Get(0).(*models.Character); ok && check != nil {
+		if err := check(character); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// UpdateLabelAssignments 期待値の人物（トランザクション内で読み直した人物の代わり）で plan を呼ぶ
+// 付ける・外す人物がある場合は ApplyLabelAssignments の呼び出しとして記録する
+func (m *MockCharacterRepository) UpdateLabelAssignments(labelID string, characterIDs []string, plan repositories.LabelAssignmentPlan) error {
+	args := m.Called(labelID, characterIDs)
+	characters, _ := args.Get(0).([]models.Character)
+	addIDs, removeIDs := plan(characters)
+	if len(addIDs)+len(removeIDs) > 0 {
+		m.MethodCalled("ApplyLabelAssignments", labelID, addIDs, removeIDs)
+	}
+	return args.Error(1)
+}
+
+func (m *MockCharacterRepository) CountByPhoto(keys ...string) (int64, error) {
+	args := m.Called(keys)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCharacterRepository) GetPhotoKeys() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockCharacterRepository) GetWithPhoto() ([]models.Character, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Character), args.Error(1)
+}
+
+func (m *MockCharacterRepository) ReplacePhoto(newKey string, oldKeys ...string) error {
+	args := m.Called(newKey, oldKeys)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) GetPhotoSizes(excludeCharacterID string) (map[string]int64, error) {
+	args := m.Called(excludeCharacterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockCharacterRepository) GetPhotoKeysWithoutSize() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockCharacterRepository) SetPhotoSize(key string, size int64) error {
+	args := m.Called(key, size)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) GetWithRelatedLinks() ([]models.Character, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Character), args.Error(1)
+}
+
+func (m *MockCharacterRepository) SetRelatedLinks(id string, links []models.RelatedLink) error {
+	args := m.Called(id, links)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) GetAllImages() ([]models.CharacterImage, error) {
+	args := m.Called()
+	return args.Get(0).([]models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterRepository) GetImages(characterID string) ([]models.CharacterImage, error) {
+	args := m.Called(characterID)
+	return args.Get(0).([]models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterRepository) GetImage(characterID, imageID string) (*models.CharacterImage, error) {
+	args := m.Called(characterID, imageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CharacterImage), args.Error(1)
+}
+
+func (m *MockCharacterRepository) AddImage(image *models.CharacterImage) error {
+	args := m.Called(image)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) UpdateImage(image *models.CharacterImage) error {
+	args := m.Called(image)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) SetPrimaryImage(characterID, imageID string) error {
+	args := m.Called(characterID, imageID)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) ReorderImages(characterID string, imageIDs []string) error {
+	args := m.Called(characterID, imageIDs)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) DeleteImage(characterID, imageID string) error {
+	args := m.Called(characterID, imageID)
+	return args.Error(0)
+}
+
+func (m *MockCharacterRepository) WithWorkspace(workspaceID string) repositories.CharacterRepository {
+	return m
+}
+
 // MockGroupRepository グループリポジトリのモック
 type MockGroupRepository struct {
 	mock.Mock
@@ -105,6 +242,10 @@ func (m *MockGroupRepository) ExistsByID(id string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockGroupRepository) WithWorkspace(workspaceID string) repositories.GroupRepository {
+	return m
+}
+
 // MockLabelRepository ラベルリポジトリのモック
 type MockLabelRepository struct {
 	mock.Mock
@@ -120,11 +261,40 @@ func (m *MockLabelRepository) GetByID(id string) (*models.Label, error) {
 	return args.Get(0).(*models.Label), args.Error(1)
 }
 
+func (m *MockLabelRepository) GetByIDs(ids []string) ([]models.Label, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]models.Label), args.Error(1)
+}
+
+// Merge 期待値の人物（トランザクション内で読み直した人物の代わり）で check を呼ぶ
+// check が通った場合は ApplyMerge の呼び出しとして記録する
+func (m *MockLabelRepository) Merge(sourceID, targetID string, check repositories.LabelMergeCheck) error {
+	args := m.Called(sourceID, targetID)
+	characters, _ := args.Get(0).([]models.Character)
+	if check != nil {
+		if err := check(characters); err != nil {
+			return err
+		}
+	}
+	m.MethodCalled("ApplyMerge", sourceID, targetID)
+	return args.Error(1)
+}
+
+func (m *MockLabelRepository) GetUsage() (map[string]repositories.LabelUsage, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]repositories.LabelUsage), args.Error(1)
+}
+
 func (m *MockLabelRepository) GetAll() ([]models.Label, error) {
 	args := m.Called()
 	return args.Get(0).([]models.Label), args.Error(1)
 }
 
+func (m *MockLabelRepository) GetForGroup(groupID string) ([]models.Label, error) {
+	args := m.Called(groupID)
+	return args.Get(0).([]models.Label), args.Error(1)
+}
+
 func (m *MockLabelRepository) Update(label *models.Label) error {
 	args := m.Called(label)
 	return args.Error(0)
@@ -140,11 +310,15 @@ func (m *MockLabelRepository) ExistsByID(id string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockLabelRepository) ExistsByName(name string) (bool, error) {
-	args := m.Called(name)
+func (m *MockLabelRepository) ExistsByName(name string, groupID *string) (bool, error) {
+	args := m.Called(name, groupID)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockLabelRepository) WithWorkspace(workspaceID string) repositories.LabelRepository {
+	return m
+}
+
 // MockRelationshipRepository 関係リポジトリのモック
 type MockRelationshipRepository struct {
 	mock.Mock
@@ -190,6 +364,11 @@ func (m *MockRelationshipRepository) GetByCharacterID(characterID string) ([]mod
 	return args.Get(0).([]models.Relationship), args.Error(1)
 }
 
+func (m *MockRelationshipRepository) GetAmongCharacters(characterIDs []string) ([]models.Relationship, error) {
+	args := m.Called(characterIDs)
+	return args.Get(0).([]models.Relationship), args.Error(1)
+}
+
 func (m *MockRelationshipRepository) ExistsByCharacters(char1ID, char2ID string) (bool, error) {
 	args := m.Called(char1ID, char2ID)
 	return args.Bool(0), args.Error(1)
@@ -200,22 +379,125 @@ func (m *MockRelationshipRepository) ExistsBetweenCharacters(character1ID, chara
 	return args.Bool(0), args.Error(1)
 }
 
-// MockImageService 画像サービスのモック
-type MockImageService struct {
+func (m *MockRelationshipRepository) WithWorkspace(workspaceID string) repositories.RelationshipRepository {
+	return m
+}
+
+// MockWorkspaceRepository ワークスペースリポジトリのモック
+type MockWorkspaceRepository struct {
 	mock.Mock
 }
 
-func (m *MockImageService) SaveImage(file io.Reader, filename string, maxWidth, maxHeight uint) (string, error) {
-	args := m.Called(file, filename, maxWidth, maxHeight)
-	return args.String(0), args.Error(1)
+func (m *MockWorkspaceRepository) Create(workspace *models.Workspace) error {
+	args := m.Called(workspace)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) GetByID(id string) (*models.Workspace, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) GetBySlug(slug string) (*models.Workspace, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Workspace), args.Error(1)
 }
 
-func (m *MockImageService) DeleteImage(filePath string) error {
-	args := m.Called(filePath)
+func (m *MockWorkspaceRepository) GetAll() ([]models.Workspace, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) ExistsBySlug(slug string) (bool, error) {
+	args := m.Called(slug)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockLabelCategoryRepository ラベルの分類リポジトリのモック
+type MockLabelCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockLabelCategoryRepository) Create(category *models.LabelCategory) error {
+	args := m.Called(category)
 	return args.Error(0)
 }
 
-func (m *MockImageService) ValidateImageFile(filename string) error {
-	args := m.Called(filename)
+func (m *MockLabelCategoryRepository) GetByID(id string) (*models.LabelCategory, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.LabelCategory), args.Error(1)
+}
+
+func (m *MockLabelCategoryRepository) GetAll() ([]models.LabelCategory, error) {
+	args := m.Called()
+	return args.Get(0).([]models.LabelCategory), args.Error(1)
+}
+
+func (m *MockLabelCategoryRepository) Update(category *models.LabelCategory) error {
+	args := m.Called(category)
+	return args.Error(0)
+}
+
+func (m *MockLabelCategoryRepository) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockLabelCategoryRepository) ExistsByID(id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLabelCategoryRepository) ExistsByName(name string) (bool, error) {
+	args := m.Called(name)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLabelCategoryRepository) WithWorkspace(workspaceID string) repositories.LabelCategoryRepository {
+	return m
+}
+
+// MockSavedViewRepository 保存したビューリポジトリのモック
+type MockSavedViewRepository struct {
+	mock.Mock
+}
+
+func (m *MockSavedViewRepository) Create(view *models.SavedView) error {
+	args := m.Called(view)
 	return args.Error(0)
-}
\ No newline at end of file
+}
+
+func (m *MockSavedViewRepository) GetByID(id string) (*models.SavedView, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewRepository) GetAll() ([]models.SavedView, error) {
+	args := m.Called()
+	return args.Get(0).([]models.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewRepository) Update(view *models.SavedView) error {
+	args := m.Called(view)
+	return args.Error(0)
+}
+
+func (m *MockSavedViewRepository) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockSavedViewRepository) ExistsByName(name string) (bool, error) {
+	args := m.Called(name)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSavedViewRepository) WithWorkspace(workspaceID string) repositories.SavedViewRepository {
+	return m
+}
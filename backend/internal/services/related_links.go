@@ -0,0 +1,259 @@
+package services
+
+import (
+	"character-management-app/internal/linkpreview"
+	"character-management-app/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// MaxRelatedLinks 人物1人あたりの関連リンクの上限
+const MaxRelatedLinks = 50
+
+// maxRelatedLinkURLLength 関連リンクの URL の最大文字数
+const maxRelatedLinkURLLength = 2048
+
+// maxRelatedLinkTitleLength 関連リンクのタイトルの最大文字数
+const maxRelatedLinkTitleLength = 255
+
+// linkPreviewTimeout 人物の作成・更新1回でリンク情報の取得を待つ時間の上限
+const linkPreviewTimeout = 5 * time.Second
+
+// snsHosts SNS として扱うホスト（サブドメインを含む）
+var snsHosts = []string{
+	"twitter.com", "x.com", "facebook.com", "instagram.com", "threads.net",
+	"youtube.com", "youtu.be", "tiktok.com", "bsky.app", "pixiv.net",
+}
+
+// linkKinds 関連リンクの種類
+var linkKinds = map[string]bool{
+	models.LinkKindWikipedia: true,
+	models.LinkKindOfficial:  true,
+	models.LinkKindSNS:       true,
+	models.LinkKindSource:    true,
+	models.LinkKindOther:     true,
+}
+
+// normalizeRelatedLinks 関連リンクを検証し、種類と言語を補って正規化する
+// known の URL（更新前から登録されているリンク）は URL の検証を省く（URL の検証の導入前に登録したリンクで更新が失敗しないようにする）
+func normalizeRelatedLinks(links []models.RelatedLink, known map[string]bool) error {
+	if len(links) > MaxRelatedLinks {
+		return NewLimitExceededError("RELATED_LINK_LIMIT_EXCEEDED",
+			fmt.Sprintf("character cannot have more than %d related links", MaxRelatedLinks), map[string]int{"limit": MaxRelatedLinks})
+	}
+
+	seen := make(map[string]bool, len(links))
+	for i := range links {
+		link := &links[i]
+		link.URL = strings.TrimSpace(link.URL)
+		link.Title = strings.TrimSpace(link.Title)
+		link.Kind = strings.TrimSpace(link.Kind)
+		link.Language = strings.TrimSpace(link.Language)
+
+		parsed, err := url.Parse(link.URL)
+		if !known[link.URL] {
+			if reason := checkLinkURL(link.URL, parsed, err); reason != "" {
+				return invalidRelatedLink(i, link.URL, reason)
+			}
+			if link.FaviconURL != "" {
+				favicon, err := url.Parse(link.FaviconURL)
+				if reason := checkLinkURL(link.FaviconURL, favicon, err); reason != "" {
+					return invalidRelatedLink(i, link.URL, "faviconUrl: "+reason)
+				}
+			}
+		}
+		if seen[link.URL] {
+			return invalidRelatedLink(i, link.URL, "duplicate url")
+		}
+		seen[link.URL] = true
+
+		if utf8.RuneCountInString(link.Title) > maxRelatedLinkTitleLength {
+			return invalidRelatedLink(i, link.URL, fmt.Sprintf("title must be at most %d characters", maxRelatedLinkTitleLength))
+		}
+
+		if link.Kind == "" {
+			link.Kind = inferLinkKind(parsed)
+		} else if !linkKinds[link.Kind] {
+			return invalidRelatedLink(i, link.URL, fmt.Sprintf("unknown kind %q (expected wikipedia, official, sns, source or other)", link.Kind))
+		}
+
+		if link.Language == "" {
+			link.Language = inferLinkLanguage(parsed, link.Kind)
+		} else {
+			tag, err := language.Parse(link.Language)
+			if err != nil {
+				return invalidRelatedLink(i, link.URL, fmt.Sprintf("invalid language tag %q", link.Language))
+			}
+			link.Language = tag.String()
+		}
+	}
+	return nil
+}
+
+// checkLinkURL http・https の絶対URLか確認し、不正な場合は理由を返す
+func checkLinkURL(raw string, parsed *url.URL, err error) string {
+	switch {
+	case raw == "":
+		return "url is required"
+	case utf8.RuneCountInString(raw) > maxRelatedLinkURLLength:
+		return fmt.Sprintf("url must be at most %d characters", maxRelatedLinkURLLength)
+	case err != nil:
+		return "invalid url"
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return "url must start with http:// or https://"
+	case parsed.Hostname() == "":
+		return "url must have a host"
+	}
+	return ""
+}
+
+// invalidRelatedLink 関連リンクの検証エラー（details に何番目のリンクかを含める）
+func invalidRelatedLink(index int, rawURL, reason string) error {
+	return NewValidationError("INVALID_RELATED_LINK", fmt.Sprintf("relatedLinks[%d]: %s", index, reason), map[string]interface{}{
+		"index":  index,
+		"url":    rawURL,
+		"reason": reason,
+	})
+}
+
+// inferLinkKind URL のホストから関連リンクの種類を推定する（Wikipedia・SNS 以外は other）
+func inferLinkKind(parsed *url.URL) string {
+	if parsed == nil {
+		return models.LinkKindOther
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if hasDomain(host, "wikipedia.org") {
+		return models.LinkKindWikipedia
+	}
+	for _, domain := range snsHosts {
+		if hasDomain(host, domain) {
+			return models.LinkKindSNS
+		}
+	}
+	return models.LinkKindOther
+}
+
+// inferLinkLanguage Wikipedia の記事の言語をサブドメイン（ja.wikipedia.org など）から推定する
+func inferLinkLanguage(parsed *url.URL, kind string) string {
+	if parsed == nil || kind != models.LinkKindWikipedia {
+		return ""
+	}
+	host := strings.ToLower(parsed.Hostname())
+	subdomain := strings.TrimSuffix(strings.TrimSuffix(host, "wikipedia.org"), ".")
+	subdomain = strings.TrimSuffix(strings.TrimPrefix(subdomain, "m."), ".m")
+	if subdomain == "" || strings.Contains(subdomain, ".") {
+		return ""
+	}
+	tag, err := language.Parse(subdomain)
+	if err != nil {
+		return ""
+	}
+	return tag.String()
+}
+
+// hasDomain ホストがドメインそのものかそのサブドメインか
+func hasDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// enrichRelatedLinks タイトルまたはファビコンのない新しいリンクの情報を fetcher で取得して補う
+// fetcher が nil の場合や取得に失敗したリンクはそのままにする（オフラインでも人物の作成・更新は失敗しない）
+func enrichRelatedLinks(fetcher linkpreview.Fetcher, links []models.RelatedLink, known map[string]bool) {
+	if fetcher == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkPreviewTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range links {
+		link := &links[i]
+		if known[link.URL] || (link.Title != "" && link.FaviconURL != "") {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			meta, err := fetcher.Fetch(ctx, link.URL)
+			if err != nil || meta == nil {
+				return
+			}
+			if link.Title == "" {
+				link.Title = meta.Title
+			}
+			if link.FaviconURL == "" {
+				link.FaviconURL = meta.FaviconURL
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// relatedLinkURLs 関連リンクの URL の集合
+func relatedLinkURLs(links []models.RelatedLink) map[string]bool {
+	urls := make(map[string]bool, len(links))
+	for _, link := range links {
+		urls[link.URL] = true
+	}
+	return urls
+}
+
+// MigrateRelatedLinks URL の文字列の配列として保存した関連リンクを種類・言語を補ったリンクに書き換える
+// 書き換え済み（種類のある）リンクの人物は読み飛ばすため、起動のたびに実行してよい。書き換えた人物の数を返す
+func (s *characterService) MigrateRelatedLinks() (int, error) {
+	characters, err := s.characterRepo.GetWithRelatedLinks()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get related links: %w", err)
+	}
+
+	migrated := 0
+	var errs []error
+	for _, character := range characters {
+		legacy := false
+		for _, link := range character.RelatedLinks {
+			legacy = legacy || link.Kind == ""
+		}
+		if !legacy {
+			continue
+		}
+
+		// 空の URL と重複した URL は除く（URL の形式は検証しない）
+		var links []models.RelatedLink
+		seen := make(map[string]bool)
+		for _, link := range character.RelatedLinks {
+			link.URL = strings.TrimSpace(link.URL)
+			if link.URL == "" || seen[link.URL] {
+				continue
+			}
+			seen[link.URL] = true
+			links = append(links, link)
+		}
+		if links == nil {
+			links = []models.RelatedLink{}
+		}
+
+		err := normalizeRelatedLinks(links, seen)
+		if err == nil {
+			err = s.characterRepo.SetRelatedLinks(character.ID, links)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", character.ID, err))
+			continue
+		}
+		migrated++
+	}
+
+	if len(errs) > 0 {
+		return migrated, fmt.Errorf("failed to migrate related links: %w", errors.Join(errs...))
+	}
+	return migrated, nil
+}
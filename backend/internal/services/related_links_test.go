@@ -0,0 +1,221 @@
+package services
+
+import (
+	"character-management-app/internal/linkpreview"
+	"character-management-app/internal/models"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLinkFetcher URL ごとに決めた結果を返す Fetcher（取得した URL を記録する）
+type stubLinkFetcher struct {
+	mu      sync.Mutex
+	pages   map[string]*linkpreview.Metadata
+	fetched []string
+}
+
+func (f *stubLinkFetcher) Fetch(ctx context.Context, rawURL string) (*linkpreview.Metadata, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetched = append(f.fetched, rawURL)
+	if meta, ok := f.pages[rawURL]; ok {
+		return meta, nil
+	}
+	return nil, errors.New("offline")
+}
+
+func TestNormalizeRelatedLinks(t *testing.T) {
+	t.Run("種類と言語を補う", func(t *testing.T) {
+		links := []models.RelatedLink{
+			{URL: " https://ja.wikipedia.org/wiki/織田信長 "},
+			{URL: "https://en.m.wikipedia.org/wiki/Oda_Nobunaga"},
+			{URL: "https://x.com/example"},
+			{URL: "https://www.youtube.com/watch?v=abc"},
+			{URL: "https://example.com", Kind: models.LinkKindOfficial, Language: "EN-us"},
+			{URL: "http://example.org/book"},
+		}
+
+		require.NoError(t, normalizeRelatedLinks(links, nil))
+
+		assert.Equal(t, []models.RelatedLink{
+			{URL: "https://ja.wikipedia.org/wiki/織田信長", Kind: models.LinkKindWikipedia, Language: "ja"},
+			{URL: "https://en.m.wikipedia.org/wiki/Oda_Nobunaga", Kind: models.LinkKindWikipedia, Language: "en"},
+			{URL: "https://x.com/example", Kind: models.LinkKindSNS},
+			{URL: "https://www.youtube.com/watch?v=abc", Kind: models.LinkKindSNS},
+			{URL: "https://example.com", Kind: models.LinkKindOfficial, Language: "en-US"},
+			{URL: "http://example.org/book", Kind: models.LinkKindOther},
+		}, links)
+	})
+
+	t.Run("不正なリンク", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			link   models.RelatedLink
+			reason string
+		}{
+			{"URL がない", models.RelatedLink{Title: "タイトル"}, "url is required"},
+			{"相対URL", models.RelatedLink{URL: "/wiki/織田信長"}, "url must start with http:// or https://"},
+			{"http・https 以外", models.RelatedLink{URL: "javascript:alert(1)"}, "url must start with http:// or https://"},
+			{"ホストがない", models.RelatedLink{URL: "https:///path"}, "url must have a host"},
+			{"長すぎる URL", models.RelatedLink{URL: "https://example.com/" + strings.Repeat("a", maxRelatedLinkURLLength)}, "url must be at most"},
+			{"不明な種類", models.RelatedLink{URL: "https://example.com", Kind: "blog"}, `unknown kind "blog"`},
+			{"不正な言語タグ", models.RelatedLink{URL: "https://example.com", Language: "japanese!"}, "invalid language tag"},
+			{"長すぎるタイトル", models.RelatedLink{URL: "https://example.com", Title: strings.Repeat("あ", maxRelatedLinkTitleLength+1)}, "title must be at most"},
+			{"不正なファビコン", models.RelatedLink{URL: "https://example.com", FaviconURL: "data:image/png;base64,AAAA"}, "faviconUrl:"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := normalizeRelatedLinks([]models.RelatedLink{{URL: "https://example.net"}, tt.link}, nil)
+
+				var serviceError *Error
+				require.ErrorAs(t, err, &serviceError)
+				assert.Equal(t, "INVALID_RELATED_LINK", serviceError.Code)
+				details := serviceError.Details.(map[string]interface{})
+				assert.Equal(t, 1, details["index"])
+				assert.Contains(t, details["reason"], tt.reason)
+			})
+		}
+	})
+
+	t.Run("重複した URL", func(t *testing.T) {
+		err := normalizeRelatedLinks([]models.RelatedLink{{URL: "https://example.com"}, {URL: " https://example.com"}}, nil)
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Contains(t, serviceError.Message, "duplicate url")
+	})
+
+	t.Run("登録済みの URL は形式を検証しない", func(t *testing.T) {
+		links := []models.RelatedLink{{URL: "example.com"}}
+
+		require.NoError(t, normalizeRelatedLinks(links, map[string]bool{"example.com": true}))
+		assert.Equal(t, models.LinkKindOther, links[0].Kind)
+	})
+
+	t.Run("上限を超えるリンク", func(t *testing.T) {
+		links := make([]models.RelatedLink, MaxRelatedLinks+1)
+
+		err := normalizeRelatedLinks(links, nil)
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "RELATED_LINK_LIMIT_EXCEEDED", serviceError.Code)
+	})
+}
+
+func TestEnrichRelatedLinks(t *testing.T) {
+	t.Run("Fetcher が未設定の場合は取得しない", func(t *testing.T) {
+		links := []models.RelatedLink{{URL: "https://example.com"}}
+
+		enrichRelatedLinks(nil, links, nil)
+
+		assert.Equal(t, []models.RelatedLink{{URL: "https://example.com"}}, links)
+	})
+
+	t.Run("新しいリンクのタイトルとファビコンを補う", func(t *testing.T) {
+		fetcher := &stubLinkFetcher{pages: map[string]*linkpreview.Metadata{
+			"https://example.com/new":    {Title: "取得したタイトル", FaviconURL: "https://example.com/favicon.ico"},
+			"https://example.com/titled": {Title: "取得したタイトル", FaviconURL: "https://example.com/icon.png"},
+			"https://example.com/known":  {Title: "取得したタイトル"},
+		}}
+		links := []models.RelatedLink{
+			{URL: "https://example.com/new"},
+			{URL: "https://example.com/titled", Title: "入力したタイトル"},
+			{URL: "https://example.com/known"},
+			{URL: "https://example.com/offline"},
+		}
+
+		enrichRelatedLinks(fetcher, links, map[string]bool{"https://example.com/known": true})
+
+		assert.Equal(t, []models.RelatedLink{
+			{URL: "https://example.com/new", Title: "取得したタイトル", FaviconURL: "https://example.com/favicon.ico"},
+			{URL: "https://example.com/titled", Title: "入力したタイトル", FaviconURL: "https://example.com/icon.png"},
+			{URL: "https://example.com/known"},
+			{URL: "https://example.com/offline"},
+		}, links)
+		assert.NotContains(t, fetcher.fetched, "https://example.com/known")
+	})
+}
+
+func TestCharacterService_RelatedLinks(t *testing.T) {
+	t.Run("作成時に関連リンクを検証", func(t *testing.T) {
+		mockGroupRepo := new(MockGroupRepository)
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewCharacterService(mockCharacterRepo, mockGroupRepo, new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		mockGroupRepo.On("ExistsByID", "group-1").Return(true, nil)
+
+		_, err := service.CreateCharacter(&models.Character{
+			GroupID:      "group-1",
+			Name:         "織田信長",
+			RelatedLinks: []models.RelatedLink{{URL: "ftp://example.com"}},
+		})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.Equal(t, "INVALID_RELATED_LINK", serviceError.Code)
+		mockCharacterRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("更新時は新しいリンクの情報だけを取得", func(t *testing.T) {
+		fetcher := &stubLinkFetcher{pages: map[string]*linkpreview.Metadata{
+			"https://example.com/new": {Title: "新しいリンク"},
+		}}
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{
+			LinkFetcher: fetcher,
+		})
+
+		existing := &models.Character{ID: "char-1", GroupID: "group-1", Name: "織田信長", Version: 1, RelatedLinks: []models.RelatedLink{
+			{URL: "legacy-link", Kind: models.LinkKindOther},
+		}}
+		mockCharacterRepo.On("GetByID", "char-1").Return(existing, nil)
+		mockCharacterRepo.On("Update", mock.Anything).Return(nil)
+
+		character := &models.Character{GroupID: "group-1", Name: "織田信長", RelatedLinks: []models.RelatedLink{
+			{URL: "legacy-link"},
+			{URL: "https://example.com/new"},
+		}}
+		_, err := service.UpdateCharacter("char-1", character)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.RelatedLink{
+			{URL: "legacy-link", Kind: models.LinkKindOther},
+			{URL: "https://example.com/new", Title: "新しいリンク", Kind: models.LinkKindOther},
+		}, character.RelatedLinks)
+		assert.Equal(t, []string{"https://example.com/new"}, fetcher.fetched)
+	})
+
+	t.Run("以前の形式の関連リンクを移行", func(t *testing.T) {
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewCharacterService(mockCharacterRepo, new(MockGroupRepository), new(MockLabelRepository), new(MockSavedViewRepository), CharacterOptions{})
+
+		mockCharacterRepo.On("GetWithRelatedLinks").Return([]models.Character{
+			{ID: "char-1", RelatedLinks: []models.RelatedLink{
+				{URL: "https://ja.wikipedia.org/wiki/織田信長"},
+				{URL: ""},
+				{URL: "https://ja.wikipedia.org/wiki/織田信長"},
+				{URL: "not a url"},
+			}},
+			{ID: "char-2", RelatedLinks: []models.RelatedLink{{URL: "https://example.com", Kind: models.LinkKindOfficial}}},
+		}, nil)
+		mockCharacterRepo.On("SetRelatedLinks", "char-1", []models.RelatedLink{
+			{URL: "https://ja.wikipedia.org/wiki/織田信長", Kind: models.LinkKindWikipedia, Language: "ja"},
+			{URL: "not a url", Kind: models.LinkKindOther},
+		}).Return(nil)
+
+		migrated, err := service.MigrateRelatedLinks()
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, migrated)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"character-management-app/internal/models"
 	"character-management-app/internal/repositories"
-	"errors"
 	"fmt"
 	"log"
 )
@@ -17,19 +16,31 @@ type RelationshipService interface {
 	GetRelationshipsByCharacterID(characterID string) ([]models.Relationship, error)
 	UpdateRelationship(id string, relationship *models.Relationship) (*models.Relationship, error)
 	DeleteRelationship(id string) error
+	WithWorkspace(workspaceID string) RelationshipService
 }
 
 // relationshipService 関係サービスの実装
 type relationshipService struct {
 	relationshipRepo repositories.RelationshipRepository
 	characterRepo    repositories.CharacterRepository
+	viewRepo         repositories.SavedViewRepository
 }
 
 // NewRelationshipService 関係サービスのコンストラクタ
-func NewRelationshipService(relationshipRepo repositories.RelationshipRepository, characterRepo repositories.CharacterRepository) RelationshipService {
+func NewRelationshipService(relationshipRepo repositories.RelationshipRepository, characterRepo repositories.CharacterRepository, viewRepo repositories.SavedViewRepository) RelationshipService {
 	return &relationshipService{
 		relationshipRepo: relationshipRepo,
 		characterRepo:    characterRepo,
+		viewRepo:         viewRepo,
+	}
+}
+
+// WithWorkspace 指定したワークスペースに限定したサービスを返す
+func (s *relationshipService) WithWorkspace(workspaceID string) RelationshipService {
+	return &relationshipService{
+		relationshipRepo: s.relationshipRepo.WithWorkspace(workspaceID),
+		characterRepo:    s.characterRepo.WithWorkspace(workspaceID),
+		viewRepo:         s.viewRepo.WithWorkspace(workspaceID),
 	}
 }
 
@@ -37,7 +48,7 @@ func NewRelationshipService(relationshipRepo repositories.RelationshipRepository
 func (s *relationshipService) CreateRelationship(relationship *models.Relationship) (*models.Relationship, error) {
 	// 同じ人物同士の関係は作成できない
 	if relationship.Character1ID == relationship.Character2ID {
-		return nil, errors.New("cannot create relationship between the same character")
+		return nil, NewValidationError("SELF_RELATIONSHIP", "cannot create relationship between the same character", nil)
 	}
 
 	// 両方の人物が存在するかチェック
@@ -46,7 +57,7 @@ func (s *relationshipService) CreateRelationship(relationship *models.Relationsh
 		return nil, fmt.Errorf("failed to check character1 existence: %w", err)
 	}
 	if !exists1 {
-		return nil, errors.New("character1 not found")
+		return nil, NewNotFoundError("CHARACTER_NOT_FOUND", "character1 not found")
 	}
 
 	exists2, err := s.characterRepo.ExistsByID(relationship.Character2ID)
@@ -54,7 +65,7 @@ func (s *relationshipService) CreateRelationship(relationship *models.Relationsh
 		return nil, fmt.Errorf("failed to check character2 existence: %w", err)
 	}
 	if !exists2 {
-		return nil, errors.New("character2 not found")
+		return nil, NewNotFoundError("CHARACTER_NOT_FOUND", "character2 not found")
 	}
 
 	// 両方の人物が同じグループに属しているかチェック
@@ -69,7 +80,7 @@ func (s *relationshipService) CreateRelationship(relationship *models.Relationsh
 	}
 
 	if char1.GroupID != char2.GroupID {
-		return nil, errors.New("characters must be in the same group")
+		return nil, NewValidationError("DIFFERENT_GROUPS", "characters must be in the same group", nil)
 	}
 
 	// グループIDを設定
@@ -81,7 +92,7 @@ func (s *relationshipService) CreateRelationship(relationship *models.Relationsh
 		return nil, fmt.Errorf("failed to check relationship existence: %w", err)
 	}
 	if exists {
-		return nil, errors.New("relationship already exists between these characters")
+		return nil, NewConflictError("RELATIONSHIP_ALREADY_EXISTS", "relationship already exists between these characters")
 	}
 
 	// 関係を作成
@@ -97,7 +108,7 @@ func (s *relationshipService) CreateRelationship(relationship *models.Relationsh
 func (s *relationshipService) GetRelationshipByID(id string) (*models.Relationship, error) {
 	relationship, err := s.relationshipRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get relationship: %w", err)
+		return nil, lookupError(err, errRelationshipNotFound(), "failed to get relationship")
 	}
 	return relationship, nil
 }
@@ -112,7 +123,28 @@ func (s *relationshipService) GetAllRelationships() ([]models.Relationship, erro
 }
 
 // GetRelationshipsByGroupID グループIDで関係を取得
+// 保存したビューのIDの場合はビューの人物同士の関係を返す（関係図の描画用）
 func (s *relationshipService) GetRelationshipsByGroupID(groupID string) ([]models.Relationship, error) {
+	expr, err := savedViewQuery(s.viewRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if expr != nil {
+		characters, err := s.characterRepo.Find(repositories.CharacterFilter{Query: expr})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get characters by saved view: %w", err)
+		}
+		ids := make([]string, len(characters))
+		for i, character := range characters {
+			ids[i] = character.ID
+		}
+		relationships, err := s.relationshipRepo.GetAmongCharacters(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relationships by saved view: %w", err)
+		}
+		return relationships, nil
+	}
+
 	relationships, err := s.relationshipRepo.GetByGroupID(groupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get relationships by group: %w", err)
@@ -128,7 +160,7 @@ func (s *relationshipService) GetRelationshipsByCharacterID(characterID string)
 		return nil, fmt.Errorf("failed to check character existence: %w", err)
 	}
 	if !exists {
-		return nil, errors.New("character not found")
+		return nil, errCharacterNotFound()
 	}
 
 	relationships, err := s.relationshipRepo.GetByCharacterID(characterID)
@@ -143,12 +175,12 @@ func (s *relationshipService) UpdateRelationship(id string, relationship *models
 	// 既存の関係を取得
 	existing, err := s.relationshipRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("relationship not found: %w", err)
+		return nil, lookupError(err, errRelationshipNotFound(), "failed to get existing relationship")
 	}
 
 	// 同じ人物同士の関係は作成できない
 	if relationship.Character1ID == relationship.Character2ID {
-		return nil, errors.New("cannot create relationship between the same character")
+		return nil, NewValidationError("SELF_RELATIONSHIP", "cannot create relationship between the same character", nil)
 	}
 
 	// 人物IDが変更される場合の検証
@@ -159,7 +191,7 @@ func (s *relationshipService) UpdateRelationship(id string, relationship *models
 			return nil, fmt.Errorf("failed to check character1 existence: %w", err)
 		}
 		if !exists1 {
-			return nil, errors.New("character1 not found")
+			return nil, NewNotFoundError("CHARACTER_NOT_FOUND", "character1 not found")
 		}
 
 		exists2, err := s.characterRepo.ExistsByID(relationship.Character2ID)
@@ -167,7 +199,7 @@ func (s *relationshipService) UpdateRelationship(id string, relationship *models
 			return nil, fmt.Errorf("failed to check character2 existence: %w", err)
 		}
 		if !exists2 {
-			return nil, errors.New("character2 not found")
+			return nil, NewNotFoundError("CHARACTER_NOT_FOUND", "character2 not found")
 		}
 
 		// 両方の人物が同じグループに属しているかチェック
@@ -182,7 +214,7 @@ func (s *relationshipService) UpdateRelationship(id string, relationship *models
 		}
 
 		if char1.GroupID != char2.GroupID {
-			return nil, errors.New("characters must be in the same group")
+			return nil, NewValidationError("DIFFERENT_GROUPS", "characters must be in the same group", nil)
 		}
 
 		// グループIDを設定
@@ -194,7 +226,7 @@ func (s *relationshipService) UpdateRelationship(id string, relationship *models
 			return nil, fmt.Errorf("failed to check relationship existence: %w", err)
 		}
 		if exists {
-			return nil, errors.New("relationship already exists between these characters")
+			return nil, NewConflictError("RELATIONSHIP_ALREADY_EXISTS", "relationship already exists between these characters")
 		}
 	} else {
 		// 人物IDが変更されない場合は、既存のGroupIDを保持
@@ -206,6 +238,11 @@ func (s *relationshipService) UpdateRelationship(id string, relationship *models
 	relationship.ID = existing.ID
 	relationship.CreatedAt = existing.CreatedAt
 
+	// 期待するバージョンの指定がなければ現在のバージョンに対して更新する
+	if relationship.Version == 0 {
+		relationship.Version = existing.Version
+	}
+
 	// 関係を更新
 	if err := s.relationshipRepo.Update(relationship); err != nil {
 		return nil, fmt.Errorf("failed to update relationship: %w", err)
@@ -223,7 +260,7 @@ func (s *relationshipService) DeleteRelationship(id string) error {
 		return fmt.Errorf("failed to check relationship existence: %w", err)
 	}
 	if !exists {
-		return errors.New("relationship not found")
+		return errRelationshipNotFound()
 	}
 
 	// 関係を削除
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/query"
+	"character-management-app/internal/repositories"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// SavedViewService 保存したビューサービスのインターフェース
+type SavedViewService interface {
+	CreateView(view *models.SavedView) (*models.SavedView, error)
+	GetViewByID(id string) (*models.SavedView, error)
+	GetAllViews() ([]models.SavedView, error)
+	UpdateView(id string, view *models.SavedView) (*models.SavedView, error)
+	DeleteView(id string) error
+	GetViewCharacters(id string) ([]models.Character, error)
+	WithWorkspace(workspaceID string) SavedViewService
+}
+
+// savedViewService 保存したビューサービスの実装
+type savedViewService struct {
+	viewRepo      repositories.SavedViewRepository
+	characterRepo repositories.CharacterRepository
+}
+
+// NewSavedViewService 保存したビューサービスのコンストラクタ
+func NewSavedViewService(viewRepo repositories.SavedViewRepository, characterRepo repositories.CharacterRepository) SavedViewService {
+	return &savedViewService{
+		viewRepo:      viewRepo,
+		characterRepo: characterRepo,
+	}
+}
+
+// WithWorkspace 指定したワークスペースに限定したサービスを返す
+func (s *savedViewService) WithWorkspace(workspaceID string) SavedViewService {
+	return &savedViewService{
+		viewRepo:      s.viewRepo.WithWorkspace(workspaceID),
+		characterRepo: s.characterRepo.WithWorkspace(workspaceID),
+	}
+}
+
+// CreateView ビューを作成
+func (s *savedViewService) CreateView(view *models.SavedView) (*models.SavedView, error) {
+	// 条件式は保存時に検証する（評価は一覧の取得のたびに行う）
+	if _, err := parseCharacterQuery(view.Query); err != nil {
+		return nil, err
+	}
+
+	// 名前の重複チェック
+	exists, err := s.viewRepo.ExistsByName(view.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check saved view name existence: %w", err)
+	}
+	if exists {
+		return nil, NewConflictError("SAVED_VIEW_NAME_TAKEN", "saved view with this name already exists")
+	}
+
+	if err := s.viewRepo.Create(view); err != nil {
+		return nil, fmt.Errorf("failed to create saved view: %w", err)
+	}
+
+	return view, nil
+}
+
+// GetViewByID IDでビューを取得
+func (s *savedViewService) GetViewByID(id string) (*models.SavedView, error) {
+	view, err := s.viewRepo.GetByID(id)
+	if err != nil {
+		return nil, lookupError(err, errSavedViewNotFound(), "failed to get saved view")
+	}
+	return view, nil
+}
+
+// GetAllViews 全てのビューを取得
+func (s *savedViewService) GetAllViews() ([]models.SavedView, error) {
+	views, err := s.viewRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved views: %w", err)
+	}
+	return views, nil
+}
+
+// UpdateView ビューを更新
+func (s *savedViewService) UpdateView(id string, view *models.SavedView) (*models.SavedView, error) {
+	existing, err := s.viewRepo.GetByID(id)
+	if err != nil {
+		return nil, lookupError(err, errSavedViewNotFound(), "failed to get existing saved view")
+	}
+
+	if _, err := parseCharacterQuery(view.Query); err != nil {
+		return nil, err
+	}
+
+	// 名前が変更されている場合、重複チェック
+	if existing.Name != view.Name {
+		exists, err := s.viewRepo.ExistsByName(view.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check saved view name existence: %w", err)
+		}
+		if exists {
+			return nil, NewConflictError("SAVED_VIEW_NAME_TAKEN", "saved view with this name already exists")
+		}
+	}
+
+	// ID・ワークスペース・作成日時を保持
+	view.ID = existing.ID
+	view.WorkspaceID = existing.WorkspaceID
+	view.CreatedAt = existing.CreatedAt
+
+	// 期待するバージョンの指定がなければ現在のバージョンに対して更新する
+	if view.Version == 0 {
+		view.Version = existing.Version
+	}
+
+	if err := s.viewRepo.Update(view); err != nil {
+		return nil, fmt.Errorf("failed to update saved view: %w", err)
+	}
+
+	return view, nil
+}
+
+// DeleteView ビューを削除（人物は削除しない）
+func (s *savedViewService) DeleteView(id string) error {
+	if err := s.viewRepo.Delete(id); err != nil {
+		return lookupError(err, errSavedViewNotFound(), "failed to delete saved view")
+	}
+	return nil
+}
+
+// GetViewCharacters ビューの条件式に一致する人物を取得
+func (s *savedViewService) GetViewCharacters(id string) ([]models.Character, error) {
+	view, err := s.viewRepo.GetByID(id)
+	if err != nil {
+		return nil, lookupError(err, errSavedViewNotFound(), "failed to get saved view")
+	}
+
+	expr, err := parseCharacterQuery(view.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	characters, err := s.characterRepo.Find(repositories.CharacterFilter{Query: expr})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved view characters: %w", err)
+	}
+	sortCharactersByReading(characters)
+	return characters, nil
+}
+
+// savedViewQuery ID がビューの場合はその条件式を返す（ビューでなければ nil）
+// グループIDを受け付ける一覧・関係図の取得でビューのIDも使えるようにする
+func savedViewQuery(viewRepo repositories.SavedViewRepository, id string) (query.Expr, error) {
+	view, err := viewRepo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved view: %w", err)
+	}
+	return parseCharacterQuery(view.Query)
+}
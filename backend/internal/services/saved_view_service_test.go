@@ -0,0 +1,122 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/query"
+	"character-management-app/internal/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSavedViewService_CreateView(t *testing.T) {
+	t.Run("ビューを作成", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewSavedViewService(mockViewRepo, new(MockCharacterRepository))
+
+		view := &models.SavedView{Name: "存命の武将", Query: "武将 NOT 故人"}
+		mockViewRepo.On("ExistsByName", "存命の武将").Return(false, nil)
+		mockViewRepo.On("Create", view).Return(nil)
+
+		result, err := service.CreateView(view)
+
+		assert.NoError(t, err)
+		assert.Equal(t, view, result)
+		mockViewRepo.AssertExpectations(t)
+	})
+
+	t.Run("条件式の構文エラーは422", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewSavedViewService(mockViewRepo, new(MockCharacterRepository))
+
+		_, err := service.CreateView(&models.SavedView{Name: "存命の武将", Query: "武将 NOT"})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, "INVALID_QUERY_SYNTAX", serviceError.Code)
+		mockViewRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("同じ名前のビューは409", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewSavedViewService(mockViewRepo, new(MockCharacterRepository))
+
+		mockViewRepo.On("ExistsByName", "存命の武将").Return(true, nil)
+
+		_, err := service.CreateView(&models.SavedView{Name: "存命の武将", Query: "武将"})
+
+		var serviceError *Error
+		require.ErrorAs(t, err, &serviceError)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.Equal(t, "SAVED_VIEW_NAME_TAKEN", serviceError.Code)
+		mockViewRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestSavedViewService_UpdateView(t *testing.T) {
+	t.Run("条件式を変更", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewSavedViewService(mockViewRepo, new(MockCharacterRepository))
+
+		mockViewRepo.On("GetByID", "view-1").Return(&models.SavedView{ID: "view-1", WorkspaceID: "ws-1", Name: "武将", Query: "武将", Version: 3}, nil)
+		mockViewRepo.On("Update", mock.MatchedBy(func(view *models.SavedView) bool {
+			return view.ID == "view-1" && view.WorkspaceID == "ws-1" && view.Query == "relation:主従" && view.Version == 3
+		})).Return(nil)
+
+		result, err := service.UpdateView("view-1", &models.SavedView{Name: "武将", Query: "relation:主従"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "relation:主従", result.Query)
+		mockViewRepo.AssertNotCalled(t, "ExistsByName", mock.Anything)
+		mockViewRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しないビューは404", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		service := NewSavedViewService(mockViewRepo, new(MockCharacterRepository))
+
+		mockViewRepo.On("GetByID", "missing").Return((*models.SavedView)(nil), gorm.ErrRecordNotFound)
+
+		_, err := service.UpdateView("missing", &models.SavedView{Name: "武将", Query: "武将"})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Contains(t, err.Error(), "saved view not found")
+	})
+}
+
+func TestSavedViewService_GetViewCharacters(t *testing.T) {
+	t.Run("条件式に一致する人物を取得", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewSavedViewService(mockViewRepo, mockCharacterRepo)
+
+		characters := []models.Character{{ID: "char-1", Name: "豊臣秀吉"}}
+		mockViewRepo.On("GetByID", "view-1").Return(&models.SavedView{ID: "view-1", Query: "related:織田信長"}, nil)
+		mockCharacterRepo.On("Find", repositories.CharacterFilter{
+			Query: &query.Term{Field: query.FieldRelated, Value: "織田信長", Position: 0},
+		}).Return(characters, nil)
+
+		result, err := service.GetViewCharacters("view-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, characters, result)
+		mockCharacterRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しないビューは404", func(t *testing.T) {
+		mockViewRepo := new(MockSavedViewRepository)
+		mockCharacterRepo := new(MockCharacterRepository)
+		service := NewSavedViewService(mockViewRepo, mockCharacterRepo)
+
+		mockViewRepo.On("GetByID", "missing").Return((*models.SavedView)(nil), gorm.ErrRecordNotFound)
+
+		_, err := service.GetViewCharacters("missing")
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		mockCharacterRepo.AssertNotCalled(t, "Find", mock.Anything)
+	})
+}
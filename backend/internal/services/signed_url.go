@@ -0,0 +1,95 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultSignedURLTTL 署名付きURLの有効期間の既定値
+const DefaultSignedURLTTL = time.Hour
+
+// MinURLSecretLength 署名に使う秘密鍵の最小の長さ（バイト数）
+const MinURLSecretLength = 32
+
+// urlSigner 画像の配信用URLに HMAC-SHA256 の署名と有効期限を付ける
+// 署名の対象はストレージのキーと有効期限で、バリアントごとに別の署名になる
+type urlSigner struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// newURLSigner 署名付きURLの生成・検証を作成（secret が空の場合は署名しないため nil）
+// now が nil の場合は time.Now を使う
+func newURLSigner(secret []byte, ttl time.Duration, now func() time.Time) *urlSigner {
+	if len(secret) == 0 {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultSignedURLTTL
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &urlSigner{secret: secret, ttl: ttl, now: now}
+}
+
+// sign 配信用URLに有効期限と署名のクエリを付ける
+func (s *urlSigner) sign(rawURL, key string) string {
+	expires := s.expires()
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {s.signature(key, expires)},
+	}
+	return rawURL + "?" + query.Encode()
+}
+
+// expires 有効期限（Unix 秒）
+// ttl ごとの区切りに揃え、同じ区切りの間は同じURLにしてブラウザのキャッシュを効かせる（有効期間は ttl 以上 2*ttl 未満）
+func (s *urlSigner) expires() int64 {
+	return s.now().Truncate(s.ttl).Add(2 * s.ttl).Unix()
+}
+
+// signature キーと有効期限の署名（URL で使える Base64）
+func (s *urlSigner) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify 署名と有効期限を確認し、有効期限を返す
+func (s *urlSigner) verify(key, expires, signature string) (time.Time, error) {
+	if expires == "" || signature == "" {
+		return time.Time{}, NewForbiddenError("SIGNATURE_REQUIRED", "image URL must be signed")
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.signature(key, unix))) {
+		return time.Time{}, NewForbiddenError("INVALID_SIGNATURE", "image URL signature is invalid")
+	}
+	expiresAt := time.Unix(unix, 0)
+	if !s.now().Before(expiresAt) {
+		return time.Time{}, NewForbiddenError("URL_EXPIRED", "image URL has expired")
+	}
+	return expiresAt, nil
+}
+
+// ImageCacheControl 画像の配信で返す Cache-Control
+// 署名付きURLは有効期限まで本人のブラウザにのみキャッシュさせる。内容のハッシュに保存した画像は
+// 同じキーの内容が変わらないため長期間キャッシュし、それ以外は毎回 Last-Modified で再検証させる
+func ImageCacheControl(key string, expiresAt time.Time, now time.Time) string {
+	if !expiresAt.IsZero() {
+		maxAge := int64(expiresAt.Sub(now) / time.Second)
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		return "private, max-age=" + strconv.FormatInt(maxAge, 10)
+	}
+	if isContentKey(storageKey(key)) {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"character-management-app/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageService_SignedURLs(t *testing.T) {
+	secret := []byte(strings.Repeat("s", MinURLSecretLength))
+	key := strings.Repeat("ab", 32) + "/full.jpg"
+	now := time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	// newService 現在時刻を固定した署名付きURLの画像サービスを作成
+	newService := func(secret []byte) *imageService {
+		return NewImageService(storage.NewLocal(t.TempDir(), "/uploads"), nil, ImageOptions{
+			URLSecret: secret,
+			Clock:     func() time.Time { return now },
+		}).(*imageService)
+	}
+	// parse 配信用URLからキーと有効期限・署名を取り出す
+	parse := func(t *testing.T, rawURL string) (string, string, string) {
+		parsed, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return strings.TrimPrefix(parsed.Path, "/uploads/"), parsed.Query().Get("expires"), parsed.Query().Get("signature")
+	}
+
+	t.Run("署名しない設定では通常のURL", func(t *testing.T) {
+		service := newService(nil)
+
+		assert.True(t, service.URLExpiry().IsZero())
+		assert.Equal(t, "/uploads/"+key, service.URL(key))
+
+		expiresAt, err := service.VerifyURL(key, "", "")
+		assert.NoError(t, err)
+		assert.True(t, expiresAt.IsZero())
+	})
+
+	t.Run("署名したURLを検証", func(t *testing.T) {
+		service := newService(secret)
+
+		path, expires, signature := parse(t, service.URL(key))
+		assert.Equal(t, key, path)
+
+		expiresAt, err := service.VerifyURL(path, expires, signature)
+		require.NoError(t, err)
+		// 有効期間は区切り（1時間）に揃えて ttl 以上 2*ttl 未満
+		assert.Equal(t, time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC), expiresAt.UTC())
+		assert.Equal(t, expiresAt, service.URLExpiry())
+
+		// 同じ区切りの間は同じURLになる
+		service.signer.now = func() time.Time { return now.Add(20 * time.Minute) }
+		assert.Equal(t, "/uploads/"+key+"?expires="+expires+"&signature="+signature, service.URL(key))
+	})
+
+	t.Run("バリアントごとに署名", func(t *testing.T) {
+		service := newService(secret)
+
+		urls := service.VariantURLs(key)
+		avatar, expires, signature := parse(t, urls["avatar"])
+		assert.Equal(t, strings.Repeat("ab", 32)+"/avatar.jpg", avatar)
+
+		_, err := service.VerifyURL(avatar, expires, signature)
+		assert.NoError(t, err)
+		// 他のバリアントには使えない
+		_, err = service.VerifyURL(key, expires, signature)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("誤り・期限切れは Forbidden", func(t *testing.T) {
+		service := newService(secret)
+		_, expires, signature := parse(t, service.URL(key))
+
+		cases := map[string]struct {
+			expires, signature, code string
+		}{
+			"署名なし":    {"", "", "SIGNATURE_REQUIRED"},
+			"署名の誤り":   {expires, signature + "x", "INVALID_SIGNATURE"},
+			"有効期限の改変": {"9999999999", signature, "INVALID_SIGNATURE"},
+			"数値でない期限": {"soon", signature, "INVALID_SIGNATURE"},
+		}
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				_, err := service.VerifyURL(key, tc.expires, tc.signature)
+
+				var serviceError *Error
+				require.ErrorAs(t, err, &serviceError)
+				assert.ErrorIs(t, err, ErrForbidden)
+				assert.Equal(t, tc.code, serviceError.Code)
+			})
+		}
+
+		t.Run("期限切れ", func(t *testing.T) {
+			service.signer.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+			_, err := service.VerifyURL(key, expires, signature)
+
+			var serviceError *Error
+			require.ErrorAs(t, err, &serviceError)
+			assert.Equal(t, "URL_EXPIRED", serviceError.Code)
+		})
+	})
+
+	t.Run("異なる秘密鍵の署名は無効", func(t *testing.T) {
+		_, expires, signature := parse(t, newService(secret).URL(key))
+
+		_, err := newService([]byte(strings.Repeat("t", MinURLSecretLength))).VerifyURL(key, expires, signature)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+}
+
+func TestImageCacheControl(t *testing.T) {
+	now := time.Now()
+	contentKey := strings.Repeat("ab", 32) + "/full.jpg"
+
+	assert.Equal(t, "private, max-age=600", ImageCacheControl(contentKey, now.Add(10*time.Minute), now))
+	assert.Equal(t, "private, max-age=0", ImageCacheControl(contentKey, now.Add(-time.Minute), now))
+	assert.Equal(t, "public, max-age=31536000, immutable", ImageCacheControl(contentKey, time.Time{}, now))
+	assert.Equal(t, "no-cache", ImageCacheControl("1700000000_photo.jpg", time.Time{}, now))
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"character-management-app/internal/repositories"
+	"character-management-app/internal/storage"
+)
+
+// DefaultUploadGracePeriod 参照されていないファイルを孤立とみなすまでの猶予
+// 保存してから人物を作成・更新するまでの間のファイルを削除しないために使う
+const DefaultUploadGracePeriod = time.Hour
+
+// UploadMaintenanceService アップロード画像の整合性確認と不要ファイルの削除を行うサービスのインターフェース
+type UploadMaintenanceService interface {
+	CheckUploads(dryRun bool) (*UploadReport, error)
+}
+
+// UploadReport アップロード画像の確認結果
+type UploadReport struct {
+	// DryRun true の場合は報告のみで、孤立したファイルを削除していない
+	DryRun bool `json:"dryRun"`
+	// CheckedFiles ストレージに保存されているファイルの数
+	CheckedFiles int `json:"checkedFiles"`
+	// OrphanedFiles どの人物からも参照されていないファイル
+	OrphanedFiles []OrphanedFile `json:"orphanedFiles"`
+	// MissingFiles 人物の photo・ギャラリーの画像が参照しているが存在しないファイル
+	MissingFiles []MissingPhoto `json:"missingFiles"`
+	// DeletedFiles 削除したファイルの数
+	DeletedFiles int `json:"deletedFiles"`
+	// ReclaimedBytes 削除したファイルの合計サイズ
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+}
+
+// OrphanedFile 参照されていないファイル
+type OrphanedFile struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// MissingPhoto ファイルが欠けている人物の写真（ギャラリーの画像の場合は ImageID を持つ）
+type MissingPhoto struct {
+	CharacterID string   `json:"characterId"`
+	ImageID     string   `json:"imageId,omitempty"`
+	Name        string   `json:"name"`
+	Photo       string   `json:"photo"`
+	MissingKeys []string `json:"missingKeys"`
+}
+
+// uploadMaintenanceService アップロード画像の整合性確認サービスの実装
+type uploadMaintenanceService struct {
+	storage       storage.Storage
+	characterRepo repositories.CharacterRepository
+	gracePeriod   time.Duration
+	now           func() time.Time
+}
+
+// NewUploadMaintenanceService アップロード画像の整合性確認サービスのコンストラクタ
+// characterRepo は全てのワークスペースの人物を参照するため、ワークスペースに限定しないリポジトリを渡す
+func NewUploadMaintenanceService(store storage.Storage, characterRepo repositories.CharacterRepository, gracePeriod time.Duration) UploadMaintenanceService {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultUploadGracePeriod
+	}
+	return &uploadMaintenanceService{
+		storage:       store,
+		characterRepo: characterRepo,
+		gracePeriod:   gracePeriod,
+		now:           time.Now,
+	}
+}
+
+// CheckUploads ストレージのファイルと人物の photo を突き合わせる
+// 猶予期間を過ぎた参照されていないファイルを報告し、dryRun でなければ削除する
+// ImageService.MigrateContentKeys が移さない、参照されていない移行前のファイルもここで削除する
+func (s *uploadMaintenanceService) CheckUploads(dryRun bool) (*UploadReport, error) {
+	// 一覧を先に取得し、その後に保存された写真の参照を孤立と誤認しないようにする
+	objects, err := s.storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uploads: %w", err)
+	}
+	characters, err := s.characterRepo.GetWithPhoto()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get characters with photo: %w", err)
+	}
+	images, err := s.characterRepo.GetAllImages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character images: %w", err)
+	}
+
+	report := &UploadReport{
+		DryRun:        dryRun,
+		CheckedFiles:  len(objects),
+		OrphanedFiles: []OrphanedFile{},
+		MissingFiles:  []MissingPhoto{},
+	}
+
+	stored := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		stored[object.Key] = true
+	}
+
+	// 参照されているキーと、欠けているファイル
+	referenced := make(map[string]bool)
+	check := func(photo MissingPhoto) {
+		// バリアント導入前の単一の画像は全てのバリアントが同じキーになる
+		missingKeys := make(map[string]bool)
+		for _, key := range variantKeys(photo.Photo) {
+			referenced[key] = true
+			if !stored[key] {
+				missingKeys[key] = true
+			}
+		}
+		for key := range missingKeys {
+			photo.MissingKeys = append(photo.MissingKeys, key)
+		}
+		if len(photo.MissingKeys) > 0 {
+			sort.Strings(photo.MissingKeys)
+			report.MissingFiles = append(report.MissingFiles, photo)
+		}
+	}
+	names := make(map[string]string, len(characters))
+	for _, character := range characters {
+		names[character.ID] = character.Name
+		check(MissingPhoto{CharacterID: character.ID, Name: character.Name, Photo: *character.Photo})
+	}
+	// 代表画像は人物の photo と同じ画像のため、ギャラリーのそれ以外の画像を確認する
+	for _, image := range images {
+		if !image.IsPrimary {
+			check(MissingPhoto{CharacterID: image.CharacterID, ImageID: image.ID, Name: names[image.CharacterID], Photo: image.Key})
+		}
+	}
+
+	// 参照されていないファイル（保存して間もないファイルは人物の保存前の可能性があるため除く）
+	threshold := s.now().Add(-s.gracePeriod)
+	for _, object := range objects {
+		if referenced[object.Key] || object.ModTime.After(threshold) {
+			continue
+		}
+		report.OrphanedFiles = append(report.OrphanedFiles, OrphanedFile{Key: object.Key, Size: object.Size, ModTime: object.ModTime})
+	}
+	sort.Slice(report.OrphanedFiles, func(i, j int) bool {
+		return report.OrphanedFiles[i].Key < report.OrphanedFiles[j].Key
+	})
+
+	if dryRun {
+		return report, nil
+	}
+
+	var errs []error
+	for _, orphan := range report.OrphanedFiles {
+		if err := s.storage.Delete(orphan.Key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report.DeletedFiles++
+		report.ReclaimedBytes += orphan.Size
+	}
+	if len(errs) > 0 {
+		return report, fmt.Errorf("failed to delete orphaned uploads: %w", errors.Join(errs...))
+	}
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"character-management-app/internal/models"
+	"character-management-app/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadMaintenanceService_CheckUploads(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+
+	// setup 参照されている画像（ギャラリーを含む）・孤立した画像・保存直後の画像を用意する
+	setup := func(t *testing.T) (string, UploadMaintenanceService) {
+		dir := t.TempDir()
+		store := storage.NewLocal(dir, "/uploads")
+
+		files := map[string]time.Time{
+			"aaaa/avatar.jpg":    old,
+			"aaaa/card.jpg":      old,
+			"aaaa/full.jpg":      old,
+			"gallery/avatar.jpg": old,
+			"gallery/card.jpg":   old,
+			"gallery/full.jpg":   old,
+			"legacy.jpg":         old,
+			"orphan/avatar.jpg":  old,
+			"orphan/full.jpg":    old,
+			"orphan/.upload-123": old,
+			"fresh/full.jpg":     time.Now(),
+		}
+		for key, modTime := range files {
+			require.NoError(t, store.Put(key, []byte(key), "image/jpeg"))
+			require.NoError(t, os.Chtimes(filepath.Join(dir, filepath.FromSlash(key)), modTime, modTime))
+		}
+
+		referenced := "aaaa/full.jpg"
+		legacy := "/uploads/legacy.jpg"
+		missing := "bbbb/full.png"
+		characterRepo := new(MockCharacterRepository)
+		characterRepo.On("GetWithPhoto").Return([]models.Character{
+			{ID: "char-1", Name: "参照あり", Photo: &referenced},
+			{ID: "char-2", Name: "旧形式", Photo: &legacy},
+			{ID: "char-3", Name: "ファイルなし", Photo: &missing},
+			{ID: "char-4", Name: "共有", Photo: &referenced},
+		}, nil)
+		characterRepo.On("GetAllImages").Return([]models.CharacterImage{
+			{ID: "image-1", CharacterID: "char-1", Key: referenced, IsPrimary: true},
+			{ID: "image-2", CharacterID: "char-1", Key: "gallery/full.jpg"},
+			{ID: "image-3", CharacterID: "char-5", Key: "cccc/full.webp"},
+		}, nil)
+
+		return dir, NewUploadMaintenanceService(store, characterRepo, time.Hour)
+	}
+
+	t.Run("dryRun では報告のみ", func(t *testing.T) {
+		dir, service := setup(t)
+
+		report, err := service.CheckUploads(true)
+		require.NoError(t, err)
+
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 11, report.CheckedFiles)
+
+		var orphans []string
+		for _, orphan := range report.OrphanedFiles {
+			orphans = append(orphans, orphan.Key)
+		}
+		assert.Equal(t, []string{"orphan/.upload-123", "orphan/avatar.jpg", "orphan/full.jpg"}, orphans)
+
+		require.Len(t, report.MissingFiles, 2)
+		assert.Equal(t, "char-3", report.MissingFiles[0].CharacterID)
+		assert.Equal(t, []string{"bbbb/avatar.png", "bbbb/card.png", "bbbb/full.png"}, report.MissingFiles[0].MissingKeys)
+		// ギャラリーの画像は画像のIDとともに報告する
+		assert.Equal(t, "char-5", report.MissingFiles[1].CharacterID)
+		assert.Equal(t, "image-3", report.MissingFiles[1].ImageID)
+		assert.Equal(t, []string{"cccc/avatar.webp", "cccc/card.webp", "cccc/full.webp"}, report.MissingFiles[1].MissingKeys)
+
+		assert.Zero(t, report.DeletedFiles)
+		_, err = os.Stat(filepath.Join(dir, "orphan", "full.jpg"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("孤立したファイルを削除", func(t *testing.T) {
+		dir, service := setup(t)
+
+		report, err := service.CheckUploads(false)
+		require.NoError(t, err)
+
+		assert.False(t, report.DryRun)
+		assert.Equal(t, 3, report.DeletedFiles)
+		assert.Equal(t, int64(len("orphan/.upload-123")+len("orphan/avatar.jpg")+len("orphan/full.jpg")), report.ReclaimedBytes)
+
+		for _, key := range []string{"orphan/full.jpg", "orphan/.upload-123"} {
+			_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+			assert.True(t, os.IsNotExist(err), key)
+		}
+		// 参照されているファイルと保存直後のファイルは残す
+		for _, key := range []string{"aaaa/avatar.jpg", "gallery/card.jpg", "legacy.jpg", "fresh/full.jpg"} {
+			_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+			assert.NoError(t, err, key)
+		}
+	})
+}
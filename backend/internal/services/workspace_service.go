@@ -0,0 +1,103 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"character-management-app/internal/repositories"
+	"fmt"
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// workspaceSlugPattern サブドメインとしても使えるスラッグの形式
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// WorkspaceService ワークスペースサービスのインターフェース
+type WorkspaceService interface {
+	CreateWorkspace(req *CreateWorkspaceRequest) (*models.Workspace, error)
+	GetWorkspaceBySlug(slug string) (*models.Workspace, error)
+	GetAllWorkspaces() ([]models.Workspace, error)
+	ResolveWorkspaceID(slug string) (string, error)
+}
+
+// CreateWorkspaceRequest ワークスペース作成リクエスト
+type CreateWorkspaceRequest struct {
+	Slug string `json:"slug" validate:"required,max=63"`
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// workspaceService ワークスペースサービスの実装
+type workspaceService struct {
+	workspaceRepo repositories.WorkspaceRepository
+	validator     *validator.Validate
+}
+
+// NewWorkspaceService ワークスペースサービスのコンストラクタ
+func NewWorkspaceService(workspaceRepo repositories.WorkspaceRepository) WorkspaceService {
+	return &workspaceService{
+		workspaceRepo: workspaceRepo,
+		validator:     validator.New(),
+	}
+}
+
+// CreateWorkspace ワークスペースを作成
+func (s *workspaceService) CreateWorkspace(req *CreateWorkspaceRequest) (*models.Workspace, error) {
+	// バリデーション
+	if err := s.validator.Struct(req); err != nil {
+		return nil, validationFailed(err)
+	}
+	if !workspaceSlugPattern.MatchString(req.Slug) {
+		return nil, NewValidationError("INVALID_SLUG", "workspace slug must contain only lowercase letters, digits and hyphens", nil)
+	}
+
+	// スラッグの重複チェック
+	exists, err := s.workspaceRepo.ExistsBySlug(req.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check workspace slug existence: %w", err)
+	}
+	if exists {
+		return nil, NewConflictError("WORKSPACE_ALREADY_EXISTS", "workspace with this slug already exists")
+	}
+
+	workspace := &models.Workspace{
+		Slug: req.Slug,
+		Name: req.Name,
+	}
+
+	if err := s.workspaceRepo.Create(workspace); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	return workspace, nil
+}
+
+// GetWorkspaceBySlug スラッグでワークスペースを取得
+func (s *workspaceService) GetWorkspaceBySlug(slug string) (*models.Workspace, error) {
+	workspace, err := s.workspaceRepo.GetBySlug(slug)
+	if err != nil {
+		return nil, lookupError(err, errWorkspaceNotFound(), "failed to get workspace")
+	}
+	return workspace, nil
+}
+
+// GetAllWorkspaces 全てのワークスペースを取得
+func (s *workspaceService) GetAllWorkspaces() ([]models.Workspace, error) {
+	workspaces, err := s.workspaceRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+// ResolveWorkspaceID リクエストで指定されたスラッグをワークスペースIDに解決
+func (s *workspaceService) ResolveWorkspaceID(slug string) (string, error) {
+	if slug == "" {
+		slug = models.DefaultWorkspaceSlug
+	}
+
+	workspace, err := s.workspaceRepo.GetBySlug(slug)
+	if err != nil {
+		return "", lookupError(err, errWorkspaceNotFound(), fmt.Sprintf("failed to resolve workspace %q", slug))
+	}
+	return workspace.ID, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"character-management-app/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestWorkspaceService_CreateWorkspace(t *testing.T) {
+	t.Run("正常なワークスペース作成", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		service := NewWorkspaceService(mockRepo)
+
+		mockRepo.On("ExistsBySlug", "team-a").Return(false, nil)
+		mockRepo.On("Create", mock.AnythingOfType("*models.Workspace")).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Workspace).ID = "ws-1"
+		})
+
+		// テスト実行
+		result, err := service.CreateWorkspace(&CreateWorkspaceRequest{Slug: "team-a", Name: "Team A"})
+
+		// 検証
+		assert.NoError(t, err)
+		assert.Equal(t, "ws-1", result.ID)
+		assert.Equal(t, "team-a", result.Slug)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("不正なスラッグ", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		service := NewWorkspaceService(mockRepo)
+
+		for _, slug := range []string{"Team", "team_a", "-team", "team-", "チーム"} {
+			result, err := service.CreateWorkspace(&CreateWorkspaceRequest{Slug: slug, Name: "Team"})
+
+			assert.Error(t, err, slug)
+			assert.Nil(t, result)
+		}
+		mockRepo.AssertNotCalled(t, "ExistsBySlug", mock.Anything)
+	})
+
+	t.Run("スラッグの重複", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		service := NewWorkspaceService(mockRepo)
+
+		mockRepo.On("ExistsBySlug", "team-a").Return(true, nil)
+
+		// テスト実行
+		result, err := service.CreateWorkspace(&CreateWorkspaceRequest{Slug: "team-a", Name: "Team A"})
+
+		// 検証
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "already exists")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestWorkspaceService_ResolveWorkspaceID(t *testing.T) {
+	t.Run("スラッグ指定なしはデフォルトワークスペース", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		service := NewWorkspaceService(mockRepo)
+
+		mockRepo.On("GetBySlug", models.DefaultWorkspaceSlug).Return(&models.Workspace{ID: "ws-default"}, nil)
+
+		id, err := service.ResolveWorkspaceID("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ws-default", id)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しないワークスペース", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		service := NewWorkspaceService(mockRepo)
+
+		mockRepo.On("GetBySlug", "unknown").Return(nil, gorm.ErrRecordNotFound)
+
+		id, err := service.ResolveWorkspaceID("unknown")
+
+		assert.Empty(t, id)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,57 @@
+// Package storage はアップロード画像の保存先（ローカルディスク・S3互換ストレージ）を抽象化する
+package storage
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 指定したキーのオブジェクトが存在しない
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidKey キーの形式が不正（空、絶対パス、.. を含む等）
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Storage 画像ファイルの保存先のインターフェース
+// キーは "/" 区切りの相対パスで、URL は配信用のパスまたは絶対URLを返す
+// Touch は内容を変えずに更新日時を現在時刻にする（存在しない場合は ErrNotFound）
+type Storage interface {
+	Put(key string, data []byte, contentType string) error
+	Open(key string) (*Object, error)
+	Delete(key string) error
+	Exists(key string) (bool, error)
+	Touch(key string) error
+	Stat(key string) (*ObjectInfo, error)
+	List() ([]ObjectInfo, error)
+	URL(key string) string
+}
+
+// Object 保存されたオブジェクトの内容
+// Body がローカルファイルの場合は io.ReadSeeker として扱える
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// ObjectInfo 一覧・Stat で返すオブジェクトの情報
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ValidateKey キーが保存先の外を指さないことを確認
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	if path.Clean(key) != key || key == "." || strings.HasPrefix(key, "../") || key == ".." {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -39,7 +39,7 @@ const CharacterCard: React.FC<CharacterCardProps> = ({
         {character.photo ? (
           <img
             className="h-48 w-full object-cover object-center"
-            src={character.photo}
+            src={character.photoVariants?.card ?? character.photo}
             alt={character.name}
             onError={(e) => {
               // 画像読み込みエラー時のフォールバック
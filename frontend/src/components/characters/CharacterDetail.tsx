@@ -19,9 +19,10 @@ const CharacterDetail: React.FC<CharacterDetailProps> = ({
 }) => {
     const [showAllLinks, setShowAllLinks] = useState(false);
 
+    const relatedLinks = character.relatedLinks || [];
     const displayedLinks = showAllLinks
-        ? character.relatedLinks
-        : character.relatedLinks.slice(0, 3);
+        ? relatedLinks
+        : relatedLinks.slice(0, 3);
 
     const handleLinkClick = (url: string) => {
         // URLの形式チェック
@@ -116,7 +117,7 @@ const CharacterDetail: React.FC<CharacterDetailProps> = ({
                         )}
 
                         {/* 関連リンク */}
-                        {character.relatedLinks && character.relatedLinks.length > 0 && (
+                        {relatedLinks.length > 0 && (
                             <div>
                                 <h4 className="text-sm font-medium text-gray-700 mb-2">関連リンク</h4>
                                 <div className="space-y-2">
@@ -125,19 +126,23 @@ const CharacterDetail: React.FC<CharacterDetailProps> = ({
                                             <button
                                                 type="button"
                                                 className="flex items-center space-x-2 text-blue-600 hover:text-blue-800 text-sm"
-                                                onClick={() => handleLinkClick(link)}
+                                                onClick={() => handleLinkClick(link.url)}
                                             >
-                                                <svg className="h-4 w-4" fill="none" viewBox="0 0 24 24" stroke="currentColor">
-                                                    <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={2} d="M10 6H6a2 2 0 00-2 2v10a2 2 0 002 2h10a2 2 0 002-2v-4M14 4h6m0 0v6m0-6L10 14" />
-                                                </svg>
-                                                <span className="truncate max-w-xs" title={link}>
-                                                    {link}
+                                                {link.faviconUrl ? (
+                                                    <img src={link.faviconUrl} alt="" className="h-4 w-4" loading="lazy" />
+                                                ) : (
+                                                    <svg className="h-4 w-4" fill="none" viewBox="0 0 24 24" stroke="currentColor">
+                                                        <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={2} d="M10 6H6a2 2 0 00-2 2v10a2 2 0 002 2h10a2 2 0 002-2v-4M14 4h6m0 0v6m0-6L10 14" />
+                                                    </svg>
+                                                )}
+                                                <span className="truncate max-w-xs" title={link.url}>
+                                                    {link.title || link.url}
                                                 </span>
                                             </button>
                                         </div>
                                     ))}
 
-                                    {character.relatedLinks.length > 3 && (
+                                    {relatedLinks.length > 3 && (
                                         <button
                                             type="button"
                                             className="text-sm text-gray-500 hover:text-gray-700"
@@ -145,7 +150,7 @@ const CharacterDetail: React.FC<CharacterDetailProps> = ({
                                         >
                                             {showAllLinks
                                                 ? '表示を減らす'
-                                                : `他 ${character.relatedLinks.length - 3} 件のリンクを表示`
+                                                : `他 ${relatedLinks.length - 3} 件のリンクを表示`
                                             }
                                         </button>
                                     )}
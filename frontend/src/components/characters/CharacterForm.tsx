@@ -1,5 +1,5 @@
 import React, { useState, useEffect } from 'react';
-import { Character, CreateCharacterData, UpdateCharacterData, Label } from '../../types';
+import { Character, CreateCharacterData, UpdateCharacterData, Label, RelatedLinkData } from '../../types';
 import { useCharacterStore } from '../../stores/characterStore';
 import { useGroupStore } from '../../stores/groupStore';
 import { ImageUpload } from '../common';
@@ -26,7 +26,7 @@ const CharacterForm: React.FC<CharacterFormProps> = ({
   const [formData, setFormData] = useState({
     name: character?.name || '',
     information: character?.information || '',
-    relatedLinks: character?.relatedLinks || [''],
+    relatedLinks: character?.relatedLinks?.length ? character.relatedLinks.map(link => link.url) : [''],
     groupId: character?.groupId || groupId || selectedGroup?.id || '',
   });
 
@@ -132,8 +132,10 @@ const CharacterForm: React.FC<CharacterFormProps> = ({
       return;
     }
 
-    // 空の関連リンクを除去
-    const cleanedLinks = formData.relatedLinks.filter(link => link.trim());
+    // 空の関連リンクを除去（既存のリンクはタイトル・種類などを保持する）
+    const cleanedLinks: RelatedLinkData[] = formData.relatedLinks
+      .filter(link => link.trim())
+      .map(url => character?.relatedLinks?.find(link => link.url === url) || { url });
 
     try {
       let result: Character | null = null;
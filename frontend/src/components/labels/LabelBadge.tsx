@@ -22,7 +22,7 @@ export const LabelBadge: React.FC<LabelBadgeProps> = ({
     lg: 'px-4 py-2 text-base'
   };
 
-  const textColor = getContrastColor(label.color);
+  const textColor = label.textColor || getContrastColor(label.color);
 
   return (
     <span
@@ -49,7 +49,7 @@ export const LabelBadge: React.FC<LabelBadgeProps> = ({
   );
 };
 
-// 背景色に対して適切なテキスト色を計算する関数
+// 背景色に対して適切なテキスト色を計算する関数（API が textColor を返さない場合の代替）
 function getContrastColor(hexColor: string): string {
   // HEX色を RGB に変換
   const hex = hexColor.replace('#', '');
@@ -85,12 +85,12 @@ export const CharacterDetailModal: React.FC<CharacterDetailModalProps> = ({
                   {character.relatedLinks.map((link, index) => (
                     <a
                       key={index}
-                      href={link}
+                      href={link.url}
                       target="_blank"
                       rel="noopener noreferrer"
                       className="block text-sm text-blue-600 hover:text-blue-800 hover:underline break-all"
                     >
-                      {link}
+                      {link.title || link.url}
                     </a>
                   ))}
                 </div>
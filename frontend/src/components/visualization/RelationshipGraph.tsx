@@ -70,7 +70,7 @@ export const RelationshipGraph: React.FC<RelationshipGraphProps> = ({
     const nodes: Node[] = characters.map(char => ({
       id: char.id,
       name: char.name,
-      photo: char.photo,
+      photo: char.photoVariants?.avatar ?? char.photo,
       labels: char.labels || []
     }));
 
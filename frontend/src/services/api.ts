@@ -2,8 +2,22 @@ import axios, { AxiosResponse, AxiosError } from 'axios';
 import {
   Group,
   Character,
+  CharacterDuplicate,
+  CharacterImage,
+  CharacterImageMetadata,
+  PhotoCrop,
   Label,
+  LabelNode,
+  LabelCategory,
+  LabelCategoryData,
+  AssignLabelData,
+  LabelAssignmentResult,
+  LabelWithUsage,
+  LabelMergeResult,
+  ColorSuggestion,
   Relationship,
+  SavedView,
+  SavedViewData,
   CreateGroupData,
   UpdateGroupData,
   CreateCharacterData,
@@ -13,7 +27,8 @@ import {
   CreateRelationshipData,
   UpdateRelationshipData,
   ApiError,
-  ApiResponse
+  ApiResponse,
+  ProblemDetails
 } from '../types';
 import { transformApiResponse, transformApiArrayResponse } from './utils';
 
@@ -30,8 +45,9 @@ const api = axios.create({
 api.interceptors.response.use(
   (response: AxiosResponse) => response,
   (error: AxiosError) => {
+    const problem = error.response?.data as ProblemDetails | undefined;
     const apiError: ApiError = {
-      code: error.code || 'UNKNOWN_ERROR',
+      code: problem?.code || error.code || 'UNKNOWN_ERROR',
       message: error.message || 'An unknown error occurred',
       details: error.response?.data,
     };
@@ -54,6 +70,12 @@ api.interceptors.response.use(
         case 409:
           apiError.message = 'Conflict: Resource already exists';
           break;
+        case 412:
+          apiError.message = 'Precondition Failed: Resource has been modified';
+          break;
+        case 422:
+          apiError.message = 'Unprocessable Entity: Validation failed';
+          break;
         case 500:
           apiError.message = 'Internal Server Error: Please try again later';
           break;
@@ -101,7 +123,7 @@ export const groupApi = {
 
 // 人物 API
 export const characterApi = {
-  // 人物一覧取得（グループIDでフィルタ可能）
+  // 人物一覧取得（グループIDでフィルタ可能、保存したビューのIDも指定できる）
   getAll: (groupId?: string): Promise<Character[]> => {
     const params = groupId ? { groupId } : {};
     return api.get<ApiResponse<Character[]>>('/characters', { params }).then(response =>
@@ -109,6 +131,35 @@ export const characterApi = {
     );
   },
 
+  // ラベルで人物を検索（includeDescendants で子孫のラベルが付いた人物も含める）
+  getByLabel: (labelId: string, includeDescendants = false, groupId?: string): Promise<Character[]> => {
+    const params: any = { labelId, includeDescendants };
+    if (groupId) params.groupId = groupId;
+    return api.get<ApiResponse<Character[]>>('/characters', { params }).then(response =>
+      transformApiArrayResponse(response.data, ['createdAt', 'updatedAt'])
+    );
+  },
+
+  // 条件式で人物を検索（例: (武将 OR 公家) AND NOT 故人）
+  search: (q: string, groupId?: string): Promise<Character[]> => {
+    const params: any = { q };
+    if (groupId) params.groupId = groupId;
+    return api.get<ApiResponse<Character[]>>('/characters', { params }).then(response =>
+      transformApiArrayResponse(response.data, ['createdAt', 'updatedAt'])
+    );
+  },
+
+  // 名前・別名が一致する同じグループの人物（重複の候補）
+  findDuplicates: (groupId?: string): Promise<CharacterDuplicate[]> => {
+    const params = groupId ? { groupId } : {};
+    return api.get<CharacterDuplicate[]>('/characters/duplicates', { params }).then(response =>
+      (response.data || []).map(duplicate => ({
+        ...duplicate,
+        characters: transformApiArrayResponse(duplicate.characters, ['createdAt', 'updatedAt']),
+      }))
+    );
+  },
+
   // 人物詳細取得
   getById: (id: string): Promise<Character> =>
     api.get(`/characters/${id}`).then(response =>
@@ -144,10 +195,48 @@ export const characterApi = {
       transformApiResponse(response.data, ['createdAt', 'updatedAt'])
     ),
 
+  // 人物の写真を切り抜き直す（再アップロード不要）
+  cropPhoto: (id: string, crop: PhotoCrop): Promise<Character> =>
+    api.put(`/characters/${id}/photo/crop`, crop).then(response =>
+      transformApiResponse(response.data, ['createdAt', 'updatedAt'])
+    ),
+
   // 人物削除
   delete: (id: string): Promise<void> =>
     api.delete(`/characters/${id}`).then(() => undefined),
 
+  // 人物のギャラリーの画像一覧（表示順）
+  getImages: (id: string): Promise<CharacterImage[]> =>
+    api.get(`/characters/${id}/images`).then(response =>
+      transformApiArrayResponse(response.data, ['createdAt'])
+    ),
+
+  // 人物のギャラリーに画像を追加（FormData - image と説明などの項目）
+  addImage: (id: string, data: FormData): Promise<CharacterImage> =>
+    api.post(`/characters/${id}/images`, data, {
+      headers: { 'Content-Type': 'multipart/form-data' }
+    }).then(response =>
+      transformApiResponse(response.data, ['createdAt'])
+    ),
+
+  // ギャラリーの画像の説明などを更新（isPrimary: true で代表画像に切り替え）
+  updateImage: (id: string, imageId: string, data: CharacterImageMetadata): Promise<CharacterImage> =>
+    api.patch(`/characters/${id}/images/${imageId}`, data, {
+      headers: { 'Content-Type': 'application/merge-patch+json' }
+    }).then(response =>
+      transformApiResponse(response.data, ['createdAt'])
+    ),
+
+  // ギャラリーの画像を並べ替え（全ての画像の ID を新しい順で指定）
+  reorderImages: (id: string, imageIds: string[]): Promise<CharacterImage[]> =>
+    api.put(`/characters/${id}/images/order`, { imageIds }).then(response =>
+      transformApiArrayResponse(response.data, ['createdAt'])
+    ),
+
+  // ギャラリーから画像を削除
+  deleteImage: (id: string, imageId: string): Promise<void> =>
+    api.delete(`/characters/${id}/images/${imageId}`).then(() => undefined),
+
   // 人物にラベル追加
   addLabel: (characterId: string, labelId: string): Promise<void> =>
     api.post(`/characters/${characterId}/labels/${labelId}`).then(() => undefined),
@@ -155,16 +244,48 @@ export const characterApi = {
   // 人物からラベル削除
   removeLabel: (characterId: string, labelId: string): Promise<void> =>
     api.delete(`/characters/${characterId}/labels/${labelId}`).then(() => undefined),
+
+  // 人物のラベルをまとめて置き換え（空の配列で全て外す）
+  replaceLabels: (characterId: string, labelIds: string[]): Promise<Character> =>
+    api.put(`/characters/${characterId}/labels`, { labelIds }).then(response =>
+      transformApiResponse(response.data, ['createdAt', 'updatedAt'])
+    ),
 };
 
 // ラベル API
 export const labelApi = {
-  // ラベル一覧取得
-  getAll: (): Promise<Label[]> =>
-    api.get<ApiResponse<Label[]>>('/labels').then(response => {
+  // ラベル一覧取得（グループIDを指定するとそのグループの人物に付けられるラベルのみ）
+  getAll: (groupId?: string): Promise<Label[]> => {
+    const params = groupId ? { groupId } : {};
+    return api.get<ApiResponse<Label[]>>('/labels', { params }).then(response => {
       const data = response.data;
       return transformApiArrayResponse(data || [], ['createdAt']);
-    }),
+    });
+  },
+
+  // 利用状況付きのラベル一覧取得（使われていないラベルの整理用）
+  getAllWithUsage: (groupId?: string): Promise<LabelWithUsage[]> => {
+    const params = groupId ? { groupId, withUsage: true } : { withUsage: true };
+    return api.get<LabelWithUsage[]>('/labels', { params }).then(response =>
+      transformApiArrayResponse(response.data || [], ['createdAt'])
+    );
+  },
+
+  // ラベルの階層取得
+  getTree: (): Promise<LabelNode[]> => {
+    const transform = (nodes: LabelNode[]): LabelNode[] =>
+      transformApiArrayResponse(nodes || [], ['createdAt']).map(node => ({
+        ...node,
+        children: transform(node.children),
+      }));
+    return api.get<LabelNode[]>('/labels/tree').then(response => transform(response.data));
+  },
+
+  // 既存のラベルと見分けやすい色を提案（グループIDを指定するとそのグループで使えるラベルと比べる）
+  suggestColor: (groupId?: string): Promise<ColorSuggestion> => {
+    const params = groupId ? { groupId } : {};
+    return api.get<ColorSuggestion>('/labels/suggest-color', { params }).then(response => response.data);
+  },
 
   // ラベル詳細取得
   getById: (id: string): Promise<Label> =>
@@ -193,11 +314,53 @@ export const labelApi = {
   // ラベル削除
   delete: (id: string): Promise<void> =>
     api.delete(`/labels/${id}`).then(() => undefined),
+
+  // ラベルを複数の人物に一括で付け外し（人物ごとの結果を返す）
+  assign: (id: string, data: AssignLabelData): Promise<LabelAssignmentResult[]> =>
+    api.post<{ results: LabelAssignmentResult[] }>(`/labels/${id}/assign`, data).then(response => response.data.results),
+
+  // ラベルを別のラベルに統合（統合元のラベルは削除される）
+  mergeInto: (id: string, targetId: string): Promise<LabelMergeResult> =>
+    api.post<LabelMergeResult>(`/labels/${id}/merge-into/${targetId}`).then(response => ({
+      ...response.data,
+      target: transformApiResponse(response.data.target, ['createdAt']),
+    })),
+};
+
+// ラベルの分類 API
+export const labelCategoryApi = {
+  // 分類一覧取得（名前順）
+  getAll: (): Promise<LabelCategory[]> =>
+    api.get<LabelCategory[]>('/label-categories').then(response =>
+      transformApiArrayResponse(response.data || [], ['createdAt'])
+    ),
+
+  // 分類詳細取得
+  getById: (id: string): Promise<LabelCategory> =>
+    api.get<LabelCategory>(`/label-categories/${id}`).then(response =>
+      transformApiResponse(response.data, ['createdAt'])
+    ),
+
+  // 分類作成
+  create: (data: LabelCategoryData): Promise<LabelCategory> =>
+    api.post<LabelCategory>('/label-categories', data).then(response =>
+      transformApiResponse(response.data, ['createdAt'])
+    ),
+
+  // 分類更新
+  update: (id: string, data: LabelCategoryData): Promise<LabelCategory> =>
+    api.put<LabelCategory>(`/label-categories/${id}`, data).then(response =>
+      transformApiResponse(response.data, ['createdAt'])
+    ),
+
+  // 分類削除
+  delete: (id: string): Promise<void> =>
+    api.delete(`/label-categories/${id}`).then(() => undefined),
 };
 
 // 関係 API
 export const relationshipApi = {
-  // 関係一覧取得（グループIDまたは人物IDでフィルタ可能）
+  // 関係一覧取得（グループIDまたは人物IDでフィルタ可能、保存したビューのIDも指定できる）
   getAll: (groupId?: string, characterId?: string): Promise<Relationship[]> => {
     const params: any = {};
     if (groupId) params.groupId = groupId;
@@ -230,6 +393,43 @@ export const relationshipApi = {
     api.delete(`/relationships/${id}`).then(() => undefined),
 };
 
+// 保存したビュー API
+export const savedViewApi = {
+  // ビュー一覧取得（名前順）
+  getAll: (): Promise<SavedView[]> =>
+    api.get<SavedView[]>('/saved-views').then(response =>
+      transformApiArrayResponse(response.data || [], ['createdAt', 'updatedAt'])
+    ),
+
+  // ビュー詳細取得
+  getById: (id: string): Promise<SavedView> =>
+    api.get<SavedView>(`/saved-views/${id}`).then(response =>
+      transformApiResponse(response.data, ['createdAt', 'updatedAt'])
+    ),
+
+  // ビューの条件式に一致する人物一覧取得
+  getCharacters: (id: string): Promise<Character[]> =>
+    api.get<Character[]>(`/saved-views/${id}/characters`).then(response =>
+      transformApiArrayResponse(response.data || [], ['createdAt', 'updatedAt'])
+    ),
+
+  // ビュー作成
+  create: (data: SavedViewData): Promise<SavedView> =>
+    api.post<SavedView>('/saved-views', data).then(response =>
+      transformApiResponse(response.data, ['createdAt', 'updatedAt'])
+    ),
+
+  // ビュー更新
+  update: (id: string, data: SavedViewData): Promise<SavedView> =>
+    api.put<SavedView>(`/saved-views/${id}`, data).then(response =>
+      transformApiResponse(response.data, ['createdAt', 'updatedAt'])
+    ),
+
+  // ビュー削除
+  delete: (id: string): Promise<void> =>
+    api.delete(`/saved-views/${id}`).then(() => undefined),
+};
+
 // ヘルスチェック API
 export const healthApi = {
   check: (): Promise<{ status: string; message: string }> =>
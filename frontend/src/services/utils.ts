@@ -1,11 +1,12 @@
-import { ApiError } from '../types';
+import { ApiError, CharacterAlias, RelatedLinkData } from '../types';
 
 /**
  * API エラーを人間が読みやすい形式に変換
  */
 export const formatApiError = (error: ApiError): string => {
-  if (error.details?.error) {
-    return error.details.error;
+  // サーバーのエラーは RFC 7807 (application/problem+json) 形式で返る
+  if (error.details?.detail) {
+    return error.details.detail;
   }
   return error.message;
 };
@@ -18,7 +19,8 @@ export const createCharacterFormData = (
     groupId: string;
     name: string;
     information: string;
-    relatedLinks: string[];
+    relatedLinks: RelatedLinkData[];
+    aliases?: CharacterAlias[];
   },
   photo?: File
 ): FormData => {
@@ -28,6 +30,10 @@ export const createCharacterFormData = (
   formData.append('name', data.name);
   formData.append('information', data.information);
   formData.append('relatedLinks', JSON.stringify(data.relatedLinks));
+  // 省略した場合は更新時に既存の別名を保持する
+  if (data.aliases) {
+    formData.append('aliases', JSON.stringify(data.aliases));
+  }
 
   if (photo) {
     formData.append('photo', photo);
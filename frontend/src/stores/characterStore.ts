@@ -151,7 +151,8 @@ export const useCharacterStore = create<CharacterState>()(
               groupId: data.groupId || '',
               name: data.name || '',
               information: data.information || '',
-              relatedLinks: data.relatedLinks || []
+              relatedLinks: data.relatedLinks || [],
+              aliases: data.aliases
             }, photo);
             updatedCharacter = await characterApi.updateWithImage(id, formData);
           } else {
@@ -3,6 +3,7 @@ export interface Group {
   id: string;
   name: string;
   description?: string;
+  labelLimit?: number | null;
   createdAt: Date;
   updatedAt: Date;
 }
@@ -11,21 +12,131 @@ export interface Character {
   id: string;
   groupId: string;
   name: string;
+  aliases?: CharacterAlias[];
   photo?: string;
+  photoVariants?: PhotoVariants;
+  photoCrop?: PhotoCrop;
   information: string;
-  relatedLinks: string[];
+  relatedLinks: RelatedLink[] | null;
   labels: Label[];
+  images?: CharacterImage[];
   createdAt: Date;
   updatedAt: Date;
 }
 
+// 人物の関連リンク（kind は省略時にサーバーが URL から推定する）
+export type RelatedLinkKind = 'wikipedia' | 'official' | 'sns' | 'source' | 'other';
+
+export interface RelatedLink {
+  url: string;
+  title?: string;
+  kind: RelatedLinkKind;
+  language?: string;
+  faviconUrl?: string;
+}
+
+// 人物の作成・更新で送る関連リンク
+export interface RelatedLinkData {
+  url: string;
+  title?: string;
+  kind?: RelatedLinkKind;
+  language?: string;
+}
+
+// 人物の別名（reading は一覧の並び替えに使う読み）
+export type CharacterAliasType = 'alias' | 'title' | 'reading' | 'romanization';
+
+export interface CharacterAlias {
+  type: CharacterAliasType;
+  name: string;
+}
+
+// 名前・別名が一致する同じグループの人物
+export interface CharacterDuplicate {
+  names: string[];
+  characters: Character[];
+}
+
+// 人物のギャラリーの画像（isPrimary の画像は photo と同じ）
+export interface CharacterImage {
+  id: string;
+  characterId: string;
+  url: string;
+  variants?: PhotoVariants;
+  crop?: PhotoCrop;
+  position: number;
+  isPrimary: boolean;
+  caption: string;
+  credit: string;
+  sourceUrl: string;
+  license: string;
+  createdAt: Date;
+}
+
+// ギャラリーの画像の説明・クレジット・出典・ライセンス
+export interface CharacterImageMetadata {
+  caption?: string;
+  credit?: string;
+  sourceUrl?: string;
+  license?: string;
+  isPrimary?: boolean;
+}
+
+// 画像のサイズ別URL（avatar: 64px 正方形, card: 256px, full: 1200px）
+export interface PhotoVariants {
+  avatar?: string;
+  card?: string;
+  full?: string;
+}
+
+// 写真の切り抜き範囲と注目点（元画像に対する 0〜1 の割合、avatar と card に適用）
+export interface PhotoCrop {
+  rect?: { x: number; y: number; width: number; height: number };
+  focus?: { x: number; y: number };
+}
+
 export interface Label {
   id: string;
   name: string;
   color: string;
+  groupId?: string | null;
+  parentId?: string | null;
+  categoryId?: string | null;
+  // color に対してコントラスト比が高い方の文字色（黒または白）
+  textColor?: string;
+  // 作成・更新した色に近い色の既存のラベル（作成・更新のレスポンスのみ）
+  colorWarnings?: LabelColorWarning[];
+  createdAt: Date;
+}
+
+// 色が近い既存のラベル（distance は CIEDE2000 の色差）
+export interface LabelColorWarning {
+  labelId: string;
+  name: string;
+  color: string;
+  distance: number;
+}
+
+// 既存のラベルと見分けやすい色の提案
+export interface ColorSuggestion {
+  color: string;
+  textColor: string;
+  distance: number | null;
+}
+
+// ラベルの分類（人物1人あたりに付けられる数の上限を持つ）
+export interface LabelCategory {
+  id: string;
+  name: string;
+  maxPerCharacter: number;
   createdAt: Date;
 }
 
+// ラベルの階層（子は名前順）
+export interface LabelNode extends Label {
+  children: LabelNode[];
+}
+
 export interface Relationship {
   id: string;
   groupId: string;
@@ -36,39 +147,99 @@ export interface Relationship {
   createdAt: Date;
 }
 
+// 保存したビュー（id はグループIDの代わりに人物一覧・関係一覧の取得に使える）
+export interface SavedView {
+  id: string;
+  name: string;
+  description?: string | null;
+  query: string;
+  createdAt: Date;
+  updatedAt: Date;
+}
+
 // API リクエスト用の型
 export interface CreateGroupData {
   name: string;
   description?: string;
+  labelLimit?: number | null;
 }
 
 export interface UpdateGroupData {
   name?: string;
   description?: string;
+  labelLimit?: number | null;
 }
 
 export interface CreateCharacterData {
   groupId: string;
   name: string;
   information: string;
-  relatedLinks: string[];
+  relatedLinks: RelatedLinkData[];
+  aliases?: CharacterAlias[];
 }
 
 export interface UpdateCharacterData {
   groupId?: string;
   name?: string;
   information?: string;
-  relatedLinks?: string[];
+  relatedLinks?: RelatedLinkData[];
+  aliases?: CharacterAlias[];
 }
 
 export interface CreateLabelData {
   name: string;
   color: string;
+  groupId?: string | null;
+  parentId?: string | null;
+  categoryId?: string | null;
 }
 
 export interface UpdateLabelData {
   name?: string;
   color?: string;
+  parentId?: string | null;
+  categoryId?: string | null;
+}
+
+export interface AssignLabelData {
+  add?: string[];
+  remove?: string[];
+}
+
+// ラベルの一括付け外しの人物ごとの結果（失敗した場合は code・message を含む）
+export interface LabelAssignmentResult {
+  characterId: string;
+  action: 'add' | 'remove';
+  status: 'added' | 'removed' | 'unchanged' | 'failed';
+  code?: string;
+  message?: string;
+  details?: unknown;
+}
+
+// ラベルの利用状況（GET /labels?withUsage=true）
+export interface LabelWithUsage extends Label {
+  usage: {
+    characters: number;
+    groups: number;
+  };
+}
+
+// ラベルの統合の結果
+export interface LabelMergeResult {
+  target: Label;
+  moved: number;
+  deduplicated: number;
+}
+
+export interface LabelCategoryData {
+  name: string;
+  maxPerCharacter: number;
+}
+
+export interface SavedViewData {
+  name: string;
+  description?: string | null;
+  query: string;
 }
 
 export interface CreateRelationshipData {
@@ -95,4 +266,15 @@ export interface ApiError {
   code: string;
   message: string;
   details?: any;
+}
+
+// RFC 7807 形式のエラーレスポンス
+export interface ProblemDetails {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code: string;
+  details?: any;
 }
\ No newline at end of file